@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"backend/db"
+	"backend/models"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// AnnouncementSightingsHandler - карта наблюдений по объявлению о потере
+// GET /api/announcements/{id}/sightings
+func AnnouncementSightingsHandler(w http.ResponseWriter, r *http.Request, announcementID int) {
+	if r.Method != http.MethodGet {
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var announcementType string
+	err := db.DB.QueryRow(ConvertPlaceholders("SELECT type FROM pet_announcements WHERE id = ?"), announcementID).Scan(&announcementType)
+	if err == sql.ErrNoRows {
+		sendError(w, "Announcement not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if announcementType != "lost" {
+		sendError(w, "Sightings are only available for lost announcements", http.StatusBadRequest)
+		return
+	}
+
+	rows, err := db.DB.Query(ConvertPlaceholders(`
+		SELECT p.id, p.announcement_id, p.author_id, p.post_type, p.content, p.created_at,
+		       p.sighting_lat, p.sighting_lon, p.sighted_at,
+		       u.name, u.last_name, u.avatar
+		FROM announcement_posts p
+		LEFT JOIN users u ON p.author_id = u.id
+		WHERE p.announcement_id = ? AND p.sighting_lat IS NOT NULL AND p.sighting_lon IS NOT NULL
+		ORDER BY p.sighted_at ASC
+	`), announcementID)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	collection := models.GeoJSONFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: []models.GeoJSONFeature{},
+	}
+	for rows.Next() {
+		var post models.AnnouncementPost
+		var name, lastName, avatar sql.NullString
+
+		err := rows.Scan(
+			&post.ID, &post.AnnouncementID, &post.AuthorID, &post.PostType, &post.Content, &post.CreatedAt,
+			&post.SightingLat, &post.SightingLon, &post.SightedAt,
+			&name, &lastName, &avatar,
+		)
+		if err != nil {
+			sendError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if name.Valid {
+			post.Author = &models.User{ID: post.AuthorID, Name: name.String, LastName: lastName.String, Avatar: avatar.String}
+		}
+
+		collection.Features = append(collection.Features, sightingFeature(post))
+	}
+
+	// GeoJSON отдаём без обёртки success/data, чтобы его можно было сразу передать в карту
+	w.Header().Set("Content-Type", "application/geo+json")
+	json.NewEncoder(w).Encode(collection)
+}
+
+// sightingFeature превращает публикацию с геометкой в GeoJSON-точку
+func sightingFeature(post models.AnnouncementPost) models.GeoJSONFeature {
+	properties := map[string]interface{}{
+		"announcement_id": post.AnnouncementID,
+		"author_id":       post.AuthorID,
+		"post_type":       post.PostType,
+		"content":         post.Content,
+		"sighted_at":      post.SightedAt,
+		"created_at":      post.CreatedAt,
+	}
+	if post.Author != nil {
+		properties["author"] = post.Author
+	}
+
+	return models.GeoJSONFeature{
+		Type: "Feature",
+		ID:   post.ID,
+		Geometry: models.GeoJSONPoint{
+			Type:        "Point",
+			Coordinates: [2]float64{*post.SightingLon, *post.SightingLat},
+		},
+		Properties: properties,
+	}
+}
+
+// notifyAnnouncementSighting - уведомление автору объявления о новом наблюдении.
+// Точка на карте приходит по WebSocket только при включённом push в категории "announcement",
+// а запись в списке уведомлений и письмо создаются через CreateNotification по тем же настройкам.
+func notifyAnnouncementSighting(authorID, actorID, announcementID int, post models.AnnouncementPost) {
+	pref := loadNotificationPreference(db.DB, authorID, notificationCategoryAnnouncement)
+	if pref.Push {
+		NotifyUser(authorID, "announcement_sighting", sightingFeature(post))
+	}
+
+	notifHandler := &NotificationsHandler{DB: db.DB}
+	if err := notifHandler.CreateNotification(authorID, actorID, "announcement_sighting", "announcement", announcementID, "Новое наблюдение по вашему объявлению"); err != nil {
+		log.Printf("⚠️ Failed to notify user %d about sighting on announcement %d: %v", authorID, announcementID, err)
+	}
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"backend/db"
+	"backend/models"
+	"context"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// postAnnouncementPost отправляет публикацию к объявлению 5 от имени пользователя
+func postAnnouncementPost(userID int, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/announcements/5/posts", strings.NewReader(body))
+	req = req.WithContext(context.WithValue(req.Context(), "userID", userID))
+	rec := httptest.NewRecorder()
+	handleCreateAnnouncementPost(rec, req, 5)
+	return rec
+}
+
+func TestCreateAnnouncementSighting(t *testing.T) {
+	tests := []struct {
+		name          string
+		annType       string
+		body          string
+		wantStatus    int
+		wantSightedAt time.Time // нулевое значение - момент публикации
+	}{
+		{"default time is now in UTC", "lost", `{"post_type":"observation","content":"Видели у парка","sighting_lat":55.75,"sighting_lon":37.61}`, http.StatusOK, time.Time{}},
+		{"client offset converted to UTC", "lost", `{"post_type":"search","content":"Искали во дворе","sighting_lat":55.75,"sighting_lon":37.61,"sighted_at":"2026-01-15T10:00:00+03:00"}`, http.StatusOK, time.Date(2026, 1, 15, 7, 0, 0, 0, time.UTC)},
+		{"future time", "lost", `{"post_type":"observation","content":"x","sighting_lat":55.75,"sighting_lon":37.61,"sighted_at":"2999-01-01T00:00:00Z"}`, http.StatusBadRequest, time.Time{}},
+		{"bad time format", "lost", `{"post_type":"observation","content":"x","sighting_lat":55.75,"sighting_lon":37.61,"sighted_at":"19.10.2026"}`, http.StatusBadRequest, time.Time{}},
+		{"found announcement", "found", `{"post_type":"observation","content":"x","sighting_lat":55.75,"sighting_lon":37.61}`, http.StatusBadRequest, time.Time{}},
+		{"wrong post type", "lost", `{"post_type":"photo","content":"x","sighting_lat":55.75,"sighting_lon":37.61}`, http.StatusBadRequest, time.Time{}},
+		{"missing longitude", "lost", `{"post_type":"observation","content":"x","sighting_lat":55.75}`, http.StatusBadRequest, time.Time{}},
+		{"latitude out of range", "lost", `{"post_type":"observation","content":"x","sighting_lat":91,"sighting_lon":37.61}`, http.StatusBadRequest, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inserted []driver.Value
+			useFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+				switch {
+				case strings.Contains(query, "FROM pet_announcements"):
+					return &fakeResult{rows: [][]driver.Value{{int64(10), tt.annType}}}, nil
+				case strings.Contains(query, "INSERT INTO announcement_posts"):
+					inserted = args
+					return &fakeResult{rows: [][]driver.Value{{int64(77)}}}, nil
+				}
+				return &fakeResult{}, nil
+			})
+
+			// Наблюдение публикует сам автор объявления - уведомлений нет
+			before := time.Now()
+			rec := postAnnouncementPost(10, tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				if inserted != nil {
+					t.Fatal("rejected sighting was inserted")
+				}
+				return
+			}
+
+			sightedAt, ok := inserted[8].(time.Time)
+			if !ok {
+				t.Fatalf("sighted_at = %#v, want time", inserted[8])
+			}
+			// TIMESTAMP без зоны хранит часы как есть, поэтому записывать можно только UTC
+			if sightedAt.Location() != time.UTC {
+				t.Errorf("sighted_at written in %v, want UTC", sightedAt.Location())
+			}
+			if tt.wantSightedAt.IsZero() {
+				if sightedAt.Before(before.Add(-time.Second)) || sightedAt.After(time.Now()) {
+					t.Errorf("default sighted_at %v is not the publication time", sightedAt)
+				}
+			} else if !sightedAt.Equal(tt.wantSightedAt) {
+				t.Errorf("sighted_at %v, want %v", sightedAt, tt.wantSightedAt)
+			}
+		})
+	}
+}
+
+func TestCreateAnnouncementSightingNotifiesAuthor(t *testing.T) {
+	const sighting = `{"post_type":"observation","content":"Видели у парка","sighting_lat":55.75,"sighting_lon":37.61}`
+
+	tests := []struct {
+		name        string
+		posterID    int
+		body        string
+		wantEvents  []string
+		wantInserts int
+	}{
+		{"sighting by another user", 20, sighting, []string{"announcement_sighting", "notification", "notification_unread_count"}, 1},
+		{"sighting by the author", 10, sighting, nil, 0},
+		{"post without location", 20, `{"post_type":"update","content":"Новости"}`, nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inserts := 0
+			useFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+				switch {
+				case strings.Contains(query, "FROM pet_announcements"):
+					return &fakeResult{rows: [][]driver.Value{{int64(10), "lost"}}}, nil
+				case strings.Contains(query, "INSERT INTO announcement_posts"):
+					return &fakeResult{rows: [][]driver.Value{{int64(77)}}}, nil
+				case strings.Contains(query, "FROM notification_preferences"):
+					// Всё, кроме email: письмо уходит в фоне и в тесте не проверяется
+					return &fakeResult{rows: [][]driver.Value{{true, true, false}}}, nil
+				case strings.Contains(query, "INSERT INTO notifications"):
+					inserts++
+					return &fakeResult{rows: [][]driver.Value{{int64(1), time.Now()}}}, nil
+				case strings.Contains(query, "SELECT COUNT(*) FROM notifications"):
+					return &fakeResult{rows: [][]driver.Value{{int64(inserts)}}}, nil
+				}
+				return &fakeResult{}, nil
+			})
+
+			h := newTestHub(t)
+			h.db = db.DB
+			author := addTestClient(h, 10)
+			prev := hub
+			hub = h
+			t.Cleanup(func() { hub = prev })
+
+			if rec := postAnnouncementPost(tt.posterID, tt.body); rec.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+			}
+
+			var got []string
+			deadline := time.After(200 * time.Millisecond)
+			for waiting := len(got) < len(tt.wantEvents) || len(tt.wantEvents) == 0; waiting; {
+				select {
+				case m := <-author.Send:
+					got = append(got, m.Type)
+					if m.Type == "announcement_sighting" {
+						feature, ok := m.Data.(models.GeoJSONFeature)
+						if !ok || feature.ID != 77 || feature.Geometry.Coordinates != [2]float64{37.61, 55.75} {
+							t.Errorf("sighting event %#v", m.Data)
+						}
+					}
+					waiting = len(tt.wantEvents) == 0 || len(got) < len(tt.wantEvents)
+				case <-deadline:
+					waiting = false
+				}
+			}
+
+			if strings.Join(got, ",") != strings.Join(tt.wantEvents, ",") {
+				t.Errorf("author events %v, want %v", got, tt.wantEvents)
+			}
+			if inserts != tt.wantInserts {
+				t.Errorf("notification inserts = %d, want %d", inserts, tt.wantInserts)
+			}
+		})
+	}
+}
+
+func TestNotifyAnnouncementSightingRespectsPreferences(t *testing.T) {
+	// Push выключен во всех случаях: иначе CreateNotification доставляет уведомление в фоне
+	tests := []struct {
+		name        string
+		inApp       bool
+		email       bool
+		wantInserts int
+	}{
+		{"in-app only", true, false, 1},
+		{"all channels off", false, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inserts := 0
+			useFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+				switch {
+				case strings.Contains(query, "FROM notification_preferences"):
+					return &fakeResult{rows: [][]driver.Value{{tt.inApp, false, tt.email}}}, nil
+				case strings.Contains(query, "INSERT INTO notifications"):
+					inserts++
+					return &fakeResult{rows: [][]driver.Value{{int64(1), time.Now()}}}, nil
+				}
+				return &fakeResult{}, nil
+			})
+
+			h := newTestHub(t)
+			h.db = db.DB
+			c := addTestClient(h, 10)
+			prev := hub
+			hub = h
+			t.Cleanup(func() { hub = prev })
+
+			notifyAnnouncementSighting(10, 20, 5, models.AnnouncementPost{ID: 1, AnnouncementID: 5, AuthorID: 20, PostType: "sighting"})
+
+			// Счётчик непрочитанных приходит в фоне, точки на карте быть не должно
+			deadline := time.After(100 * time.Millisecond)
+			for waiting := true; waiting; {
+				select {
+				case m := <-c.Send:
+					if m.Type == "announcement_sighting" {
+						t.Fatalf("sighting pushed with push disabled")
+					}
+				case <-deadline:
+					waiting = false
+				}
+			}
+			if inserts != tt.wantInserts {
+				t.Fatalf("notification inserts = %d, want %d", inserts, tt.wantInserts)
+			}
+		})
+	}
+}
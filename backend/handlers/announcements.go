@@ -35,6 +35,42 @@ func AnnouncementHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Вложенные ресурсы: /api/announcements/{id}/{resource}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) > 3 {
+		switch parts[3] {
+		case "expenses":
+			AnnouncementExpensesHandler(w, r, id, parts[4:])
+		case "summary":
+			AnnouncementSummaryHandler(w, r, id)
+		case "wishlist":
+			AnnouncementWishlistHandler(w, r, id)
+		case "report":
+			AnnouncementReportHandler(w, r, id)
+		case "sightings":
+			AnnouncementSightingsHandler(w, r, id)
+		case "search-sessions":
+			AnnouncementSearchSessionsHandler(w, r, id)
+		case "search-coverage":
+			AnnouncementSearchCoverageHandler(w, r, id)
+		case "posts":
+			if r.Method != http.MethodPost {
+				sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			handleCreateAnnouncementPost(w, r, id)
+		case "donations":
+			if r.Method != http.MethodPost {
+				sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			handleCreateDonation(w, r, id)
+		default:
+			sendError(w, "Not found", http.StatusNotFound)
+		}
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		handleGetAnnouncement(w, r, id)
@@ -370,7 +406,8 @@ func loadAnnouncementRelations(a *models.PetAnnouncement) {
 
 	// Загружаем публикации
 	rows, err := db.DB.Query(ConvertPlaceholders(`
-		SELECT id, announcement_id, author_id, post_type, content, media_urls, donation_amount, created_at
+		SELECT id, announcement_id, author_id, post_type, content, media_urls, donation_amount, created_at,
+		       sighting_lat, sighting_lon, sighted_at
 		FROM announcement_posts
 		WHERE announcement_id = ?
 		ORDER BY created_at DESC
@@ -381,7 +418,8 @@ func loadAnnouncementRelations(a *models.PetAnnouncement) {
 		for rows.Next() {
 			var post models.AnnouncementPost
 			rows.Scan(&post.ID, &post.AnnouncementID, &post.AuthorID, &post.PostType,
-				&post.Content, &post.MediaURLs, &post.DonationAmount, &post.CreatedAt)
+				&post.Content, &post.MediaURLs, &post.DonationAmount, &post.CreatedAt,
+				&post.SightingLat, &post.SightingLon, &post.SightedAt)
 			posts = append(posts, post)
 		}
 		a.Posts = posts
@@ -407,6 +445,14 @@ func loadAnnouncementRelations(a *models.PetAnnouncement) {
 			}
 			a.Donations = donations
 		}
+
+		// Отчёт о расходах и сводка по сбору
+		if expenses, err := loadAnnouncementExpenses(a.ID); err == nil {
+			a.Expenses = expenses
+		}
+		if summary, err := getFundraisingSummary(a.ID); err == nil {
+			a.FundraisingSummary = summary
+		}
 	}
 }
 
@@ -436,7 +482,10 @@ func AnnouncementPostsHandler(w http.ResponseWriter, r *http.Request) {
 
 // handleCreateAnnouncementPost - создать публикацию к объявлению
 func handleCreateAnnouncementPost(w http.ResponseWriter, r *http.Request, announcementID int) {
-	userID := r.Context().Value("userID").(int)
+	userID, ok := RequireAuth(w, r)
+	if !ok {
+		return
+	}
 
 	var req models.CreateAnnouncementPostRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -457,19 +506,82 @@ func handleCreateAnnouncementPost(w http.ResponseWriter, r *http.Request, announ
 		mediaURLsJSON = &jsonStr
 	}
 
+	var authorID int
+	var announcementType string
+	err := db.DB.QueryRow(ConvertPlaceholders("SELECT author_id, type FROM pet_announcements WHERE id = ?"), announcementID).
+		Scan(&authorID, &announcementType)
+	if err != nil {
+		sendError(w, "Announcement not found", http.StatusNotFound)
+		return
+	}
+
+	// Геометка наблюдения: только для наблюдений и поисков по потерянным питомцам
+	isSighting := req.SightingLat != nil || req.SightingLon != nil
+	var sightedAt *time.Time
+	if isSighting {
+		if announcementType != "lost" {
+			sendError(w, "Sightings are only allowed on lost announcements", http.StatusBadRequest)
+			return
+		}
+		if req.PostType != "observation" && req.PostType != "search" {
+			sendError(w, "Sightings must have post_type observation or search", http.StatusBadRequest)
+			return
+		}
+		if req.SightingLat == nil || req.SightingLon == nil ||
+			*req.SightingLat < -90 || *req.SightingLat > 90 ||
+			*req.SightingLon < -180 || *req.SightingLon > 180 {
+			sendError(w, "Invalid sighting coordinates", http.StatusBadRequest)
+			return
+		}
+
+		// Время наблюдения по умолчанию - момент публикации (в UTC, как и явно переданное)
+		now := time.Now().UTC()
+		sightedAt = &now
+		if req.SightedAt != nil && *req.SightedAt != "" {
+			parsed, err := time.Parse(time.RFC3339, *req.SightedAt)
+			if err != nil {
+				sendError(w, "sighted_at must be in RFC3339 format", http.StatusBadRequest)
+				return
+			}
+			// Колонка TIMESTAMP без зоны: храним UTC, иначе смещение клиента теряется
+			parsed = parsed.UTC()
+			if parsed.After(now) {
+				sendError(w, "sighted_at cannot be in the future", http.StatusBadRequest)
+				return
+			}
+			sightedAt = &parsed
+		}
+	}
+
 	query := ConvertPlaceholders(`
-		INSERT INTO announcement_posts (announcement_id, author_id, post_type, content, media_urls, donation_amount)
-		VALUES (?, ?, ?, ?, ?, ?) RETURNING id
+		INSERT INTO announcement_posts (announcement_id, author_id, post_type, content, media_urls, donation_amount,
+			sighting_lat, sighting_lon, sighted_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id
 	`)
 
 	var id int64
-	var err error
-	err = db.DB.QueryRow(query, announcementID, userID, req.PostType, req.Content, mediaURLsJSON, req.DonationAmount).Scan(&id)
+	err = db.DB.QueryRow(query, announcementID, userID, req.PostType, req.Content, mediaURLsJSON, req.DonationAmount,
+		req.SightingLat, req.SightingLon, sightedAt).Scan(&id)
 	if err != nil {
 		sendError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	// 🔔 Сообщаем автору объявления о новом наблюдении с учётом его настроек уведомлений
+	if isSighting && authorID != userID {
+		notifyAnnouncementSighting(authorID, userID, announcementID, models.AnnouncementPost{
+			ID:             int(id),
+			AnnouncementID: announcementID,
+			AuthorID:       userID,
+			PostType:       req.PostType,
+			Content:        req.Content,
+			SightingLat:    req.SightingLat,
+			SightingLon:    req.SightingLon,
+			SightedAt:      sightedAt,
+			CreatedAt:      time.Now().UTC(),
+		})
+	}
+
 	sendSuccess(w, map[string]interface{}{"id": id, "message": "Post created successfully"})
 }
 
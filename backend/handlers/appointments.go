@@ -0,0 +1,896 @@
+package handlers
+
+import (
+	"backend/db"
+	"backend/models"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Владелец может отменить или перенести запись не позже чем за это время до приёма
+	appointmentChangeDeadline = 2 * time.Hour
+	// За сколько до приёма отправляется напоминание
+	appointmentReminderLead = 24 * time.Hour
+	// Ограничение на количество слотов, создаваемых одним запросом
+	maxSlotsPerRequest = 48
+	// Длительность слота, если не указана ни в запросе, ни в услуге
+	defaultSlotDurationMinutes = 30
+)
+
+// OrganizationAppointmentSlotsHandler - окна приёма клиники
+// GET /api/organizations/{id}/appointment-slots?from=2026-10-20&to=2026-10-27&doctor_id=&service_id= - свободные слоты
+// POST /api/organizations/{id}/appointment-slots - создать слоты (участники с can_edit)
+// DELETE /api/organizations/{id}/appointment-slots/{slotId} - удалить свободный слот
+func OrganizationAppointmentSlotsHandler(w http.ResponseWriter, r *http.Request, orgID int, rest []string) {
+	if len(rest) == 0 && r.Method == http.MethodGet {
+		listAvailableSlots(w, r, orgID)
+		return
+	}
+
+	userID, ok := RequireAuth(w, r)
+	if !ok {
+		return
+	}
+	if !canEditOrganization(orgID, userID) {
+		sendJSONError(w, http.StatusForbidden, "You don't have permission to manage appointment slots")
+		return
+	}
+
+	if len(rest) == 0 {
+		if r.Method != http.MethodPost {
+			sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		createAppointmentSlots(w, r, orgID, userID)
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	slotID, err := strconv.Atoi(rest[0])
+	if err != nil {
+		sendJSONError(w, http.StatusBadRequest, "Invalid slot ID")
+		return
+	}
+
+	// Слот с активной записью удалить нельзя - сначала нужно отменить запись
+	result, err := db.DB.Exec(ConvertPlaceholders(`
+		DELETE FROM vet_appointment_slots
+		WHERE id = ? AND organization_id = ?
+		AND NOT EXISTS (SELECT 1 FROM vet_appointments WHERE slot_id = ? AND status = 'booked')
+	`), slotID, orgID, slotID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to delete slot: "+err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		sendJSONError(w, http.StatusConflict, "Slot not found or has an active appointment")
+		return
+	}
+
+	sendJSONSuccess(w, map[string]interface{}{"message": "Slot deleted"})
+}
+
+func listAvailableSlots(w http.ResponseWriter, r *http.Request, orgID int) {
+	from, to, err := parseCalendarRange(r, 14)
+	if err != nil {
+		sendJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if now := time.Now().UTC(); from.Before(now) {
+		from = now
+	}
+
+	query := appointmentSlotSelect + `
+		WHERE s.organization_id = ? AND s.status = 'open'
+		AND s.starts_at >= ? AND s.starts_at < ?
+		AND NOT EXISTS (SELECT 1 FROM vet_appointments a WHERE a.slot_id = s.id AND a.status = 'booked')`
+	args := []interface{}{orgID, from, to}
+	if doctorID, err := strconv.Atoi(r.URL.Query().Get("doctor_id")); err == nil {
+		query += " AND s.doctor_id = ?"
+		args = append(args, doctorID)
+	}
+	if serviceID, err := strconv.Atoi(r.URL.Query().Get("service_id")); err == nil {
+		query += " AND s.service_id = ?"
+		args = append(args, serviceID)
+	}
+	query += " ORDER BY s.starts_at, s.doctor_id"
+
+	slots, err := queryAppointmentSlots(query, args...)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+
+	sendJSONSuccess(w, slots)
+}
+
+func createAppointmentSlots(w http.ResponseWriter, r *http.Request, orgID, userID int) {
+	var orgType string
+	err := db.DB.QueryRow(ConvertPlaceholders("SELECT type FROM organizations WHERE id = ?"), orgID).Scan(&orgType)
+	if err == sql.ErrNoRows {
+		sendJSONError(w, http.StatusNotFound, "Organization not found")
+		return
+	}
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	if orgType != "vet_clinic" {
+		sendJSONError(w, http.StatusBadRequest, "Appointments are available only for veterinary clinics")
+		return
+	}
+
+	var req models.CreateAppointmentSlotsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	// Врач должен быть участником клиники
+	if req.DoctorID == 0 || !isOrganizationMember(orgID, req.DoctorID) {
+		sendJSONError(w, http.StatusBadRequest, "doctor_id must be a member of the organization")
+		return
+	}
+
+	startsAt, err := time.Parse(time.RFC3339, req.StartsAt)
+	if err != nil {
+		sendJSONError(w, http.StatusBadRequest, "starts_at must be in RFC3339 format")
+		return
+	}
+	// Колонки TIMESTAMP без зоны, сервер работает в UTC: смещение клиента иначе потеряется
+	startsAt = startsAt.UTC()
+	if startsAt.Before(time.Now().UTC()) {
+		sendJSONError(w, http.StatusBadRequest, "starts_at must be in the future")
+		return
+	}
+
+	duration := req.DurationMinutes
+	if req.ServiceID != nil {
+		var serviceDuration sql.NullInt64
+		err := db.DB.QueryRow(ConvertPlaceholders(`
+			SELECT duration_minutes FROM organization_services WHERE id = ? AND organization_id = ?
+		`), *req.ServiceID, orgID).Scan(&serviceDuration)
+		if err == sql.ErrNoRows {
+			sendJSONError(w, http.StatusBadRequest, "Service not found")
+			return
+		}
+		if err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+			return
+		}
+		if duration == 0 && serviceDuration.Valid {
+			duration = int(serviceDuration.Int64)
+		}
+	}
+	if duration == 0 {
+		duration = defaultSlotDurationMinutes
+	}
+	if duration < 0 {
+		sendJSONError(w, http.StatusBadRequest, "duration_minutes must be positive")
+		return
+	}
+
+	count := req.Count
+	if count == 0 {
+		count = 1
+	}
+	if count < 0 || count > maxSlotsPerRequest {
+		sendJSONError(w, http.StatusBadRequest, fmt.Sprintf("count must be from 1 to %d", maxSlotsPerRequest))
+		return
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	var createdIDs []int
+	for i := 0; i < count; i++ {
+		slotStart := startsAt.Add(time.Duration(i*duration) * time.Minute)
+		slotEnd := slotStart.Add(time.Duration(duration) * time.Minute)
+
+		// Окна одного врача не должны пересекаться
+		var overlaps bool
+		err := tx.QueryRow(ConvertPlaceholders(`
+			SELECT EXISTS(
+				SELECT 1 FROM vet_appointment_slots
+				WHERE doctor_id = ? AND status = 'open' AND starts_at < ? AND ends_at > ?
+			)
+		`), req.DoctorID, slotEnd, slotStart).Scan(&overlaps)
+		if err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+			return
+		}
+		if overlaps {
+			sendJSONError(w, http.StatusConflict, fmt.Sprintf("Slot starting at %s overlaps with an existing slot of this doctor", slotStart.Format(time.RFC3339)))
+			return
+		}
+
+		var slotID int64
+		err = tx.QueryRow(ConvertPlaceholders(`
+			INSERT INTO vet_appointment_slots (organization_id, doctor_id, service_id, starts_at, ends_at, status, created_by)
+			VALUES (?, ?, ?, ?, ?, 'open', ?)
+			RETURNING id
+		`), orgID, req.DoctorID, req.ServiceID, slotStart, slotEnd, userID).Scan(&slotID)
+		if err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Failed to create slot: "+err.Error())
+			return
+		}
+		createdIDs = append(createdIDs, int(slotID))
+	}
+
+	if err := tx.Commit(); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to create slots: "+err.Error())
+		return
+	}
+
+	slots, err := queryAppointmentSlots(appointmentSlotSelect+" WHERE s.organization_id = ? AND s.id >= ? AND s.id <= ? AND s.created_by = ? ORDER BY s.starts_at",
+		orgID, createdIDs[0], createdIDs[len(createdIDs)-1], userID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load slots: "+err.Error())
+		return
+	}
+
+	sendJSONSuccess(w, slots)
+}
+
+// OrganizationAppointmentsHandler - записи клиники
+// POST /api/organizations/{id}/appointments - записать своего питомца на слот
+// GET /api/organizations/{id}/appointments?from=&to=&doctor_id= - календарь для сотрудников клиники
+func OrganizationAppointmentsHandler(w http.ResponseWriter, r *http.Request, orgID int) {
+	userID, ok := RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		bookAppointment(w, r, orgID, userID)
+	case http.MethodGet:
+		getClinicCalendar(w, r, orgID, userID)
+	default:
+		sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func bookAppointment(w http.ResponseWriter, r *http.Request, orgID, userID int) {
+	var req models.BookAppointmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	// Записать можно только своего питомца
+	var petOwnerID int
+	err := db.DB.QueryRow(ConvertPlaceholders("SELECT user_id FROM pets WHERE id = ?"), req.PetID).Scan(&petOwnerID)
+	if err == sql.ErrNoRows {
+		sendJSONError(w, http.StatusNotFound, "Pet not found")
+		return
+	}
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	if petOwnerID != userID {
+		sendJSONError(w, http.StatusForbidden, "You can only book appointments for your own pets")
+		return
+	}
+
+	var comment *string
+	if strings.TrimSpace(req.Comment) != "" {
+		comment = &req.Comment
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	startsAt, endsAt, err := lockBookableSlot(tx, req.SlotID, orgID)
+	if err != nil {
+		sendSlotLockError(w, err)
+		return
+	}
+
+	if busy, err := petHasOverlappingAppointment(tx, req.PetID, 0, startsAt, endsAt); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	} else if busy {
+		sendJSONError(w, http.StatusConflict, "This pet already has an appointment at this time")
+		return
+	}
+
+	var appointmentID int64
+	err = tx.QueryRow(ConvertPlaceholders(`
+		INSERT INTO vet_appointments (slot_id, organization_id, pet_id, owner_id, status, comment)
+		VALUES (?, ?, ?, ?, 'booked', ?)
+		RETURNING id
+	`), req.SlotID, orgID, req.PetID, userID, comment).Scan(&appointmentID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to book appointment: "+err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to book appointment: "+err.Error())
+		return
+	}
+
+	appointment, err := getAppointment(int(appointmentID))
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load appointment: "+err.Error())
+		return
+	}
+
+	log.Printf("✅ Appointment %d booked: pet %d, slot %d, organization %d", appointment.ID, req.PetID, req.SlotID, orgID)
+
+	message := fmt.Sprintf("Новая запись на приём: %s, %s", appointment.PetName, formatAppointmentTime(orgID, appointment.StartsAt))
+	notifHandler := &NotificationsHandler{DB: db.DB}
+	if err := notifHandler.CreateNotification(appointment.DoctorID, userID, "appointment_booked", "appointment", appointment.ID, message); err != nil {
+		log.Printf("⚠️ Failed to notify doctor %d about appointment %d: %v", appointment.DoctorID, appointment.ID, err)
+	}
+
+	sendJSONSuccess(w, appointment)
+}
+
+// getClinicCalendar - все слоты клиники за период вместе с записями
+func getClinicCalendar(w http.ResponseWriter, r *http.Request, orgID, userID int) {
+	if !isOrganizationMember(orgID, userID) {
+		sendJSONError(w, http.StatusForbidden, "Only clinic staff can view the calendar")
+		return
+	}
+
+	from, to, err := parseCalendarRange(r, 7)
+	if err != nil {
+		sendJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query := appointmentSlotSelect + " WHERE s.organization_id = ? AND s.starts_at >= ? AND s.starts_at < ?"
+	args := []interface{}{orgID, from, to}
+	if doctorID, err := strconv.Atoi(r.URL.Query().Get("doctor_id")); err == nil {
+		query += " AND s.doctor_id = ?"
+		args = append(args, doctorID)
+	}
+	query += " ORDER BY s.starts_at, s.doctor_id"
+
+	slots, err := queryAppointmentSlots(query, args...)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+
+	appointments, err := queryAppointments(appointmentSelect+`
+		WHERE a.organization_id = ? AND a.status <> 'cancelled' AND s.starts_at >= ? AND s.starts_at < ?
+	`, orgID, from, to)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	bySlot := make(map[int]*models.Appointment, len(appointments))
+	for i := range appointments {
+		bySlot[appointments[i].SlotID] = &appointments[i]
+	}
+	for i := range slots {
+		slots[i].Appointment = bySlot[slots[i].ID]
+	}
+
+	sendJSONSuccess(w, slots)
+}
+
+// AppointmentHandler - записи текущего пользователя и действия с ними
+// GET /api/appointments?upcoming=1
+// GET /api/appointments/{id}
+// POST /api/appointments/{id}/cancel
+// POST /api/appointments/{id}/reschedule
+// POST /api/appointments/{id}/status - отметка клиники (completed, no_show)
+func AppointmentHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 3 || parts[2] == "" {
+		if r.Method != http.MethodGet {
+			sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		query := appointmentSelect + " WHERE a.owner_id = ?"
+		if r.URL.Query().Get("upcoming") == "1" {
+			query += " AND a.status = 'booked' AND s.starts_at >= ? ORDER BY s.starts_at ASC"
+			appointments, err := queryAppointments(query, userID, time.Now().UTC())
+			if err != nil {
+				sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+				return
+			}
+			sendJSONSuccess(w, appointments)
+			return
+		}
+		appointments, err := queryAppointments(query+" ORDER BY s.starts_at DESC", userID)
+		if err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+			return
+		}
+		sendJSONSuccess(w, appointments)
+		return
+	}
+
+	appointmentID, err := strconv.Atoi(parts[2])
+	if err != nil {
+		sendJSONError(w, http.StatusBadRequest, "Invalid appointment ID")
+		return
+	}
+
+	appointment, err := getAppointment(appointmentID)
+	if err == sql.ErrNoRows {
+		sendJSONError(w, http.StatusNotFound, "Appointment not found")
+		return
+	}
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+
+	isOwner := appointment.OwnerID == userID
+	isStaff := appointment.DoctorID == userID || canEditOrganization(appointment.OrganizationID, userID)
+	if !isOwner && !isStaff {
+		sendJSONError(w, http.StatusForbidden, "Access denied")
+		return
+	}
+
+	action := ""
+	if len(parts) > 3 {
+		action = parts[3]
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		sendJSONSuccess(w, appointment)
+	case action == "cancel" && r.Method == http.MethodPost:
+		cancelAppointment(w, r, appointment, userID, isOwner && !isStaff)
+	case action == "reschedule" && r.Method == http.MethodPost:
+		if !isOwner {
+			sendJSONError(w, http.StatusForbidden, "Only the pet owner can reschedule an appointment")
+			return
+		}
+		rescheduleAppointment(w, r, appointment, userID)
+	case action == "status" && r.Method == http.MethodPost:
+		if !isStaff {
+			sendJSONError(w, http.StatusForbidden, "Only clinic staff can update the appointment status")
+			return
+		}
+		updateAppointmentStatus(w, r, appointment)
+	default:
+		sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// cancelAppointment - владелец отменяет не позже appointmentChangeDeadline до приёма, клиника - в любое время
+func cancelAppointment(w http.ResponseWriter, r *http.Request, appointment *models.Appointment, userID int, ownerOnly bool) {
+	var req models.CancelAppointmentRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendJSONError(w, http.StatusBadRequest, "Invalid request: "+err.Error())
+			return
+		}
+	}
+
+	if appointment.Status != "booked" {
+		sendJSONError(w, http.StatusConflict, "Appointment is already "+appointment.Status)
+		return
+	}
+	if ownerOnly && time.Until(appointment.StartsAt) < appointmentChangeDeadline {
+		sendJSONError(w, http.StatusBadRequest, fmt.Sprintf("Appointments can be cancelled no later than %d hours before the visit. Please contact the clinic", int(appointmentChangeDeadline.Hours())))
+		return
+	}
+	if !ownerOnly && strings.TrimSpace(req.Reason) == "" && appointment.OwnerID != userID {
+		sendJSONError(w, http.StatusBadRequest, "reason is required when the clinic cancels an appointment")
+		return
+	}
+
+	result, err := db.DB.Exec(ConvertPlaceholders(`
+		UPDATE vet_appointments
+		SET status = 'cancelled', cancelled_by = ?, cancel_reason = ?, cancelled_at = NOW(), updated_at = NOW()
+		WHERE id = ? AND status = 'booked'
+	`), userID, nullIfEmpty(req.Reason), appointment.ID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to cancel appointment: "+err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		sendJSONError(w, http.StatusConflict, "Appointment is no longer active")
+		return
+	}
+
+	// Уведомляем другую сторону
+	when := formatAppointmentTime(appointment.OrganizationID, appointment.StartsAt)
+	notifHandler := &NotificationsHandler{DB: db.DB}
+	if userID == appointment.OwnerID {
+		message := fmt.Sprintf("Запись отменена владельцем: %s, %s", appointment.PetName, when)
+		if err := notifHandler.CreateNotification(appointment.DoctorID, userID, "appointment_cancelled", "appointment", appointment.ID, message); err != nil {
+			log.Printf("⚠️ Failed to notify doctor %d about cancellation %d: %v", appointment.DoctorID, appointment.ID, err)
+		}
+	} else {
+		message := fmt.Sprintf("Клиника «%s» отменила запись на %s: %s", appointment.OrganizationName, when, req.Reason)
+		if err := notifHandler.CreateNotification(appointment.OwnerID, userID, "appointment_cancelled", "appointment", appointment.ID, message); err != nil {
+			log.Printf("⚠️ Failed to notify owner %d about cancellation %d: %v", appointment.OwnerID, appointment.ID, err)
+		}
+	}
+
+	updated, err := getAppointment(appointment.ID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load appointment: "+err.Error())
+		return
+	}
+	sendJSONSuccess(w, updated)
+}
+
+// rescheduleAppointment переносит запись на другой свободный слот той же клиники
+func rescheduleAppointment(w http.ResponseWriter, r *http.Request, appointment *models.Appointment, userID int) {
+	var req models.RescheduleAppointmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+	if req.SlotID == appointment.SlotID {
+		sendJSONError(w, http.StatusBadRequest, "Appointment is already in this slot")
+		return
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	// Блокируем запись, чтобы параллельная отмена или перенос не прошли одновременно
+	var status string
+	var currentStart time.Time
+	err = tx.QueryRow(ConvertPlaceholders(`
+		SELECT a.status, s.starts_at FROM vet_appointments a
+		JOIN vet_appointment_slots s ON a.slot_id = s.id
+		WHERE a.id = ? FOR UPDATE OF a
+	`), appointment.ID).Scan(&status, &currentStart)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	if status != "booked" {
+		sendJSONError(w, http.StatusConflict, "Appointment is already "+status)
+		return
+	}
+	if time.Until(currentStart) < appointmentChangeDeadline {
+		sendJSONError(w, http.StatusBadRequest, fmt.Sprintf("Appointments can be rescheduled no later than %d hours before the visit. Please contact the clinic", int(appointmentChangeDeadline.Hours())))
+		return
+	}
+
+	startsAt, endsAt, err := lockBookableSlot(tx, req.SlotID, appointment.OrganizationID)
+	if err != nil {
+		sendSlotLockError(w, err)
+		return
+	}
+
+	if busy, err := petHasOverlappingAppointment(tx, appointment.PetID, appointment.ID, startsAt, endsAt); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	} else if busy {
+		sendJSONError(w, http.StatusConflict, "This pet already has an appointment at this time")
+		return
+	}
+
+	_, err = tx.Exec(ConvertPlaceholders(`
+		UPDATE vet_appointments SET slot_id = ?, reminder_sent_at = NULL, updated_at = NOW() WHERE id = ?
+	`), req.SlotID, appointment.ID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to reschedule appointment: "+err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to reschedule appointment: "+err.Error())
+		return
+	}
+
+	updated, err := getAppointment(appointment.ID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load appointment: "+err.Error())
+		return
+	}
+
+	message := fmt.Sprintf("Запись перенесена: %s, %s → %s", updated.PetName,
+		formatAppointmentTime(updated.OrganizationID, appointment.StartsAt), formatAppointmentTime(updated.OrganizationID, updated.StartsAt))
+	notifHandler := &NotificationsHandler{DB: db.DB}
+	for _, doctorID := range uniqueInts([]int{appointment.DoctorID, updated.DoctorID}) {
+		if err := notifHandler.CreateNotification(doctorID, userID, "appointment_rescheduled", "appointment", updated.ID, message); err != nil {
+			log.Printf("⚠️ Failed to notify doctor %d about reschedule %d: %v", doctorID, updated.ID, err)
+		}
+	}
+
+	sendJSONSuccess(w, updated)
+}
+
+func updateAppointmentStatus(w http.ResponseWriter, r *http.Request, appointment *models.Appointment) {
+	var req models.UpdateAppointmentStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+	if req.Status != "completed" && req.Status != "no_show" {
+		sendJSONError(w, http.StatusBadRequest, "status must be completed or no_show")
+		return
+	}
+	if appointment.StartsAt.After(time.Now().UTC()) {
+		sendJSONError(w, http.StatusBadRequest, "The visit hasn't started yet")
+		return
+	}
+
+	result, err := db.DB.Exec(ConvertPlaceholders(`
+		UPDATE vet_appointments SET status = ?, updated_at = NOW() WHERE id = ? AND status = 'booked'
+	`), req.Status, appointment.ID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to update appointment: "+err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		sendJSONError(w, http.StatusConflict, "Appointment is already "+appointment.Status)
+		return
+	}
+
+	updated, err := getAppointment(appointment.ID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load appointment: "+err.Error())
+		return
+	}
+	sendJSONSuccess(w, updated)
+}
+
+var (
+	errSlotNotFound    = fmt.Errorf("slot not found")
+	errSlotUnavailable = fmt.Errorf("slot is not available")
+	errSlotBooked      = fmt.Errorf("slot is already booked")
+)
+
+// lockBookableSlot блокирует слот (SELECT ... FOR UPDATE) и проверяет, что на него можно записаться.
+// Блокировка сериализует параллельные попытки записи на один слот.
+func lockBookableSlot(tx *sql.Tx, slotID, orgID int) (time.Time, time.Time, error) {
+	var slotOrgID int
+	var status string
+	var startsAt, endsAt time.Time
+	err := tx.QueryRow(ConvertPlaceholders(`
+		SELECT organization_id, status, starts_at, ends_at FROM vet_appointment_slots WHERE id = ? FOR UPDATE
+	`), slotID).Scan(&slotOrgID, &status, &startsAt, &endsAt)
+	if err == sql.ErrNoRows || (err == nil && slotOrgID != orgID) {
+		return startsAt, endsAt, errSlotNotFound
+	}
+	if err != nil {
+		return startsAt, endsAt, err
+	}
+	if status != "open" || !startsAt.After(time.Now().UTC()) {
+		return startsAt, endsAt, errSlotUnavailable
+	}
+
+	var booked bool
+	err = tx.QueryRow(ConvertPlaceholders(`
+		SELECT EXISTS(SELECT 1 FROM vet_appointments WHERE slot_id = ? AND status = 'booked')
+	`), slotID).Scan(&booked)
+	if err != nil {
+		return startsAt, endsAt, err
+	}
+	if booked {
+		return startsAt, endsAt, errSlotBooked
+	}
+
+	return startsAt, endsAt, nil
+}
+
+func sendSlotLockError(w http.ResponseWriter, err error) {
+	switch err {
+	case errSlotNotFound:
+		sendJSONError(w, http.StatusNotFound, "Slot not found")
+	case errSlotUnavailable:
+		sendJSONError(w, http.StatusBadRequest, "Slot is not available for booking")
+	case errSlotBooked:
+		sendJSONError(w, http.StatusConflict, "Slot is already booked")
+	default:
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+	}
+}
+
+func petHasOverlappingAppointment(tx *sql.Tx, petID, excludeAppointmentID int, startsAt, endsAt time.Time) (bool, error) {
+	var busy bool
+	err := tx.QueryRow(ConvertPlaceholders(`
+		SELECT EXISTS(
+			SELECT 1 FROM vet_appointments a
+			JOIN vet_appointment_slots s ON a.slot_id = s.id
+			WHERE a.pet_id = ? AND a.status = 'booked' AND a.id <> ?
+			AND s.starts_at < ? AND s.ends_at > ?
+		)
+	`), petID, excludeAppointmentID, endsAt, startsAt).Scan(&busy)
+	return busy, err
+}
+
+// parseCalendarRange читает from/to (YYYY-MM-DD, to включительно); по умолчанию - defaultDays дней от сегодня.
+// Границы дней - в UTC, как и starts_at слотов
+func parseCalendarRange(r *http.Request, defaultDays int) (time.Time, time.Time, error) {
+	from := truncateToDay(time.Now().UTC())
+	if v := r.URL.Query().Get("from"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return from, from, fmt.Errorf("from must be in YYYY-MM-DD format")
+		}
+		from = parsed
+	}
+	to := from.AddDate(0, 0, defaultDays)
+	if v := r.URL.Query().Get("to"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return from, to, fmt.Errorf("to must be in YYYY-MM-DD format")
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+	if !to.After(from) {
+		return from, to, fmt.Errorf("to must not be before from")
+	}
+	if to.Sub(from) > 62*24*time.Hour {
+		return from, to, fmt.Errorf("date range must not exceed 62 days")
+	}
+	return from, to, nil
+}
+
+// formatAppointmentTime форматирует время приёма в часовом поясе клиники
+func formatAppointmentTime(orgID int, t time.Time) string {
+	var timezone string
+	db.DB.QueryRow(ConvertPlaceholders("SELECT COALESCE(timezone, '') FROM organizations WHERE id = ?"), orgID).Scan(&timezone)
+	location, err := time.LoadLocation(timezone)
+	if timezone == "" || err != nil {
+		location, _ = time.LoadLocation(defaultOrganizationTimezone)
+	}
+	return t.In(location).Format("02.01.2006 15:04")
+}
+
+// StartAppointmentReminders периодически отправляет напоминания о приёмах в ближайшие appointmentReminderLead
+func StartAppointmentReminders(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			sendAppointmentReminders()
+			<-ticker.C
+		}
+	}()
+}
+
+func sendAppointmentReminders() {
+	// starts_at хранится в UTC (TIMESTAMP без зоны): локальное время сервера сдвинуло бы окно на его смещение
+	now := time.Now().UTC()
+	appointments, err := queryAppointments(appointmentSelect+`
+		WHERE a.status = 'booked' AND a.reminder_sent_at IS NULL
+		AND s.starts_at > ? AND s.starts_at <= ?
+	`, now, now.Add(appointmentReminderLead))
+	if err != nil {
+		log.Printf("❌ Appointment reminders: %v", err)
+		return
+	}
+
+	notifHandler := &NotificationsHandler{DB: db.DB}
+	for _, a := range appointments {
+		// Помечаем заранее, чтобы при нескольких экземплярах напоминание ушло один раз
+		result, err := db.DB.Exec(ConvertPlaceholders(`
+			UPDATE vet_appointments SET reminder_sent_at = NOW() WHERE id = ? AND reminder_sent_at IS NULL
+		`), a.ID)
+		if err != nil {
+			log.Printf("❌ Appointment reminders: failed to mark appointment %d: %v", a.ID, err)
+			continue
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			continue
+		}
+
+		message := fmt.Sprintf("Напоминание: %s — приём в «%s» %s", a.PetName, a.OrganizationName, formatAppointmentTime(a.OrganizationID, a.StartsAt))
+		if err := notifHandler.CreateNotification(a.OwnerID, 0, "appointment_reminder", "appointment", a.ID, message); err != nil {
+			log.Printf("⚠️ Failed to send reminder for appointment %d: %v", a.ID, err)
+		}
+	}
+}
+
+const appointmentSlotSelect = `
+	SELECT s.id, s.organization_id, s.doctor_id, s.service_id, s.starts_at, s.ends_at, s.status, s.created_at,
+	       EXISTS(SELECT 1 FROM vet_appointments a WHERE a.slot_id = s.id AND a.status = 'booked'),
+	       u.name || ' ' || COALESCE(u.last_name, ''), sv.name
+	FROM vet_appointment_slots s
+	JOIN users u ON s.doctor_id = u.id
+	LEFT JOIN organization_services sv ON s.service_id = sv.id
+`
+
+func queryAppointmentSlots(query string, args ...interface{}) ([]models.AppointmentSlot, error) {
+	rows, err := db.DB.Query(ConvertPlaceholders(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	slots := []models.AppointmentSlot{}
+	for rows.Next() {
+		var s models.AppointmentSlot
+		err := rows.Scan(
+			&s.ID, &s.OrganizationID, &s.DoctorID, &s.ServiceID, &s.StartsAt, &s.EndsAt, &s.Status, &s.CreatedAt,
+			&s.IsBooked, &s.DoctorName, &s.ServiceName,
+		)
+		if err != nil {
+			return nil, err
+		}
+		s.DoctorName = strings.TrimSpace(s.DoctorName)
+		slots = append(slots, s)
+	}
+	return slots, rows.Err()
+}
+
+const appointmentSelect = `
+	SELECT a.id, a.slot_id, a.organization_id, a.pet_id, a.owner_id, a.status, a.comment,
+	       a.cancelled_by, a.cancel_reason, a.cancelled_at, a.reminder_sent_at, a.created_at, a.updated_at,
+	       s.starts_at, s.ends_at, s.doctor_id,
+	       d.name || ' ' || COALESCE(d.last_name, ''), sv.name,
+	       p.name, ou.name || ' ' || COALESCE(ou.last_name, ''), o.name
+	FROM vet_appointments a
+	JOIN vet_appointment_slots s ON a.slot_id = s.id
+	JOIN users d ON s.doctor_id = d.id
+	LEFT JOIN organization_services sv ON s.service_id = sv.id
+	JOIN pets p ON a.pet_id = p.id
+	JOIN users ou ON a.owner_id = ou.id
+	JOIN organizations o ON a.organization_id = o.id
+`
+
+func getAppointment(appointmentID int) (*models.Appointment, error) {
+	appointments, err := queryAppointments(appointmentSelect+" WHERE a.id = ?", appointmentID)
+	if err != nil {
+		return nil, err
+	}
+	if len(appointments) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &appointments[0], nil
+}
+
+func queryAppointments(query string, args ...interface{}) ([]models.Appointment, error) {
+	rows, err := db.DB.Query(ConvertPlaceholders(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	appointments := []models.Appointment{}
+	for rows.Next() {
+		var a models.Appointment
+		err := rows.Scan(
+			&a.ID, &a.SlotID, &a.OrganizationID, &a.PetID, &a.OwnerID, &a.Status, &a.Comment,
+			&a.CancelledBy, &a.CancelReason, &a.CancelledAt, &a.ReminderSentAt, &a.CreatedAt, &a.UpdatedAt,
+			&a.StartsAt, &a.EndsAt, &a.DoctorID,
+			&a.DoctorName, &a.ServiceName,
+			&a.PetName, &a.OwnerName, &a.OrganizationName,
+		)
+		if err != nil {
+			return nil, err
+		}
+		a.DoctorName = strings.TrimSpace(a.DoctorName)
+		a.OwnerName = strings.TrimSpace(a.OwnerName)
+		appointments = append(appointments, a)
+	}
+	return appointments, rows.Err()
+}
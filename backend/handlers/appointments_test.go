@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slotInsertsDB отвечает на запросы createAppointmentSlots и запоминает starts_at/ends_at вставленных слотов
+func slotInsertsDB(mu *sync.Mutex, inserted *[][2]time.Time) fakeQueryFunc {
+	return func(query string, args []driver.Value) (*fakeResult, error) {
+		switch {
+		case strings.Contains(query, "SELECT type FROM organizations"):
+			return &fakeResult{rows: [][]driver.Value{{"vet_clinic"}}}, nil
+		case strings.Contains(query, "SELECT EXISTS(SELECT 1 FROM organization_members"):
+			return &fakeResult{rows: [][]driver.Value{{true}}}, nil
+		case strings.Contains(query, "FROM vet_appointment_slots WHERE doctor_id"):
+			return &fakeResult{rows: [][]driver.Value{{false}}}, nil
+		case strings.Contains(query, "INSERT INTO vet_appointment_slots"):
+			mu.Lock()
+			defer mu.Unlock()
+			*inserted = append(*inserted, [2]time.Time{args[3].(time.Time), args[4].(time.Time)})
+			return &fakeResult{rows: [][]driver.Value{{int64(len(*inserted))}}}, nil
+		}
+		return &fakeResult{}, nil
+	}
+}
+
+func TestCreateAppointmentSlotsStoresUTC(t *testing.T) {
+	// Начало слота - послезавтра в 10:00 по времени клиента
+	day := time.Now().UTC().Add(48 * time.Hour)
+
+	tests := []struct {
+		name   string
+		offset int // смещение клиента в часах
+	}{
+		{"moscow", 3},
+		{"new york", -5},
+		{"utc", 0},
+		{"kamchatka", 12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var inserted [][2]time.Time
+			useFakeDB(t, slotInsertsDB(&mu, &inserted))
+
+			zone := time.FixedZone("client", tt.offset*3600)
+			local := time.Date(day.Year(), day.Month(), day.Day(), 10, 0, 0, 0, zone)
+			body := `{"doctor_id": 7, "starts_at": "` + local.Format(time.RFC3339) + `", "duration_minutes": 30, "count": 2}`
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/organizations/1/appointment-slots", strings.NewReader(body))
+			createAppointmentSlots(rec, req, 1, 42)
+
+			if len(inserted) != 2 {
+				t.Fatalf("inserted %d slots, want 2 (status %d: %s)", len(inserted), rec.Code, rec.Body.String())
+			}
+
+			for i, slot := range inserted {
+				wantStart := local.Add(time.Duration(i*30) * time.Minute)
+				start, end := slot[0], slot[1]
+
+				// В TIMESTAMP без зоны lib/pq пишет часы как есть, поэтому значение должно быть в UTC
+				if start.Location() != time.UTC || end.Location() != time.UTC {
+					t.Errorf("slot %d: location %v/%v, want UTC", i, start.Location(), end.Location())
+				}
+				if !start.Equal(wantStart) {
+					t.Errorf("slot %d: starts_at %s, want %s", i, start, wantStart.UTC())
+				}
+				if got := start.Hour(); got != (10-tt.offset+24)%24 {
+					t.Errorf("slot %d: stored hour %d, want %d", i, got, (10-tt.offset+24)%24)
+				}
+				if end.Sub(start) != 30*time.Minute {
+					t.Errorf("slot %d: duration %s, want 30m", i, end.Sub(start))
+				}
+			}
+		})
+	}
+}
+
+func TestCreateAppointmentSlotsRejectsPastOffsetTime(t *testing.T) {
+	var mu sync.Mutex
+	var inserted [][2]time.Time
+	useFakeDB(t, slotInsertsDB(&mu, &inserted))
+
+	// В UTC это уже прошедшее время, хотя часы клиента "впереди"
+	past := time.Now().Add(-time.Hour).In(time.FixedZone("client", 5*3600))
+	body := `{"doctor_id": 7, "starts_at": "` + past.Format(time.RFC3339) + `"}`
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/organizations/1/appointment-slots", strings.NewReader(body))
+	createAppointmentSlots(rec, req, 1, 42)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, want 400", rec.Code)
+	}
+	if len(inserted) != 0 {
+		t.Fatalf("inserted %d slots, want 0", len(inserted))
+	}
+}
+
+func TestFormatAppointmentTime(t *testing.T) {
+	// Время хранится в UTC, а показывается в часовом поясе клиники
+	stored := time.Date(2026, 11, 1, 7, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		timezone string
+		want     string
+	}{
+		{"default", "", "01.11.2026 10:00"},
+		{"novosibirsk", "Asia/Novosibirsk", "01.11.2026 14:00"},
+		{"unknown zone", "Mars/Olympus", "01.11.2026 10:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+				return &fakeResult{rows: [][]driver.Value{{tt.timezone}}}, nil
+			})
+			if got := formatAppointmentTime(1, stored); got != tt.want {
+				t.Errorf("formatAppointmentTime = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// withLocalZone меняет часовой пояс "сервера" до конца теста
+func withLocalZone(t *testing.T, offsetHours int) {
+	t.Helper()
+	prev := time.Local
+	time.Local = time.FixedZone("server", offsetHours*3600)
+	t.Cleanup(func() { time.Local = prev })
+}
+
+func TestSendAppointmentRemindersWindowInUTC(t *testing.T) {
+	for _, offset := range []int{5, -7} {
+		t.Run(fmt.Sprintf("UTC%+d", offset), func(t *testing.T) {
+			withLocalZone(t, offset)
+
+			var window []time.Time
+			useFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+				if strings.Contains(query, "a.reminder_sent_at IS NULL") {
+					window = []time.Time{args[0].(time.Time), args[1].(time.Time)}
+				}
+				return &fakeResult{}, nil
+			})
+
+			before := time.Now()
+			sendAppointmentReminders()
+
+			if len(window) != 2 {
+				t.Fatal("reminder query was not run")
+			}
+			from, to := window[0], window[1]
+			// В TIMESTAMP без зоны попадают часы как есть - они должны быть часами UTC
+			if from.Location() != time.UTC || to.Location() != time.UTC {
+				t.Fatalf("window in %v/%v, want UTC", from.Location(), to.Location())
+			}
+			if from.Sub(before) < 0 || from.Sub(before) > time.Second {
+				t.Errorf("window starts at %s, want now (%s)", from, before.UTC())
+			}
+			if to.Sub(from) != appointmentReminderLead {
+				t.Errorf("window length %s, want %s", to.Sub(from), appointmentReminderLead)
+			}
+		})
+	}
+}
+
+func TestParseCalendarRangeDefaultsToUTCDay(t *testing.T) {
+	// На краях шкалы часовых поясов местная дата почти всегда отличается от даты в UTC
+	for _, offset := range []int{14, -12} {
+		t.Run(fmt.Sprintf("UTC%+d", offset), func(t *testing.T) {
+			withLocalZone(t, offset)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/organizations/1/appointment-slots", nil)
+			from, to, err := parseCalendarRange(req, 7)
+			if err != nil {
+				t.Fatal(err)
+			}
+			nowUTC := time.Now().UTC()
+			want := time.Date(nowUTC.Year(), nowUTC.Month(), nowUTC.Day(), 0, 0, 0, 0, time.UTC)
+			if !from.Equal(want) || from.Location() != time.UTC {
+				t.Errorf("from = %s, want %s", from, want)
+			}
+			if to.Sub(from) != 7*24*time.Hour {
+				t.Errorf("range %s, want 7 days", to.Sub(from))
+			}
+		})
+	}
+}
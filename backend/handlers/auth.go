@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"backend/db"
+	"backend/middleware"
 	"backend/models"
 	"bytes"
 	"database/sql"
@@ -305,6 +306,12 @@ func MeHandler(w http.ResponseWriter, r *http.Request) {
 
 		userID := int(claims["user_id"].(float64))
 
+		// Токен выдан до сброса пароля
+		if middleware.IsTokenRevoked(userID, claims) {
+			sendError(w, "Session revoked", http.StatusUnauthorized)
+			return
+		}
+
 		// Получаем данные пользователя из локальной БД
 		var user models.User
 		var lastName, bio, phone, location, avatar, coverPhoto sql.NullString
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"backend/db"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Минимальный драйвер database/sql для тестов обработчиков без PostgreSQL:
+// каждый запрос передаётся функции теста, которая возвращает строки результата.
+
+// fakeResult - ответ на один запрос (строки для SELECT/RETURNING или число затронутых строк)
+type fakeResult struct {
+	columns  []string
+	rows     [][]driver.Value
+	affected int64
+}
+
+// fakeQueryFunc получает запрос (с пробелами, сжатыми в один) и аргументы
+type fakeQueryFunc func(query string, args []driver.Value) (*fakeResult, error)
+
+type fakeDriver struct {
+	mu       sync.Mutex
+	handlers map[string]fakeQueryFunc
+}
+
+var fakeDrv = &fakeDriver{handlers: make(map[string]fakeQueryFunc)}
+
+func init() {
+	sql.Register("handlers_fakedb", fakeDrv)
+}
+
+// useFakeDB подменяет db.DB на фейковую базу до конца теста
+func useFakeDB(t *testing.T, fn fakeQueryFunc) {
+	t.Helper()
+
+	fakeDrv.mu.Lock()
+	fakeDrv.handlers[t.Name()] = fn
+	fakeDrv.mu.Unlock()
+
+	conn, err := sql.Open("handlers_fakedb", t.Name())
+	if err != nil {
+		t.Fatalf("open fake db: %v", err)
+	}
+
+	prev := db.DB
+	db.DB = conn
+	t.Cleanup(func() {
+		conn.Close()
+		db.DB = prev
+		fakeDrv.mu.Lock()
+		delete(fakeDrv.handlers, t.Name())
+		fakeDrv.mu.Unlock()
+	})
+}
+
+// emptyDB - на любой запрос пустой результат
+func emptyDB(query string, args []driver.Value) (*fakeResult, error) {
+	return &fakeResult{}, nil
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	d.mu.Lock()
+	fn, ok := d.handlers[name]
+	d.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("fake db %q is not registered", name)
+	}
+	return &fakeConn{fn: fn}, nil
+}
+
+type fakeConn struct {
+	fn fakeQueryFunc
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: strings.Join(strings.Fields(query), " ")}, nil
+}
+func (c *fakeConn) Close() error { return nil }
+
+// Транзакции тоже приходят функции теста запросами "BEGIN", "COMMIT" и "ROLLBACK"
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	if _, err := c.fn("BEGIN", nil); err != nil {
+		return nil, err
+	}
+	return fakeTx{conn: c}, nil
+}
+
+type fakeTx struct {
+	conn *fakeConn
+}
+
+func (tx fakeTx) Commit() error {
+	_, err := tx.conn.fn("COMMIT", nil)
+	return err
+}
+
+func (tx fakeTx) Rollback() error {
+	_, err := tx.conn.fn("ROLLBACK", nil)
+	return err
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	res, err := s.conn.fn(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(res.affected), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	res, err := s.conn.fn(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{columns: res.columns, rows: res.rows}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+	pos     int
+}
+
+func (r *fakeRows) Columns() []string {
+	if r.columns == nil && len(r.rows) > 0 {
+		// Имена колонок тестам не важны, важно только их число
+		cols := make([]string, len(r.rows[0]))
+		for i := range cols {
+			cols[i] = fmt.Sprintf("c%d", i)
+		}
+		return cols
+	}
+	return r.columns
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.pos])
+	r.pos++
+	return nil
+}
@@ -0,0 +1,771 @@
+package handlers
+
+import (
+	"backend/db"
+	"backend/models"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Периодичность отчётов передержки по умолчанию
+	defaultCheckinIntervalDays = 7
+	// За сколько дней до окончания размещение попадает в "заканчивается скоро"
+	fosterEndingSoonDays = 7
+)
+
+// FosterHomesHandler - анкеты передержек
+// GET    /api/foster-homes?species=cat&city=Москва - свободные передержки (для участников организаций)
+// GET    /api/foster-homes/me - своя анкета
+// PUT    /api/foster-homes/me - зарегистрироваться или изменить анкету (роль volunteer)
+// DELETE /api/foster-homes/me - скрыть анкету
+// GET    /api/foster-homes/me/placements - свои размещения
+func FosterHomesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 3 || parts[2] == "" {
+		if r.Method != http.MethodGet {
+			sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		listFosterHomes(w, r, userID)
+		return
+	}
+
+	if parts[2] != "me" {
+		sendJSONError(w, http.StatusNotFound, "Not found")
+		return
+	}
+
+	if len(parts) > 3 {
+		if parts[3] != "placements" || r.Method != http.MethodGet {
+			sendJSONError(w, http.StatusNotFound, "Not found")
+			return
+		}
+		placements, err := queryFosterPlacements(fosterPlacementSelect+" WHERE fp.foster_user_id = ? ORDER BY fp.status = 'active' DESC, fp.start_date DESC", userID)
+		if err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+			return
+		}
+		sendJSONSuccess(w, placements)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		home, err := getFosterHomeByUser(userID)
+		if err == sql.ErrNoRows {
+			sendJSONError(w, http.StatusNotFound, "Foster home not registered")
+			return
+		}
+		if err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+			return
+		}
+		sendJSONSuccess(w, home)
+	case http.MethodPut:
+		saveFosterHome(w, r, userID)
+	case http.MethodDelete:
+		_, err := db.DB.Exec(ConvertPlaceholders("UPDATE foster_homes SET is_active = FALSE, updated_at = NOW() WHERE user_id = ?"), userID)
+		if err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Failed to update foster home: "+err.Error())
+			return
+		}
+		sendJSONSuccess(w, map[string]interface{}{"message": "Foster home deactivated"})
+	default:
+		sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// listFosterHomes - поиск передержек доступен только участникам организаций
+func listFosterHomes(w http.ResponseWriter, r *http.Request, userID int) {
+	var isMember bool
+	db.DB.QueryRow(ConvertPlaceholders("SELECT EXISTS(SELECT 1 FROM organization_members WHERE user_id = ?)"), userID).Scan(&isMember)
+	if !isMember {
+		sendJSONError(w, http.StatusForbidden, "Only organization members can search foster homes")
+		return
+	}
+
+	query := fosterHomeSelect + " WHERE fh.is_active = TRUE"
+	var args []interface{}
+	if city := strings.TrimSpace(r.URL.Query().Get("city")); city != "" {
+		query += " AND LOWER(fh.city) = LOWER(?)"
+		args = append(args, city)
+	}
+	query += " ORDER BY fh.updated_at DESC"
+
+	homes, err := queryFosterHomes(query, args...)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+
+	// Фильтр по виду и свободным местам - после загрузки, виды хранятся JSON-массивом
+	species := r.URL.Query().Get("species")
+	onlyFree := r.URL.Query().Get("available") != "0"
+	filtered := []models.FosterHome{}
+	for _, home := range homes {
+		if onlyFree && home.FreeCapacity == 0 {
+			continue
+		}
+		if species != "" && !fosterAcceptsSpecies(&home, species) {
+			continue
+		}
+		filtered = append(filtered, home)
+	}
+
+	sendJSONSuccess(w, filtered)
+}
+
+func saveFosterHome(w http.ResponseWriter, r *http.Request, userID int) {
+	if !hasRole(db.DB, userID, models.RoleVolunteer) {
+		sendJSONError(w, http.StatusForbidden, "Only volunteers can register as foster homes")
+		return
+	}
+
+	var req models.FosterHomeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+	if req.Capacity <= 0 {
+		sendJSONError(w, http.StatusBadRequest, "capacity must be positive")
+		return
+	}
+
+	species := []string{}
+	for _, s := range req.Species {
+		if s = strings.ToLower(strings.TrimSpace(s)); s != "" {
+			species = append(species, s)
+		}
+	}
+	speciesJSON, _ := json.Marshal(species)
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	_, err := db.DB.Exec(ConvertPlaceholders(`
+		INSERT INTO foster_homes (user_id, capacity, species, city, conditions, is_active)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET
+			capacity = EXCLUDED.capacity, species = EXCLUDED.species, city = EXCLUDED.city,
+			conditions = EXCLUDED.conditions, is_active = EXCLUDED.is_active, updated_at = NOW()
+	`), userID, req.Capacity, string(speciesJSON), req.City, req.Conditions, isActive)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to save foster home: "+err.Error())
+		return
+	}
+
+	home, err := getFosterHomeByUser(userID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load foster home: "+err.Error())
+		return
+	}
+	sendJSONSuccess(w, home)
+}
+
+// OrganizationFostersHandler - размещения питомцев организации на передержках
+// GET  /api/organizations/{id}/fosters - обзор: кто где находится
+// POST /api/organizations/{id}/fosters - разместить питомца
+// GET  /api/organizations/{id}/fosters/{placementId} - размещение с отчётами
+// PUT  /api/organizations/{id}/fosters/{placementId} - изменить даты и условия
+// POST /api/organizations/{id}/fosters/{placementId}/end - завершить размещение
+// GET|POST /api/organizations/{id}/fosters/{placementId}/checkins - отчёты передержки
+func OrganizationFostersHandler(w http.ResponseWriter, r *http.Request, orgID int, rest []string) {
+	userID, ok := RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	if len(rest) == 0 {
+		if !canEditOrganization(orgID, userID) {
+			sendJSONError(w, http.StatusForbidden, "You don't have permission to manage foster placements")
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			getFosterOverview(w, orgID)
+		case http.MethodPost:
+			createFosterPlacement(w, r, orgID, userID)
+		default:
+			sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+		return
+	}
+
+	placementID, err := strconv.Atoi(rest[0])
+	if err != nil {
+		sendJSONError(w, http.StatusBadRequest, "Invalid placement ID")
+		return
+	}
+	placement, err := getFosterPlacement(placementID)
+	if err == sql.ErrNoRows || (err == nil && placement.OrganizationID != orgID) {
+		sendJSONError(w, http.StatusNotFound, "Placement not found")
+		return
+	}
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+
+	isStaff := canEditOrganization(orgID, userID)
+	isFoster := placement.FosterUserID == userID
+	if !isStaff && !isFoster {
+		sendJSONError(w, http.StatusForbidden, "Access denied")
+		return
+	}
+
+	action := ""
+	if len(rest) > 1 {
+		action = rest[1]
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		checkins, err := loadFosterCheckins(placement.ID)
+		if err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+			return
+		}
+		placement.Checkins = checkins
+		sendJSONSuccess(w, placement)
+	case action == "" && r.Method == http.MethodPut && isStaff:
+		updateFosterPlacement(w, r, placement)
+	case action == "end" && r.Method == http.MethodPost && isStaff:
+		endFosterPlacement(w, r, placement, userID)
+	case action == "checkins" && r.Method == http.MethodGet:
+		checkins, err := loadFosterCheckins(placement.ID)
+		if err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+			return
+		}
+		sendJSONSuccess(w, checkins)
+	case action == "checkins" && r.Method == http.MethodPost && isFoster:
+		createFosterCheckin(w, r, placement, userID)
+	case action == "" || action == "end" || action == "checkins":
+		sendJSONError(w, http.StatusForbidden, "Access denied")
+	default:
+		sendJSONError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+// getFosterOverview - питомцы на передержках и оставшиеся в приюте
+func getFosterOverview(w http.ResponseWriter, orgID int) {
+	placements, err := queryFosterPlacements(fosterPlacementSelect+`
+		WHERE fp.organization_id = ? AND fp.status = 'active'
+		ORDER BY fp.start_date ASC
+	`, orgID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+
+	overview := models.FosterOverview{Placements: placements, AtShelter: []models.Pet{}, EndingSoonIDs: []int{}}
+	soon := truncateToDay(time.Now()).AddDate(0, 0, fosterEndingSoonDays)
+	for _, p := range placements {
+		if p.CheckinOverdue {
+			overview.OverdueCount++
+		}
+		if p.EndDate != nil && !p.EndDate.After(soon) {
+			overview.EndingSoonIDs = append(overview.EndingSoonIDs, p.ID)
+		}
+	}
+
+	rows, err := db.DB.Query(ConvertPlaceholders(`
+		SELECT p.id, p.user_id, p.name, p.species, COALESCE(p.photo, ''), p.created_at
+		FROM pets p
+		WHERE p.organization_id = ?
+		AND NOT EXISTS (SELECT 1 FROM foster_placements fp WHERE fp.pet_id = p.id AND fp.status = 'active')
+		ORDER BY p.name
+	`), orgID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var pet models.Pet
+		if err := rows.Scan(&pet.ID, &pet.UserID, &pet.Name, &pet.Species, &pet.Photo, &pet.CreatedAt); err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+			return
+		}
+		pet.OrganizationID = &orgID
+		overview.AtShelter = append(overview.AtShelter, pet)
+	}
+
+	sendJSONSuccess(w, overview)
+}
+
+func createFosterPlacement(w http.ResponseWriter, r *http.Request, orgID, userID int) {
+	var req models.CreateFosterPlacementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	startDate := truncateToDay(time.Now())
+	if req.StartDate != "" {
+		parsed, err := time.Parse("2006-01-02", req.StartDate)
+		if err != nil {
+			sendJSONError(w, http.StatusBadRequest, "start_date must be in YYYY-MM-DD format")
+			return
+		}
+		startDate = parsed
+	}
+	var endDate *time.Time
+	if req.EndDate != nil && *req.EndDate != "" {
+		parsed, err := time.Parse("2006-01-02", *req.EndDate)
+		if err != nil {
+			sendJSONError(w, http.StatusBadRequest, "end_date must be in YYYY-MM-DD format")
+			return
+		}
+		if parsed.Before(startDate) {
+			sendJSONError(w, http.StatusBadRequest, "end_date must not be before start_date")
+			return
+		}
+		endDate = &parsed
+	}
+	interval := req.CheckinIntervalDays
+	if interval == 0 {
+		interval = defaultCheckinIntervalDays
+	}
+	if interval < 0 {
+		sendJSONError(w, http.StatusBadRequest, "checkin_interval_days must be positive")
+		return
+	}
+
+	// Питомец должен принадлежать организации
+	var petOrgID sql.NullInt64
+	var petName, petSpecies string
+	err := db.DB.QueryRow(ConvertPlaceholders("SELECT organization_id, name, species FROM pets WHERE id = ?"), req.PetID).Scan(&petOrgID, &petName, &petSpecies)
+	if err == sql.ErrNoRows {
+		sendJSONError(w, http.StatusNotFound, "Pet not found")
+		return
+	}
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	if !petOrgID.Valid || int(petOrgID.Int64) != orgID {
+		sendJSONError(w, http.StatusBadRequest, "Pet doesn't belong to this organization")
+		return
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	// Блокируем анкету, чтобы параллельные размещения не превысили вместимость
+	var fosterUserID, capacity int
+	var speciesJSON string
+	var isActive bool
+	err = tx.QueryRow(ConvertPlaceholders(`
+		SELECT user_id, capacity, species, is_active FROM foster_homes WHERE id = ? FOR UPDATE
+	`), req.FosterHomeID).Scan(&fosterUserID, &capacity, &speciesJSON, &isActive)
+	if err == sql.ErrNoRows {
+		sendJSONError(w, http.StatusNotFound, "Foster home not found")
+		return
+	}
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	if !isActive {
+		sendJSONError(w, http.StatusConflict, "Foster home is not accepting animals")
+		return
+	}
+
+	home := models.FosterHome{}
+	json.Unmarshal([]byte(speciesJSON), &home.Species)
+	if !fosterAcceptsSpecies(&home, petSpecies) {
+		sendJSONError(w, http.StatusConflict, "Foster home doesn't accept this species")
+		return
+	}
+
+	var activeCount int
+	err = tx.QueryRow(ConvertPlaceholders(`
+		SELECT COUNT(*) FROM foster_placements WHERE foster_home_id = ? AND status = 'active'
+	`), req.FosterHomeID).Scan(&activeCount)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	if activeCount >= capacity {
+		sendJSONError(w, http.StatusConflict, "Foster home is at full capacity")
+		return
+	}
+
+	var alreadyPlaced bool
+	err = tx.QueryRow(ConvertPlaceholders(`
+		SELECT EXISTS(SELECT 1 FROM foster_placements WHERE pet_id = ? AND status = 'active')
+	`), req.PetID).Scan(&alreadyPlaced)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	if alreadyPlaced {
+		sendJSONError(w, http.StatusConflict, "Pet is already in foster care")
+		return
+	}
+
+	var placementID int64
+	err = tx.QueryRow(ConvertPlaceholders(`
+		INSERT INTO foster_placements (organization_id, pet_id, foster_home_id, foster_user_id, start_date, end_date, notes, checkin_interval_days, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`), orgID, req.PetID, req.FosterHomeID, fosterUserID, startDate, endDate, req.Notes, interval, userID).Scan(&placementID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to create placement: "+err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to create placement: "+err.Error())
+		return
+	}
+
+	log.Printf("✅ Foster placement %d: pet %d → foster home %d (organization %d)", placementID, req.PetID, req.FosterHomeID, orgID)
+
+	placement, err := getFosterPlacement(int(placementID))
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load placement: "+err.Error())
+		return
+	}
+
+	message := fmt.Sprintf("«%s» передаёт вам на передержку: %s с %s", placement.OrganizationName, petName, startDate.Format("02.01.2006"))
+	notifHandler := &NotificationsHandler{DB: db.DB}
+	if err := notifHandler.CreateNotification(fosterUserID, userID, "foster_placement", "foster_placement", placement.ID, message); err != nil {
+		log.Printf("⚠️ Failed to notify foster %d about placement %d: %v", fosterUserID, placement.ID, err)
+	}
+
+	sendJSONSuccess(w, placement)
+}
+
+func updateFosterPlacement(w http.ResponseWriter, r *http.Request, placement *models.FosterPlacement) {
+	if placement.Status != "active" {
+		sendJSONError(w, http.StatusConflict, "Placement is already "+placement.Status)
+		return
+	}
+
+	var req models.UpdateFosterPlacementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	endDate := placement.EndDate
+	if req.EndDate != nil {
+		endDate = nil
+		if *req.EndDate != "" {
+			parsed, err := time.Parse("2006-01-02", *req.EndDate)
+			if err != nil {
+				sendJSONError(w, http.StatusBadRequest, "end_date must be in YYYY-MM-DD format")
+				return
+			}
+			if parsed.Before(placement.StartDate) {
+				sendJSONError(w, http.StatusBadRequest, "end_date must not be before start_date")
+				return
+			}
+			endDate = &parsed
+		}
+	}
+	notes := placement.Notes
+	if req.Notes != nil {
+		notes = req.Notes
+	}
+	interval := placement.CheckinIntervalDays
+	if req.CheckinIntervalDays != nil {
+		if *req.CheckinIntervalDays <= 0 {
+			sendJSONError(w, http.StatusBadRequest, "checkin_interval_days must be positive")
+			return
+		}
+		interval = *req.CheckinIntervalDays
+	}
+
+	_, err := db.DB.Exec(ConvertPlaceholders(`
+		UPDATE foster_placements SET end_date = ?, notes = ?, checkin_interval_days = ?, updated_at = NOW() WHERE id = ?
+	`), endDate, notes, interval, placement.ID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to update placement: "+err.Error())
+		return
+	}
+
+	updated, err := getFosterPlacement(placement.ID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load placement: "+err.Error())
+		return
+	}
+	sendJSONSuccess(w, updated)
+}
+
+func endFosterPlacement(w http.ResponseWriter, r *http.Request, placement *models.FosterPlacement, userID int) {
+	var req models.EndFosterPlacementRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendJSONError(w, http.StatusBadRequest, "Invalid request: "+err.Error())
+			return
+		}
+	}
+	if req.Status == "" {
+		req.Status = "completed"
+	}
+	if req.Status != "completed" && req.Status != "cancelled" {
+		sendJSONError(w, http.StatusBadRequest, "status must be completed or cancelled")
+		return
+	}
+
+	result, err := db.DB.Exec(ConvertPlaceholders(`
+		UPDATE foster_placements SET status = ?, ended_at = NOW(), updated_at = NOW() WHERE id = ? AND status = 'active'
+	`), req.Status, placement.ID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to end placement: "+err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		sendJSONError(w, http.StatusConflict, "Placement is already "+placement.Status)
+		return
+	}
+
+	message := fmt.Sprintf("Передержка завершена: %s. Спасибо за помощь!", placement.PetName)
+	if req.Status == "cancelled" {
+		message = fmt.Sprintf("Размещение отменено: %s", placement.PetName)
+	}
+	notifHandler := &NotificationsHandler{DB: db.DB}
+	if err := notifHandler.CreateNotification(placement.FosterUserID, userID, "foster_placement_ended", "foster_placement", placement.ID, message); err != nil {
+		log.Printf("⚠️ Failed to notify foster %d about placement %d: %v", placement.FosterUserID, placement.ID, err)
+	}
+
+	updated, err := getFosterPlacement(placement.ID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load placement: "+err.Error())
+		return
+	}
+	sendJSONSuccess(w, updated)
+}
+
+func createFosterCheckin(w http.ResponseWriter, r *http.Request, placement *models.FosterPlacement, userID int) {
+	if placement.Status != "active" {
+		sendJSONError(w, http.StatusConflict, "Placement is already "+placement.Status)
+		return
+	}
+
+	var req models.CreateFosterCheckinRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+	req.Text = strings.TrimSpace(req.Text)
+	if req.Text == "" && len(req.Attachments) == 0 {
+		sendJSONError(w, http.StatusBadRequest, "text or attachments are required")
+		return
+	}
+	if req.Wellbeing == "" {
+		req.Wellbeing = "good"
+	}
+	if req.Wellbeing != "good" && req.Wellbeing != "attention" && req.Wellbeing != "urgent" {
+		sendJSONError(w, http.StatusBadRequest, "wellbeing must be good, attention or urgent")
+		return
+	}
+	if req.Attachments == nil {
+		req.Attachments = []models.Attachment{}
+	}
+	attachmentsJSON, _ := json.Marshal(req.Attachments)
+
+	var checkinID int64
+	err := db.DB.QueryRow(ConvertPlaceholders(`
+		INSERT INTO foster_checkins (placement_id, author_id, wellbeing, text, attachments)
+		VALUES (?, ?, ?, ?, ?)
+		RETURNING id
+	`), placement.ID, userID, req.Wellbeing, req.Text, string(attachmentsJSON)).Scan(&checkinID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to create check-in: "+err.Error())
+		return
+	}
+
+	message := fmt.Sprintf("Новый отчёт с передержки: %s", placement.PetName)
+	if req.Wellbeing != "good" {
+		message = fmt.Sprintf("⚠️ Передержка просит внимания: %s", placement.PetName)
+	}
+	notifHandler := &NotificationsHandler{DB: db.DB}
+	for _, managerID := range getOrganizationMemberManagers(placement.OrganizationID) {
+		if err := notifHandler.CreateNotification(managerID, userID, "foster_checkin", "foster_placement", placement.ID, message); err != nil {
+			log.Printf("⚠️ Failed to notify user %d about check-in %d: %v", managerID, checkinID, err)
+		}
+	}
+
+	checkins, err := loadFosterCheckins(placement.ID)
+	if err != nil || len(checkins) == 0 {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load check-in")
+		return
+	}
+	sendJSONSuccess(w, checkins[0])
+}
+
+// fosterAcceptsSpecies - пустой список видов означает "любые"
+func fosterAcceptsSpecies(home *models.FosterHome, species string) bool {
+	if len(home.Species) == 0 {
+		return true
+	}
+	species = strings.ToLower(strings.TrimSpace(species))
+	for _, s := range home.Species {
+		if s == species {
+			return true
+		}
+	}
+	return false
+}
+
+const fosterHomeSelect = `
+	SELECT fh.id, fh.user_id, fh.capacity, fh.species, fh.city, fh.conditions, fh.is_active,
+	       fh.created_at, fh.updated_at,
+	       (SELECT COUNT(*) FROM foster_placements fp WHERE fp.foster_home_id = fh.id AND fp.status = 'active'),
+	       u.name || ' ' || COALESCE(u.last_name, ''), u.avatar
+	FROM foster_homes fh
+	JOIN users u ON fh.user_id = u.id
+`
+
+func getFosterHomeByUser(userID int) (*models.FosterHome, error) {
+	homes, err := queryFosterHomes(fosterHomeSelect+" WHERE fh.user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(homes) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &homes[0], nil
+}
+
+func queryFosterHomes(query string, args ...interface{}) ([]models.FosterHome, error) {
+	rows, err := db.DB.Query(ConvertPlaceholders(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	homes := []models.FosterHome{}
+	for rows.Next() {
+		var h models.FosterHome
+		var speciesJSON string
+		err := rows.Scan(
+			&h.ID, &h.UserID, &h.Capacity, &speciesJSON, &h.City, &h.Conditions, &h.IsActive,
+			&h.CreatedAt, &h.UpdatedAt, &h.ActiveCount, &h.UserName, &h.UserAvatar,
+		)
+		if err != nil {
+			return nil, err
+		}
+		json.Unmarshal([]byte(speciesJSON), &h.Species)
+		if h.Species == nil {
+			h.Species = []string{}
+		}
+		h.UserName = strings.TrimSpace(h.UserName)
+		h.FreeCapacity = max(h.Capacity-h.ActiveCount, 0)
+		homes = append(homes, h)
+	}
+	return homes, rows.Err()
+}
+
+const fosterPlacementSelect = `
+	SELECT fp.id, fp.organization_id, fp.pet_id, fp.foster_home_id, fp.foster_user_id,
+	       fp.start_date, fp.end_date, fp.ended_at, fp.status, fp.notes, fp.checkin_interval_days,
+	       fp.created_by, fp.created_at,
+	       p.name, p.species, p.photo, u.name || ' ' || COALESCE(u.last_name, ''), o.name,
+	       (SELECT MAX(c.created_at) FROM foster_checkins c WHERE c.placement_id = fp.id)
+	FROM foster_placements fp
+	JOIN pets p ON fp.pet_id = p.id
+	JOIN users u ON fp.foster_user_id = u.id
+	JOIN organizations o ON fp.organization_id = o.id
+`
+
+func getFosterPlacement(placementID int) (*models.FosterPlacement, error) {
+	placements, err := queryFosterPlacements(fosterPlacementSelect+" WHERE fp.id = ?", placementID)
+	if err != nil {
+		return nil, err
+	}
+	if len(placements) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &placements[0], nil
+}
+
+func queryFosterPlacements(query string, args ...interface{}) ([]models.FosterPlacement, error) {
+	rows, err := db.DB.Query(ConvertPlaceholders(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	placements := []models.FosterPlacement{}
+	for rows.Next() {
+		var p models.FosterPlacement
+		err := rows.Scan(
+			&p.ID, &p.OrganizationID, &p.PetID, &p.FosterHomeID, &p.FosterUserID,
+			&p.StartDate, &p.EndDate, &p.EndedAt, &p.Status, &p.Notes, &p.CheckinIntervalDays,
+			&p.CreatedBy, &p.CreatedAt,
+			&p.PetName, &p.PetSpecies, &p.PetPhoto, &p.FosterName, &p.OrganizationName,
+			&p.LastCheckinAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		p.FosterName = strings.TrimSpace(p.FosterName)
+
+		// Отчёт просрочен, если с последнего отчёта (или начала размещения) прошло больше интервала
+		if p.Status == "active" {
+			since := p.StartDate
+			if p.LastCheckinAt != nil {
+				since = *p.LastCheckinAt
+			}
+			p.CheckinOverdue = now.Sub(since) > time.Duration(p.CheckinIntervalDays)*24*time.Hour
+		}
+		placements = append(placements, p)
+	}
+	return placements, rows.Err()
+}
+
+func loadFosterCheckins(placementID int) ([]models.FosterCheckin, error) {
+	rows, err := db.DB.Query(ConvertPlaceholders(`
+		SELECT c.id, c.placement_id, c.author_id, c.wellbeing, c.text, c.attachments, c.created_at,
+		       u.name || ' ' || COALESCE(u.last_name, '')
+		FROM foster_checkins c
+		JOIN users u ON c.author_id = u.id
+		WHERE c.placement_id = ?
+		ORDER BY c.created_at DESC
+	`), placementID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checkins := []models.FosterCheckin{}
+	for rows.Next() {
+		var c models.FosterCheckin
+		var attachmentsJSON string
+		if err := rows.Scan(&c.ID, &c.PlacementID, &c.AuthorID, &c.Wellbeing, &c.Text, &attachmentsJSON, &c.CreatedAt, &c.AuthorName); err != nil {
+			return nil, err
+		}
+		json.Unmarshal([]byte(attachmentsJSON), &c.Attachments)
+		if c.Attachments == nil {
+			c.Attachments = []models.Attachment{}
+		}
+		c.AuthorName = strings.TrimSpace(c.AuthorName)
+		checkins = append(checkins, c)
+	}
+	return checkins, rows.Err()
+}
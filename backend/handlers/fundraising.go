@@ -0,0 +1,415 @@
+package handlers
+
+import (
+	"backend/db"
+	"backend/models"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var errNotFundraising = errors.New("announcement is not a fundraising")
+
+// AnnouncementExpensesHandler - расходы по сбору средств
+// GET    /api/announcements/{id}/expenses
+// POST   /api/announcements/{id}/expenses
+// DELETE /api/announcements/{id}/expenses/{expenseID}
+func AnnouncementExpensesHandler(w http.ResponseWriter, r *http.Request, announcementID int, rest []string) {
+	switch {
+	case r.Method == http.MethodGet && len(rest) == 0:
+		handleGetExpenses(w, announcementID)
+	case r.Method == http.MethodPost && len(rest) == 0:
+		handleCreateExpense(w, r, announcementID)
+	case r.Method == http.MethodDelete && len(rest) == 1:
+		expenseID, err := strconv.Atoi(rest[0])
+		if err != nil {
+			sendError(w, "Invalid expense ID", http.StatusBadRequest)
+			return
+		}
+		handleDeleteExpense(w, r, announcementID, expenseID)
+	default:
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// AnnouncementSummaryHandler - сводка по сбору: собрано, потрачено, остаток
+// GET /api/announcements/{id}/summary
+func AnnouncementSummaryHandler(w http.ResponseWriter, r *http.Request, announcementID int) {
+	if r.Method != http.MethodGet {
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, err := getFundraisingAnnouncementAuthor(announcementID); err != nil {
+		sendFundraisingLookupError(w, err)
+		return
+	}
+
+	summary, err := getFundraisingSummary(announcementID)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sendSuccess(w, summary)
+}
+
+// AnnouncementReportHandler - выгрузка пожертвований и отчёта о расходах для бухгалтерии
+// GET /api/announcements/{id}/report?format=html
+func AnnouncementReportHandler(w http.ResponseWriter, r *http.Request, announcementID int) {
+	if r.Method != http.MethodGet {
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "html"
+	}
+	if format != "html" {
+		sendError(w, "Unsupported report format", http.StatusBadRequest)
+		return
+	}
+
+	var title string
+	err := db.DB.QueryRow(ConvertPlaceholders(`
+		SELECT title FROM pet_announcements WHERE id = ? AND type = 'fundraising'
+	`), announcementID).Scan(&title)
+	if err == sql.ErrNoRows {
+		sendError(w, "Fundraising announcement not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	summary, err := getFundraisingSummary(announcementID)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	donations, err := loadAnnouncementDonations(announcementID)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	expenses, err := loadAnnouncementExpenses(announcementID)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="fundraising-%d.html"`, announcementID))
+
+	err = fundraisingReportTemplate.Execute(w, map[string]interface{}{
+		"Title":       title,
+		"GeneratedAt": time.Now(),
+		"Summary":     summary,
+		"Donations":   donations,
+		"Expenses":    expenses,
+	})
+	if err != nil {
+		log.Printf("❌ Failed to render fundraising report %d: %v", announcementID, err)
+	}
+}
+
+// handleGetExpenses - список расходов по сбору
+func handleGetExpenses(w http.ResponseWriter, announcementID int) {
+	if _, err := getFundraisingAnnouncementAuthor(announcementID); err != nil {
+		sendFundraisingLookupError(w, err)
+		return
+	}
+
+	expenses, err := loadAnnouncementExpenses(announcementID)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sendSuccess(w, expenses)
+}
+
+// handleCreateExpense - добавить статью расходов (только автор сбора)
+func handleCreateExpense(w http.ResponseWriter, r *http.Request, announcementID int) {
+	userID, ok := RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	authorID, err := getFundraisingAnnouncementAuthor(announcementID)
+	if err != nil {
+		sendFundraisingLookupError(w, err)
+		return
+	}
+	if authorID != userID {
+		sendError(w, "Access denied", http.StatusForbidden)
+		return
+	}
+
+	var req models.CreateExpenseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Title == "" {
+		sendError(w, "title is required", http.StatusBadRequest)
+		return
+	}
+	if req.Amount <= 0 {
+		sendError(w, "Amount must be greater than 0", http.StatusBadRequest)
+		return
+	}
+
+	// Чек должен быть загружен в медиатеку автора
+	if req.ReceiptMediaID != nil {
+		var mediaOwnerID int
+		err := db.DB.QueryRow(ConvertPlaceholders("SELECT user_id FROM user_media WHERE id = ?"), *req.ReceiptMediaID).Scan(&mediaOwnerID)
+		if err != nil || mediaOwnerID != userID {
+			sendError(w, "Receipt media not found", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var spentAt *time.Time
+	if req.SpentAt != nil && *req.SpentAt != "" {
+		parsed, err := time.Parse("2006-01-02", *req.SpentAt)
+		if err != nil {
+			sendError(w, "spent_at must be in YYYY-MM-DD format", http.StatusBadRequest)
+			return
+		}
+		spentAt = &parsed
+	}
+
+	var id int64
+	err = db.DB.QueryRow(ConvertPlaceholders(`
+		INSERT INTO announcement_expenses (announcement_id, author_id, title, description, amount, receipt_media_id, spent_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id
+	`), announcementID, userID, req.Title, req.Description, req.Amount, req.ReceiptMediaID, spentAt).Scan(&id)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sendSuccess(w, map[string]interface{}{"id": id, "message": "Expense created successfully"})
+}
+
+// handleDeleteExpense - удалить статью расходов (только автор сбора)
+func handleDeleteExpense(w http.ResponseWriter, r *http.Request, announcementID, expenseID int) {
+	userID, ok := RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	authorID, err := getFundraisingAnnouncementAuthor(announcementID)
+	if err != nil {
+		sendFundraisingLookupError(w, err)
+		return
+	}
+	if authorID != userID {
+		sendError(w, "Access denied", http.StatusForbidden)
+		return
+	}
+
+	result, err := db.DB.Exec(ConvertPlaceholders(`
+		DELETE FROM announcement_expenses WHERE id = ? AND announcement_id = ?
+	`), expenseID, announcementID)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		sendError(w, "Expense not found", http.StatusNotFound)
+		return
+	}
+
+	sendSuccess(w, map[string]string{"message": "Expense deleted successfully"})
+}
+
+// getFundraisingAnnouncementAuthor возвращает автора объявления, если это сбор средств
+func getFundraisingAnnouncementAuthor(announcementID int) (int, error) {
+	var authorID int
+	var announcementType string
+	err := db.DB.QueryRow(ConvertPlaceholders("SELECT author_id, type FROM pet_announcements WHERE id = ?"), announcementID).
+		Scan(&authorID, &announcementType)
+	if err != nil {
+		return 0, err
+	}
+	if announcementType != "fundraising" {
+		return 0, errNotFundraising
+	}
+	return authorID, nil
+}
+
+func sendFundraisingLookupError(w http.ResponseWriter, err error) {
+	switch err {
+	case sql.ErrNoRows:
+		sendError(w, "Announcement not found", http.StatusNotFound)
+	case errNotFundraising:
+		sendError(w, "This announcement is not a fundraising", http.StatusBadRequest)
+	default:
+		sendError(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// getFundraisingSummary считает собранные, потраченные и оставшиеся средства
+func getFundraisingSummary(announcementID int) (*models.FundraisingSummary, error) {
+	var summary models.FundraisingSummary
+
+	err := db.DB.QueryRow(ConvertPlaceholders(`
+		SELECT
+			a.fundraising_goal_amount,
+			COALESCE((SELECT SUM(amount) FROM announcement_donations WHERE announcement_id = a.id), 0),
+			COALESCE((SELECT COUNT(*) FROM announcement_donations WHERE announcement_id = a.id), 0),
+			COALESCE((SELECT SUM(amount) FROM announcement_expenses WHERE announcement_id = a.id), 0),
+			COALESCE((SELECT COUNT(*) FROM announcement_expenses WHERE announcement_id = a.id), 0)
+		FROM pet_announcements a
+		WHERE a.id = ?
+	`), announcementID).Scan(
+		&summary.GoalAmount,
+		&summary.Raised, &summary.DonationsCount,
+		&summary.Spent, &summary.ExpensesCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	summary.Remaining = summary.Raised - summary.Spent
+	return &summary, nil
+}
+
+// loadAnnouncementExpenses загружает расходы вместе с чеками из медиатеки
+func loadAnnouncementExpenses(announcementID int) ([]models.AnnouncementExpense, error) {
+	rows, err := db.DB.Query(ConvertPlaceholders(`
+		SELECT e.id, e.announcement_id, e.author_id, e.title, e.description, e.amount,
+		       e.receipt_media_id, e.spent_at, e.created_at,
+		       m.file_name, m.original_name, m.file_path, m.mime_type, m.media_type
+		FROM announcement_expenses e
+		LEFT JOIN user_media m ON e.receipt_media_id = m.id
+		WHERE e.announcement_id = ?
+		ORDER BY COALESCE(e.spent_at, e.created_at) ASC, e.id ASC
+	`), announcementID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	expenses := []models.AnnouncementExpense{}
+	for rows.Next() {
+		var e models.AnnouncementExpense
+		var fileName, originalName, filePath, mimeType, mediaType sql.NullString
+
+		err := rows.Scan(
+			&e.ID, &e.AnnouncementID, &e.AuthorID, &e.Title, &e.Description, &e.Amount,
+			&e.ReceiptMediaID, &e.SpentAt, &e.CreatedAt,
+			&fileName, &originalName, &filePath, &mimeType, &mediaType,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if e.ReceiptMediaID != nil && filePath.Valid {
+			e.Receipt = &models.UserMedia{
+				ID:           *e.ReceiptMediaID,
+				UserID:       e.AuthorID,
+				FileName:     fileName.String,
+				OriginalName: originalName.String,
+				FilePath:     filePath.String,
+				MimeType:     mimeType.String,
+				MediaType:    mediaType.String,
+				URL:          "/api/media/file/" + strconv.Itoa(*e.ReceiptMediaID),
+			}
+		}
+
+		expenses = append(expenses, e)
+	}
+
+	return expenses, nil
+}
+
+// loadAnnouncementDonations загружает пожертвования по объявлению
+func loadAnnouncementDonations(announcementID int) ([]models.AnnouncementDonation, error) {
+	rows, err := db.DB.Query(ConvertPlaceholders(`
+		SELECT id, announcement_id, donor_id, donor_name, amount, message, is_anonymous, created_at
+		FROM announcement_donations
+		WHERE announcement_id = ?
+		ORDER BY created_at ASC
+	`), announcementID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	donations := []models.AnnouncementDonation{}
+	for rows.Next() {
+		var d models.AnnouncementDonation
+		if err := rows.Scan(&d.ID, &d.AnnouncementID, &d.DonorID, &d.DonorName, &d.Amount,
+			&d.Message, &d.IsAnonymous, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		donations = append(donations, d)
+	}
+
+	return donations, nil
+}
+
+// fundraisingReportTemplate - печатная форма отчёта (браузер сохраняет её в PDF)
+var fundraisingReportTemplate = template.Must(template.New("fundraising_report").Funcs(template.FuncMap{
+	"date": func(t time.Time) string { return t.Format("02.01.2006") },
+	"spentDate": func(e models.AnnouncementExpense) string {
+		if e.SpentAt != nil {
+			return e.SpentAt.Format("02.01.2006")
+		}
+		return e.CreatedAt.Format("02.01.2006")
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<title>Отчёт о сборе: {{.Title}}</title>
+<style>
+	body { font-family: Arial, sans-serif; font-size: 13px; margin: 24px; }
+	table { border-collapse: collapse; width: 100%; margin-bottom: 24px; }
+	th, td { border: 1px solid #999; padding: 4px 8px; text-align: left; }
+	td.amount, th.amount { text-align: right; }
+	@media print { a { color: inherit; text-decoration: none; } }
+</style>
+</head>
+<body>
+<h1>Отчёт о сборе: {{.Title}}</h1>
+<p>Сформирован {{date .GeneratedAt}}</p>
+
+<h2>Итоги</h2>
+<table>
+	{{if .Summary.GoalAmount}}<tr><th>Цель сбора</th><td class="amount">{{.Summary.GoalAmount}} ₽</td></tr>{{end}}
+	<tr><th>Собрано</th><td class="amount">{{.Summary.Raised}} ₽</td></tr>
+	<tr><th>Потрачено</th><td class="amount">{{.Summary.Spent}} ₽</td></tr>
+	<tr><th>Остаток</th><td class="amount">{{.Summary.Remaining}} ₽</td></tr>
+</table>
+
+<h2>Пожертвования ({{.Summary.DonationsCount}})</h2>
+<table>
+	<tr><th>Дата</th><th>Жертвователь</th><th>Комментарий</th><th class="amount">Сумма</th></tr>
+	{{range .Donations}}<tr><td>{{date .CreatedAt}}</td><td>{{.DonorName}}</td><td>{{if .Message}}{{.Message}}{{end}}</td><td class="amount">{{.Amount}} ₽</td></tr>
+	{{end}}
+</table>
+
+<h2>Расходы ({{.Summary.ExpensesCount}})</h2>
+<table>
+	<tr><th>Дата</th><th>Статья</th><th>Чек</th><th class="amount">Сумма</th></tr>
+	{{range .Expenses}}<tr><td>{{spentDate .}}</td><td>{{.Title}}{{if .Description}}<br>{{.Description}}{{end}}</td><td>{{if .Receipt}}<a href="{{.Receipt.URL}}">{{.Receipt.OriginalName}}</a>{{end}}</td><td class="amount">{{.Amount}} ₽</td></tr>
+	{{end}}
+</table>
+</body>
+</html>
+`))
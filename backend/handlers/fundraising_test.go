@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fundraisingDB - объявление 4 автора 1 заданного типа (nil - объявления нет) и медиатека: id -> владелец
+func fundraisingDB(announcementType interface{}, media map[int64]int64, inserted *[]driver.Value) fakeQueryFunc {
+	return func(query string, args []driver.Value) (*fakeResult, error) {
+		switch {
+		case strings.HasPrefix(query, "SELECT author_id, type FROM pet_announcements"):
+			if announcementType == nil {
+				return &fakeResult{}, nil
+			}
+			return &fakeResult{rows: [][]driver.Value{{int64(1), announcementType}}}, nil
+		case strings.HasPrefix(query, "SELECT user_id FROM user_media"):
+			if owner, ok := media[args[0].(int64)]; ok {
+				return &fakeResult{rows: [][]driver.Value{{owner}}}, nil
+			}
+		case strings.HasPrefix(query, "INSERT INTO announcement_expenses"):
+			*inserted = args
+			return &fakeResult{rows: [][]driver.Value{{int64(12)}}}, nil
+		}
+		return &fakeResult{}, nil
+	}
+}
+
+func TestCreateAnnouncementExpense(t *testing.T) {
+	tests := []struct {
+		name       string
+		annType    interface{}
+		userID     int
+		body       string
+		wantStatus int
+	}{
+		{"author adds expense", "fundraising", 1, `{"title":"Корм","amount":1500,"spent_at":"2026-10-01"}`, http.StatusOK},
+		{"author attaches own receipt", "fundraising", 1, `{"title":"Лечение","amount":7000,"receipt_media_id":8}`, http.StatusOK},
+		{"receipt from another user", "fundraising", 1, `{"title":"Лечение","amount":7000,"receipt_media_id":9}`, http.StatusBadRequest},
+		{"missing receipt", "fundraising", 1, `{"title":"Лечение","amount":7000,"receipt_media_id":10}`, http.StatusBadRequest},
+		{"not the author", "fundraising", 2, `{"title":"Корм","amount":1500}`, http.StatusForbidden},
+		{"not a fundraising", "lost", 1, `{"title":"Корм","amount":1500}`, http.StatusBadRequest},
+		{"unknown announcement", nil, 1, `{"title":"Корм","amount":1500}`, http.StatusNotFound},
+		{"no title", "fundraising", 1, `{"amount":1500}`, http.StatusBadRequest},
+		{"zero amount", "fundraising", 1, `{"title":"Корм","amount":0}`, http.StatusBadRequest},
+		{"bad date", "fundraising", 1, `{"title":"Корм","amount":1500,"spent_at":"01.10.2026"}`, http.StatusBadRequest},
+		{"anonymous", "fundraising", 0, `{"title":"Корм","amount":1500}`, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inserted []driver.Value
+			useFakeDB(t, fundraisingDB(tt.annType, map[int64]int64{8: 1, 9: 2}, &inserted))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/announcements/4/expenses", strings.NewReader(tt.body))
+			if tt.userID != 0 {
+				req = req.WithContext(context.WithValue(req.Context(), "userID", tt.userID))
+			}
+			rec := httptest.NewRecorder()
+			AnnouncementExpensesHandler(rec, req, 4, nil)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if (inserted != nil) != (tt.wantStatus == http.StatusOK) {
+				t.Fatalf("expense inserted = %v", inserted != nil)
+			}
+			if inserted != nil && (inserted[0] != int64(4) || inserted[1] != int64(1)) {
+				t.Errorf("expense saved for announcement %v by user %v", inserted[0], inserted[1])
+			}
+		})
+	}
+}
+
+func TestDeleteAnnouncementExpense(t *testing.T) {
+	tests := []struct {
+		name       string
+		userID     int
+		affected   int64
+		wantStatus int
+	}{
+		{"author deletes", 1, 1, http.StatusOK},
+		{"expense of another announcement", 1, 0, http.StatusNotFound},
+		{"not the author", 2, 1, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deleted []driver.Value
+			useFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+				switch {
+				case strings.HasPrefix(query, "SELECT author_id, type FROM pet_announcements"):
+					return &fakeResult{rows: [][]driver.Value{{int64(1), "fundraising"}}}, nil
+				case strings.HasPrefix(query, "DELETE FROM announcement_expenses"):
+					deleted = args
+					return &fakeResult{affected: tt.affected}, nil
+				}
+				return &fakeResult{}, nil
+			})
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/announcements/4/expenses/12", nil)
+			req = req.WithContext(context.WithValue(req.Context(), "userID", tt.userID))
+			rec := httptest.NewRecorder()
+			AnnouncementExpensesHandler(rec, req, 4, []string{"12"})
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			// Статья удаляется только в пределах своего сбора
+			if deleted != nil && (deleted[0] != int64(12) || deleted[1] != int64(4)) {
+				t.Errorf("delete args %v", deleted)
+			}
+			if tt.userID != 1 && deleted != nil {
+				t.Error("expense deleted by another user")
+			}
+		})
+	}
+}
+
+func TestAnnouncementSummary(t *testing.T) {
+	useFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+		switch {
+		case strings.HasPrefix(query, "SELECT author_id, type FROM pet_announcements"):
+			return &fakeResult{rows: [][]driver.Value{{int64(1), "fundraising"}}}, nil
+		case strings.Contains(query, "FROM pet_announcements a"):
+			return &fakeResult{rows: [][]driver.Value{{int64(20000), int64(12500), int64(7), int64(8500), int64(3)}}}, nil
+		}
+		return &fakeResult{}, nil
+	})
+
+	rec := httptest.NewRecorder()
+	AnnouncementSummaryHandler(rec, httptest.NewRequest(http.MethodGet, "/api/announcements/4/summary", nil), 4)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp struct {
+		Data map[string]int `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"goal_amount": 20000, "raised": 12500, "spent": 8500, "remaining": 4000, "donations_count": 7, "expenses_count": 3}
+	for key, value := range want {
+		if resp.Data[key] != value {
+			t.Errorf("%s = %d, want %d", key, resp.Data[key], value)
+		}
+	}
+}
+
+func TestAnnouncementReport(t *testing.T) {
+	now := time.Date(2026, 10, 5, 12, 0, 0, 0, time.UTC)
+	useFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+		switch {
+		case strings.HasPrefix(query, "SELECT title FROM pet_announcements"):
+			return &fakeResult{rows: [][]driver.Value{{"Лечение <Барсика>"}}}, nil
+		case strings.Contains(query, "FROM pet_announcements a"):
+			return &fakeResult{rows: [][]driver.Value{{nil, int64(3000), int64(1), int64(1200), int64(1)}}}, nil
+		case strings.Contains(query, "FROM announcement_donations"):
+			return &fakeResult{rows: [][]driver.Value{{int64(1), int64(4), nil, "Аноним", int64(3000), "<b>держись</b>", true, now}}}, nil
+		case strings.Contains(query, "FROM announcement_expenses e"):
+			spent := time.Date(2026, 10, 3, 0, 0, 0, 0, time.UTC)
+			return &fakeResult{rows: [][]driver.Value{{
+				int64(12), int64(4), int64(1), "Приём у ветеринара", nil, int64(1200), int64(8), spent, now,
+				"r.jpg", "чек.jpg", "media/r.jpg", "image/jpeg", "image",
+			}}}, nil
+		}
+		return &fakeResult{}, nil
+	})
+
+	rec := httptest.NewRecorder()
+	AnnouncementReportHandler(rec, httptest.NewRequest(http.MethodGet, "/api/announcements/4/report", nil), 4)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		"Лечение &lt;Барсика&gt;",
+		"&lt;b&gt;держись&lt;/b&gt;",
+		"<tr><th>Остаток</th><td class=\"amount\">1800 ₽</td></tr>",
+		"<td>03.10.2026</td><td>Приём у ветеринара</td>",
+		`<a href="/api/media/file/8">чек.jpg</a>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("report does not contain %q", want)
+		}
+	}
+	// Без цели сбора строка цели не выводится
+	if strings.Contains(body, "Цель сбора") {
+		t.Error("report shows a goal for a fundraising without one")
+	}
+
+	rec = httptest.NewRecorder()
+	AnnouncementReportHandler(rec, httptest.NewRequest(http.MethodGet, "/api/announcements/4/report?format=pdf", nil), 4)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unsupported format: status %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,1194 @@
+package handlers
+
+import (
+	"backend/db"
+	"backend/models"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	chatTypeDirect            = "direct"
+	chatTypeGroup             = "group"
+	chatTypeOrganizationStaff = "organization_staff"
+
+	chatRoleMember = "member"
+	chatRoleAdmin  = "admin"
+
+	groupChatTitleMaxLength = 100
+	groupChatMembersLimit   = 200
+)
+
+// ChatHandler маршрутизирует запросы /api/chats/...
+//
+//	POST   /api/chats/groups                     - создать групповой чат
+//	GET    /api/chats/{id}, /api/chats/{id}/messages - сообщения (см. GetChatMessagesHandler)
+//	PUT    /api/chats/{id}                       - название/аватар группы (администраторы)
+//	GET    /api/chats/{id}/info                  - чат с участниками
+//	GET    /api/chats/{id}/members               - участники
+//	POST   /api/chats/{id}/members               - пригласить участников (администраторы)
+//	PUT    /api/chats/{id}/members/{userId}      - назначить/снять администратора
+//	DELETE /api/chats/{id}/members/{userId}      - исключить участника
+//	POST   /api/chats/{id}/leave                 - покинуть группу
+//	POST   /api/chats/{id}/read                  - отметить чат прочитанным
+//	GET    /api/chats/{id}/receipts              - до какого сообщения прочитал каждый участник
+func ChatHandler(db *sql.DB) http.HandlerFunc {
+	messagesHandler := GetChatMessagesHandler(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value("userID").(int)
+		if !ok || userID == 0 {
+			http.Error(w, `{"success":false,"error":"Unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		if len(parts) < 3 {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+
+		if parts[2] == "groups" && len(parts) == 3 {
+			if r.Method != http.MethodPost {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			createGroupChat(db, w, r, userID)
+			return
+		}
+
+		chatID, err := strconv.Atoi(parts[2])
+		if err != nil {
+			http.Error(w, "Invalid chat ID", http.StatusBadRequest)
+			return
+		}
+
+		action := ""
+		if len(parts) > 3 {
+			action = parts[3]
+		}
+
+		switch {
+		case (action == "" || action == "messages") && r.Method == http.MethodGet:
+			messagesHandler(w, r)
+		case action == "" && r.Method == http.MethodPut:
+			updateGroupChat(db, w, r, chatID, userID)
+		case action == "info" && r.Method == http.MethodGet:
+			getGroupChatInfo(db, w, chatID, userID)
+		case action == "members" && len(parts) == 4:
+			switch r.Method {
+			case http.MethodGet:
+				listGroupChatMembers(db, w, chatID, userID)
+			case http.MethodPost:
+				inviteGroupChatMembers(db, w, r, chatID, userID)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		case action == "members" && len(parts) == 5:
+			memberID, err := strconv.Atoi(parts[4])
+			if err != nil {
+				http.Error(w, "Invalid user ID", http.StatusBadRequest)
+				return
+			}
+			switch r.Method {
+			case http.MethodPut:
+				updateGroupChatMember(db, w, r, chatID, userID, memberID)
+			case http.MethodDelete:
+				removeGroupChatMember(db, w, chatID, userID, memberID)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		case action == "leave" && r.Method == http.MethodPost:
+			leaveGroupChat(db, w, chatID, userID)
+		case action == "read" && r.Method == http.MethodPost:
+			if !isUserInChat(db, chatID, userID) {
+				http.Error(w, "Access denied", http.StatusForbidden)
+				return
+			}
+			markMessagesAsRead(db, chatID, userID)
+			writeChatJSON(w, http.StatusOK, map[string]interface{}{"success": true})
+		case action == "receipts" && r.Method == http.MethodGet:
+			getGroupChatReceipts(db, w, r, chatID, userID)
+		default:
+			http.Error(w, "Not found", http.StatusNotFound)
+		}
+	}
+}
+
+// createGroupChat создаёт групповой чат; создатель становится администратором
+func createGroupChat(db *sql.DB, w http.ResponseWriter, r *http.Request, userID int) {
+	var req models.CreateGroupChatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	title, err := validateGroupChatTitle(req.Title)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var memberIDs []int
+	for _, id := range uniqueInts(req.MemberIDs) {
+		if id != userID {
+			memberIDs = append(memberIDs, id)
+		}
+	}
+	if len(memberIDs) == 0 {
+		http.Error(w, "At least one member is required", http.StatusBadRequest)
+		return
+	}
+	if len(memberIDs)+1 > groupChatMembersLimit {
+		http.Error(w, fmt.Sprintf("Group chat cannot have more than %d members", groupChatMembersLimit), http.StatusBadRequest)
+		return
+	}
+	if err := ensureUsersExist(db, memberIDs); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		http.Error(w, "Failed to create chat", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var chatID int
+	err = tx.QueryRow(ConvertPlaceholders(`
+		INSERT INTO chats (type, title, avatar, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?)
+		RETURNING id
+	`), chatTypeGroup, title, req.Avatar, userID, time.Now()).Scan(&chatID)
+	if err != nil {
+		log.Printf("❌ Error creating group chat: %v", err)
+		http.Error(w, "Failed to create chat", http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := tx.Exec(ConvertPlaceholders(`
+		INSERT INTO chat_members (chat_id, user_id, role, joined_at)
+		VALUES (?, ?, ?, ?)
+	`), chatID, userID, chatRoleAdmin, time.Now()); err != nil {
+		log.Printf("❌ Error adding creator to group chat %d: %v", chatID, err)
+		http.Error(w, "Failed to create chat", http.StatusInternalServerError)
+		return
+	}
+
+	for _, memberID := range memberIDs {
+		if _, err := tx.Exec(ConvertPlaceholders(`
+			INSERT INTO chat_members (chat_id, user_id, role, invited_by, joined_at)
+			VALUES (?, ?, ?, ?, ?)
+		`), chatID, memberID, chatRoleMember, userID, time.Now()); err != nil {
+			log.Printf("❌ Error adding user %d to group chat %d: %v", memberID, chatID, err)
+			http.Error(w, "Failed to create chat", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to create chat", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("✅ Group chat %d created by user %d (%d members)", chatID, userID, len(memberIDs)+1)
+
+	notifyGroupChatInvite(chatID, userID, title, memberIDs)
+
+	chat, err := loadGroupChat(db, chatID, userID)
+	if err != nil {
+		log.Printf("❌ Error loading group chat %d: %v", chatID, err)
+		http.Error(w, "Chat created but failed to fetch", http.StatusInternalServerError)
+		return
+	}
+	writeChatJSON(w, http.StatusCreated, chat)
+}
+
+// updateGroupChat меняет название и аватар группы
+func updateGroupChat(db *sql.DB, w http.ResponseWriter, r *http.Request, chatID, userID int) {
+	if !requireGroupChatAdmin(db, w, chatID, userID) {
+		return
+	}
+
+	var req models.UpdateGroupChatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var title interface{}
+	if req.Title != nil {
+		t, err := validateGroupChatTitle(*req.Title)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		title = t
+	}
+	var avatar interface{}
+	if req.Avatar != nil {
+		avatar = nullIfEmpty(*req.Avatar)
+	}
+
+	_, err := db.Exec(ConvertPlaceholders(`
+		UPDATE chats
+		SET title = COALESCE(?, title),
+		    avatar = CASE WHEN ? THEN ? ELSE avatar END
+		WHERE id = ?
+	`), title, req.Avatar != nil, avatar, chatID)
+	if err != nil {
+		log.Printf("❌ Error updating group chat %d: %v", chatID, err)
+		http.Error(w, "Failed to update chat", http.StatusInternalServerError)
+		return
+	}
+
+	chat, err := loadGroupChat(db, chatID, userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch chat", http.StatusInternalServerError)
+		return
+	}
+
+	notifyChatMembers(db, chatID, userID, "chat_updated", chat)
+	writeChatJSON(w, http.StatusOK, chat)
+}
+
+func getGroupChatInfo(db *sql.DB, w http.ResponseWriter, chatID, userID int) {
+	chat, err := loadGroupChat(db, chatID, userID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Chat not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error loading group chat %d: %v", chatID, err)
+		http.Error(w, "Failed to fetch chat", http.StatusInternalServerError)
+		return
+	}
+
+	members, err := getChatMembers(db, chatID)
+	if err != nil {
+		http.Error(w, "Failed to fetch members", http.StatusInternalServerError)
+		return
+	}
+	chat.Members = members
+	writeChatJSON(w, http.StatusOK, chat)
+}
+
+func listGroupChatMembers(db *sql.DB, w http.ResponseWriter, chatID, userID int) {
+	if !isGroupChatMember(db, chatID, userID) {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	}
+
+	members, err := getChatMembers(db, chatID)
+	if err != nil {
+		log.Printf("❌ Error loading members of chat %d: %v", chatID, err)
+		http.Error(w, "Failed to fetch members", http.StatusInternalServerError)
+		return
+	}
+	writeChatJSON(w, http.StatusOK, members)
+}
+
+// inviteGroupChatMembers добавляет участников; новые участники не видят старые сообщения непрочитанными
+func inviteGroupChatMembers(db *sql.DB, w http.ResponseWriter, r *http.Request, chatID, userID int) {
+	if !requireGroupChatAdmin(db, w, chatID, userID) {
+		return
+	}
+
+	var req models.InviteChatMembersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	userIDs := uniqueInts(req.UserIDs)
+	if len(userIDs) == 0 {
+		http.Error(w, "user_ids is required", http.StatusBadRequest)
+		return
+	}
+	if err := ensureUsersExist(db, userIDs); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		http.Error(w, "Failed to invite members", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// Блокируем чат, чтобы параллельные приглашения не превысили лимит
+	var title sql.NullString
+	var lastMessageID sql.NullInt64
+	if err := tx.QueryRow(ConvertPlaceholders(`
+		SELECT title, last_message_id FROM chats WHERE id = ? FOR UPDATE
+	`), chatID).Scan(&title, &lastMessageID); err != nil {
+		http.Error(w, "Failed to invite members", http.StatusInternalServerError)
+		return
+	}
+
+	var membersCount int
+	if err := tx.QueryRow(ConvertPlaceholders("SELECT COUNT(*) FROM chat_members WHERE chat_id = ?"), chatID).Scan(&membersCount); err != nil {
+		http.Error(w, "Failed to invite members", http.StatusInternalServerError)
+		return
+	}
+
+	var added []int
+	for _, memberID := range userIDs {
+		if membersCount >= groupChatMembersLimit {
+			http.Error(w, fmt.Sprintf("Group chat cannot have more than %d members", groupChatMembersLimit), http.StatusBadRequest)
+			return
+		}
+		result, err := tx.Exec(ConvertPlaceholders(`
+			INSERT INTO chat_members (chat_id, user_id, role, invited_by, joined_at, last_read_message_id)
+			VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT (chat_id, user_id) DO NOTHING
+		`), chatID, memberID, chatRoleMember, userID, time.Now(), lastMessageID)
+		if err != nil {
+			log.Printf("❌ Error inviting user %d to chat %d: %v", memberID, chatID, err)
+			http.Error(w, "Failed to invite members", http.StatusInternalServerError)
+			return
+		}
+		if affected, _ := result.RowsAffected(); affected > 0 {
+			added = append(added, memberID)
+			membersCount++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to invite members", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("✅ User %d invited %d members to group chat %d", userID, len(added), chatID)
+
+	notifyGroupChatInvite(chatID, userID, title.String, added)
+	notifyChatMembers(db, chatID, 0, "chat_members_changed", map[string]interface{}{
+		"chat_id":  chatID,
+		"added":    added,
+		"actor_id": userID,
+	})
+
+	members, err := getChatMembers(db, chatID)
+	if err != nil {
+		http.Error(w, "Failed to fetch members", http.StatusInternalServerError)
+		return
+	}
+	writeChatJSON(w, http.StatusOK, members)
+}
+
+// updateGroupChatMember назначает или снимает администратора (последнего снять нельзя)
+func updateGroupChatMember(db *sql.DB, w http.ResponseWriter, r *http.Request, chatID, userID, memberID int) {
+	if !requireGroupChatAdmin(db, w, chatID, userID) {
+		return
+	}
+
+	var req models.UpdateChatMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Role != chatRoleMember && req.Role != chatRoleAdmin {
+		http.Error(w, "Role must be member or admin", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		http.Error(w, "Failed to update member", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if err := lockChat(tx, chatID); err != nil {
+		http.Error(w, "Failed to update member", http.StatusInternalServerError)
+		return
+	}
+
+	result, err := tx.Exec(ConvertPlaceholders(`
+		UPDATE chat_members SET role = ? WHERE chat_id = ? AND user_id = ?
+	`), req.Role, chatID, memberID)
+	if err != nil {
+		http.Error(w, "Failed to update member", http.StatusInternalServerError)
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		http.Error(w, "Member not found", http.StatusNotFound)
+		return
+	}
+
+	admins, err := countChatAdmins(tx, chatID)
+	if err != nil {
+		http.Error(w, "Failed to update member", http.StatusInternalServerError)
+		return
+	}
+	if admins == 0 {
+		http.Error(w, "Group chat must have at least one admin", http.StatusBadRequest)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to update member", http.StatusInternalServerError)
+		return
+	}
+
+	notifyChatMembers(db, chatID, 0, "chat_members_changed", map[string]interface{}{
+		"chat_id":  chatID,
+		"updated":  []int{memberID},
+		"actor_id": userID,
+	})
+	writeChatJSON(w, http.StatusOK, map[string]interface{}{"success": true})
+}
+
+// removeGroupChatMember исключает участника (себя - через /leave)
+func removeGroupChatMember(db *sql.DB, w http.ResponseWriter, chatID, userID, memberID int) {
+	if memberID == userID {
+		http.Error(w, "Use /leave to leave the chat", http.StatusBadRequest)
+		return
+	}
+	if !requireGroupChatAdmin(db, w, chatID, userID) {
+		return
+	}
+
+	result, err := db.Exec(ConvertPlaceholders(`
+		DELETE FROM chat_members WHERE chat_id = ? AND user_id = ?
+	`), chatID, memberID)
+	if err != nil {
+		log.Printf("❌ Error removing user %d from chat %d: %v", memberID, chatID, err)
+		http.Error(w, "Failed to remove member", http.StatusInternalServerError)
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		http.Error(w, "Member not found", http.StatusNotFound)
+		return
+	}
+
+	log.Printf("✅ User %d removed from group chat %d by user %d", memberID, chatID, userID)
+
+	event := map[string]interface{}{
+		"chat_id":  chatID,
+		"removed":  []int{memberID},
+		"actor_id": userID,
+	}
+	NotifyUser(memberID, "chat_members_changed", event)
+	NotifyUnreadCount(memberID)
+	notifyChatMembers(db, chatID, 0, "chat_members_changed", event)
+	writeChatJSON(w, http.StatusOK, map[string]interface{}{"success": true})
+}
+
+// leaveGroupChat - выход из группы. Если ушёл последний администратор - им становится
+// самый давний участник; опустевшая группа удаляется.
+func leaveGroupChat(db *sql.DB, w http.ResponseWriter, chatID, userID int) {
+	chatType, err := getChatType(db, chatID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Chat not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to leave chat", http.StatusInternalServerError)
+		return
+	}
+	if chatType == chatTypeOrganizationStaff {
+		http.Error(w, "Staff chat membership follows organization membership", http.StatusBadRequest)
+		return
+	}
+	if chatType != chatTypeGroup {
+		http.Error(w, "Not a group chat", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		http.Error(w, "Failed to leave chat", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if err := lockChat(tx, chatID); err != nil {
+		http.Error(w, "Failed to leave chat", http.StatusInternalServerError)
+		return
+	}
+
+	result, err := tx.Exec(ConvertPlaceholders(`
+		DELETE FROM chat_members WHERE chat_id = ? AND user_id = ?
+	`), chatID, userID)
+	if err != nil {
+		http.Error(w, "Failed to leave chat", http.StatusInternalServerError)
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	}
+
+	var remaining int
+	if err := tx.QueryRow(ConvertPlaceholders("SELECT COUNT(*) FROM chat_members WHERE chat_id = ?"), chatID).Scan(&remaining); err != nil {
+		http.Error(w, "Failed to leave chat", http.StatusInternalServerError)
+		return
+	}
+
+	deleted := false
+	if remaining == 0 {
+		if _, err := tx.Exec(ConvertPlaceholders("DELETE FROM chats WHERE id = ?"), chatID); err != nil {
+			http.Error(w, "Failed to leave chat", http.StatusInternalServerError)
+			return
+		}
+		deleted = true
+	} else {
+		admins, err := countChatAdmins(tx, chatID)
+		if err != nil {
+			http.Error(w, "Failed to leave chat", http.StatusInternalServerError)
+			return
+		}
+		if admins == 0 {
+			_, err := tx.Exec(ConvertPlaceholders(`
+				UPDATE chat_members SET role = ?
+				WHERE chat_id = ? AND user_id = (
+					SELECT user_id FROM chat_members WHERE chat_id = ? ORDER BY joined_at ASC, user_id ASC LIMIT 1
+				)
+			`), chatRoleAdmin, chatID, chatID)
+			if err != nil {
+				http.Error(w, "Failed to leave chat", http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to leave chat", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("✅ User %d left group chat %d (deleted=%v)", userID, chatID, deleted)
+
+	NotifyUnreadCount(userID)
+	if !deleted {
+		notifyChatMembers(db, chatID, 0, "chat_members_changed", map[string]interface{}{
+			"chat_id":  chatID,
+			"removed":  []int{userID},
+			"actor_id": userID,
+		})
+	}
+	writeChatJSON(w, http.StatusOK, map[string]interface{}{"success": true, "deleted": deleted})
+}
+
+// getGroupChatReceipts возвращает прочтения по участникам.
+// С ?message_id= - только тех, кто уже прочитал это сообщение.
+func getGroupChatReceipts(db *sql.DB, w http.ResponseWriter, r *http.Request, chatID, userID int) {
+	if !isGroupChatMember(db, chatID, userID) {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	}
+
+	var messageID int
+	if raw := r.URL.Query().Get("message_id"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil || id <= 0 {
+			http.Error(w, "Invalid message_id", http.StatusBadRequest)
+			return
+		}
+		messageID = id
+	}
+
+	members, err := getChatMembers(db, chatID)
+	if err != nil {
+		log.Printf("❌ Error loading receipts of chat %d: %v", chatID, err)
+		http.Error(w, "Failed to fetch receipts", http.StatusInternalServerError)
+		return
+	}
+
+	if messageID > 0 {
+		readBy := []models.ChatMember{}
+		for _, m := range members {
+			if m.LastReadMessageID != nil && *m.LastReadMessageID >= messageID {
+				readBy = append(readBy, m)
+			}
+		}
+		members = readBy
+	}
+	writeChatJSON(w, http.StatusOK, members)
+}
+
+// Вспомогательные функции
+
+func writeChatJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func validateGroupChatTitle(title string) (string, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return "", errors.New("Title is required")
+	}
+	if utf8.RuneCountInString(title) > groupChatTitleMaxLength {
+		return "", fmt.Errorf("Title must be at most %d characters", groupChatTitleMaxLength)
+	}
+	return title, nil
+}
+
+func ensureUsersExist(db *sql.DB, userIDs []int) error {
+	placeholders := make([]string, len(userIDs))
+	args := make([]interface{}, len(userIDs))
+	for i, id := range userIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	var count int
+	err := db.QueryRow(ConvertPlaceholders(fmt.Sprintf(
+		"SELECT COUNT(*) FROM users WHERE id IN (%s)", strings.Join(placeholders, ","),
+	)), args...).Scan(&count)
+	if err != nil {
+		return errors.New("Failed to check users")
+	}
+	if count != len(userIDs) {
+		return errors.New("Some users not found")
+	}
+	return nil
+}
+
+func getChatType(db *sql.DB, chatID int) (string, error) {
+	var chatType string
+	err := db.QueryRow(ConvertPlaceholders("SELECT type FROM chats WHERE id = ?"), chatID).Scan(&chatType)
+	return chatType, err
+}
+
+func getChatMemberRole(db *sql.DB, chatID, userID int) (string, error) {
+	var role string
+	err := db.QueryRow(ConvertPlaceholders(`
+		SELECT role FROM chat_members WHERE chat_id = ? AND user_id = ?
+	`), chatID, userID).Scan(&role)
+	return role, err
+}
+
+func isGroupChatMember(db *sql.DB, chatID, userID int) bool {
+	_, err := getChatMemberRole(db, chatID, userID)
+	return err == nil
+}
+
+// requireGroupChatAdmin - изменять состав и оформление можно только в обычной группе и только администраторам
+func requireGroupChatAdmin(db *sql.DB, w http.ResponseWriter, chatID, userID int) bool {
+	chatType, err := getChatType(db, chatID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Chat not found", http.StatusNotFound)
+		return false
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return false
+	}
+
+	role, err := getChatMemberRole(db, chatID, userID)
+	if err != nil {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return false
+	}
+	if chatType == chatTypeOrganizationStaff {
+		http.Error(w, "Staff chat is managed by the organization", http.StatusBadRequest)
+		return false
+	}
+	if chatType != chatTypeGroup {
+		http.Error(w, "Not a group chat", http.StatusBadRequest)
+		return false
+	}
+	if role != chatRoleAdmin {
+		http.Error(w, "Only chat admins can do this", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+func lockChat(tx *sql.Tx, chatID int) error {
+	var id int
+	return tx.QueryRow(ConvertPlaceholders("SELECT id FROM chats WHERE id = ? FOR UPDATE"), chatID).Scan(&id)
+}
+
+func countChatAdmins(tx *sql.Tx, chatID int) (int, error) {
+	var count int
+	err := tx.QueryRow(ConvertPlaceholders(`
+		SELECT COUNT(*) FROM chat_members WHERE chat_id = ? AND role = ?
+	`), chatID, chatRoleAdmin).Scan(&count)
+	return count, err
+}
+
+func getChatMemberIDs(db *sql.DB, chatID int) []int {
+	rows, err := db.Query(ConvertPlaceholders("SELECT user_id FROM chat_members WHERE chat_id = ?"), chatID)
+	if err != nil {
+		log.Printf("⚠️ Failed to load members of chat %d: %v", chatID, err)
+		return nil
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func getChatMembers(db *sql.DB, chatID int) ([]models.ChatMember, error) {
+	rows, err := db.Query(ConvertPlaceholders(`
+		SELECT cm.chat_id, cm.user_id, cm.role, cm.invited_by, cm.joined_at,
+		       cm.last_read_message_id, cm.last_read_at,
+		       u.name, u.last_name, u.avatar
+		FROM chat_members cm
+		JOIN users u ON u.id = cm.user_id
+		WHERE cm.chat_id = ?
+		ORDER BY CASE WHEN cm.role = 'admin' THEN 0 ELSE 1 END, cm.joined_at ASC
+	`), chatID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []models.ChatMember{}
+	for rows.Next() {
+		var m models.ChatMember
+		var invitedBy, lastReadID sql.NullInt64
+		var lastReadAt sql.NullTime
+		var name string
+		var lastName, avatar sql.NullString
+		if err := rows.Scan(
+			&m.ChatID, &m.UserID, &m.Role, &invitedBy, &m.JoinedAt,
+			&lastReadID, &lastReadAt,
+			&name, &lastName, &avatar,
+		); err != nil {
+			return nil, err
+		}
+		if invitedBy.Valid {
+			id := int(invitedBy.Int64)
+			m.InvitedBy = &id
+		}
+		if lastReadID.Valid {
+			id := int(lastReadID.Int64)
+			m.LastReadMessageID = &id
+		}
+		if lastReadAt.Valid {
+			m.LastReadAt = &lastReadAt.Time
+		}
+		m.UserName = strings.TrimSpace(name + " " + lastName.String)
+		if avatar.Valid && avatar.String != "" {
+			m.UserAvatar = &avatar.String
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+// groupChatSelect - групповые чаты пользователя; параметр - userID, непрочитанные считаются без удалённых и скрытых им сообщений
+const groupChatSelect = `
+	SELECT
+		c.id, c.type, c.title, c.avatar, c.created_by, c.organization_id,
+		c.last_message_id, c.last_message_at, c.created_at,
+		cm.role,
+		(SELECT COUNT(*) FROM chat_members WHERE chat_id = c.id) AS members_count,
+		(SELECT COUNT(*) FROM messages
+		 WHERE chat_id = c.id AND sender_id <> cm.user_id AND id > COALESCE(cm.last_read_message_id, 0)
+		   AND deleted_at IS NULL
+		   AND NOT EXISTS (SELECT 1 FROM message_hidden h WHERE h.message_id = messages.id AND h.user_id = cm.user_id)) AS unread_count,
+		m.id, m.sender_id, m.content, m.created_at,
+		su.name, su.last_name
+	FROM chats c
+	JOIN chat_members cm ON cm.chat_id = c.id AND cm.user_id = ?
+	LEFT JOIN messages m ON m.id = c.last_message_id
+	LEFT JOIN users su ON su.id = m.sender_id
+	WHERE c.type <> 'direct'`
+
+func queryGroupChats(db *sql.DB, query string, args ...interface{}) ([]models.Chat, error) {
+	rows, err := db.Query(ConvertPlaceholders(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var chats []models.Chat
+	for rows.Next() {
+		var chat models.Chat
+		var title, avatar sql.NullString
+		var createdBy, organizationID sql.NullInt64
+		var msgID, msgSenderID sql.NullInt64
+		var msgContent, msgCreatedAt, senderName, senderLastName sql.NullString
+
+		if err := rows.Scan(
+			&chat.ID, &chat.Type, &title, &avatar, &createdBy, &organizationID,
+			&chat.LastMessageID, &chat.LastMessageAt, &chat.CreatedAt,
+			&chat.MyRole, &chat.MembersCount, &chat.UnreadCount,
+			&msgID, &msgSenderID, &msgContent, &msgCreatedAt,
+			&senderName, &senderLastName,
+		); err != nil {
+			return nil, err
+		}
+
+		if title.Valid {
+			chat.Title = &title.String
+		}
+		if avatar.Valid && avatar.String != "" {
+			chat.Avatar = &avatar.String
+		}
+		if createdBy.Valid {
+			id := int(createdBy.Int64)
+			chat.CreatedBy = &id
+		}
+		if organizationID.Valid {
+			id := int(organizationID.Int64)
+			chat.OrganizationID = &id
+		}
+		if msgID.Valid {
+			lastMessage := models.Message{
+				ID:       int(msgID.Int64),
+				ChatID:   chat.ID,
+				SenderID: int(msgSenderID.Int64),
+				Content:  msgContent.String,
+			}
+			if msgCreatedAt.Valid {
+				lastMessage.CreatedAt = parseMessageTime(msgCreatedAt.String)
+			}
+			if senderName.Valid {
+				lastMessage.Sender = &models.User{
+					ID:       lastMessage.SenderID,
+					Name:     senderName.String,
+					LastName: senderLastName.String,
+				}
+			}
+			chat.LastMessage = &lastMessage
+		}
+		chats = append(chats, chat)
+	}
+	return chats, rows.Err()
+}
+
+// loadUserGroupChats - групповые чаты и чаты сотрудников, в которых состоит пользователь
+func loadUserGroupChats(db *sql.DB, userID int) ([]models.Chat, error) {
+	return queryGroupChats(db, groupChatSelect, userID)
+}
+
+// loadGroupChat возвращает групповой чат глазами участника (sql.ErrNoRows - нет чата или доступа)
+func loadGroupChat(db *sql.DB, chatID, userID int) (*models.Chat, error) {
+	chats, err := queryGroupChats(db, groupChatSelect+" AND c.id = ?", userID, chatID)
+	if err != nil {
+		return nil, err
+	}
+	if len(chats) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &chats[0], nil
+}
+
+// sortChatsByActivity - сначала чаты со свежими сообщениями, затем новые без сообщений
+func sortChatsByActivity(chats []models.Chat) {
+	sort.SliceStable(chats, func(i, j int) bool {
+		a, b := chats[i].LastMessageAt, chats[j].LastMessageAt
+		if a != nil && b != nil && !a.Equal(*b) {
+			return a.After(*b)
+		}
+		if (a == nil) != (b == nil) {
+			return a != nil
+		}
+		return chats[i].CreatedAt.After(chats[j].CreatedAt)
+	})
+}
+
+// notifyChatMembers отправляет WebSocket событие всем участникам группы (кроме exceptUserID)
+func notifyChatMembers(db *sql.DB, chatID, exceptUserID int, messageType string, data interface{}) {
+	var recipients []int
+	for _, memberID := range getChatMemberIDs(db, chatID) {
+		if memberID != exceptUserID {
+			recipients = append(recipients, memberID)
+		}
+	}
+	NotifyUsers(recipients, messageType, data)
+}
+
+// notifyGroupMessage рассылает новое сообщение и счётчики непрочитанных всем участникам, кроме отправителя.
+// new_message уходит двумя пачками: обычным получателям и тем, у кого чат без звука
+func notifyGroupMessage(db *sql.DB, chatID, senderID int, message *models.Message) {
+	var loud, silent []int
+	for _, memberID := range getChatMemberIDs(db, chatID) {
+		if memberID == senderID {
+			continue
+		}
+		if isMessageSilent(db, memberID, chatID) {
+			silent = append(silent, memberID)
+		} else {
+			loud = append(loud, memberID)
+		}
+	}
+
+	NotifyUsers(loud, "new_message", message)
+	if len(silent) > 0 {
+		silentMessage := *message
+		silentMessage.Silent = true
+		NotifyUsers(silent, "new_message", &silentMessage)
+	}
+	for _, memberID := range append(loud, silent...) {
+		NotifyUnreadCount(memberID)
+	}
+}
+
+// notifyIncomingMessage доставляет новое сообщение получателю с учётом его настроек уведомлений:
+// если сообщения отключены или чат заглушён, событие приходит с silent (без звука и всплывающего окна)
+func notifyIncomingMessage(db *sql.DB, userID, chatID int, message *models.Message) {
+	if isMessageSilent(db, userID, chatID) {
+		silent := *message
+		silent.Silent = true
+		NotifyNewMessage(userID, &silent)
+		return
+	}
+	NotifyNewMessage(userID, message)
+}
+
+func notifyGroupChatInvite(chatID, actorID int, title string, userIDs []int) {
+	if len(userIDs) == 0 {
+		return
+	}
+	notifHandler := &NotificationsHandler{DB: db.DB}
+	message := fmt.Sprintf("%s добавил вас в групповой чат «%s»", getUserFullName(actorID), title)
+	for _, userID := range userIDs {
+		if err := notifHandler.CreateNotification(userID, actorID, "group_chat_invite", "chat", chatID, message); err != nil {
+			log.Printf("⚠️ Failed to notify user %d about group chat %d: %v", userID, chatID, err)
+		}
+		NotifyUser(userID, "chat_added", map[string]int{"chat_id": chatID})
+	}
+}
+
+// outgoingChat - куда отправляется сообщение: диалог (ReceiverID) или группа (ReceiverID = 0)
+type outgoingChat struct {
+	ChatID     int
+	ReceiverID int
+}
+
+var errChatAccessDenied = errors.New("Access denied")
+
+// resolveOutgoingChat находит чат для отправки: по chat_id (любой тип) или по receiver_id (диалог создаётся при необходимости).
+// Возвращает HTTP статус для ошибки.
+func resolveOutgoingChat(db *sql.DB, userID, chatID, receiverID int) (*outgoingChat, int, error) {
+	if chatID != 0 {
+		var chatType string
+		var user1ID, user2ID sql.NullInt64
+		err := db.QueryRow(ConvertPlaceholders(`
+			SELECT type, user1_id, user2_id FROM chats WHERE id = ?
+		`), chatID).Scan(&chatType, &user1ID, &user2ID)
+		if err == sql.ErrNoRows {
+			return nil, http.StatusNotFound, errors.New("Chat not found")
+		}
+		if err != nil {
+			return nil, http.StatusInternalServerError, errors.New("Failed to load chat")
+		}
+
+		if chatType == chatTypeDirect {
+			switch userID {
+			case int(user1ID.Int64):
+				return &outgoingChat{ChatID: chatID, ReceiverID: int(user2ID.Int64)}, 0, nil
+			case int(user2ID.Int64):
+				return &outgoingChat{ChatID: chatID, ReceiverID: int(user1ID.Int64)}, 0, nil
+			}
+			return nil, http.StatusForbidden, errChatAccessDenied
+		}
+		if !isGroupChatMember(db, chatID, userID) {
+			return nil, http.StatusForbidden, errChatAccessDenied
+		}
+		return &outgoingChat{ChatID: chatID}, 0, nil
+	}
+
+	if receiverID == 0 {
+		return nil, http.StatusBadRequest, errors.New("Receiver ID or chat ID is required")
+	}
+	if receiverID == userID {
+		return nil, http.StatusBadRequest, errors.New("Cannot send message to yourself")
+	}
+
+	// Проверяем, существует ли получатель
+	receiverExists, err := userExists(db, receiverID)
+	if err != nil || !receiverExists {
+		return nil, http.StatusNotFound, errors.New("Receiver not found")
+	}
+
+	// Ищем или создаем чат
+	newChatID, err := getOrCreateChat(db, userID, receiverID)
+	if err != nil {
+		log.Printf("❌ Error getting/creating chat: %v", err)
+		return nil, http.StatusInternalServerError, errors.New("Failed to create chat")
+	}
+	return &outgoingChat{ChatID: newChatID, ReceiverID: receiverID}, 0, nil
+}
+
+// receiverArg - receiver_id для INSERT (NULL в группах)
+func (c *outgoingChat) receiverArg() interface{} {
+	if c.ReceiverID == 0 {
+		return nil
+	}
+	return c.ReceiverID
+}
+
+// notifyRecipients отправляет WebSocket уведомления получателю диалога или всем участникам группы
+func (c *outgoingChat) notifyRecipients(db *sql.DB, senderID int, message *models.Message) {
+	if c.ReceiverID != 0 {
+		notifyIncomingMessage(db, c.ReceiverID, c.ChatID, message)
+		NotifyUnreadCount(c.ReceiverID)
+		return
+	}
+	notifyGroupMessage(db, c.ChatID, senderID, message)
+}
+
+// markGroupChatAsRead сдвигает отметку прочтения участника до последнего сообщения чата
+// и сообщает остальным участникам, до какого сообщения он дочитал
+func markGroupChatAsRead(db *sql.DB, chatID, userID int) {
+	var lastReadID int
+	err := db.QueryRow(ConvertPlaceholders(`
+		UPDATE chat_members
+		SET last_read_message_id = c.last_message_id, last_read_at = ?
+		FROM chats c
+		WHERE c.id = chat_members.chat_id
+		  AND chat_members.chat_id = ? AND chat_members.user_id = ?
+		  AND c.last_message_id IS NOT NULL
+		  AND COALESCE(chat_members.last_read_message_id, 0) < c.last_message_id
+		RETURNING chat_members.last_read_message_id
+	`), time.Now(), chatID, userID).Scan(&lastReadID)
+	if err == sql.ErrNoRows {
+		return
+	}
+	if err != nil {
+		log.Printf("⚠️ Warning: Failed to mark group chat %d as read: %v", chatID, err)
+		return
+	}
+
+	log.Printf("✅ User %d read group chat %d up to message %d", userID, chatID, lastReadID)
+
+	NotifyUnreadCount(userID)
+	notifyChatMembers(db, chatID, userID, "chat_read", map[string]int{
+		"chat_id":              chatID,
+		"user_id":              userID,
+		"last_read_message_id": lastReadID,
+	})
+}
+
+// syncOrganizationStaffChat приводит чат сотрудников организации в соответствие с organization_members:
+// создаёт его при первом участнике, обновляет название, добавляет/удаляет участников.
+// Владельцы, администраторы и управляющие участниками - администраторы чата.
+func syncOrganizationStaffChat(orgID int) {
+	var orgName string
+	var orgMembers int
+	err := db.DB.QueryRow(ConvertPlaceholders(`
+		SELECT o.name, (SELECT COUNT(*) FROM organization_members WHERE organization_id = o.id)
+		FROM organizations o WHERE o.id = ?
+	`), orgID).Scan(&orgName, &orgMembers)
+	if err != nil {
+		log.Printf("⚠️ Staff chat sync: failed to load organization %d: %v", orgID, err)
+		return
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Printf("⚠️ Staff chat sync: %v", err)
+		return
+	}
+	defer tx.Rollback()
+
+	if orgMembers > 0 {
+		_, err = tx.Exec(ConvertPlaceholders(`
+			INSERT INTO chats (type, title, organization_id, created_at)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT (organization_id) WHERE type = 'organization_staff' DO NOTHING
+		`), chatTypeOrganizationStaff, orgName, orgID, time.Now())
+		if err != nil {
+			log.Printf("⚠️ Staff chat sync: failed to create chat for organization %d: %v", orgID, err)
+			return
+		}
+	}
+
+	var chatID int
+	err = tx.QueryRow(ConvertPlaceholders(`
+		SELECT id FROM chats WHERE type = ? AND organization_id = ? FOR UPDATE
+	`), chatTypeOrganizationStaff, orgID).Scan(&chatID)
+	if err == sql.ErrNoRows {
+		return
+	}
+	if err != nil {
+		log.Printf("⚠️ Staff chat sync: failed to load chat for organization %d: %v", orgID, err)
+		return
+	}
+
+	if _, err := tx.Exec(ConvertPlaceholders(`
+		UPDATE chats SET title = ? WHERE id = ? AND title IS DISTINCT FROM ?
+	`), orgName, chatID, orgName); err != nil {
+		log.Printf("⚠️ Staff chat sync: failed to update title of chat %d: %v", chatID, err)
+		return
+	}
+
+	if _, err := tx.Exec(ConvertPlaceholders(`
+		DELETE FROM chat_members
+		WHERE chat_id = ? AND user_id NOT IN (SELECT user_id FROM organization_members WHERE organization_id = ?)
+	`), chatID, orgID); err != nil {
+		log.Printf("⚠️ Staff chat sync: failed to remove members of chat %d: %v", chatID, err)
+		return
+	}
+
+	if _, err := tx.Exec(ConvertPlaceholders(`
+		INSERT INTO chat_members (chat_id, user_id, role, joined_at, last_read_message_id)
+		SELECT c.id, om.user_id,
+		       CASE WHEN om.role IN ('owner', 'admin') OR om.can_manage_members THEN 'admin' ELSE 'member' END,
+		       ?, c.last_message_id
+		FROM organization_members om
+		JOIN chats c ON c.id = ?
+		WHERE om.organization_id = ?
+		ON CONFLICT (chat_id, user_id) DO UPDATE SET role = EXCLUDED.role
+	`), time.Now(), chatID, orgID); err != nil {
+		log.Printf("⚠️ Staff chat sync: failed to add members to chat %d: %v", chatID, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("⚠️ Staff chat sync: failed to commit for organization %d: %v", orgID, err)
+		return
+	}
+
+	log.Printf("🔄 Staff chat %d synced with organization %d (%d members)", chatID, orgID, orgMembers)
+}
+
+// OrganizationStaffChatHandler - GET /api/organizations/{id}/staff-chat (только участники организации)
+func OrganizationStaffChatHandler(w http.ResponseWriter, r *http.Request, orgID int) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	userID, ok := RequireAuth(w, r)
+	if !ok {
+		return
+	}
+	if !isOrganizationMember(orgID, userID) {
+		sendJSONError(w, http.StatusForbidden, "Only organization members can access the staff chat")
+		return
+	}
+
+	syncOrganizationStaffChat(orgID)
+
+	var chatID int
+	err := db.DB.QueryRow(ConvertPlaceholders(`
+		SELECT id FROM chats WHERE type = ? AND organization_id = ?
+	`), chatTypeOrganizationStaff, orgID).Scan(&chatID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load staff chat")
+		return
+	}
+
+	chat, err := loadGroupChat(db.DB, chatID, userID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load staff chat")
+		return
+	}
+	sendJSONSuccess(w, chat)
+}
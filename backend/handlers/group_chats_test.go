@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"backend/db"
+	"context"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// groupChatDB - фейковая группа 5: тип чата, роли участников, число администраторов и участников после изменения
+type groupChatDB struct {
+	chatType  string
+	roles     map[int64]string
+	admins    int64
+	remaining int64
+	stmts     []string
+}
+
+func (g *groupChatDB) query(query string, args []driver.Value) (*fakeResult, error) {
+	switch {
+	case query == "BEGIN" || query == "COMMIT" || query == "ROLLBACK":
+		g.stmts = append(g.stmts, query)
+	case strings.HasPrefix(query, "SELECT type FROM chats"):
+		return &fakeResult{rows: [][]driver.Value{{g.chatType}}}, nil
+	case strings.HasPrefix(query, "SELECT role FROM chat_members WHERE chat_id = ? AND user_id = ?"):
+		if role, ok := g.roles[args[1].(int64)]; ok {
+			return &fakeResult{rows: [][]driver.Value{{role}}}, nil
+		}
+	case strings.HasPrefix(query, "SELECT id FROM chats WHERE id = ? FOR UPDATE"):
+		return &fakeResult{rows: [][]driver.Value{{int64(5)}}}, nil
+	case strings.HasPrefix(query, "SELECT COUNT(*) FROM chat_members WHERE chat_id = ? AND role = ?"):
+		return &fakeResult{rows: [][]driver.Value{{g.admins}}}, nil
+	case query == "SELECT COUNT(*) FROM chat_members WHERE chat_id = ?":
+		return &fakeResult{rows: [][]driver.Value{{g.remaining}}}, nil
+	case strings.HasPrefix(query, "UPDATE chat_members SET role = ? WHERE chat_id = ? AND user_id = ( SELECT"):
+		g.stmts = append(g.stmts, "promote oldest")
+		return &fakeResult{affected: 1}, nil
+	case strings.HasPrefix(query, "UPDATE chat_members SET role = ?"):
+		g.stmts = append(g.stmts, "set role "+args[0].(string))
+		return &fakeResult{affected: 1}, nil
+	case strings.HasPrefix(query, "DELETE FROM chat_members"):
+		if _, ok := g.roles[args[1].(int64)]; !ok {
+			return &fakeResult{}, nil
+		}
+		g.stmts = append(g.stmts, "remove member")
+		return &fakeResult{affected: 1}, nil
+	case strings.HasPrefix(query, "DELETE FROM chats"):
+		g.stmts = append(g.stmts, "delete chat")
+		return &fakeResult{affected: 1}, nil
+	}
+	return &fakeResult{}, nil
+}
+
+func callChatHandler(method, target, body string, userID int) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req = req.WithContext(context.WithValue(req.Context(), "userID", userID))
+	rec := httptest.NewRecorder()
+	ChatHandler(db.DB)(rec, req)
+	return rec
+}
+
+func TestUpdateGroupChatMemberKeepsAnAdmin(t *testing.T) {
+	tests := []struct {
+		name       string
+		chat       groupChatDB
+		body       string
+		wantStatus int
+		wantStmts  []string
+	}{
+		{"promote member",
+			groupChatDB{chatType: chatTypeGroup, roles: map[int64]string{2: chatRoleAdmin, 3: chatRoleMember}, admins: 2},
+			`{"role":"admin"}`, http.StatusOK, []string{"BEGIN", "set role admin", "COMMIT"}},
+		{"demote one of two admins",
+			groupChatDB{chatType: chatTypeGroup, roles: map[int64]string{2: chatRoleAdmin, 3: chatRoleAdmin}, admins: 1},
+			`{"role":"member"}`, http.StatusOK, []string{"BEGIN", "set role member", "COMMIT"}},
+		{"demote the last admin",
+			groupChatDB{chatType: chatTypeGroup, roles: map[int64]string{2: chatRoleAdmin, 3: chatRoleMember}, admins: 0},
+			`{"role":"member"}`, http.StatusBadRequest, []string{"BEGIN", "set role member", "ROLLBACK"}},
+		{"unknown role",
+			groupChatDB{chatType: chatTypeGroup, roles: map[int64]string{2: chatRoleAdmin}},
+			`{"role":"owner"}`, http.StatusBadRequest, nil},
+		{"not an admin",
+			groupChatDB{chatType: chatTypeGroup, roles: map[int64]string{2: chatRoleMember}},
+			`{"role":"admin"}`, http.StatusForbidden, nil},
+		{"not a member",
+			groupChatDB{chatType: chatTypeGroup, roles: map[int64]string{}},
+			`{"role":"admin"}`, http.StatusForbidden, nil},
+		{"staff chat",
+			groupChatDB{chatType: chatTypeOrganizationStaff, roles: map[int64]string{2: chatRoleAdmin}},
+			`{"role":"admin"}`, http.StatusBadRequest, nil},
+	}
+
+	prev := hub
+	hub = nil
+	t.Cleanup(func() { hub = prev })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat := tt.chat
+			useFakeDB(t, chat.query)
+
+			rec := callChatHandler(http.MethodPut, "/api/chats/5/members/3", tt.body, 2)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if strings.Join(chat.stmts, ", ") != strings.Join(tt.wantStmts, ", ") {
+				t.Errorf("statements %q, want %q", chat.stmts, tt.wantStmts)
+			}
+		})
+	}
+}
+
+func TestLeaveGroupChat(t *testing.T) {
+	tests := []struct {
+		name        string
+		chat        groupChatDB
+		wantStatus  int
+		wantDeleted bool
+		wantStmts   []string
+	}{
+		{"member leaves",
+			groupChatDB{chatType: chatTypeGroup, roles: map[int64]string{2: chatRoleMember}, admins: 1, remaining: 3},
+			http.StatusOK, false, []string{"BEGIN", "remove member", "COMMIT"}},
+		{"last admin leaves",
+			groupChatDB{chatType: chatTypeGroup, roles: map[int64]string{2: chatRoleAdmin}, admins: 0, remaining: 3},
+			http.StatusOK, false, []string{"BEGIN", "remove member", "promote oldest", "COMMIT"}},
+		{"last member leaves",
+			groupChatDB{chatType: chatTypeGroup, roles: map[int64]string{2: chatRoleAdmin}, remaining: 0},
+			http.StatusOK, true, []string{"BEGIN", "remove member", "delete chat", "COMMIT"}},
+		{"not a member",
+			groupChatDB{chatType: chatTypeGroup, roles: map[int64]string{}},
+			http.StatusForbidden, false, []string{"BEGIN", "ROLLBACK"}},
+		{"staff chat",
+			groupChatDB{chatType: chatTypeOrganizationStaff, roles: map[int64]string{2: chatRoleMember}},
+			http.StatusBadRequest, false, nil},
+		{"direct chat",
+			groupChatDB{chatType: chatTypeDirect, roles: map[int64]string{}},
+			http.StatusBadRequest, false, nil},
+	}
+
+	prev := hub
+	hub = nil
+	t.Cleanup(func() { hub = prev })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat := tt.chat
+			useFakeDB(t, chat.query)
+
+			rec := callChatHandler(http.MethodPost, "/api/chats/5/leave", "", 2)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if strings.Join(chat.stmts, ", ") != strings.Join(tt.wantStmts, ", ") {
+				t.Errorf("statements %q, want %q", chat.stmts, tt.wantStmts)
+			}
+			if tt.wantStatus == http.StatusOK && strings.Contains(rec.Body.String(), `"deleted":true`) != tt.wantDeleted {
+				t.Errorf("response %s, want deleted=%v", rec.Body.String(), tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestRemoveGroupChatMember(t *testing.T) {
+	tests := []struct {
+		name       string
+		memberID   string
+		chat       groupChatDB
+		wantStatus int
+		wantStmts  []string
+	}{
+		{"admin removes member", "3",
+			groupChatDB{chatType: chatTypeGroup, roles: map[int64]string{2: chatRoleAdmin, 3: chatRoleMember}},
+			http.StatusOK, []string{"remove member"}},
+		{"member is already gone", "4",
+			groupChatDB{chatType: chatTypeGroup, roles: map[int64]string{2: chatRoleAdmin}},
+			http.StatusNotFound, nil},
+		{"member cannot remove others", "3",
+			groupChatDB{chatType: chatTypeGroup, roles: map[int64]string{2: chatRoleMember, 3: chatRoleMember}},
+			http.StatusForbidden, nil},
+		{"removing yourself", "2",
+			groupChatDB{chatType: chatTypeGroup, roles: map[int64]string{2: chatRoleAdmin}},
+			http.StatusBadRequest, nil},
+	}
+
+	prev := hub
+	hub = nil
+	t.Cleanup(func() { hub = prev })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat := tt.chat
+			useFakeDB(t, chat.query)
+
+			rec := callChatHandler(http.MethodDelete, "/api/chats/5/members/"+tt.memberID, "", 2)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if strings.Join(chat.stmts, ", ") != strings.Join(tt.wantStmts, ", ") {
+				t.Errorf("statements %q, want %q", chat.stmts, tt.wantStmts)
+			}
+		})
+	}
+}
+
+func TestMarkGroupChatRead(t *testing.T) {
+	tests := []struct {
+		name        string
+		alreadyRead bool
+		wantReader  []string
+		wantOthers  []string
+	}{
+		{"new messages", false, []string{"unread_count"}, []string{"chat_read"}},
+		{"nothing new", true, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var readerArg driver.Value
+			useFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+				switch {
+				case strings.HasPrefix(query, "SELECT COUNT(*) FROM chats c"):
+					return &fakeResult{rows: [][]driver.Value{{int64(1)}}}, nil
+				case strings.HasPrefix(query, "SELECT type FROM chats"):
+					return &fakeResult{rows: [][]driver.Value{{chatTypeGroup}}}, nil
+				case strings.HasPrefix(query, "UPDATE chat_members SET last_read_message_id"):
+					// Прочтение хранится у участника, сами сообщения не меняются
+					readerArg = args[2]
+					if tt.alreadyRead {
+						return &fakeResult{}, nil
+					}
+					return &fakeResult{rows: [][]driver.Value{{int64(40)}}}, nil
+				case strings.HasPrefix(query, "UPDATE messages"):
+					t.Errorf("group read updates messages: %s", query)
+				case strings.HasPrefix(query, "SELECT user_id FROM chat_members"):
+					return &fakeResult{rows: [][]driver.Value{{int64(2)}, {int64(3)}}}, nil
+				case strings.Contains(query, "SELECT (SELECT COUNT(*) FROM messages"):
+					return &fakeResult{rows: [][]driver.Value{{int64(0)}}}, nil
+				}
+				return &fakeResult{}, nil
+			})
+
+			h := newTestHub(t)
+			h.db = db.DB
+			reader, other := addTestClient(h, 2), addTestClient(h, 3)
+			prev := hub
+			hub = h
+			t.Cleanup(func() { hub = prev })
+
+			rec := callChatHandler(http.MethodPost, "/api/chats/5/read", "", 2)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+			}
+			if readerArg != int64(2) {
+				t.Errorf("read receipt stored for %v, want user 2", readerArg)
+			}
+			if got := collectEvents(reader, 100*time.Millisecond); strings.Join(got, ",") != strings.Join(tt.wantReader, ",") {
+				t.Errorf("reader events %v, want %v", got, tt.wantReader)
+			}
+			if got := collectEvents(other, 50*time.Millisecond); strings.Join(got, ",") != strings.Join(tt.wantOthers, ",") {
+				t.Errorf("member events %v, want %v", got, tt.wantOthers)
+			}
+		})
+	}
+}
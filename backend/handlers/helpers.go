@@ -3,10 +3,13 @@ package handlers
 import (
 	"backend/models"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 // ConvertPlaceholders converts ? to $1, $2, $3 for PostgreSQL in production
@@ -27,6 +30,13 @@ func ConvertPlaceholders(query string) string {
 	return query
 }
 
+// isUniqueViolation - ошибка PostgreSQL 23505: запись нарушила уникальный индекс
+// (например, параллельный запрос успел вставить такую же строку)
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
 // sendErrorResponse отправляет JSON ответ с ошибкой
 func sendErrorResponse(w http.ResponseWriter, message string, status int) {
 	w.Header().Set("Content-Type", "application/json")
@@ -39,7 +39,7 @@ func GetChatsHandler(db *sql.DB) http.HandlerFunc {
 				COALESCE((
 					SELECT COUNT(*) 
 					FROM messages 
-					WHERE chat_id = c.id AND receiver_id = ? AND is_read = FALSE
+					WHERE chat_id = c.id AND receiver_id = ? AND is_read = FALSE AND deleted_at IS NULL
 				), 0) as unread_count
 			FROM chats c
 			LEFT JOIN users u ON (
@@ -100,6 +100,7 @@ func GetChatsHandler(db *sql.DB) http.HandlerFunc {
 				}
 			}
 
+			chat.Type = chatTypeDirect
 			chat.OtherUser = &otherUser
 			chat.UnreadCount = unreadCount
 
@@ -127,6 +128,15 @@ func GetChatsHandler(db *sql.DB) http.HandlerFunc {
 			chats = append(chats, chat)
 		}
 
+		// Групповые чаты и чаты сотрудников организаций
+		groupChats, err := loadUserGroupChats(db, userID)
+		if err != nil {
+			log.Printf("❌ Error fetching group chats: %v", err)
+		} else if len(groupChats) > 0 {
+			chats = append(chats, groupChats...)
+			sortChatsByActivity(chats)
+		}
+
 		if chats == nil {
 			chats = []models.Chat{}
 		}
@@ -136,7 +146,9 @@ func GetChatsHandler(db *sql.DB) http.HandlerFunc {
 	}
 }
 
-// GetChatMessagesHandler возвращает сообщения конкретного чата
+// GetChatMessagesHandler возвращает сообщения конкретного чата (по умолчанию - последние messagesPageDefault)
+// Параметры: limit, before_id (страница старше), after_id (страница новее), around (сообщение с контекстом).
+// Тело - массив сообщений по возрастанию; X-Has-More / X-Has-Newer сообщают, есть ли ещё страницы.
 func GetChatMessagesHandler(db *sql.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		startTime := time.Now()
@@ -161,107 +173,33 @@ func GetChatMessagesHandler(db *sql.DB) http.HandlerFunc {
 
 		log.Printf("⏱️ [TIMING] GetChatMessagesHandler START: chatID=%d, userID=%d", chatID, userID)
 
-		// Оптимизация: проверяем доступ И загружаем сообщения одним запросом
-		queryStart := time.Now()
-		query := ConvertPlaceholders(`
-			SELECT 
-				m.id, m.chat_id, m.sender_id, m.receiver_id, 
-				m.content, m.is_read, m.read_at, m.created_at
-			FROM messages m
-			INNER JOIN chats c ON m.chat_id = c.id
-			WHERE m.chat_id = ? AND (c.user1_id = ? OR c.user2_id = ?)
-			ORDER BY m.created_at ASC
-		`)
+		// Проверяем доступ к чату (участник диалога или группы)
+		if !isUserInChat(db, chatID, userID) {
+			log.Printf("❌ User %d has no access to chat %d", userID, chatID)
+			http.Error(w, "Access denied", http.StatusForbidden)
+			return
+		}
 
-		rows, err := db.Query(query, chatID, userID, userID)
+		// Курсорная пагинация: последние N, страницы старше before_id, новее after_id или окрестность around
+		cursor, err := parseMessageCursor(r)
 		if err != nil {
-			log.Printf("❌ Error fetching messages: %v", err)
-			http.Error(w, "Failed to fetch messages", http.StatusInternalServerError)
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-		defer rows.Close()
-		log.Printf("⏱️ [TIMING] Query execution (with access check): %v", time.Since(queryStart))
-
-		// Сканируем строки
-		scanStart := time.Now()
-		var messages []models.Message
-		rowCount := 0
-		for rows.Next() {
-			rowCount++
-			var msg models.Message
-			var readAtStr, createdAtStr sql.NullString
-
-			err := rows.Scan(
-				&msg.ID, &msg.ChatID, &msg.SenderID, &msg.ReceiverID,
-				&msg.Content, &msg.IsRead, &readAtStr, &createdAtStr,
-			)
-			if err != nil {
-				log.Printf("❌ Error scanning message row %d: %v", rowCount, err)
-				continue
-			}
-
-			// Парсим даты
-			if createdAtStr.Valid {
-				formats := []string{
-					time.RFC3339Nano,
-					time.RFC3339,
-					"2006-01-02 15:04:05.999999-07:00",
-					"2006-01-02 15:04:05",
-				}
-				var t time.Time
-				var err error
-				for _, format := range formats {
-					t, err = time.Parse(format, createdAtStr.String)
-					if err == nil {
-						msg.CreatedAt = &t
-						break
-					}
-				}
-				if err != nil {
-					log.Printf("⚠️ Failed to parse created_at: %s, error: %v", createdAtStr.String, err)
-				}
-			}
 
-			if readAtStr.Valid {
-				formats := []string{
-					time.RFC3339Nano,
-					time.RFC3339,
-					"2006-01-02 15:04:05.999999-07:00",
-					"2006-01-02 15:04:05",
-				}
-				var t time.Time
-				var err error
-				for _, format := range formats {
-					t, err = time.Parse(format, readAtStr.String)
-					if err == nil {
-						msg.ReadAt = &t
-						break
-					}
-				}
-			}
-
-			messages = append(messages, msg)
+		queryStart := time.Now()
+		page, err := loadChatMessagePage(db, chatID, userID, cursor)
+		if err == sql.ErrNoRows {
+			http.Error(w, "Message not found", http.StatusNotFound)
+			return
 		}
-		log.Printf("⏱️ [TIMING] Scanning %d rows: %v", rowCount, time.Since(scanStart))
-
-		// Если сообщений нет, проверяем существует ли чат и есть ли доступ
-		if len(messages) == 0 {
-			var chatExists bool
-			err := db.QueryRow(ConvertPlaceholders(`
-				SELECT EXISTS(
-					SELECT 1 FROM chats 
-					WHERE id = ? AND (user1_id = ? OR user2_id = ?)
-				)
-			`), chatID, userID, userID).Scan(&chatExists)
-
-			if err != nil || !chatExists {
-				log.Printf("❌ User %d has no access to chat %d", userID, chatID)
-				http.Error(w, "Access denied", http.StatusForbidden)
-				return
-			}
-			// Чат существует, просто пустой
-			log.Printf("✅ Chat %d is empty", chatID)
+		if err != nil {
+			log.Printf("❌ Error fetching messages: %v", err)
+			http.Error(w, "Failed to fetch messages", http.StatusInternalServerError)
+			return
 		}
+		messages := page.Messages
+		log.Printf("⏱️ [TIMING] Loading page of %d messages: %v", len(messages), time.Since(queryStart))
 
 		// Оптимизация: загружаем всех отправителей одним запросом
 		loadSendersStart := time.Now()
@@ -372,10 +310,14 @@ func GetChatMessagesHandler(db *sql.DB) http.HandlerFunc {
 				messages[i].Attachments = []models.MessageAttachment{}
 			}
 		}
+		loadReplyPreviews(db, messages)
 		log.Printf("⏱️ [TIMING] Assigning data to messages: %v", time.Since(assignStart))
 
-		// Отмечаем все сообщения как прочитанные
-		go markMessagesAsRead(db, chatID, userID)
+		// Отмечаем все сообщения как прочитанные только при открытии чата: ни догрузка истории,
+		// ни переход к найденному сообщению (around) не означают, что последние сообщения увидены
+		if cursor.isLatest() {
+			go markMessagesAsRead(db, chatID, userID)
+		}
 
 		if messages == nil {
 			messages = []models.Message{}
@@ -384,6 +326,13 @@ func GetChatMessagesHandler(db *sql.DB) http.HandlerFunc {
 		totalDuration := time.Since(startTime)
 		log.Printf("⏱️ [TIMING] TOTAL GetChatMessagesHandler: %v (returned %d messages)", totalDuration, len(messages))
 
+		// Метаданные пагинации - в заголовках, тело остаётся массивом сообщений
+		w.Header().Set("X-Has-More", strconv.FormatBool(page.HasOlder))
+		w.Header().Set("X-Has-Newer", strconv.FormatBool(page.HasNewer))
+		if len(messages) > 0 {
+			w.Header().Set("X-Oldest-Id", strconv.Itoa(messages[0].ID))
+			w.Header().Set("X-Newest-Id", strconv.Itoa(messages[len(messages)-1].ID))
+		}
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(messages)
 	}
@@ -400,7 +349,9 @@ func SendMessageHandler(db *sql.DB) http.HandlerFunc {
 
 		var req struct {
 			ReceiverID int    `json:"receiver_id"`
+			ChatID     int    `json:"chat_id"` // Для групповых чатов (или существующего диалога)
 			Content    string `json:"content"`
+			ReplyToID  *int   `json:"reply_to_message_id"`
 		}
 
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -408,38 +359,31 @@ func SendMessageHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		if req.ReceiverID == 0 || req.Content == "" {
-			http.Error(w, "Receiver ID and content are required", http.StatusBadRequest)
-			return
-		}
-
-		if req.ReceiverID == userID {
-			http.Error(w, "Cannot send message to yourself", http.StatusBadRequest)
+		if (req.ReceiverID == 0 && req.ChatID == 0) || req.Content == "" {
+			http.Error(w, "Receiver ID (or chat ID) and content are required", http.StatusBadRequest)
 			return
 		}
 
-		// Проверяем, существует ли получатель
-		receiverExists, err := userExists(db, req.ReceiverID)
-		if err != nil || !receiverExists {
-			http.Error(w, "Receiver not found", http.StatusNotFound)
+		// Ищем или создаем чат
+		target, status, err := resolveOutgoingChat(db, userID, req.ChatID, req.ReceiverID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
 			return
 		}
+		chatID := target.ChatID
 
-		// Ищем или создаем чат
-		chatID, err := getOrCreateChat(db, userID, req.ReceiverID)
-		if err != nil {
-			log.Printf("❌ Error getting/creating chat: %v", err)
-			http.Error(w, "Failed to create chat", http.StatusInternalServerError)
+		if err := validateReplyTarget(db, chatID, req.ReplyToID); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
 		// Создаем сообщение
 		var messageID int
 		err = db.QueryRow(ConvertPlaceholders(`
-			INSERT INTO messages (chat_id, sender_id, receiver_id, content, created_at)
-			VALUES (?, ?, ?, ?, ?)
+			INSERT INTO messages (chat_id, sender_id, receiver_id, content, reply_to_message_id, created_at)
+			VALUES (?, ?, ?, ?, ?, ?)
 			RETURNING id
-		`), chatID, userID, req.ReceiverID, req.Content, time.Now()).Scan(&messageID)
+		`), chatID, userID, target.receiverArg(), req.Content, req.ReplyToID, time.Now()).Scan(&messageID)
 
 		if err != nil {
 			log.Printf("❌ Error creating message: %v", err)
@@ -466,11 +410,10 @@ func SendMessageHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		log.Printf("✅ Message sent: user %d -> user %d in chat %d", userID, req.ReceiverID, chatID)
+		log.Printf("✅ Message sent: user %d -> user %d in chat %d", userID, target.ReceiverID, chatID)
 
-		// 🔔 Отправляем WebSocket уведомление получателю
-		NotifyNewMessage(req.ReceiverID, message)
-		NotifyUnreadCount(req.ReceiverID)
+		// 🔔 Отправляем WebSocket уведомление получателю (в группе - всем участникам)
+		target.notifyRecipients(db, userID, message)
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(message)
@@ -486,13 +429,7 @@ func GetUnreadCountHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		var count int
-		err := db.QueryRow(ConvertPlaceholders(`
-			SELECT COUNT(*) 
-			FROM messages 
-			WHERE receiver_id = ? AND is_read = FALSE
-		`), userID).Scan(&count)
-
+		count, err := countUnreadMessages(db, userID)
 		if err != nil {
 			log.Printf("❌ Error counting unread messages: %v", err)
 			sendErrorResponse(w, "Failed to count unread messages", http.StatusInternalServerError)
@@ -516,26 +453,37 @@ func SendMediaMessageHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		// Получаем receiver_id
+		// Получаем receiver_id (диалог) или chat_id (групповой чат)
 		receiverIDStr := r.FormValue("receiver_id")
-		if receiverIDStr == "" {
+		chatIDStr := r.FormValue("chat_id")
+		if receiverIDStr == "" && chatIDStr == "" {
 			http.Error(w, "Receiver ID is required", http.StatusBadRequest)
 			return
 		}
 
-		receiverID, err := strconv.Atoi(receiverIDStr)
-		if err != nil {
-			http.Error(w, "Invalid receiver ID", http.StatusBadRequest)
-			return
+		var receiverID, requestedChatID int
+		if receiverIDStr != "" {
+			receiverID, err = strconv.Atoi(receiverIDStr)
+			if err != nil {
+				http.Error(w, "Invalid receiver ID", http.StatusBadRequest)
+				return
+			}
 		}
-
-		if receiverID == userID {
-			http.Error(w, "Cannot send message to yourself", http.StatusBadRequest)
-			return
+		if chatIDStr != "" {
+			requestedChatID, err = strconv.Atoi(chatIDStr)
+			if err != nil {
+				http.Error(w, "Invalid chat ID", http.StatusBadRequest)
+				return
+			}
 		}
 
-		// Получаем текст сообщения (опционально)
+		// Получаем текст сообщения и ответ на сообщение (опционально)
 		content := r.FormValue("content")
+		replyToID, err := parseReplyToMessageID(r.FormValue("reply_to_message_id"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 
 		// Получаем файлы
 		files := r.MultipartForm.File["media"]
@@ -544,28 +492,26 @@ func SendMediaMessageHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		// Проверяем, существует ли получатель
-		receiverExists, err := userExists(db, receiverID)
-		if err != nil || !receiverExists {
-			http.Error(w, "Receiver not found", http.StatusNotFound)
+		// Ищем или создаем чат
+		target, status, err := resolveOutgoingChat(db, userID, requestedChatID, receiverID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
 			return
 		}
+		chatID := target.ChatID
 
-		// Ищем или создаем чат
-		chatID, err := getOrCreateChat(db, userID, receiverID)
-		if err != nil {
-			log.Printf("❌ Error getting/creating chat: %v", err)
-			http.Error(w, "Failed to create chat", http.StatusInternalServerError)
+		if err := validateReplyTarget(db, chatID, replyToID); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
 		// Создаем сообщение
 		var messageID int
 		err = db.QueryRow(ConvertPlaceholders(`
-			INSERT INTO messages (chat_id, sender_id, receiver_id, content, created_at)
-			VALUES (?, ?, ?, ?, ?)
+			INSERT INTO messages (chat_id, sender_id, receiver_id, content, reply_to_message_id, created_at)
+			VALUES (?, ?, ?, ?, ?, ?)
 			RETURNING id
-		`), chatID, userID, receiverID, content, time.Now()).Scan(&messageID)
+		`), chatID, userID, target.receiverArg(), content, replyToID, time.Now()).Scan(&messageID)
 
 		if err != nil {
 			log.Printf("❌ Error creating message: %v", err)
@@ -653,7 +599,10 @@ func SendMediaMessageHandler(db *sql.DB) http.HandlerFunc {
 		// Добавляем attachments к сообщению
 		message.Attachments = attachments
 
-		log.Printf("✅ Media message sent: user %d -> user %d in chat %d (%d attachments)", userID, receiverID, chatID, len(attachments))
+		log.Printf("✅ Media message sent: user %d -> user %d in chat %d (%d attachments)", userID, target.ReceiverID, chatID, len(attachments))
+
+		// 🔔 Отправляем WebSocket уведомление получателю (в группе - всем участникам)
+		target.notifyRecipients(db, userID, message)
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(message)
@@ -697,9 +646,12 @@ func getOrCreateChat(db *sql.DB, user1ID, user2ID int) (int, error) {
 func isUserInChat(db *sql.DB, chatID, userID int) bool {
 	var count int
 	err := db.QueryRow(ConvertPlaceholders(`
-		SELECT COUNT(*) FROM chats 
-		WHERE id = ? AND (user1_id = ? OR user2_id = ?)
-	`), chatID, userID, userID).Scan(&count)
+		SELECT COUNT(*) FROM chats c
+		WHERE c.id = ? AND (
+			c.user1_id = ? OR c.user2_id = ?
+			OR EXISTS (SELECT 1 FROM chat_members cm WHERE cm.chat_id = c.id AND cm.user_id = ?)
+		)
+	`), chatID, userID, userID, userID).Scan(&count)
 
 	return err == nil && count > 0
 }
@@ -707,11 +659,13 @@ func isUserInChat(db *sql.DB, chatID, userID int) bool {
 func getMessageByID(db *sql.DB, messageID int) (*models.Message, error) {
 	var msg models.Message
 	err := db.QueryRow(ConvertPlaceholders(`
-		SELECT id, chat_id, sender_id, receiver_id, content, is_read, read_at, created_at
+		SELECT id, chat_id, sender_id, COALESCE(receiver_id, 0), content, is_read, read_at, created_at,
+		       edited_at, deleted_at IS NOT NULL, reply_to_message_id
 		FROM messages WHERE id = ?
 	`), messageID).Scan(
 		&msg.ID, &msg.ChatID, &msg.SenderID, &msg.ReceiverID,
 		&msg.Content, &msg.IsRead, &msg.ReadAt, &msg.CreatedAt,
+		&msg.EditedAt, &msg.IsDeleted, &msg.ReplyToMessageID,
 	)
 
 	if err != nil {
@@ -737,6 +691,13 @@ func getMessageByID(db *sql.DB, messageID int) (*models.Message, error) {
 		log.Printf("⚠️ Failed to get attachments for message %d: %v", msg.ID, err)
 	}
 
+	// Цитата сообщения, на которое отвечают
+	if msg.ReplyToMessageID != nil {
+		withReply := []models.Message{msg}
+		loadReplyPreviews(db, withReply)
+		msg.ReplyTo = withReply[0].ReplyTo
+	}
+
 	return &msg, nil
 }
 
@@ -808,6 +769,12 @@ func getUnreadCount(db *sql.DB, chatID, userID int) (int, error) {
 }
 
 func markMessagesAsRead(db *sql.DB, chatID, userID int) {
+	// В групповых чатах прочтение хранится по участнику, а не по сообщению
+	if chatType, err := getChatType(db, chatID); err == nil && chatType != chatTypeDirect {
+		markGroupChatAsRead(db, chatID, userID)
+		return
+	}
+
 	result, err := db.Exec(ConvertPlaceholders(`
 		UPDATE messages 
 		SET is_read = TRUE, read_at = ?
@@ -826,13 +793,7 @@ func markMessagesAsRead(db *sql.DB, chatID, userID int) {
 
 		// Отправляем обновленный счетчик непрочитанных через WebSocket
 		go func() {
-			var count int
-			err := db.QueryRow(ConvertPlaceholders(`
-				SELECT COUNT(*) 
-				FROM messages 
-				WHERE receiver_id = ? AND is_read = FALSE
-			`), userID).Scan(&count)
-
+			count, err := countUnreadMessages(db, userID)
 			if err == nil {
 				NotifyUnreadCount(userID)
 				log.Printf("📤 Sent updated unread count (%d) to user %d via WebSocket", count, userID)
@@ -843,6 +804,23 @@ func markMessagesAsRead(db *sql.DB, chatID, userID int) {
 	}
 }
 
+// countUnreadMessages - непрочитанные в диалогах (по is_read) и в группах (после last_read_message_id)
+func countUnreadMessages(db *sql.DB, userID int) (int, error) {
+	var count int
+	err := db.QueryRow(ConvertPlaceholders(`
+		SELECT
+			(SELECT COUNT(*) FROM messages WHERE receiver_id = ? AND is_read = FALSE AND deleted_at IS NULL)
+			+
+			(SELECT COUNT(*)
+			 FROM chat_members cm
+			 JOIN messages m ON m.chat_id = cm.chat_id
+			 WHERE cm.user_id = ? AND m.sender_id <> ? AND m.id > COALESCE(cm.last_read_message_id, 0)
+			   AND m.deleted_at IS NULL
+			   AND NOT EXISTS (SELECT 1 FROM message_hidden h WHERE h.message_id = m.id AND h.user_id = cm.user_id))
+	`), userID, userID, userID).Scan(&count)
+	return count, err
+}
+
 func userExists(db *sql.DB, userID int) (bool, error) {
 	var count int
 	err := db.QueryRow(ConvertPlaceholders("SELECT COUNT(*) FROM users WHERE id = ?"), userID).Scan(&count)
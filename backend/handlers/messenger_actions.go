@@ -0,0 +1,397 @@
+package handlers
+
+import (
+	"backend/models"
+	"backend/storage"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// Редактировать сообщение можно в течение 48 часов после отправки
+	messageEditWindow = 48 * time.Hour
+	// Длина текста в цитате ответа
+	messagePreviewLength = 120
+)
+
+// MessageHandler обрабатывает /api/messages/{id}
+//
+//	PUT    /api/messages/{id}                  - изменить текст (только автор, в течение messageEditWindow)
+//	DELETE /api/messages/{id}?for=me|everyone  - удалить у себя или у всех (у всех - автор или администратор группы)
+func MessageHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value("userID").(int)
+		if !ok || userID == 0 {
+			http.Error(w, `{"success":false,"error":"Unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		if len(parts) != 3 {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+		messageID, err := strconv.Atoi(parts[2])
+		if err != nil {
+			http.Error(w, "Invalid message ID", http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodPut:
+			editMessage(db, w, r, messageID, userID)
+		case http.MethodDelete:
+			deleteMessage(db, w, r, messageID, userID)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// messageState - поля сообщения, нужные для проверки прав на изменение
+type messageState struct {
+	ChatID     int
+	SenderID   int
+	ReceiverID int
+	IsRead     bool
+	Deleted    bool
+	CreatedAt  time.Time
+}
+
+func getMessageState(db *sql.DB, messageID int) (*messageState, error) {
+	var st messageState
+	err := db.QueryRow(ConvertPlaceholders(`
+		SELECT chat_id, sender_id, COALESCE(receiver_id, 0), is_read, deleted_at IS NOT NULL, created_at
+		FROM messages WHERE id = ?
+	`), messageID).Scan(&st.ChatID, &st.SenderID, &st.ReceiverID, &st.IsRead, &st.Deleted, &st.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+func editMessage(db *sql.DB, w http.ResponseWriter, r *http.Request, messageID, userID int) {
+	var req models.EditMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	content := strings.TrimSpace(req.Content)
+	if content == "" {
+		http.Error(w, "Content is required", http.StatusBadRequest)
+		return
+	}
+
+	st, err := getMessageState(db, messageID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Message not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to load message", http.StatusInternalServerError)
+		return
+	}
+	if st.SenderID != userID {
+		http.Error(w, "Only the author can edit a message", http.StatusForbidden)
+		return
+	}
+	if st.Deleted {
+		http.Error(w, "Message was deleted", http.StatusGone)
+		return
+	}
+	if time.Since(st.CreatedAt) > messageEditWindow {
+		http.Error(w, "Message can no longer be edited", http.StatusForbidden)
+		return
+	}
+
+	result, err := db.Exec(ConvertPlaceholders(`
+		UPDATE messages SET content = ?, edited_at = ?
+		WHERE id = ? AND deleted_at IS NULL
+	`), content, time.Now(), messageID)
+	if err != nil {
+		log.Printf("❌ Error editing message %d: %v", messageID, err)
+		http.Error(w, "Failed to edit message", http.StatusInternalServerError)
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		http.Error(w, "Message was deleted", http.StatusGone)
+		return
+	}
+
+	message, err := getMessageByID(db, messageID)
+	if err != nil {
+		http.Error(w, "Message edited but failed to fetch", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("✏️ Message %d edited by user %d in chat %d", messageID, userID, st.ChatID)
+
+	// 🔔 Остальные участники видят правку сразу
+	var recipients []int
+	for _, participantID := range getChatParticipantIDs(db, st.ChatID) {
+		if participantID != userID {
+			recipients = append(recipients, participantID)
+		}
+	}
+	NotifyUsers(recipients, "message_edited", message)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(message)
+}
+
+func deleteMessage(db *sql.DB, w http.ResponseWriter, r *http.Request, messageID, userID int) {
+	scope := r.URL.Query().Get("for")
+	if scope == "" {
+		scope = "me"
+	}
+	if scope != "me" && scope != "everyone" {
+		http.Error(w, "Parameter 'for' must be me or everyone", http.StatusBadRequest)
+		return
+	}
+
+	st, err := getMessageState(db, messageID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Message not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to load message", http.StatusInternalServerError)
+		return
+	}
+	if !isUserInChat(db, st.ChatID, userID) {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	}
+
+	event := map[string]interface{}{
+		"chat_id":      st.ChatID,
+		"message_id":   messageID,
+		"for_everyone": scope == "everyone",
+	}
+
+	if scope == "me" {
+		if _, err := db.Exec(ConvertPlaceholders(`
+			INSERT INTO message_hidden (message_id, user_id, hidden_at)
+			VALUES (?, ?, ?)
+			ON CONFLICT (message_id, user_id) DO NOTHING
+		`), messageID, userID, time.Now()); err != nil {
+			log.Printf("❌ Error hiding message %d for user %d: %v", messageID, userID, err)
+			http.Error(w, "Failed to delete message", http.StatusInternalServerError)
+			return
+		}
+
+		// Удалённое у себя входящее больше не считается непрочитанным
+		if st.ReceiverID == userID && !st.IsRead {
+			db.Exec(ConvertPlaceholders(`
+				UPDATE messages SET is_read = TRUE, read_at = ? WHERE id = ? AND is_read = FALSE
+			`), time.Now(), messageID)
+			NotifyUnreadCount(userID)
+		} else if st.ReceiverID == 0 && st.SenderID != userID {
+			// В группе прочтение хранится по участнику: скрытое сообщение просто выпадает из счётчика
+			NotifyUnreadCount(userID)
+		}
+
+		log.Printf("🗑️ Message %d deleted for user %d", messageID, userID)
+		NotifyUser(userID, "message_deleted", event)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": true})
+		return
+	}
+
+	// Удалить у всех может автор, а в группе - ещё и администратор
+	if st.SenderID != userID {
+		role, err := getChatMemberRole(db, st.ChatID, userID)
+		if err != nil || role != chatRoleAdmin {
+			http.Error(w, "Only the author can delete a message for everyone", http.StatusForbidden)
+			return
+		}
+	}
+	if st.Deleted {
+		http.Error(w, "Message was already deleted", http.StatusGone)
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		http.Error(w, "Failed to delete message", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(ConvertPlaceholders(`
+		UPDATE messages SET content = '', deleted_at = ?
+		WHERE id = ? AND deleted_at IS NULL
+	`), time.Now(), messageID)
+	if err != nil {
+		log.Printf("❌ Error deleting message %d: %v", messageID, err)
+		http.Error(w, "Failed to delete message", http.StatusInternalServerError)
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		http.Error(w, "Message was already deleted", http.StatusGone)
+		return
+	}
+
+	var filePaths []string
+	rows, err := tx.Query(ConvertPlaceholders("SELECT file_path FROM message_attachments WHERE message_id = ?"), messageID)
+	if err != nil {
+		http.Error(w, "Failed to delete message", http.StatusInternalServerError)
+		return
+	}
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err == nil {
+			filePaths = append(filePaths, path)
+		}
+	}
+	rows.Close()
+
+	if _, err := tx.Exec(ConvertPlaceholders("DELETE FROM message_attachments WHERE message_id = ?"), messageID); err != nil {
+		http.Error(w, "Failed to delete message", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to delete message", http.StatusInternalServerError)
+		return
+	}
+
+	// Файлы удаляем после коммита: ошибка хранилища не должна откатывать удаление
+	for _, path := range filePaths {
+		if err := storage.DeleteFile(path); err != nil {
+			log.Printf("⚠️ Failed to delete attachment file %s: %v", path, err)
+		}
+	}
+
+	log.Printf("🗑️ Message %d deleted for everyone by user %d in chat %d", messageID, userID, st.ChatID)
+
+	// 🔔 Сообщение пропадает у всех участников; непрочитанное - уменьшает их счётчики
+	participants := getChatParticipantIDs(db, st.ChatID)
+	NotifyUsers(participants, "message_deleted", event)
+	for _, participantID := range participants {
+		if participantID != st.SenderID {
+			NotifyUnreadCount(participantID)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true})
+}
+
+// getChatParticipantIDs - оба собеседника диалога или все участники группы
+func getChatParticipantIDs(db *sql.DB, chatID int) []int {
+	var chatType string
+	var user1ID, user2ID sql.NullInt64
+	err := db.QueryRow(ConvertPlaceholders(`
+		SELECT type, user1_id, user2_id FROM chats WHERE id = ?
+	`), chatID).Scan(&chatType, &user1ID, &user2ID)
+	if err != nil {
+		log.Printf("⚠️ Failed to load chat %d: %v", chatID, err)
+		return nil
+	}
+	if chatType == chatTypeDirect {
+		return uniqueInts([]int{int(user1ID.Int64), int(user2ID.Int64)})
+	}
+	return getChatMemberIDs(db, chatID)
+}
+
+// parseReplyToMessageID разбирает необязательный reply_to_message_id из формы
+func parseReplyToMessageID(value string) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil || id <= 0 {
+		return nil, errors.New("Invalid reply_to_message_id")
+	}
+	return &id, nil
+}
+
+// validateReplyTarget - отвечать можно только на неудалённое сообщение из того же чата
+func validateReplyTarget(db *sql.DB, chatID int, replyToID *int) error {
+	if replyToID == nil {
+		return nil
+	}
+	var replyChatID int
+	var deleted bool
+	err := db.QueryRow(ConvertPlaceholders(`
+		SELECT chat_id, deleted_at IS NOT NULL FROM messages WHERE id = ?
+	`), *replyToID).Scan(&replyChatID, &deleted)
+	if err != nil || replyChatID != chatID {
+		return errors.New("Reply target not found in this chat")
+	}
+	if deleted {
+		return errors.New("Cannot reply to a deleted message")
+	}
+	return nil
+}
+
+// loadReplyPreviews одним запросом подгружает цитаты для сообщений-ответов
+func loadReplyPreviews(db *sql.DB, messages []models.Message) {
+	ids := make([]int, 0)
+	for _, msg := range messages {
+		if msg.ReplyToMessageID != nil {
+			ids = append(ids, *msg.ReplyToMessageID)
+		}
+	}
+	ids = uniqueInts(ids)
+	if len(ids) == 0 {
+		return
+	}
+
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	rows, err := db.Query(ConvertPlaceholders(fmt.Sprintf(`
+		SELECT m.id, m.sender_id, m.content, m.deleted_at IS NOT NULL,
+		       EXISTS(SELECT 1 FROM message_attachments a WHERE a.message_id = m.id),
+		       u.name, u.last_name
+		FROM messages m
+		LEFT JOIN users u ON u.id = m.sender_id
+		WHERE m.id IN (%s)
+	`, strings.Join(placeholders, ","))), args...)
+	if err != nil {
+		log.Printf("⚠️ Failed to load reply previews: %v", err)
+		return
+	}
+	defer rows.Close()
+
+	previews := make(map[int]*models.MessagePreview)
+	for rows.Next() {
+		var p models.MessagePreview
+		var name, lastName sql.NullString
+		if err := rows.Scan(&p.ID, &p.SenderID, &p.Content, &p.IsDeleted, &p.HasAttachments, &name, &lastName); err != nil {
+			continue
+		}
+		p.SenderName = strings.TrimSpace(name.String + " " + lastName.String)
+		p.Content = truncateMessagePreview(p.Content)
+		previews[p.ID] = &p
+	}
+
+	for i := range messages {
+		if messages[i].ReplyToMessageID != nil {
+			messages[i].ReplyTo = previews[*messages[i].ReplyToMessageID]
+		}
+	}
+}
+
+func truncateMessagePreview(content string) string {
+	if utf8.RuneCountInString(content) <= messagePreviewLength {
+		return content
+	}
+	runes := []rune(content)
+	return string(runes[:messagePreviewLength]) + "…"
+}
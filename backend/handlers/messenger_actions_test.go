@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"backend/db"
+	"context"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// messageRow - строка getMessageState: chat_id, sender_id, receiver_id, is_read, удалено, created_at
+func messageRow(senderID, receiverID int, isRead, deleted bool, age time.Duration) []driver.Value {
+	return []driver.Value{int64(5), int64(senderID), int64(receiverID), isRead, deleted, time.Now().UTC().Add(-age)}
+}
+
+func callMessageHandler(method, target, body string, userID int) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req = req.WithContext(context.WithValue(req.Context(), "userID", userID))
+	rec := httptest.NewRecorder()
+	MessageHandler(db.DB)(rec, req)
+	return rec
+}
+
+// collectEvents читает типы событий подключения до паузы
+func collectEvents(c *Client, wait time.Duration) []string {
+	var got []string
+	for {
+		select {
+		case m := <-c.Send:
+			got = append(got, m.Type)
+		case <-time.After(wait):
+			return got
+		}
+	}
+}
+
+func TestEditMessage(t *testing.T) {
+	tests := []struct {
+		name       string
+		message    []driver.Value
+		body       string
+		wantStatus int
+		wantUpdate bool
+	}{
+		{"author edits", messageRow(2, 3, false, false, time.Hour), `{"content":" исправлено "}`, http.StatusOK, true},
+		{"empty text", messageRow(2, 3, false, false, time.Hour), `{"content":"  "}`, http.StatusBadRequest, false},
+		{"not the author", messageRow(3, 2, false, false, time.Hour), `{"content":"чужое"}`, http.StatusForbidden, false},
+		{"deleted message", messageRow(2, 3, false, true, time.Hour), `{"content":"поздно"}`, http.StatusGone, false},
+		{"edit window is over", messageRow(2, 3, false, false, messageEditWindow+time.Minute), `{"content":"поздно"}`, http.StatusForbidden, false},
+	}
+
+	prev := hub
+	hub = nil
+	t.Cleanup(func() { hub = prev })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated driver.Value
+			useFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+				switch {
+				case strings.Contains(query, "deleted_at IS NOT NULL, created_at FROM messages WHERE id"):
+					return &fakeResult{rows: [][]driver.Value{tt.message}}, nil
+				case strings.HasPrefix(query, "UPDATE messages SET content = ?, edited_at"):
+					updated = args[0]
+					return &fakeResult{affected: 1}, nil
+				case strings.Contains(query, "reply_to_message_id FROM messages WHERE id"):
+					now := time.Now().UTC()
+					return &fakeResult{rows: [][]driver.Value{{int64(9), int64(5), int64(2), int64(3), updated, false, nil, now, now, false, nil}}}, nil
+				}
+				return &fakeResult{}, nil
+			})
+
+			rec := callMessageHandler(http.MethodPut, "/api/messages/9", tt.body, 2)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if (updated != nil) != tt.wantUpdate {
+				t.Fatalf("message updated = %v, want %v", updated != nil, tt.wantUpdate)
+			}
+			if tt.wantUpdate && updated != "исправлено" {
+				t.Errorf("content saved as %q", updated)
+			}
+		})
+	}
+}
+
+func TestDeleteMessageForEveryone(t *testing.T) {
+	tests := []struct {
+		name       string
+		message    []driver.Value
+		role       string // роль удаляющего в группе
+		wantStatus int
+		wantStmts  []string
+	}{
+		{"author", messageRow(2, 0, false, false, time.Hour), "member", http.StatusOK,
+			[]string{"BEGIN", "erase", "drop attachments", "COMMIT"}},
+		{"group admin", messageRow(3, 0, false, false, time.Hour), chatRoleAdmin, http.StatusOK,
+			[]string{"BEGIN", "erase", "drop attachments", "COMMIT"}},
+		{"other member", messageRow(3, 0, false, false, time.Hour), "member", http.StatusForbidden, nil},
+		{"already deleted", messageRow(2, 0, false, true, time.Hour), "member", http.StatusGone, nil},
+	}
+
+	prev := hub
+	hub = nil
+	t.Cleanup(func() { hub = prev })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stmts []string
+			useFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+				switch {
+				case query == "BEGIN" || query == "COMMIT" || query == "ROLLBACK":
+					stmts = append(stmts, query)
+				case strings.Contains(query, "deleted_at IS NOT NULL, created_at FROM messages WHERE id"):
+					return &fakeResult{rows: [][]driver.Value{tt.message}}, nil
+				case strings.HasPrefix(query, "SELECT COUNT(*) FROM chats c"):
+					return &fakeResult{rows: [][]driver.Value{{int64(1)}}}, nil
+				case strings.HasPrefix(query, "SELECT role FROM chat_members"):
+					return &fakeResult{rows: [][]driver.Value{{tt.role}}}, nil
+				case strings.HasPrefix(query, "UPDATE messages SET content = '', deleted_at"):
+					stmts = append(stmts, "erase")
+					return &fakeResult{affected: 1}, nil
+				case strings.HasPrefix(query, "DELETE FROM message_attachments"):
+					stmts = append(stmts, "drop attachments")
+				}
+				return &fakeResult{}, nil
+			})
+
+			rec := callMessageHandler(http.MethodDelete, "/api/messages/9?for=everyone", "", 2)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if strings.Join(stmts, ", ") != strings.Join(tt.wantStmts, ", ") {
+				t.Errorf("statements %q, want %q", stmts, tt.wantStmts)
+			}
+		})
+	}
+}
+
+func TestDeleteMessageForMe(t *testing.T) {
+	tests := []struct {
+		name       string
+		message    []driver.Value
+		wantRead   bool
+		wantEvents []string
+	}{
+		{"unread direct message", messageRow(3, 2, false, false, time.Hour), true, []string{"message_deleted", "unread_count"}},
+		{"read direct message", messageRow(3, 2, true, false, time.Hour), false, []string{"message_deleted"}},
+		{"group message from another member", messageRow(3, 0, false, false, time.Hour), false, []string{"message_deleted", "unread_count"}},
+		{"own group message", messageRow(2, 0, false, false, time.Hour), false, []string{"message_deleted"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hidden, read := false, false
+			useFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+				switch {
+				case strings.Contains(query, "deleted_at IS NOT NULL, created_at FROM messages WHERE id"):
+					return &fakeResult{rows: [][]driver.Value{tt.message}}, nil
+				case strings.HasPrefix(query, "SELECT COUNT(*) FROM chats c"):
+					return &fakeResult{rows: [][]driver.Value{{int64(1)}}}, nil
+				case strings.HasPrefix(query, "INSERT INTO message_hidden"):
+					hidden = args[1] == int64(2)
+				case strings.HasPrefix(query, "UPDATE messages SET is_read = TRUE"):
+					read = true
+				case strings.Contains(query, "SELECT (SELECT COUNT(*) FROM messages"):
+					return &fakeResult{rows: [][]driver.Value{{int64(0)}}}, nil
+				}
+				return &fakeResult{affected: 1}, nil
+			})
+
+			h := newTestHub(t)
+			h.db = db.DB
+			user := addTestClient(h, 2)
+			prev := hub
+			hub = h
+			t.Cleanup(func() { hub = prev })
+
+			rec := callMessageHandler(http.MethodDelete, "/api/messages/9?for=me", "", 2)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+			}
+			if !hidden {
+				t.Error("message is not hidden for the user")
+			}
+			if read != tt.wantRead {
+				t.Errorf("marked read = %v, want %v", read, tt.wantRead)
+			}
+			// Скрытое непрочитанное сообщение уменьшает счётчик и в диалоге, и в группе
+			if got := collectEvents(user, 100*time.Millisecond); strings.Join(got, ",") != strings.Join(tt.wantEvents, ",") {
+				t.Errorf("events %v, want %v", got, tt.wantEvents)
+			}
+		})
+	}
+}
+
+func TestUnreadCountsSkipHiddenMessages(t *testing.T) {
+	var queries []string
+	useFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+		queries = append(queries, query)
+		if strings.HasPrefix(query, "SELECT (SELECT COUNT(*)") {
+			return &fakeResult{rows: [][]driver.Value{{int64(0)}}}, nil
+		}
+		return &fakeResult{}, nil
+	})
+
+	if _, err := countUnreadMessages(db.DB, 2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadUserGroupChats(db.DB, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	// Сообщение, удалённое "у себя", не должно висеть непрочитанным ни в общем счётчике, ни в списке чатов
+	for _, query := range queries {
+		if !strings.Contains(query, "deleted_at IS NULL") || !strings.Contains(query, "FROM message_hidden h") {
+			t.Errorf("unread count does not skip deleted and hidden messages: %s", query)
+		}
+	}
+	if len(queries) != 2 {
+		t.Errorf("queries %q, want unread count and group chats", queries)
+	}
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"backend/models"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	messagesPageDefault = 50
+	messagesPageMax     = 200
+)
+
+// messageCursor - параметры страницы истории сообщений
+type messageCursor struct {
+	Limit    int
+	BeforeID int // Сообщения старше этого ID
+	AfterID  int // Сообщения новее этого ID
+	AroundID int // Сообщение с окружающим контекстом
+}
+
+// isLatest - первая загрузка чата: последние сообщения без курсора
+func (c messageCursor) isLatest() bool {
+	return c.BeforeID == 0 && c.AfterID == 0 && c.AroundID == 0
+}
+
+// messagePage - страница сообщений по возрастанию ID
+type messagePage struct {
+	Messages []models.Message
+	HasOlder bool
+	HasNewer bool
+}
+
+func parseMessageCursor(r *http.Request) (messageCursor, error) {
+	cursor := messageCursor{Limit: messagesPageDefault}
+	q := r.URL.Query()
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return cursor, fmt.Errorf("Invalid limit")
+		}
+		cursor.Limit = min(limit, messagesPageMax)
+	}
+
+	params := map[string]*int{"before_id": &cursor.BeforeID, "after_id": &cursor.AfterID, "around": &cursor.AroundID}
+	set := 0
+	for name, target := range params {
+		v := q.Get(name)
+		if v == "" {
+			continue
+		}
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			return cursor, fmt.Errorf("Invalid %s", name)
+		}
+		*target = id
+		set++
+	}
+	if set > 1 {
+		return cursor, fmt.Errorf("Only one of before_id, after_id, around can be used")
+	}
+
+	return cursor, nil
+}
+
+// loadChatMessagePage загружает страницу сообщений чата глазами viewerID (без удалённых им "для себя").
+// Для around возвращает sql.ErrNoRows, если сообщения нет в этом чате.
+func loadChatMessagePage(db *sql.DB, chatID, viewerID int, cursor messageCursor) (*messagePage, error) {
+	page := &messagePage{}
+
+	switch {
+	case cursor.AroundID != 0:
+		var exists bool
+		err := db.QueryRow(ConvertPlaceholders("SELECT EXISTS(SELECT 1 FROM messages WHERE id = ? AND chat_id = ?)"), cursor.AroundID, chatID).Scan(&exists)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, sql.ErrNoRows
+		}
+
+		// Половина страницы до сообщения, остальное - само сообщение и следующие
+		olderLimit := cursor.Limit / 2
+		older, hasOlder, err := queryChatMessages(db, viewerID, "m.chat_id = ? AND m.id < ?", "DESC", olderLimit, chatID, cursor.AroundID)
+		if err != nil {
+			return nil, err
+		}
+		newer, hasNewer, err := queryChatMessages(db, viewerID, "m.chat_id = ? AND m.id >= ?", "ASC", cursor.Limit-olderLimit, chatID, cursor.AroundID)
+		if err != nil {
+			return nil, err
+		}
+		reverseMessages(older)
+		page.Messages = append(older, newer...)
+		page.HasOlder, page.HasNewer = hasOlder, hasNewer
+
+	case cursor.AfterID != 0:
+		messages, hasNewer, err := queryChatMessages(db, viewerID, "m.chat_id = ? AND m.id > ?", "ASC", cursor.Limit, chatID, cursor.AfterID)
+		if err != nil {
+			return nil, err
+		}
+		page.Messages = messages
+		page.HasOlder, page.HasNewer = true, hasNewer
+
+	case cursor.BeforeID != 0:
+		messages, hasOlder, err := queryChatMessages(db, viewerID, "m.chat_id = ? AND m.id < ?", "DESC", cursor.Limit, chatID, cursor.BeforeID)
+		if err != nil {
+			return nil, err
+		}
+		reverseMessages(messages)
+		page.Messages = messages
+		page.HasOlder, page.HasNewer = hasOlder, true
+
+	default:
+		messages, hasOlder, err := queryChatMessages(db, viewerID, "m.chat_id = ?", "DESC", cursor.Limit, chatID)
+		if err != nil {
+			return nil, err
+		}
+		reverseMessages(messages)
+		page.Messages = messages
+		page.HasOlder = hasOlder
+	}
+
+	if page.Messages == nil {
+		page.Messages = []models.Message{}
+	}
+	return page, nil
+}
+
+// queryChatMessages выбирает limit сообщений (на одно больше - чтобы узнать, есть ли продолжение)
+func queryChatMessages(db *sql.DB, viewerID int, where, order string, limit int, args ...interface{}) ([]models.Message, bool, error) {
+	if limit <= 0 {
+		return []models.Message{}, false, nil
+	}
+
+	query := ConvertPlaceholders(fmt.Sprintf(`
+		SELECT 
+			m.id, m.chat_id, m.sender_id, COALESCE(m.receiver_id, 0), 
+			m.content, m.is_read, m.read_at, m.created_at,
+			m.edited_at, m.deleted_at IS NOT NULL, m.reply_to_message_id
+		FROM messages m
+		WHERE %s
+		  AND NOT EXISTS (SELECT 1 FROM message_hidden h WHERE h.message_id = m.id AND h.user_id = ?)
+		ORDER BY m.id %s
+		LIMIT ?
+	`, where, order))
+
+	rows, err := db.Query(query, append(args, viewerID, limit+1)...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	messages := []models.Message{}
+	for rows.Next() {
+		var msg models.Message
+		var readAtStr, createdAtStr, editedAtStr sql.NullString
+		var replyToID sql.NullInt64
+
+		err := rows.Scan(
+			&msg.ID, &msg.ChatID, &msg.SenderID, &msg.ReceiverID,
+			&msg.Content, &msg.IsRead, &readAtStr, &createdAtStr,
+			&editedAtStr, &msg.IsDeleted, &replyToID,
+		)
+		if err != nil {
+			return nil, false, err
+		}
+		if editedAtStr.Valid {
+			msg.EditedAt = parseMessageTime(editedAtStr.String)
+		}
+		if replyToID.Valid {
+			id := int(replyToID.Int64)
+			msg.ReplyToMessageID = &id
+		}
+		if createdAtStr.Valid {
+			msg.CreatedAt = parseMessageTime(createdAtStr.String)
+		}
+		if readAtStr.Valid {
+			msg.ReadAt = parseMessageTime(readAtStr.String)
+		}
+		messages = append(messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	hasMore := len(messages) > limit
+	if hasMore {
+		messages = messages[:limit]
+	}
+	return messages, hasMore, nil
+}
+
+// parseMessageTime разбирает дату в любом из форматов, которые возвращает драйвер
+func parseMessageTime(value string) *time.Time {
+	formats := []string{
+		time.RFC3339Nano,
+		time.RFC3339,
+		"2006-01-02 15:04:05.999999-07:00",
+		"2006-01-02 15:04:05",
+	}
+	for _, format := range formats {
+		if t, err := time.Parse(format, value); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+func reverseMessages(messages []models.Message) {
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+}
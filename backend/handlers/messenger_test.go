@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"backend/db"
+	"context"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetChatMessagesMarksReadOnlyLatestPage(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		wantRead bool
+	}{
+		{"open chat", "", true},
+		{"older history", "?before_id=40", false},
+		{"newer messages", "?after_id=40", false},
+		{"jump to search result", "?around=40", false},
+	}
+
+	prev := hub
+	hub = nil
+	t.Cleanup(func() { hub = prev })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			marked := make(chan struct{}, 1)
+			useFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+				switch {
+				case strings.HasPrefix(query, "SELECT COUNT(*) FROM chats c"):
+					return &fakeResult{rows: [][]driver.Value{{int64(1)}}}, nil
+				case strings.HasPrefix(query, "SELECT EXISTS(SELECT 1 FROM messages"):
+					return &fakeResult{rows: [][]driver.Value{{true}}}, nil
+				case strings.HasPrefix(query, "SELECT type FROM chats"):
+					return &fakeResult{rows: [][]driver.Value{{chatTypeDirect}}}, nil
+				case strings.HasPrefix(query, "UPDATE messages SET is_read = TRUE"):
+					marked <- struct{}{}
+				}
+				return &fakeResult{}, nil
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/chats/5/messages"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), "userID", 2))
+			rec := httptest.NewRecorder()
+			GetChatMessagesHandler(db.DB)(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+			}
+			// Прочтение отмечается в фоне
+			read := false
+			select {
+			case <-marked:
+				read = true
+			case <-time.After(100 * time.Millisecond):
+			}
+			if read != tt.wantRead {
+				t.Errorf("messages marked read = %v, want %v", read, tt.wantRead)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"backend/db"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Ежедневная сводка: пользователям, включившим daily_digest, раз в сутки приходит уведомление "digest"
+// и письмо со сводкой непрочитанных уведомлений за период ("За сутки: 42 лайка, 3 комментария").
+// Каналы доставки настраиваются категорией "digest" в notification_preferences, как у остальных уведомлений.
+const notificationDigestPeriod = 24 * time.Hour
+
+// digestTypeLabels - формы слов для сводки (1, 2-4, 5+); остальные типы считаются как "уведомление"
+var digestTypeLabels = map[string][3]string{
+	"like":            {"лайк", "лайка", "лайков"},
+	"comment":         {"комментарий", "комментария", "комментариев"},
+	"friend_request":  {"запрос в друзья", "запроса в друзья", "запросов в друзья"},
+	"friend_accepted": {"новый друг", "новых друга", "новых друзей"},
+}
+
+var digestOtherLabel = [3]string{"уведомление", "уведомления", "уведомлений"}
+
+// Порядок типов в тексте сводки
+var digestTypeOrder = []string{"like", "comment", "friend_request", "friend_accepted", "other"}
+
+// NotificationDigestItem - строка сводки
+type NotificationDigestItem struct {
+	Type  string `json:"type"`
+	Count int    `json:"count"`
+	Label string `json:"label"`
+}
+
+// NotificationDigest - сводка непрочитанных уведомлений за период
+type NotificationDigest struct {
+	Since   time.Time                `json:"since"`
+	Total   int                      `json:"total"`
+	Items   []NotificationDigestItem `json:"items"`
+	Message string                   `json:"message"`
+}
+
+// NotificationDigestSettings - настройки сводки пользователя
+type NotificationDigestSettings struct {
+	DailyDigest bool       `json:"daily_digest"`
+	LastSentAt  *time.Time `json:"last_sent_at,omitempty"`
+}
+
+// DigestSettings - GET/PUT /api/notifications/digest
+func (h *NotificationsHandler) DigestSettings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int)
+	if !ok || userID == 0 {
+		sendJSONError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+	case "PUT":
+		var req NotificationDigestSettings
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendJSONError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		_, err := h.DB.Exec(convertPlaceholdersNotif(`
+			INSERT INTO user_notification_settings (user_id, daily_digest) VALUES (?, ?)
+			ON CONFLICT (user_id) DO UPDATE SET daily_digest = EXCLUDED.daily_digest
+		`), userID, req.DailyDigest)
+		if err != nil {
+			log.Printf("❌ Error saving digest settings for user %d: %v", userID, err)
+			sendJSONError(w, http.StatusInternalServerError, "Failed to save settings")
+			return
+		}
+	default:
+		sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	settings, err := loadDigestSettings(h.DB, userID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load settings")
+		return
+	}
+	sendJSONSuccess(w, settings)
+}
+
+// DigestPreview - GET /api/notifications/digest/preview: сводка, которая ушла бы сейчас
+func (h *NotificationsHandler) DigestPreview(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int)
+	if !ok || userID == 0 {
+		sendJSONError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	if r.Method != "GET" {
+		sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	settings, err := loadDigestSettings(h.DB, userID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load settings")
+		return
+	}
+	digest, err := buildNotificationDigest(h.DB, userID, digestPeriodStart(settings.LastSentAt))
+	if err != nil {
+		log.Printf("❌ Error building digest for user %d: %v", userID, err)
+		sendJSONError(w, http.StatusInternalServerError, "Failed to build digest")
+		return
+	}
+	sendJSONSuccess(w, digest)
+}
+
+func loadDigestSettings(db *sql.DB, userID int) (NotificationDigestSettings, error) {
+	var settings NotificationDigestSettings
+	err := db.QueryRow(convertPlaceholdersNotif(`
+		SELECT daily_digest, digest_last_sent_at FROM user_notification_settings WHERE user_id = ?
+	`), userID).Scan(&settings.DailyDigest, &settings.LastSentAt)
+	if err == sql.ErrNoRows {
+		return settings, nil
+	}
+	return settings, err
+}
+
+// digestPeriodStart - с какого момента собирать сводку: с прошлой отправки, но не раньше чем за период
+func digestPeriodStart(lastSentAt *time.Time) time.Time {
+	since := time.Now().Add(-notificationDigestPeriod)
+	if lastSentAt != nil && lastSentAt.After(since) {
+		return *lastSentAt
+	}
+	return since
+}
+
+// buildNotificationDigest собирает сводку непрочитанных уведомлений после since.
+// Для сгруппированных уведомлений считаются участники (42 лайка, а не 1 уведомление о лайках).
+func buildNotificationDigest(db *sql.DB, userID int, since time.Time) (*NotificationDigest, error) {
+	rows, err := db.Query(convertPlaceholdersNotif(`
+		SELECT type, SUM(COALESCE(actors_count, 1))
+		FROM notifications
+		WHERE user_id = ? AND is_read = FALSE AND type <> 'digest'
+		AND COALESCE(updated_at, created_at) > ?
+		GROUP BY type
+	`), userID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var notifType string
+		var count int
+		if err := rows.Scan(&notifType, &count); err != nil {
+			return nil, err
+		}
+		if _, known := digestTypeLabels[notifType]; !known {
+			notifType = "other"
+		}
+		counts[notifType] += count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	digest := &NotificationDigest{Since: since, Items: []NotificationDigestItem{}}
+	parts := []string{}
+	for _, notifType := range digestTypeOrder {
+		count := counts[notifType]
+		if count == 0 {
+			continue
+		}
+		forms, ok := digestTypeLabels[notifType]
+		if !ok {
+			forms = digestOtherLabel
+		}
+		label := fmt.Sprintf("%d %s", count, pluralRu(count, forms[0], forms[1], forms[2]))
+		digest.Items = append(digest.Items, NotificationDigestItem{Type: notifType, Count: count, Label: label})
+		digest.Total += count
+		parts = append(parts, label)
+	}
+	if digest.Total > 0 {
+		digest.Message = "За сутки: " + strings.Join(parts, ", ")
+	}
+	return digest, nil
+}
+
+// StartNotificationDigest периодически рассылает ежедневные сводки тем, у кого подошёл срок
+func StartNotificationDigest(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			sendNotificationDigests()
+			<-ticker.C
+		}
+	}()
+}
+
+func sendNotificationDigests() {
+	rows, err := db.DB.Query(ConvertPlaceholders(`
+		SELECT user_id, digest_last_sent_at FROM user_notification_settings
+		WHERE daily_digest = TRUE AND (digest_last_sent_at IS NULL OR digest_last_sent_at <= ?)
+	`), time.Now().Add(-notificationDigestPeriod))
+	if err != nil {
+		log.Printf("❌ Notification digest: %v", err)
+		return
+	}
+
+	type dueDigest struct {
+		userID     int
+		lastSentAt *time.Time
+	}
+	var due []dueDigest
+	for rows.Next() {
+		var d dueDigest
+		if err := rows.Scan(&d.userID, &d.lastSentAt); err == nil {
+			due = append(due, d)
+		}
+	}
+	rows.Close()
+
+	notifHandler := &NotificationsHandler{DB: db.DB}
+	for _, d := range due {
+		// Помечаем заранее, чтобы при нескольких экземплярах сводка ушла один раз
+		result, err := db.DB.Exec(ConvertPlaceholders(`
+			UPDATE user_notification_settings SET digest_last_sent_at = NOW()
+			WHERE user_id = ? AND (digest_last_sent_at IS NULL OR digest_last_sent_at <= ?)
+		`), d.userID, time.Now().Add(-notificationDigestPeriod))
+		if err != nil {
+			log.Printf("❌ Notification digest: failed to mark user %d: %v", d.userID, err)
+			continue
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			continue
+		}
+
+		digest, err := buildNotificationDigest(db.DB, d.userID, digestPeriodStart(d.lastSentAt))
+		if err != nil {
+			log.Printf("❌ Notification digest: failed to build for user %d: %v", d.userID, err)
+			continue
+		}
+		// Пустая сводка не отправляется
+		if digest.Total == 0 {
+			continue
+		}
+		// Письмо со сводкой ставит в очередь CreateNotification, если у категории включён email
+		if err := notifHandler.CreateNotification(d.userID, 0, "digest", "digest", 0, digest.Message); err != nil {
+			log.Printf("⚠️ Notification digest: failed to send to user %d: %v", d.userID, err)
+		}
+	}
+}
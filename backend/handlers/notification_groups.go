@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Группировка уведомлений: пока уведомление о лайках (комментариях) поста не прочитано и не старше
+// notificationGroupWindow, новые лайки добавляются в него, а не создают новые строки -
+// "Анна Иванова и ещё 41 человек лайкнули ваш пост". Участники группы хранятся в notification_actors,
+// поэтому повторный лайк того же пользователя не увеличивает счётчик.
+const notificationGroupWindow = 24 * time.Hour
+
+// groupedNotificationActions - типы, которые группируются, и текст действия во множественном числе
+var groupedNotificationActions = map[string]string{
+	"like":    "лайкнули ваш пост",
+	"comment": "прокомментировали ваш пост",
+}
+
+func isGroupedNotification(notifType string, actorID, entityID int) bool {
+	return groupedNotificationActions[notifType] != "" && actorID > 0 && entityID > 0
+}
+
+// createGroupedNotification добавляет участника в открытую группу или создаёт новую
+// и возвращает true, если создана новая строка уведомления
+func (h *NotificationsHandler) createGroupedNotification(userID, actorID int, notifType, entityType string, entityID int, message string, push bool) (bool, error) {
+	tx, err := h.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	created := false
+
+	n := Notification{
+		UserID:     userID,
+		Type:       notifType,
+		ActorID:    actorID,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Message:    message,
+	}
+
+	// Блокировка группы: параллельные лайки не должны создать две группы или потерять участника
+	err = tx.QueryRow(convertPlaceholdersNotif(`
+		SELECT id, created_at, COALESCE(actors_count, 1) FROM notifications
+		WHERE user_id = ? AND type = ? AND entity_type = ? AND entity_id = ?
+		AND is_read = FALSE AND created_at > ?
+		ORDER BY created_at DESC
+		LIMIT 1
+		FOR UPDATE
+	`), userID, notifType, entityType, entityID, time.Now().Add(-notificationGroupWindow)).Scan(&n.ID, &n.CreatedAt, &n.ActorsCount)
+
+	switch {
+	case err == sql.ErrNoRows:
+		n.ActorsCount = 1
+		err = tx.QueryRow(convertPlaceholdersNotif(`
+			INSERT INTO notifications (user_id, type, actor_id, entity_type, entity_id, message, actors_count)
+			VALUES (?, ?, ?, ?, ?, ?, 1)
+			RETURNING id, created_at
+		`), userID, notifType, actorID, entityType, entityID, message).Scan(&n.ID, &n.CreatedAt)
+		if err != nil {
+			return false, err
+		}
+		if _, err := tx.Exec(convertPlaceholdersNotif(`
+			INSERT INTO notification_actors (notification_id, actor_id) VALUES (?, ?)
+		`), n.ID, actorID); err != nil {
+			return false, err
+		}
+		created = true
+
+	case err != nil:
+		return false, err
+
+	default:
+		result, err := tx.Exec(convertPlaceholdersNotif(`
+			INSERT INTO notification_actors (notification_id, actor_id) VALUES (?, ?)
+			ON CONFLICT (notification_id, actor_id) DO NOTHING
+		`), n.ID, actorID)
+		if err != nil {
+			return false, err
+		}
+		if added, _ := result.RowsAffected(); added > 0 {
+			n.ActorsCount++
+		}
+		if n.ActorsCount > 1 {
+			n.Message = groupedNotificationMessage(getUserFullName(actorID), n.ActorsCount, groupedNotificationActions[notifType])
+		}
+
+		now := time.Now()
+		n.UpdatedAt = &now
+		if _, err := tx.Exec(convertPlaceholdersNotif(`
+			UPDATE notifications SET actor_id = ?, actors_count = ?, message = ?, updated_at = ? WHERE id = ?
+		`), actorID, n.ActorsCount, n.Message, now, n.ID); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	// Клиент заменяет уведомление с тем же id
+	if push {
+		go pushNotification(h.DB, n)
+	} else {
+		NotifyNotificationUnreadCount(userID)
+	}
+	return created, nil
+}
+
+// groupedNotificationMessage - "Анна Иванова и ещё 41 человек лайкнули ваш пост"
+func groupedNotificationMessage(lastActorName string, actorsCount int, action string) string {
+	others := actorsCount - 1
+	return fmt.Sprintf("%s и ещё %d %s %s", lastActorName, others, pluralRu(others, "человек", "человека", "человек"), action)
+}
+
+// pluralRu выбирает форму слова для числа: 1 лайк, 2 лайка, 5 лайков
+func pluralRu(n int, one, few, many string) string {
+	n %= 100
+	if n >= 11 && n <= 14 {
+		return many
+	}
+	switch n % 10 {
+	case 1:
+		return one
+	case 2, 3, 4:
+		return few
+	default:
+		return many
+	}
+}
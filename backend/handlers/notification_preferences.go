@@ -0,0 +1,359 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Настройки уведомлений: для каждой категории пользователь включает или выключает каналы
+//
+//	in_app - уведомление в списке /api/notifications (и счётчик)
+//	push   - мгновенная доставка через WebSocket
+//	email  - письмо
+//
+// Отдельно можно заглушить пост или чат (notification_mutes): уведомления по посту не создаются,
+// а сообщения заглушенного чата приходят без звука (silent).
+// Типы без категории (приёмы, передержка, владение организацией и т.п.) - служебные и не отключаются.
+const (
+	notificationCategoryComment            = "comment"
+	notificationCategoryLike               = "like"
+	notificationCategoryFriendRequest      = "friend_request"
+	notificationCategoryMessage            = "message"
+	notificationCategoryAnnouncement       = "announcement"
+	notificationCategoryOrganizationInvite = "organization_invite"
+	notificationCategoryDigest             = "digest"
+)
+
+var notificationCategories = []string{
+	notificationCategoryComment,
+	notificationCategoryLike,
+	notificationCategoryFriendRequest,
+	notificationCategoryMessage,
+	notificationCategoryAnnouncement,
+	notificationCategoryOrganizationInvite,
+	notificationCategoryDigest,
+}
+
+// notificationTypeCategories - категория настроек для типа уведомления
+var notificationTypeCategories = map[string]string{
+	"comment":                   notificationCategoryComment,
+	"like":                      notificationCategoryLike,
+	"friend_request":            notificationCategoryFriendRequest,
+	"friend_accepted":           notificationCategoryFriendRequest,
+	"group_chat_invite":         notificationCategoryMessage,
+	"organization_invite":       notificationCategoryOrganizationInvite,
+	"organization_join_request": notificationCategoryOrganizationInvite,
+	"digest":                    notificationCategoryDigest,
+}
+
+// Сущности, которые можно заглушить
+var mutableNotificationEntities = map[string]bool{
+	"post": true,
+	"chat": true,
+}
+
+// NotificationPreference - каналы доставки для категории
+type NotificationPreference struct {
+	Category string `json:"category"`
+	InApp    bool   `json:"in_app"`
+	Push     bool   `json:"push"`
+	Email    bool   `json:"email"`
+}
+
+// NotificationMute - заглушённый пост или чат
+type NotificationMute struct {
+	EntityType string     `json:"entity_type"`
+	EntityID   int        `json:"entity_id"`
+	MutedUntil *time.Time `json:"muted_until,omitempty"` // nil - бессрочно
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// UpdateNotificationPreferencesRequest - PUT /api/notifications/preferences (только переданные категории)
+type UpdateNotificationPreferencesRequest struct {
+	Preferences []NotificationPreference `json:"preferences"`
+}
+
+// MuteNotificationsRequest - POST /api/notifications/mutes
+type MuteNotificationsRequest struct {
+	EntityType    string `json:"entity_type"`
+	EntityID      int    `json:"entity_id"`
+	DurationHours int    `json:"duration_hours"` // 0 - бессрочно
+}
+
+func notificationTypeCategory(notifType string) string {
+	if category, ok := notificationTypeCategories[notifType]; ok {
+		return category
+	}
+	if strings.HasPrefix(notifType, "announcement") {
+		return notificationCategoryAnnouncement
+	}
+	return ""
+}
+
+func isNotificationCategory(category string) bool {
+	for _, c := range notificationCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+func defaultNotificationPreference(category string) NotificationPreference {
+	// Сводка изначально приходит и письмом: ради письма её обычно и включают
+	if category == notificationCategoryDigest {
+		return NotificationPreference{Category: category, InApp: true, Push: true, Email: true}
+	}
+	return NotificationPreference{Category: category, InApp: true, Push: true, Email: false}
+}
+
+// loadNotificationPreference - каналы для категории; служебные типы и ошибки БД - значения по умолчанию
+func loadNotificationPreference(db *sql.DB, userID int, category string) NotificationPreference {
+	pref := defaultNotificationPreference(category)
+	if category == "" {
+		return pref
+	}
+	err := db.QueryRow(ConvertPlaceholders(`
+		SELECT in_app, push, email FROM notification_preferences WHERE user_id = ? AND category = ?
+	`), userID, category).Scan(&pref.InApp, &pref.Push, &pref.Email)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("⚠️ Failed to load notification preferences for user %d: %v", userID, err)
+	}
+	return pref
+}
+
+func loadNotificationPreferences(db *sql.DB, userID int) ([]NotificationPreference, error) {
+	byCategory := map[string]NotificationPreference{}
+	rows, err := db.Query(ConvertPlaceholders(`
+		SELECT category, in_app, push, email FROM notification_preferences WHERE user_id = ?
+	`), userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var pref NotificationPreference
+		if err := rows.Scan(&pref.Category, &pref.InApp, &pref.Push, &pref.Email); err != nil {
+			return nil, err
+		}
+		byCategory[pref.Category] = pref
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	prefs := make([]NotificationPreference, 0, len(notificationCategories))
+	for _, category := range notificationCategories {
+		pref, ok := byCategory[category]
+		if !ok {
+			pref = defaultNotificationPreference(category)
+		}
+		prefs = append(prefs, pref)
+	}
+	return prefs, nil
+}
+
+// isNotificationMuted - заглушил ли пользователь пост или чат
+func isNotificationMuted(db *sql.DB, userID int, entityType string, entityID int) bool {
+	if !mutableNotificationEntities[entityType] || entityID <= 0 {
+		return false
+	}
+	var muted bool
+	err := db.QueryRow(ConvertPlaceholders(`
+		SELECT EXISTS(
+			SELECT 1 FROM notification_mutes
+			WHERE user_id = ? AND entity_type = ? AND entity_id = ?
+			AND (muted_until IS NULL OR muted_until > ?)
+		)
+	`), userID, entityType, entityID, time.Now()).Scan(&muted)
+	if err != nil {
+		log.Printf("⚠️ Failed to check notification mute for user %d: %v", userID, err)
+		return false
+	}
+	return muted
+}
+
+func loadNotificationMutes(db *sql.DB, userID int) ([]NotificationMute, error) {
+	rows, err := db.Query(ConvertPlaceholders(`
+		SELECT entity_type, entity_id, muted_until, created_at FROM notification_mutes
+		WHERE user_id = ? AND (muted_until IS NULL OR muted_until > ?)
+		ORDER BY created_at DESC
+	`), userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mutes := []NotificationMute{}
+	for rows.Next() {
+		var m NotificationMute
+		if err := rows.Scan(&m.EntityType, &m.EntityID, &m.MutedUntil, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		mutes = append(mutes, m)
+	}
+	return mutes, rows.Err()
+}
+
+// isMessageSilent - доставлять ли новое сообщение без звука и всплывающего уведомления
+func isMessageSilent(db *sql.DB, userID, chatID int) bool {
+	pref := loadNotificationPreference(db, userID, notificationCategoryMessage)
+	return !pref.InApp || !pref.Push || isNotificationMuted(db, userID, "chat", chatID)
+}
+
+// Preferences - GET/PUT /api/notifications/preferences
+func (h *NotificationsHandler) Preferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int)
+	if !ok || userID == 0 {
+		sendJSONError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+	case "PUT":
+		var req UpdateNotificationPreferencesRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendJSONError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		for _, pref := range req.Preferences {
+			if !isNotificationCategory(pref.Category) {
+				sendJSONError(w, http.StatusBadRequest, "Unknown notification category: "+pref.Category)
+				return
+			}
+		}
+
+		tx, err := h.DB.Begin()
+		if err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Failed to save preferences")
+			return
+		}
+		defer tx.Rollback()
+		for _, pref := range req.Preferences {
+			_, err := tx.Exec(ConvertPlaceholders(`
+				INSERT INTO notification_preferences (user_id, category, in_app, push, email, updated_at)
+				VALUES (?, ?, ?, ?, ?, ?)
+				ON CONFLICT (user_id, category) DO UPDATE
+				SET in_app = EXCLUDED.in_app, push = EXCLUDED.push, email = EXCLUDED.email, updated_at = EXCLUDED.updated_at
+			`), userID, pref.Category, pref.InApp, pref.Push, pref.Email, time.Now())
+			if err != nil {
+				log.Printf("❌ Error saving notification preferences for user %d: %v", userID, err)
+				sendJSONError(w, http.StatusInternalServerError, "Failed to save preferences")
+				return
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Failed to save preferences")
+			return
+		}
+	default:
+		sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	prefs, err := loadNotificationPreferences(h.DB, userID)
+	if err != nil {
+		log.Printf("❌ Error loading notification preferences for user %d: %v", userID, err)
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load preferences")
+		return
+	}
+	sendJSONSuccess(w, prefs)
+}
+
+// Mutes - GET /api/notifications/mutes, POST (заглушить), DELETE ?entity_type=&entity_id= (включить)
+func (h *NotificationsHandler) Mutes(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int)
+	if !ok || userID == 0 {
+		sendJSONError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+	case "POST":
+		var req MuteNotificationsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendJSONError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if !mutableNotificationEntities[req.EntityType] || req.EntityID <= 0 {
+			sendJSONError(w, http.StatusBadRequest, "entity_type must be post or chat, entity_id is required")
+			return
+		}
+		if req.DurationHours < 0 {
+			sendJSONError(w, http.StatusBadRequest, "duration_hours must not be negative")
+			return
+		}
+		if status, message := h.checkMuteTarget(userID, req.EntityType, req.EntityID); status != 0 {
+			sendJSONError(w, status, message)
+			return
+		}
+
+		var mutedUntil *time.Time
+		if req.DurationHours > 0 {
+			until := time.Now().Add(time.Duration(req.DurationHours) * time.Hour)
+			mutedUntil = &until
+		}
+		_, err := h.DB.Exec(ConvertPlaceholders(`
+			INSERT INTO notification_mutes (user_id, entity_type, entity_id, muted_until, created_at)
+			VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (user_id, entity_type, entity_id) DO UPDATE
+			SET muted_until = EXCLUDED.muted_until, created_at = EXCLUDED.created_at
+		`), userID, req.EntityType, req.EntityID, mutedUntil, time.Now())
+		if err != nil {
+			log.Printf("❌ Error muting %s %d for user %d: %v", req.EntityType, req.EntityID, userID, err)
+			sendJSONError(w, http.StatusInternalServerError, "Failed to mute")
+			return
+		}
+	case "DELETE":
+		entityType := r.URL.Query().Get("entity_type")
+		entityID, err := strconv.Atoi(r.URL.Query().Get("entity_id"))
+		if !mutableNotificationEntities[entityType] || err != nil || entityID <= 0 {
+			sendJSONError(w, http.StatusBadRequest, "entity_type must be post or chat, entity_id is required")
+			return
+		}
+		_, err = h.DB.Exec(ConvertPlaceholders(`
+			DELETE FROM notification_mutes WHERE user_id = ? AND entity_type = ? AND entity_id = ?
+		`), userID, entityType, entityID)
+		if err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Failed to unmute")
+			return
+		}
+	default:
+		sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	mutes, err := loadNotificationMutes(h.DB, userID)
+	if err != nil {
+		log.Printf("❌ Error loading notification mutes for user %d: %v", userID, err)
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load mutes")
+		return
+	}
+	sendJSONSuccess(w, mutes)
+}
+
+// checkMuteTarget проверяет, что пост существует, а пользователь - участник чата
+func (h *NotificationsHandler) checkMuteTarget(userID int, entityType string, entityID int) (int, string) {
+	switch entityType {
+	case "post":
+		var exists bool
+		if err := h.DB.QueryRow(ConvertPlaceholders("SELECT EXISTS(SELECT 1 FROM posts WHERE id = ?)"), entityID).Scan(&exists); err != nil {
+			return http.StatusInternalServerError, "Failed to check post"
+		}
+		if !exists {
+			return http.StatusNotFound, "Post not found"
+		}
+	case "chat":
+		if !isUserInChat(h.DB, entityID, userID) {
+			return http.StatusForbidden, "Access denied"
+		}
+	}
+	return 0, ""
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"backend/mail"
 	"backend/models"
 	"database/sql"
 	"encoding/json"
@@ -41,7 +42,10 @@ type Notification struct {
 	Message    string       `json:"message"`
 	IsRead     bool         `json:"is_read"`
 	CreatedAt  time.Time    `json:"created_at"`
-	Actor      *models.User `json:"actor,omitempty"`
+	Actor      *models.User `json:"actor,omitempty"` // Последний из участников для сгруппированных уведомлений
+
+	ActorsCount int        `json:"actors_count"`         // Сколько пользователей в группе ("Анна и ещё 41 человек")
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"` // Когда в группу добавлен последний участник
 }
 
 type NotificationsHandler struct {
@@ -58,12 +62,12 @@ func (h *NotificationsHandler) GetNotifications(w http.ResponseWriter, r *http.R
 
 	query := convertPlaceholdersNotif(`
 		SELECT n.id, n.user_id, n.type, n.actor_id, n.entity_type, n.entity_id, 
-		       n.message, n.is_read, n.created_at,
+		       n.message, n.is_read, n.created_at, COALESCE(n.actors_count, 1), n.updated_at,
 		       u.id, u.name, u.last_name, u.email, u.avatar
 		FROM notifications n
 		LEFT JOIN users u ON n.actor_id = u.id
 		WHERE n.user_id = ?
-		ORDER BY n.created_at DESC
+		ORDER BY COALESCE(n.updated_at, n.created_at) DESC
 		LIMIT 50
 	`)
 
@@ -85,7 +89,7 @@ func (h *NotificationsHandler) GetNotifications(w http.ResponseWriter, r *http.R
 
 		err := rows.Scan(
 			&n.ID, &n.UserID, &n.Type, &n.ActorID, &entityType, &entityID,
-			&n.Message, &n.IsRead, &n.CreatedAt,
+			&n.Message, &n.IsRead, &n.CreatedAt, &n.ActorsCount, &n.UpdatedAt,
 			&actorID, &actorName, &actorLastName, &actorEmail, &actorAvatar,
 		)
 		if err != nil {
@@ -148,9 +152,7 @@ func (h *NotificationsHandler) GetUnreadCount(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	var count int
-	query := convertPlaceholdersNotif("SELECT COUNT(*) FROM notifications WHERE user_id = ? AND is_read = FALSE")
-	err := h.DB.QueryRow(query, userID).Scan(&count)
+	count, err := countUnreadNotifications(h.DB, userID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -208,6 +210,9 @@ func (h *NotificationsHandler) MarkAsRead(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	// Синхронизируем счётчик в остальных вкладках
+	NotifyNotificationUnreadCount(userID)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
@@ -232,6 +237,8 @@ func (h *NotificationsHandler) MarkAllAsRead(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	NotifyNotificationUnreadCount(userID)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
@@ -248,13 +255,146 @@ func (h *NotificationsHandler) CreateNotification(userID, actorID int, notifType
 		return nil
 	}
 
+	// Настройки получателя (notification_preferences.go): заглушённые посты и отключённые каналы
+	pref := loadNotificationPreference(h.DB, userID, notificationTypeCategory(notifType))
+	if isNotificationMuted(h.DB, userID, entityType, entityID) {
+		return nil
+	}
+	grouped := isGroupedNotification(notifType, actorID, entityID)
+
+	// Письмо уходит только вместе с новым уведомлением: обновление группы лайков или комментариев
+	// новой строки не создаёт и письма не отправляет
+	if !pref.InApp {
+		// Без списка уведомлений строк нет; сгруппированные события письмами не рассылаются,
+		// иначе каждый лайк превращался бы в отдельное письмо
+		if pref.Email && !grouped {
+			go enqueueNotificationEmail(h.DB, userID, notificationEmailTemplate(notifType), message)
+		}
+		if !pref.Push {
+			return nil
+		}
+		// Только push: уведомление показывается сразу, но не сохраняется в списке
+		go pushNotification(h.DB, Notification{
+			UserID:      userID,
+			Type:        notifType,
+			ActorID:     actorID,
+			EntityType:  entityType,
+			EntityID:    entityID,
+			Message:     message,
+			CreatedAt:   time.Now(),
+			ActorsCount: 1,
+		})
+		return nil
+	}
+
+	// Лайки и комментарии к одному посту собираются в одно уведомление (notification_groups.go)
+	if grouped {
+		created, err := h.createGroupedNotification(userID, actorID, notifType, entityType, entityID, message, pref.Push)
+		if err == nil && created && pref.Email {
+			go enqueueNotificationEmail(h.DB, userID, notificationEmailTemplate(notifType), message)
+		}
+		return err
+	}
+
 	query := convertPlaceholdersNotif(`
 		INSERT INTO notifications (user_id, type, actor_id, entity_type, entity_id, message)
 		VALUES (?, ?, ?, ?, ?, ?)
+		RETURNING id, created_at
 	`)
 
-	_, err := h.DB.Exec(query, userID, notifType, actorID, entityType, entityID, message)
-	return err
+	n := Notification{
+		UserID:     userID,
+		Type:       notifType,
+		ActorID:    actorID,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Message:    message,
+
+		ActorsCount: 1,
+	}
+	err := h.DB.QueryRow(query, userID, notifType, actorID, entityType, entityID, message).Scan(&n.ID, &n.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if pref.Email {
+		go enqueueNotificationEmail(h.DB, userID, notificationEmailTemplate(notifType), message)
+	}
+
+	// Доставляем уведомление в открытые вкладки сразу, не дожидаясь опроса /api/notifications/unread
+	if pref.Push {
+		go pushNotification(h.DB, n)
+	} else {
+		NotifyNotificationUnreadCount(userID)
+	}
+	return nil
+}
+
+// pushNotification отправляет пользователю событие "notification" и новый счётчик непрочитанных
+func pushNotification(db *sql.DB, n Notification) {
+	if n.ActorID > 0 {
+		var actor models.User
+		var lastName, avatar sql.NullString
+		err := db.QueryRow(convertPlaceholdersNotif(`
+			SELECT id, name, last_name, avatar FROM users WHERE id = ?
+		`), n.ActorID).Scan(&actor.ID, &actor.Name, &lastName, &avatar)
+		if err == nil {
+			actor.LastName = lastName.String
+			actor.Avatar = avatar.String
+			n.Actor = &actor
+		}
+	}
+
+	NotifyUser(n.UserID, "notification", n)
+	sendNotificationUnreadCount(db, n.UserID)
+}
+
+// enqueueNotificationEmail ставит в очередь письмо с текстом уведомления
+// notificationEmailTemplate - шаблон письма для типа уведомления
+func notificationEmailTemplate(notifType string) string {
+	if notifType == "digest" {
+		return mail.TemplateNotificationDigest
+	}
+	return mail.TemplateNotification
+}
+
+func enqueueNotificationEmail(db *sql.DB, userID int, templateKey, message string) {
+	var name, email string
+	err := db.QueryRow(convertPlaceholdersNotif("SELECT name, COALESCE(email, '') FROM users WHERE id = ?"), userID).Scan(&name, &email)
+	if err != nil || email == "" {
+		return
+	}
+	_, err = mail.Enqueue(email, templateKey, mail.DefaultLocale, map[string]interface{}{
+		"Name":    name,
+		"Message": message,
+		"URL":     mail.AppURL() + "/notifications",
+	})
+	if err != nil {
+		log.Printf("⚠️ Failed to enqueue notification email for user %d: %v", userID, err)
+	}
+}
+
+// NotifyNotificationUnreadCount - сообщает пользователю актуальное количество непрочитанных уведомлений
+func NotifyNotificationUnreadCount(userID int) {
+	if hub == nil {
+		return
+	}
+	go sendNotificationUnreadCount(hub.db, userID)
+}
+
+func sendNotificationUnreadCount(db *sql.DB, userID int) {
+	count, err := countUnreadNotifications(db, userID)
+	if err != nil {
+		log.Printf("❌ Error getting notifications unread count for user %d: %v", userID, err)
+		return
+	}
+	NotifyUser(userID, "notification_unread_count", map[string]int{"count": count})
+}
+
+func countUnreadNotifications(db *sql.DB, userID int) (int, error) {
+	var count int
+	query := convertPlaceholdersNotif("SELECT COUNT(*) FROM notifications WHERE user_id = ? AND is_read = FALSE")
+	err := db.QueryRow(query, userID).Scan(&count)
+	return count, err
 }
 
 // Вспомогательные функции для создания уведомлений
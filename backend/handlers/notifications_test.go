@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"backend/db"
+	"backend/mail"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreateNotificationEmailsOnlyNewNotifications(t *testing.T) {
+	tests := []struct {
+		name       string
+		notifType  string
+		inApp      bool
+		openGroup  bool
+		wantEmails int
+	}{
+		{"first like creates a group", "like", true, false, 1},
+		{"next like updates the group", "like", true, true, 0},
+		{"next comment updates the group", "comment", true, true, 0},
+		{"friend request", "friend_request", true, false, 1},
+		{"friend request without in-app list", "friend_request", false, false, 1},
+		{"like without in-app list", "like", false, false, 0},
+	}
+
+	prev := hub
+	hub = nil
+	t.Cleanup(func() { hub = prev })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			emails := make(chan struct{}, 10)
+			useFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+				switch {
+				case strings.Contains(query, "FROM notification_preferences"):
+					// Push выключен: иначе уведомление доставляется в фоне через hub
+					return &fakeResult{rows: [][]driver.Value{{tt.inApp, false, true}}}, nil
+				case strings.Contains(query, "SELECT id, created_at, COALESCE(actors_count, 1) FROM notifications"):
+					if tt.openGroup {
+						return &fakeResult{rows: [][]driver.Value{{int64(3), time.Now().UTC(), int64(2)}}}, nil
+					}
+				case strings.HasPrefix(query, "INSERT INTO notifications"):
+					return &fakeResult{rows: [][]driver.Value{{int64(4), time.Now().UTC()}}}, nil
+				case strings.Contains(query, "SELECT name, COALESCE(email, '') FROM users"):
+					return &fakeResult{rows: [][]driver.Value{{"Анна", "anna@example.com"}}}, nil
+				case strings.Contains(query, "INSERT INTO email_outbox"):
+					emails <- struct{}{}
+					return &fakeResult{rows: [][]driver.Value{{int64(1)}}}, nil
+				}
+				return &fakeResult{affected: 1}, nil
+			})
+
+			h := &NotificationsHandler{DB: db.DB}
+			if err := h.CreateNotification(10, 20, tt.notifType, "post", 5, "Новое уведомление"); err != nil {
+				t.Fatal(err)
+			}
+
+			// Письмо ставится в очередь в фоне
+			got := 0
+			deadline := time.After(100 * time.Millisecond)
+			for waiting := true; waiting; {
+				select {
+				case <-emails:
+					got++
+				case <-deadline:
+					waiting = false
+				}
+			}
+			if got != tt.wantEmails {
+				t.Errorf("queued %d emails, want %d", got, tt.wantEmails)
+			}
+		})
+	}
+}
+
+func TestNotificationDigestFollowsPreferences(t *testing.T) {
+	tests := []struct {
+		name       string
+		pref       []driver.Value // nil - настроек нет, значения по умолчанию
+		wantInApp  bool
+		wantEmails int
+	}{
+		{"defaults", nil, true, 1},
+		{"email turned off", []driver.Value{true, false, false}, true, 0},
+		{"only email", []driver.Value{false, false, true}, false, 1},
+		{"turned off", []driver.Value{false, false, false}, false, 0},
+	}
+
+	prev := hub
+	hub = nil
+	t.Cleanup(func() { hub = prev })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			templates := make(chan driver.Value, 10)
+			inserted := false
+			useFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+				switch {
+				case strings.Contains(query, "SELECT user_id, digest_last_sent_at FROM user_notification_settings"):
+					return &fakeResult{rows: [][]driver.Value{{int64(10), nil}}}, nil
+				case strings.Contains(query, "SELECT type, SUM"):
+					return &fakeResult{rows: [][]driver.Value{{"like", int64(3)}}}, nil
+				case strings.Contains(query, "FROM notification_preferences"):
+					if args[1] != notificationCategoryDigest {
+						t.Errorf("digest preference read for category %v", args[1])
+					}
+					if tt.pref == nil {
+						return &fakeResult{}, nil
+					}
+					return &fakeResult{rows: [][]driver.Value{tt.pref}}, nil
+				case strings.HasPrefix(query, "INSERT INTO notifications"):
+					inserted = true
+					return &fakeResult{rows: [][]driver.Value{{int64(4), time.Now().UTC()}}}, nil
+				case strings.Contains(query, "SELECT name, COALESCE(email, '') FROM users"):
+					return &fakeResult{rows: [][]driver.Value{{"Анна", "anna@example.com"}}}, nil
+				case strings.Contains(query, "INSERT INTO email_outbox"):
+					templates <- args[1]
+					return &fakeResult{rows: [][]driver.Value{{int64(1)}}}, nil
+				}
+				return &fakeResult{affected: 1}, nil
+			})
+
+			sendNotificationDigests()
+
+			got := 0
+			deadline := time.After(100 * time.Millisecond)
+			for waiting := true; waiting; {
+				select {
+				case template := <-templates:
+					got++
+					if template != mail.TemplateNotificationDigest {
+						t.Errorf("digest emailed with template %v", template)
+					}
+				case <-deadline:
+					waiting = false
+				}
+			}
+			if got != tt.wantEmails {
+				t.Errorf("queued %d emails, want %d", got, tt.wantEmails)
+			}
+			if inserted != tt.wantInApp {
+				t.Errorf("digest in notification list = %v, want %v", inserted, tt.wantInApp)
+			}
+		})
+	}
+}
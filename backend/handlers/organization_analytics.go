@@ -0,0 +1,350 @@
+package handlers
+
+import (
+	"backend/db"
+	"backend/models"
+	"database/sql"
+	"net/http"
+	"time"
+)
+
+// maxAnalyticsBuckets ограничивает размер временного ряда (год по дням)
+const maxAnalyticsBuckets = 366
+
+// OrganizationAnalyticsHandler - дашборд аналитики организации
+// GET /api/organizations/{id}/analytics?from=2026-01-01&to=2026-01-31&granularity=day|week|month
+func OrganizationAnalyticsHandler(w http.ResponseWriter, r *http.Request, orgID int) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := GetUserIDFromGateway(r)
+	if !ok || userID == 0 {
+		sendJSONError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	allowed, err := canViewOrganizationAnalytics(orgID, userID)
+	if err == sql.ErrNoRows {
+		sendJSONError(w, http.StatusNotFound, "Organization not found")
+		return
+	}
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	if !allowed {
+		sendJSONError(w, http.StatusForbidden, "You don't have permission to view analytics of this organization")
+		return
+	}
+
+	// Период: по умолчанию последние 30 дней, to - включительно
+	to := truncateToDay(time.Now().UTC())
+	if v := r.URL.Query().Get("to"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			sendJSONError(w, http.StatusBadRequest, "to must be in YYYY-MM-DD format")
+			return
+		}
+		to = parsed
+	}
+	from := to.AddDate(0, 0, -29)
+	if v := r.URL.Query().Get("from"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			sendJSONError(w, http.StatusBadRequest, "from must be in YYYY-MM-DD format")
+			return
+		}
+		from = parsed
+	}
+	if from.After(to) {
+		sendJSONError(w, http.StatusBadRequest, "from must not be after to")
+		return
+	}
+
+	granularity := r.URL.Query().Get("granularity")
+	if granularity == "" {
+		granularity = "day"
+	}
+	if granularity != "day" && granularity != "week" && granularity != "month" {
+		sendJSONError(w, http.StatusBadRequest, "granularity must be day, week or month")
+		return
+	}
+
+	buckets := analyticsBuckets(from, to, granularity)
+	if len(buckets) > maxAnalyticsBuckets {
+		sendJSONError(w, http.StatusBadRequest, "Date range is too large for the selected granularity")
+		return
+	}
+
+	analytics, err := buildOrganizationAnalytics(orgID, from, to.AddDate(0, 0, 1), granularity, buckets)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to build analytics: "+err.Error())
+		return
+	}
+
+	sendJSONSuccess(w, analytics)
+}
+
+// canViewOrganizationAnalytics - владельцы и администраторы организации,
+// а также участники с глобальной ролью, дающей view_shelter_analytics / view_clinic_analytics
+func canViewOrganizationAnalytics(orgID, userID int) (bool, error) {
+	var orgType string
+	err := db.DB.QueryRow(ConvertPlaceholders("SELECT type FROM organizations WHERE id = ?"), orgID).Scan(&orgType)
+	if err != nil {
+		return false, err
+	}
+
+	roles, err := getUserActiveRoles(db.DB, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, role := range roles {
+		if role == models.RoleSuperAdmin {
+			return true, nil
+		}
+	}
+
+	var memberRole string
+	err = db.DB.QueryRow(ConvertPlaceholders(`
+		SELECT role FROM organization_members WHERE organization_id = ? AND user_id = ?
+	`), orgID, userID).Scan(&memberRole)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if memberRole == "owner" || memberRole == "admin" {
+		return true, nil
+	}
+
+	permission := ""
+	switch orgType {
+	case "shelter":
+		permission = "view_shelter_analytics"
+	case "vet_clinic":
+		permission = "view_clinic_analytics"
+	default:
+		return false, nil
+	}
+	for _, role := range roles {
+		if models.HasPermission(role, permission) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// buildOrganizationAnalytics считает показатели по существующим таблицам за [from, to)
+func buildOrganizationAnalytics(orgID int, from, to time.Time, granularity string, buckets []time.Time) (*models.OrganizationAnalytics, error) {
+	analytics := &models.OrganizationAnalytics{
+		OrganizationID: orgID,
+		From:           from,
+		To:             to.AddDate(0, 0, -1),
+		Granularity:    granularity,
+		Series:         make([]models.AnalyticsBucket, len(buckets)),
+		Totals: models.AnalyticsTotals{
+			AnnouncementsByType: map[string]int{},
+			AnnouncementOutcome: map[string]int{},
+		},
+	}
+
+	index := make(map[time.Time]*models.AnalyticsBucket, len(buckets))
+	for i, start := range buckets {
+		analytics.Series[i].PeriodStart = start
+		index[start] = &analytics.Series[i]
+	}
+
+	series := []struct {
+		query string
+		apply func(b *models.AnalyticsBucket, count, sum int)
+	}{
+		{
+			// Опубликованные посты от имени организации
+			query: `
+				SELECT date_trunc(?, p.created_at), COUNT(*), 0
+				FROM posts p
+				WHERE p.author_type = 'organization' AND p.author_id = ?
+				AND p.status = 'published' AND p.is_deleted = FALSE
+				AND p.created_at >= ? AND p.created_at < ?
+				GROUP BY 1`,
+			apply: func(b *models.AnalyticsBucket, count, _ int) { b.Posts = count },
+		},
+		{
+			query: `
+				SELECT date_trunc(?, l.created_at), COUNT(*), 0
+				FROM likes l
+				JOIN posts p ON l.post_id = p.id
+				WHERE p.author_type = 'organization' AND p.author_id = ?
+				AND l.created_at >= ? AND l.created_at < ?
+				GROUP BY 1`,
+			apply: func(b *models.AnalyticsBucket, count, _ int) { b.Likes = count },
+		},
+		{
+			query: `
+				SELECT date_trunc(?, c.created_at), COUNT(*), 0
+				FROM comments c
+				JOIN posts p ON c.post_id = p.id
+				WHERE p.author_type = 'organization' AND p.author_id = ?
+				AND c.created_at >= ? AND c.created_at < ?
+				GROUP BY 1`,
+			apply: func(b *models.AnalyticsBucket, count, _ int) { b.Comments = count },
+		},
+		{
+			// Охват: просмотры постов не хранятся, поэтому считаем уникальных пользователей,
+			// которые лайкнули или прокомментировали посты организации
+			query: `
+				SELECT date_trunc(?, e.created_at), COUNT(DISTINCT e.user_id), 0
+				FROM (
+					SELECT l.user_id, l.created_at, l.post_id FROM likes l
+					UNION ALL
+					SELECT c.user_id, c.created_at, c.post_id FROM comments c
+				) e
+				JOIN posts p ON e.post_id = p.id
+				WHERE p.author_type = 'organization' AND p.author_id = ?
+				AND e.created_at >= ? AND e.created_at < ?
+				GROUP BY 1`,
+			apply: func(b *models.AnalyticsBucket, count, _ int) { b.Reach = count },
+		},
+		{
+			query: `
+				SELECT date_trunc(?, created_at), COUNT(*), 0
+				FROM pets
+				WHERE organization_id = ? AND created_at >= ? AND created_at < ?
+				GROUP BY 1`,
+			apply: func(b *models.AnalyticsBucket, count, _ int) { b.PetsAdded = count },
+		},
+		{
+			// Пожертвования в сборы по питомцам организации
+			query: `
+				SELECT date_trunc(?, d.created_at), COUNT(*), COALESCE(SUM(d.amount), 0)
+				FROM announcement_donations d
+				JOIN pet_announcements a ON d.announcement_id = a.id
+				JOIN pets p ON a.pet_id = p.id
+				WHERE p.organization_id = ? AND d.created_at >= ? AND d.created_at < ?
+				GROUP BY 1`,
+			apply: func(b *models.AnalyticsBucket, count, sum int) {
+				b.Donations = count
+				b.DonationsAmount = sum
+			},
+		},
+	}
+
+	for _, s := range series {
+		rows, err := db.DB.Query(ConvertPlaceholders(s.query), granularity, orgID, from, to)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var start time.Time
+			var count, sum int
+			if err := rows.Scan(&start, &count, &sum); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if b, ok := index[start.UTC()]; ok {
+				s.apply(b, count, sum)
+			}
+		}
+		rows.Close()
+	}
+
+	for _, b := range analytics.Series {
+		analytics.Totals.Posts += b.Posts
+		analytics.Totals.Likes += b.Likes
+		analytics.Totals.Comments += b.Comments
+		analytics.Totals.Donations += b.Donations
+		analytics.Totals.DonationsAmount += b.DonationsAmount
+	}
+
+	// Уникальный охват за весь период (сумма по интервалам считала бы людей повторно)
+	err := db.DB.QueryRow(ConvertPlaceholders(`
+		SELECT COUNT(DISTINCT e.user_id)
+		FROM (
+			SELECT l.user_id, l.created_at, l.post_id FROM likes l
+			UNION ALL
+			SELECT c.user_id, c.created_at, c.post_id FROM comments c
+		) e
+		JOIN posts p ON e.post_id = p.id
+		WHERE p.author_type = 'organization' AND p.author_id = ?
+		AND e.created_at >= ? AND e.created_at < ?
+	`), orgID, from, to).Scan(&analytics.Totals.Reach)
+	if err != nil {
+		return nil, err
+	}
+
+	// Текущее состояние питомцев организации
+	err = db.DB.QueryRow(ConvertPlaceholders(`
+		SELECT COUNT(*), COUNT(CASE WHEN status = 'adopted' THEN 1 END)
+		FROM pets WHERE organization_id = ?
+	`), orgID).Scan(&analytics.Totals.PetsCurated, &analytics.Totals.PetsAdopted)
+	if err != nil {
+		return nil, err
+	}
+
+	// Объявления по питомцам организации, созданные в периоде: типы, исходы и просмотры
+	rows, err := db.DB.Query(ConvertPlaceholders(`
+		SELECT a.type, a.status, COUNT(*), COALESCE(SUM(a.views_count), 0)
+		FROM pet_announcements a
+		JOIN pets p ON a.pet_id = p.id
+		WHERE p.organization_id = ? AND a.created_at >= ? AND a.created_at < ?
+		GROUP BY a.type, a.status
+	`), orgID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var announcementType, status string
+		var count, views int
+		if err := rows.Scan(&announcementType, &status, &count, &views); err != nil {
+			return nil, err
+		}
+		analytics.Totals.AnnouncementsByType[announcementType] += count
+		analytics.Totals.AnnouncementOutcome[status] += count
+		analytics.Totals.AnnouncementViews += views
+	}
+
+	return analytics, nil
+}
+
+// analyticsBuckets возвращает начала интервалов от from до to включительно
+func analyticsBuckets(from, to time.Time, granularity string) []time.Time {
+	var buckets []time.Time
+	for start := truncateToPeriod(from, granularity); !start.After(to); {
+		buckets = append(buckets, start)
+		if len(buckets) > maxAnalyticsBuckets {
+			break
+		}
+		switch granularity {
+		case "week":
+			start = start.AddDate(0, 0, 7)
+		case "month":
+			start = start.AddDate(0, 1, 0)
+		default:
+			start = start.AddDate(0, 0, 1)
+		}
+	}
+	return buckets
+}
+
+// truncateToPeriod повторяет date_trunc PostgreSQL (неделя начинается с понедельника)
+func truncateToPeriod(t time.Time, granularity string) time.Time {
+	day := truncateToDay(t)
+	switch granularity {
+	case "week":
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case "month":
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
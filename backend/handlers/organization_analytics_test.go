@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"backend/models"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func analyticsDate(value string) time.Time {
+	day, _ := time.Parse("2006-01-02", value)
+	return day
+}
+
+func TestAnalyticsBuckets(t *testing.T) {
+	tests := []struct {
+		name        string
+		from, to    string
+		granularity string
+		want        []string
+	}{
+		{"days include both ends", "2026-10-01", "2026-10-03", "day", []string{"2026-10-01", "2026-10-02", "2026-10-03"}},
+		{"single day", "2026-10-01", "2026-10-01", "day", []string{"2026-10-01"}},
+		{"weeks start on monday", "2026-10-01", "2026-10-13", "week", []string{"2026-09-28", "2026-10-05", "2026-10-12"}},
+		{"sunday belongs to previous week", "2026-10-18", "2026-10-19", "week", []string{"2026-10-12", "2026-10-19"}},
+		{"months", "2026-01-31", "2026-03-01", "month", []string{"2026-01-01", "2026-02-01", "2026-03-01"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, start := range analyticsBuckets(analyticsDate(tt.from), analyticsDate(tt.to), tt.granularity) {
+				got = append(got, start.Format("2006-01-02"))
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("buckets %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Слишком длинный ряд обрывается сразу за пределом, а не строится целиком
+	long := analyticsBuckets(analyticsDate("2000-01-01"), analyticsDate("2026-01-01"), "day")
+	if len(long) != maxAnalyticsBuckets+1 {
+		t.Errorf("long range produced %d buckets, want %d", len(long), maxAnalyticsBuckets+1)
+	}
+}
+
+func getOrganizationAnalytics(query string, userID int) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/organizations/6/analytics"+query, nil)
+	req = req.WithContext(context.WithValue(req.Context(), "userID", userID))
+	rec := httptest.NewRecorder()
+	OrganizationAnalyticsHandler(rec, req, 6)
+	return rec
+}
+
+func TestOrganizationAnalyticsAccess(t *testing.T) {
+	tests := []struct {
+		name       string
+		orgType    string // "" - организации нет
+		roles      []string
+		memberRole string // "" - не участник
+		query      string
+		wantStatus int
+	}{
+		{"owner", "shelter", nil, "owner", "", http.StatusOK},
+		{"organization admin", "shelter", nil, "admin", "", http.StatusOK},
+		{"member", "shelter", nil, "member", "", http.StatusForbidden},
+		{"shelter admin role", "shelter", []string{models.RoleShelterAdmin}, "member", "", http.StatusOK},
+		{"shelter role in a clinic", "vet_clinic", []string{models.RoleShelterAdmin}, "member", "", http.StatusForbidden},
+		{"analytics role without membership", "shelter", []string{models.RoleShelterAdmin}, "", "", http.StatusForbidden},
+		{"superadmin", "shelter", []string{models.RoleSuperAdmin}, "", "", http.StatusOK},
+		{"unknown organization", "", nil, "", "", http.StatusNotFound},
+		{"bad granularity", "shelter", nil, "owner", "?granularity=year", http.StatusBadRequest},
+		{"bad date", "shelter", nil, "owner", "?from=01.10.2026", http.StatusBadRequest},
+		{"reversed range", "shelter", nil, "owner", "?from=2026-10-10&to=2026-10-01", http.StatusBadRequest},
+		{"range too large", "shelter", nil, "owner", "?from=2024-01-01&to=2026-01-01", http.StatusBadRequest},
+		{"two years by month", "shelter", nil, "owner", "?from=2024-01-01&to=2026-01-01&granularity=month", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+				switch {
+				case strings.HasPrefix(query, "SELECT type FROM organizations"):
+					if tt.orgType == "" {
+						return &fakeResult{}, nil
+					}
+					return &fakeResult{rows: [][]driver.Value{{tt.orgType}}}, nil
+				case strings.HasPrefix(query, "SELECT role FROM user_roles"):
+					res := &fakeResult{columns: []string{"role"}}
+					for _, role := range tt.roles {
+						res.rows = append(res.rows, []driver.Value{role})
+					}
+					return res, nil
+				case strings.HasPrefix(query, "SELECT role FROM organization_members"):
+					if tt.memberRole == "" {
+						return &fakeResult{}, nil
+					}
+					return &fakeResult{rows: [][]driver.Value{{tt.memberRole}}}, nil
+				case strings.HasPrefix(query, "SELECT COUNT(DISTINCT e.user_id)"):
+					return &fakeResult{rows: [][]driver.Value{{int64(0)}}}, nil
+				case strings.HasPrefix(query, "SELECT COUNT(*), COUNT(CASE"):
+					return &fakeResult{rows: [][]driver.Value{{int64(0), int64(0)}}}, nil
+				}
+				return &fakeResult{}, nil
+			})
+
+			if rec := getOrganizationAnalytics(tt.query, 2); rec.Code != tt.wantStatus {
+				t.Errorf("status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestOrganizationAnalyticsDashboard(t *testing.T) {
+	day := func(value string) time.Time { return analyticsDate(value) }
+	var periods [][]driver.Value
+	useFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+		switch {
+		case strings.HasPrefix(query, "SELECT type FROM organizations"):
+			return &fakeResult{rows: [][]driver.Value{{"shelter"}}}, nil
+		case strings.HasPrefix(query, "SELECT role FROM organization_members"):
+			return &fakeResult{rows: [][]driver.Value{{"owner"}}}, nil
+		case strings.Contains(query, "FROM posts p WHERE"):
+			periods = append(periods, args)
+			return &fakeResult{rows: [][]driver.Value{{day("2026-09-28"), int64(2), int64(0)}, {day("2026-10-05"), int64(1), int64(0)}}}, nil
+		case strings.Contains(query, "FROM likes l JOIN"):
+			return &fakeResult{rows: [][]driver.Value{{day("2026-10-05"), int64(10), int64(0)}}}, nil
+		case strings.Contains(query, "FROM announcement_donations d"):
+			return &fakeResult{rows: [][]driver.Value{
+				{day("2026-09-28"), int64(3), int64(1500)},
+				// Интервал вне ряда не учитывается
+				{day("2026-09-21"), int64(9), int64(9000)},
+			}}, nil
+		case strings.HasPrefix(query, "SELECT date_trunc(?, e.created_at), COUNT(DISTINCT e.user_id)"):
+			return &fakeResult{rows: [][]driver.Value{{day("2026-09-28"), int64(4), int64(0)}, {day("2026-10-05"), int64(5), int64(0)}}}, nil
+		case strings.HasPrefix(query, "SELECT COUNT(DISTINCT e.user_id)"):
+			return &fakeResult{rows: [][]driver.Value{{int64(7)}}}, nil
+		case strings.HasPrefix(query, "SELECT COUNT(*), COUNT(CASE"):
+			return &fakeResult{rows: [][]driver.Value{{int64(12), int64(4)}}}, nil
+		case strings.HasPrefix(query, "SELECT a.type, a.status"):
+			return &fakeResult{rows: [][]driver.Value{
+				{"fundraising", "active", int64(2), int64(40)},
+				{"adoption", "closed", int64(1), int64(15)},
+				{"fundraising", "closed", int64(1), int64(5)},
+			}}, nil
+		}
+		return &fakeResult{}, nil
+	})
+
+	rec := getOrganizationAnalytics("?from=2026-10-01&to=2026-10-07&granularity=week", 2)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp struct {
+		Data models.OrganizationAnalytics `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	a := resp.Data
+
+	// to включительно: в запросы уходит полуинтервал до следующего дня
+	if len(periods) != 1 || periods[0][0] != "week" || !periods[0][2].(time.Time).Equal(day("2026-10-01")) || !periods[0][3].(time.Time).Equal(day("2026-10-08")) {
+		t.Errorf("posts query args %v", periods)
+	}
+	if !a.To.Equal(day("2026-10-07")) {
+		t.Errorf("to = %v, want 2026-10-07", a.To)
+	}
+
+	if len(a.Series) != 2 {
+		t.Fatalf("series %+v, want two weeks", a.Series)
+	}
+	first, second := a.Series[0], a.Series[1]
+	if first.Posts != 2 || first.Reach != 4 || first.Donations != 3 || first.DonationsAmount != 1500 || first.Likes != 0 {
+		t.Errorf("first week %+v", first)
+	}
+	if second.Posts != 1 || second.Likes != 10 || second.Reach != 5 || second.Donations != 0 {
+		t.Errorf("second week %+v", second)
+	}
+
+	totals := a.Totals
+	// Охват за период считается отдельно: одни и те же люди в разные недели учитываются один раз
+	if totals.Posts != 3 || totals.Likes != 10 || totals.Reach != 7 || totals.Donations != 3 || totals.DonationsAmount != 1500 {
+		t.Errorf("totals %+v", totals)
+	}
+	if totals.PetsCurated != 12 || totals.PetsAdopted != 4 || totals.AnnouncementViews != 60 {
+		t.Errorf("pets and views %+v", totals)
+	}
+	if totals.AnnouncementsByType["fundraising"] != 3 || totals.AnnouncementsByType["adoption"] != 1 ||
+		totals.AnnouncementOutcome["closed"] != 2 || totals.AnnouncementOutcome["active"] != 2 {
+		t.Errorf("announcements by type %v, outcomes %v", totals.AnnouncementsByType, totals.AnnouncementOutcome)
+	}
+}
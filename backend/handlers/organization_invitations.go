@@ -0,0 +1,673 @@
+package handlers
+
+import (
+	"backend/db"
+	"backend/models"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Сроки отсчитываются от NOW() базы данных, как и проверки просрочки,
+// чтобы часы сервера приложения не влияли на срок действия
+const (
+	// Срок действия приглашения в организацию
+	organizationInviteTTL = 7 * 24 * time.Hour
+	// Срок рассмотрения заявки на вступление
+	organizationJoinRequestTTL = 30 * 24 * time.Hour
+)
+
+const organizationInvitationColumns = `
+	i.id, i.organization_id, i.kind, i.user_id, i.email, i.role, i.position,
+	i.can_post, i.can_edit, i.can_manage_members, i.message, i.status,
+	i.invited_by, i.responded_by, i.expires_at, i.responded_at, i.created_at,
+	o.name, COALESCE(u.name || ' ' || COALESCE(u.last_name, ''), '')
+`
+
+const organizationInvitationJoins = `
+	FROM organization_invitations i
+	JOIN organizations o ON i.organization_id = o.id
+	LEFT JOIN users u ON i.user_id = u.id
+`
+
+// createOrganizationInvitation создаёт приглашение вместо немедленного добавления участника
+func createOrganizationInvitation(w http.ResponseWriter, inviterID int, req models.CreateInvitationRequest) {
+	if req.Role == "" {
+		req.Role = "member"
+	}
+	if req.Role != "admin" && req.Role != "moderator" && req.Role != "member" {
+		sendJSONError(w, http.StatusBadRequest, "Role must be admin, moderator or member")
+		return
+	}
+
+	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
+	if req.UserID == 0 && req.Email == "" {
+		sendJSONError(w, http.StatusBadRequest, "user_id or email is required")
+		return
+	}
+
+	// Проверяем права доступа (только участники с can_manage_members могут приглашать)
+	if !canManageOrganizationMembers(req.OrganizationID, inviterID) {
+		sendJSONError(w, http.StatusForbidden, "You don't have permission to manage members")
+		return
+	}
+
+	// Если приглашают по email - ищем зарегистрированного пользователя
+	if req.UserID == 0 {
+		err := db.DB.QueryRow(ConvertPlaceholders("SELECT id FROM users WHERE LOWER(email) = ?"), req.Email).Scan(&req.UserID)
+		if err != nil && err != sql.ErrNoRows {
+			sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+			return
+		}
+	}
+
+	var inviteeID *int
+	var inviteeEmail *string
+	if req.UserID != 0 {
+		var exists bool
+		err := db.DB.QueryRow(ConvertPlaceholders("SELECT EXISTS(SELECT 1 FROM users WHERE id = ?)"), req.UserID).Scan(&exists)
+		if err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+			return
+		}
+		if !exists {
+			sendJSONError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		if isOrganizationMember(req.OrganizationID, req.UserID) {
+			sendJSONError(w, http.StatusBadRequest, "User is already a member")
+			return
+		}
+		inviteeID = &req.UserID
+	} else {
+		inviteeEmail = &req.Email
+	}
+
+	expireOrganizationInvitations()
+
+	// Не дублируем активные приглашения и заявки
+	var pendingID int
+	err := db.DB.QueryRow(ConvertPlaceholders(`
+		SELECT id FROM organization_invitations
+		WHERE organization_id = ? AND status = 'pending'
+		AND ((user_id IS NOT NULL AND user_id = ?) OR (user_id IS NULL AND LOWER(email) = ?))
+	`), req.OrganizationID, req.UserID, req.Email).Scan(&pendingID)
+	if err == nil {
+		sendJSONError(w, http.StatusConflict, "There is already a pending invitation or join request for this user")
+		return
+	}
+	if err != sql.ErrNoRows {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+
+	// Права по умолчанию зависят от роли, но могут быть заданы явно
+	canPost := req.Role == "admin" || req.Role == "moderator"
+	canEdit := req.Role == "admin"
+	canManageMembers := req.Role == "admin"
+	if req.CanPost != nil {
+		canPost = *req.CanPost
+	}
+	if req.CanEdit != nil {
+		canEdit = *req.CanEdit
+	}
+	if req.CanManageMembers != nil {
+		canManageMembers = *req.CanManageMembers
+	}
+
+	var position *string
+	if strings.TrimSpace(req.Position) != "" {
+		position = &req.Position
+	}
+
+	var invitationID int64
+	err = db.DB.QueryRow(ConvertPlaceholders(`
+		INSERT INTO organization_invitations
+			(organization_id, kind, user_id, email, role, position, can_post, can_edit, can_manage_members, status, invited_by, expires_at)
+		VALUES (?, 'invite', ?, ?, ?, ?, ?, ?, ?, 'pending', ?, NOW() + CAST(? AS INTEGER) * INTERVAL '1 second')
+		RETURNING id
+	`), req.OrganizationID, inviteeID, inviteeEmail, req.Role, position,
+		canPost, canEdit, canManageMembers, inviterID, int(organizationInviteTTL.Seconds())).Scan(&invitationID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to create invitation: "+err.Error())
+		return
+	}
+
+	invitation, err := getOrganizationInvitation(int(invitationID))
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load invitation: "+err.Error())
+		return
+	}
+
+	if inviteeID != nil {
+		message := fmt.Sprintf("%s приглашает вас в организацию «%s»", getUserFullName(inviterID), invitation.OrganizationName)
+		notifHandler := &NotificationsHandler{DB: db.DB}
+		if err := notifHandler.CreateNotification(*inviteeID, inviterID, "organization_invite", "organization_invitation", invitation.ID, message); err != nil {
+			log.Printf("⚠️ Failed to notify user %d about invitation %d: %v", *inviteeID, invitation.ID, err)
+		}
+	}
+
+	sendJSONSuccess(w, map[string]interface{}{
+		"message":    "Invitation sent",
+		"invitation": invitation,
+	})
+}
+
+// OrganizationJoinRequestHandler - заявка на вступление в организацию
+// POST /api/organizations/{id}/join-requests
+func OrganizationJoinRequestHandler(w http.ResponseWriter, r *http.Request, orgID int) {
+	if r.Method != http.MethodPost {
+		sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.JoinOrganizationRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendJSONError(w, http.StatusBadRequest, "Invalid request: "+err.Error())
+			return
+		}
+	}
+
+	var orgName, status string
+	err := db.DB.QueryRow(ConvertPlaceholders("SELECT name, status FROM organizations WHERE id = ?"), orgID).Scan(&orgName, &status)
+	if err == sql.ErrNoRows {
+		sendJSONError(w, http.StatusNotFound, "Organization not found")
+		return
+	}
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	if status == "blocked" {
+		sendJSONError(w, http.StatusForbidden, "Organization is blocked")
+		return
+	}
+
+	if isOrganizationMember(orgID, userID) {
+		sendJSONError(w, http.StatusBadRequest, "You are already a member")
+		return
+	}
+
+	expireOrganizationInvitations()
+
+	var pendingID int
+	var pendingKind string
+	err = db.DB.QueryRow(ConvertPlaceholders(`
+		SELECT id, kind FROM organization_invitations
+		WHERE organization_id = ? AND user_id = ? AND status = 'pending'
+	`), orgID, userID).Scan(&pendingID, &pendingKind)
+	if err == nil {
+		if pendingKind == "invite" {
+			sendJSONError(w, http.StatusConflict, "You already have a pending invitation to this organization")
+		} else {
+			sendJSONError(w, http.StatusConflict, "You already have a pending join request")
+		}
+		return
+	}
+	if err != sql.ErrNoRows {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+
+	var message *string
+	if strings.TrimSpace(req.Message) != "" {
+		message = &req.Message
+	}
+
+	var requestID int64
+	err = db.DB.QueryRow(ConvertPlaceholders(`
+		INSERT INTO organization_invitations (organization_id, kind, user_id, role, message, status, expires_at)
+		VALUES (?, 'request', ?, 'member', ?, 'pending', NOW() + CAST(? AS INTEGER) * INTERVAL '1 second')
+		RETURNING id
+	`), orgID, userID, message, int(organizationJoinRequestTTL.Seconds())).Scan(&requestID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to create join request: "+err.Error())
+		return
+	}
+
+	// Уведомляем всех, кто может рассмотреть заявку
+	notifText := fmt.Sprintf("%s хочет вступить в организацию «%s»", getUserFullName(userID), orgName)
+	notifHandler := &NotificationsHandler{DB: db.DB}
+	for _, managerID := range getOrganizationMemberManagers(orgID) {
+		if err := notifHandler.CreateNotification(managerID, userID, "organization_join_request", "organization_invitation", int(requestID), notifText); err != nil {
+			log.Printf("⚠️ Failed to notify manager %d about join request %d: %v", managerID, requestID, err)
+		}
+	}
+
+	invitation, err := getOrganizationInvitation(int(requestID))
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load join request: "+err.Error())
+		return
+	}
+
+	sendJSONSuccess(w, invitation)
+}
+
+// OrganizationInvitationsQueueHandler - приглашения и заявки организации (для управляющих участниками)
+// GET /api/organizations/{id}/invitations?kind=invite|request&status=pending
+func OrganizationInvitationsQueueHandler(w http.ResponseWriter, r *http.Request, orgID int) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	if !canManageOrganizationMembers(orgID, userID) {
+		sendJSONError(w, http.StatusForbidden, "You don't have permission to manage members")
+		return
+	}
+
+	expireOrganizationInvitations()
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = "pending"
+	}
+
+	query := "SELECT " + organizationInvitationColumns + organizationInvitationJoins + " WHERE i.organization_id = ?"
+	args := []interface{}{orgID}
+	if status != "all" {
+		query += " AND i.status = ?"
+		args = append(args, status)
+	}
+	if kind := r.URL.Query().Get("kind"); kind != "" {
+		query += " AND i.kind = ?"
+		args = append(args, kind)
+	}
+	query += " ORDER BY i.created_at DESC"
+
+	invitations, err := queryOrganizationInvitations(query, args...)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+
+	sendJSONSuccess(w, invitations)
+}
+
+// GetMyInvitationsHandler - входящие приглашения и собственные заявки текущего пользователя
+// GET /api/organizations/invitations
+func GetMyInvitationsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	expireOrganizationInvitations()
+
+	// Приглашения по email подхватываются после регистрации с этим адресом
+	invitations, err := queryOrganizationInvitations("SELECT "+organizationInvitationColumns+organizationInvitationJoins+`
+		WHERE i.status = 'pending'
+		AND (i.user_id = ? OR (i.user_id IS NULL AND LOWER(i.email) = (SELECT LOWER(email) FROM users WHERE id = ?)))
+		ORDER BY i.created_at DESC
+	`, userID, userID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+
+	sendJSONSuccess(w, invitations)
+}
+
+// OrganizationInvitationHandler - действия с приглашением или заявкой
+// POST /api/organizations/invitations/{id}/accept
+// POST /api/organizations/invitations/{id}/decline
+// DELETE /api/organizations/invitations/{id} - отзыв приглашения / заявки
+func OrganizationInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 4 {
+		sendJSONError(w, http.StatusBadRequest, "Invalid URL")
+		return
+	}
+	invitationID, err := strconv.Atoi(parts[3])
+	if err != nil {
+		sendJSONError(w, http.StatusBadRequest, "Invalid invitation ID")
+		return
+	}
+
+	action := ""
+	if len(parts) > 4 {
+		action = parts[4]
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodDelete:
+		cancelOrganizationInvitation(w, invitationID, userID)
+	case action == "accept" && r.Method == http.MethodPost:
+		respondToOrganizationInvitation(w, invitationID, userID, true)
+	case action == "decline" && r.Method == http.MethodPost:
+		respondToOrganizationInvitation(w, invitationID, userID, false)
+	default:
+		sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// respondToOrganizationInvitation - приглашение принимает приглашённый,
+// заявку на вступление одобряет участник с правом управления участниками
+func respondToOrganizationInvitation(w http.ResponseWriter, invitationID, userID int, accept bool) {
+	expireOrganizationInvitations()
+
+	invitation, err := getOrganizationInvitation(invitationID)
+	if err == sql.ErrNoRows {
+		sendJSONError(w, http.StatusNotFound, "Invitation not found")
+		return
+	}
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+
+	if invitation.Kind == "invite" {
+		if !isOrganizationInvitee(invitation, userID) {
+			sendJSONError(w, http.StatusForbidden, "This invitation is not addressed to you")
+			return
+		}
+	} else if !canManageOrganizationMembers(invitation.OrganizationID, userID) {
+		sendJSONError(w, http.StatusForbidden, "You don't have permission to manage members")
+		return
+	}
+
+	if invitation.Status != "pending" {
+		sendJSONError(w, http.StatusConflict, "Invitation is already "+invitation.Status)
+		return
+	}
+
+	memberID := userID
+	if invitation.Kind == "request" {
+		memberID = *invitation.UserID
+	}
+
+	status := "declined"
+	if accept {
+		status = "accepted"
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	// Условное обновление защищает от повторного ответа на одно приглашение
+	result, err := tx.Exec(ConvertPlaceholders(`
+		UPDATE organization_invitations
+		SET status = ?, user_id = ?, responded_by = ?, responded_at = NOW()
+		WHERE id = ? AND status = 'pending' AND expires_at > NOW()
+	`), status, memberID, userID, invitationID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to update invitation: "+err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		sendJSONError(w, http.StatusConflict, "Invitation is no longer pending")
+		return
+	}
+
+	if accept {
+		result, err = tx.Exec(ConvertPlaceholders(`
+			INSERT INTO organization_members (organization_id, user_id, role, position, can_post, can_edit, can_manage_members)
+			SELECT ?, ?, ?, ?, ?, ?, ?
+			WHERE NOT EXISTS (SELECT 1 FROM organization_members WHERE organization_id = ? AND user_id = ?)
+		`), invitation.OrganizationID, memberID, invitation.Role, invitation.Position,
+			invitation.CanPost, invitation.CanEdit, invitation.CanManageMembers,
+			invitation.OrganizationID, memberID)
+		if err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Failed to add member: "+err.Error())
+			return
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			sendJSONError(w, http.StatusBadRequest, "User is already a member")
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to save response: "+err.Error())
+		return
+	}
+
+	if accept {
+		syncOrganizationStaffChat(invitation.OrganizationID)
+	}
+
+	// Сообщаем второй стороне о решении
+	notifHandler := &NotificationsHandler{DB: db.DB}
+	actorName := getUserFullName(userID)
+	if invitation.Kind == "invite" && invitation.InvitedBy != nil {
+		notifType := "organization_invite_declined"
+		message := fmt.Sprintf("%s отклонил приглашение в организацию «%s»", actorName, invitation.OrganizationName)
+		if accept {
+			notifType = "organization_invite_accepted"
+			message = fmt.Sprintf("%s принял приглашение в организацию «%s»", actorName, invitation.OrganizationName)
+		}
+		if err := notifHandler.CreateNotification(*invitation.InvitedBy, userID, notifType, "organization_invitation", invitationID, message); err != nil {
+			log.Printf("⚠️ Failed to notify inviter about invitation %d: %v", invitationID, err)
+		}
+	} else if invitation.Kind == "request" {
+		notifType := "organization_join_declined"
+		message := fmt.Sprintf("Ваша заявка на вступление в организацию «%s» отклонена", invitation.OrganizationName)
+		if accept {
+			notifType = "organization_join_approved"
+			message = fmt.Sprintf("Ваша заявка на вступление в организацию «%s» одобрена", invitation.OrganizationName)
+		}
+		if err := notifHandler.CreateNotification(memberID, userID, notifType, "organization", invitation.OrganizationID, message); err != nil {
+			log.Printf("⚠️ Failed to notify user %d about join request %d: %v", memberID, invitationID, err)
+		}
+	}
+
+	updated, err := getOrganizationInvitation(invitationID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load invitation: "+err.Error())
+		return
+	}
+
+	sendJSONSuccess(w, updated)
+}
+
+// cancelOrganizationInvitation - приглашение отзывает управляющий участниками, заявку - её автор
+func cancelOrganizationInvitation(w http.ResponseWriter, invitationID, userID int) {
+	invitation, err := getOrganizationInvitation(invitationID)
+	if err == sql.ErrNoRows {
+		sendJSONError(w, http.StatusNotFound, "Invitation not found")
+		return
+	}
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+
+	allowed := false
+	if invitation.Kind == "invite" {
+		allowed = canManageOrganizationMembers(invitation.OrganizationID, userID)
+	} else {
+		allowed = invitation.UserID != nil && *invitation.UserID == userID
+	}
+	if !allowed {
+		sendJSONError(w, http.StatusForbidden, "You can't cancel this invitation")
+		return
+	}
+
+	result, err := db.DB.Exec(ConvertPlaceholders(`
+		UPDATE organization_invitations
+		SET status = 'cancelled', responded_by = ?, responded_at = NOW()
+		WHERE id = ? AND status = 'pending'
+	`), userID, invitationID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to cancel invitation: "+err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		sendJSONError(w, http.StatusConflict, "Invitation is already "+invitation.Status)
+		return
+	}
+
+	sendJSONSuccess(w, map[string]interface{}{"message": "Invitation cancelled"})
+}
+
+// expireOrganizationInvitations помечает просроченные приглашения и заявки
+func expireOrganizationInvitations() {
+	_, err := db.DB.Exec(`
+		UPDATE organization_invitations SET status = 'expired'
+		WHERE status = 'pending' AND expires_at <= NOW()
+	`)
+	if err != nil {
+		log.Printf("⚠️ Failed to expire organization invitations: %v", err)
+	}
+}
+
+func getOrganizationInvitation(invitationID int) (*models.OrganizationInvitation, error) {
+	invitations, err := queryOrganizationInvitations("SELECT "+organizationInvitationColumns+organizationInvitationJoins+" WHERE i.id = ?", invitationID)
+	if err != nil {
+		return nil, err
+	}
+	if len(invitations) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &invitations[0], nil
+}
+
+func queryOrganizationInvitations(query string, args ...interface{}) ([]models.OrganizationInvitation, error) {
+	rows, err := db.DB.Query(ConvertPlaceholders(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := []models.OrganizationInvitation{}
+	for rows.Next() {
+		var inv models.OrganizationInvitation
+		var userID, invitedBy, respondedBy sql.NullInt64
+		var email, position, message sql.NullString
+		var respondedAt sql.NullTime
+		err := rows.Scan(
+			&inv.ID, &inv.OrganizationID, &inv.Kind, &userID, &email, &inv.Role, &position,
+			&inv.CanPost, &inv.CanEdit, &inv.CanManageMembers, &message, &inv.Status,
+			&invitedBy, &respondedBy, &inv.ExpiresAt, &respondedAt, &inv.CreatedAt,
+			&inv.OrganizationName, &inv.UserName,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if userID.Valid {
+			id := int(userID.Int64)
+			inv.UserID = &id
+		}
+		if invitedBy.Valid {
+			id := int(invitedBy.Int64)
+			inv.InvitedBy = &id
+		}
+		if respondedBy.Valid {
+			id := int(respondedBy.Int64)
+			inv.RespondedBy = &id
+		}
+		if email.Valid {
+			inv.Email = &email.String
+		}
+		if position.Valid {
+			inv.Position = &position.String
+		}
+		if message.Valid {
+			inv.Message = &message.String
+		}
+		if respondedAt.Valid {
+			inv.RespondedAt = &respondedAt.Time
+		}
+		inv.UserName = strings.TrimSpace(inv.UserName)
+		invitations = append(invitations, inv)
+	}
+
+	return invitations, rows.Err()
+}
+
+// isOrganizationInvitee - приглашение адресовано пользователю напрямую или по его email
+func isOrganizationInvitee(invitation *models.OrganizationInvitation, userID int) bool {
+	if invitation.UserID != nil {
+		return *invitation.UserID == userID
+	}
+	if invitation.Email == nil {
+		return false
+	}
+
+	var email string
+	err := db.DB.QueryRow(ConvertPlaceholders("SELECT email FROM users WHERE id = ?"), userID).Scan(&email)
+	return err == nil && strings.EqualFold(email, *invitation.Email)
+}
+
+func isOrganizationMember(orgID, userID int) bool {
+	var exists bool
+	err := db.DB.QueryRow(ConvertPlaceholders(`
+		SELECT EXISTS(SELECT 1 FROM organization_members WHERE organization_id = ? AND user_id = ?)
+	`), orgID, userID).Scan(&exists)
+	return err == nil && exists
+}
+
+func canManageOrganizationMembers(orgID, userID int) bool {
+	var canManage bool
+	err := db.DB.QueryRow(ConvertPlaceholders(`
+		SELECT can_manage_members FROM organization_members
+		WHERE organization_id = ? AND user_id = ?
+	`), orgID, userID).Scan(&canManage)
+	return err == nil && canManage
+}
+
+// getOrganizationMemberManagers возвращает участников, которые могут управлять составом
+func getOrganizationMemberManagers(orgID int) []int {
+	rows, err := db.DB.Query(ConvertPlaceholders(`
+		SELECT user_id FROM organization_members
+		WHERE organization_id = ? AND can_manage_members = TRUE
+	`), orgID)
+	if err != nil {
+		log.Printf("⚠️ Failed to load managers of organization %d: %v", orgID, err)
+		return nil
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// getUserFullName возвращает "Имя Фамилия" для текстов уведомлений
+func getUserFullName(userID int) string {
+	var name string
+	var lastName sql.NullString
+	err := db.DB.QueryRow(ConvertPlaceholders("SELECT name, last_name FROM users WHERE id = ?"), userID).Scan(&name, &lastName)
+	if err != nil {
+		return "Пользователь"
+	}
+	if lastName.Valid && lastName.String != "" {
+		name += " " + lastName.String
+	}
+	return name
+}
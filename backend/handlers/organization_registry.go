@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"backend/db"
+	"backend/registry"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Организаций, сверяемых с реестром за один проход (чтобы не упереться в лимиты API)
+const registryRefreshBatchSize = 50
+
+// Кэш подсказок для формы (?prefill=1): запросы к реестру платные, повторный ИНН берётся из памяти.
+// Ошибки реестра не кэшируются.
+const (
+	registryPrefillTTL         = 24 * time.Hour
+	registryPrefillNotFoundTTL = time.Hour
+)
+
+type registryPrefillEntry struct {
+	info      *registry.CompanyInfo // nil - организации нет в реестре
+	expiresAt time.Time
+}
+
+var (
+	registryPrefillMu    sync.Mutex
+	registryPrefillCache = make(map[string]registryPrefillEntry)
+)
+
+// lookupCompanyForPrefill возвращает данные из реестра для заполнения формы (nil, если их нет)
+func lookupCompanyForPrefill(inn string) *registry.CompanyInfo {
+	if registry.Global == nil {
+		return nil
+	}
+
+	now := time.Now()
+	registryPrefillMu.Lock()
+	entry, ok := registryPrefillCache[inn]
+	registryPrefillMu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.info
+	}
+
+	info, err := registry.Global.FindByINN(inn)
+	if err != nil && err != registry.ErrNotFound {
+		log.Printf("⚠️ Company registry lookup failed for INN %s: %v", inn, err)
+		return nil
+	}
+
+	entry = registryPrefillEntry{info: info, expiresAt: now.Add(registryPrefillTTL)}
+	if err == registry.ErrNotFound {
+		entry = registryPrefillEntry{expiresAt: now.Add(registryPrefillNotFoundTTL)}
+	}
+
+	registryPrefillMu.Lock()
+	// Просроченные записи вычищаются при записи, чтобы кэш не рос бесконечно
+	for key, e := range registryPrefillCache {
+		if now.After(e.expiresAt) {
+			delete(registryPrefillCache, key)
+		}
+	}
+	registryPrefillCache[inn] = entry
+	registryPrefillMu.Unlock()
+
+	return entry.info
+}
+
+// StartOrganizationRegistryRefresh периодически сверяет организации с реестром
+// и помечает те, что были ликвидированы. Каждая организация проверяется не чаще раза в maxAge.
+func StartOrganizationRegistryRefresh(interval, maxAge time.Duration) {
+	if registry.Global == nil {
+		log.Println("⚠️  Company registry is not configured, organization refresh is disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			refreshOrganizationsFromRegistry(maxAge)
+			<-ticker.C
+		}
+	}()
+}
+
+func refreshOrganizationsFromRegistry(maxAge time.Duration) {
+	rows, err := db.DB.Query(ConvertPlaceholders(`
+		SELECT id, name, inn, COALESCE(state_status, '')
+		FROM organizations
+		WHERE inn IS NOT NULL AND inn <> ''
+		AND (registry_checked_at IS NULL OR registry_checked_at < ?)
+		ORDER BY registry_checked_at NULLS FIRST
+		LIMIT ?
+	`), time.Now().Add(-maxAge), registryRefreshBatchSize)
+	if err != nil {
+		log.Printf("❌ Registry refresh: failed to load organizations: %v", err)
+		return
+	}
+
+	type staleOrganization struct {
+		id          int
+		name        string
+		inn         string
+		stateStatus string
+	}
+	var organizations []staleOrganization
+	for rows.Next() {
+		var o staleOrganization
+		if err := rows.Scan(&o.id, &o.name, &o.inn, &o.stateStatus); err == nil {
+			organizations = append(organizations, o)
+		}
+	}
+	rows.Close()
+
+	for _, o := range organizations {
+		info, err := registry.Global.FindByINN(o.inn)
+		if err == registry.ErrNotFound {
+			// Отмечаем проверку, чтобы не опрашивать реестр по этому ИНН на каждом проходе
+			db.DB.Exec(ConvertPlaceholders("UPDATE organizations SET registry_checked_at = NOW() WHERE id = ?"), o.id)
+			continue
+		}
+		if err != nil {
+			// Реестр недоступен - попробуем на следующем проходе
+			log.Printf("⚠️ Registry refresh: failed to check organization %d: %v", o.id, err)
+			return
+		}
+
+		if err := applyRegistryStatus(o.id, o.name, o.stateStatus, info); err != nil {
+			log.Printf("❌ Registry refresh: failed to update organization %d: %v", o.id, err)
+		}
+	}
+}
+
+// applyRegistryStatus сохраняет статус из реестра и помечает организацию при переходе в LIQUIDATED
+func applyRegistryStatus(orgID int, orgName, oldStatus string, info *registry.CompanyInfo) error {
+	var liquidationDate interface{}
+	if info.StateLiquidationDate != "" {
+		liquidationDate = info.StateLiquidationDate
+	}
+
+	_, err := db.DB.Exec(ConvertPlaceholders(`
+		UPDATE organizations SET
+			state_status = ?,
+			state_liquidation_date = ?,
+			registry_checked_at = NOW(),
+			liquidation_flagged_at = CASE
+				WHEN ? = 'LIQUIDATED' THEN COALESCE(liquidation_flagged_at, NOW())
+				ELSE NULL
+			END
+		WHERE id = ?
+	`), info.StateStatus, liquidationDate, info.StateStatus, orgID)
+	if err != nil {
+		return err
+	}
+
+	if info.StateStatus != registry.StatusLiquidated || oldStatus == registry.StatusLiquidated {
+		return nil
+	}
+
+	log.Printf("⚠️ Organization %d (%s) is liquidated according to the registry", orgID, orgName)
+
+	// Сообщаем управляющим участникам организации
+	message := fmt.Sprintf("По данным ЕГРЮЛ организация «%s» ликвидирована. Проверьте данные организации", orgName)
+	notifHandler := &NotificationsHandler{DB: db.DB}
+	for _, managerID := range getOrganizationMemberManagers(orgID) {
+		if err := notifHandler.CreateNotification(managerID, 0, "organization_liquidated", "organization", orgID, message); err != nil {
+			log.Printf("⚠️ Failed to notify user %d about liquidation of organization %d: %v", managerID, orgID, err)
+		}
+	}
+
+	return nil
+}
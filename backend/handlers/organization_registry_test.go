@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"backend/registry"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type failingRegistry struct {
+	calls int
+}
+
+func (f *failingRegistry) FindByINN(inn string) (*registry.CompanyInfo, error) {
+	f.calls++
+	return nil, errors.New("dadata: 503 Service Unavailable")
+}
+
+type checkInnResponse struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error"`
+	Data    struct {
+		Organization map[string]interface{} `json:"organization"`
+		Prefill      *registry.CompanyInfo  `json:"prefill"`
+	} `json:"data"`
+}
+
+func checkInnPrefill(t *testing.T, inn string, userID int) (int, checkInnResponse) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/organizations/check-inn/"+inn+"?prefill=1", nil)
+	if userID != 0 {
+		req = req.WithContext(context.WithValue(req.Context(), "userID", userID))
+	}
+	rec := httptest.NewRecorder()
+	CheckOrganizationByInnHandler(rec, req)
+
+	var resp checkInnResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response %q: %v", rec.Body.String(), err)
+	}
+	return rec.Code, resp
+}
+
+func withRegistry(t *testing.T, r registry.CompanyRegistry) {
+	t.Helper()
+	prev := registry.Global
+	registry.Global = r
+	registryPrefillMu.Lock()
+	registryPrefillCache = make(map[string]registryPrefillEntry)
+	registryPrefillMu.Unlock()
+	t.Cleanup(func() { registry.Global = prev })
+}
+
+func TestCheckOrganizationByInnPrefill(t *testing.T) {
+	const inn = "7707083893"
+
+	t.Run("found", func(t *testing.T) {
+		useFakeDB(t, emptyDB)
+		fake := registry.NewFakeRegistry(registry.CompanyInfo{Name: "ООО Хвосты", INN: inn, OGRN: "1027700132195"})
+		withRegistry(t, fake)
+
+		code, resp := checkInnPrefill(t, inn, 1)
+		if code != http.StatusOK || !resp.Success {
+			t.Fatalf("status %d, success %v, error %q", code, resp.Success, resp.Error)
+		}
+		if resp.Data.Prefill == nil || resp.Data.Prefill.Name != "ООО Хвосты" {
+			t.Fatalf("prefill = %+v, want company from registry", resp.Data.Prefill)
+		}
+		if resp.Data.Organization != nil {
+			t.Fatalf("organization = %v, want nil", resp.Data.Organization)
+		}
+
+		// Повторный запрос берётся из кэша
+		checkInnPrefill(t, inn, 2)
+		if fake.Calls() != 1 {
+			t.Fatalf("registry calls = %d, want 1", fake.Calls())
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		useFakeDB(t, emptyDB)
+		fake := registry.NewFakeRegistry()
+		withRegistry(t, fake)
+
+		code, resp := checkInnPrefill(t, inn, 1)
+		if code != http.StatusOK || !resp.Success {
+			t.Fatalf("status %d, success %v, error %q", code, resp.Success, resp.Error)
+		}
+		if resp.Data.Prefill != nil {
+			t.Fatalf("prefill = %+v, want nil", resp.Data.Prefill)
+		}
+
+		// Отсутствие в реестре тоже кэшируется
+		checkInnPrefill(t, inn, 1)
+		if fake.Calls() != 1 {
+			t.Fatalf("registry calls = %d, want 1", fake.Calls())
+		}
+	})
+
+	t.Run("registry error", func(t *testing.T) {
+		useFakeDB(t, emptyDB)
+		failing := &failingRegistry{}
+		withRegistry(t, failing)
+
+		code, resp := checkInnPrefill(t, inn, 1)
+		if code != http.StatusOK || !resp.Success {
+			t.Fatalf("status %d, success %v, error %q", code, resp.Success, resp.Error)
+		}
+		if resp.Data.Prefill != nil {
+			t.Fatalf("prefill = %+v, want nil", resp.Data.Prefill)
+		}
+
+		// Ошибки не кэшируются: следующий запрос снова идёт в реестр
+		checkInnPrefill(t, inn, 1)
+		if failing.calls != 2 {
+			t.Fatalf("registry calls = %d, want 2", failing.calls)
+		}
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		useFakeDB(t, emptyDB)
+		fake := registry.NewFakeRegistry(registry.CompanyInfo{Name: "ООО Хвосты", INN: inn})
+		withRegistry(t, fake)
+
+		code, _ := checkInnPrefill(t, inn, 0)
+		if code != http.StatusUnauthorized {
+			t.Fatalf("status %d, want 401", code)
+		}
+		if fake.Calls() != 0 {
+			t.Fatalf("registry calls = %d, want 0", fake.Calls())
+		}
+	})
+}
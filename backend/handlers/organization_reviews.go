@@ -0,0 +1,506 @@
+package handlers
+
+import (
+	"backend/db"
+	"backend/models"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxReviewTextLength - ограничение длины отзыва и ответа организации (в символах)
+const maxReviewTextLength = 5000
+
+// OrganizationReviewsHandler - отзывы об организации
+// GET /api/organizations/{id}/reviews?limit=20&offset=0 - список отзывов и сводный рейтинг
+// POST /api/organizations/{id}/reviews - оставить отзыв
+// PUT|DELETE /api/organizations/{id}/reviews/{reviewId} - изменить/удалить свой отзыв (модератор - удалить любой)
+// PUT|DELETE /api/organizations/{id}/reviews/{reviewId}/reply - ответ организации
+// POST /api/organizations/{id}/reviews/{reviewId}/hide|unhide - модерация (по итогам жалоб)
+func OrganizationReviewsHandler(w http.ResponseWriter, r *http.Request, orgID int, rest []string) {
+	if len(rest) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			listOrganizationReviews(w, r, orgID)
+		case http.MethodPost:
+			createOrganizationReview(w, r, orgID)
+		default:
+			sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+		return
+	}
+
+	userID, ok := RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	reviewID, err := strconv.Atoi(rest[0])
+	if err != nil {
+		sendJSONError(w, http.StatusBadRequest, "Invalid review ID")
+		return
+	}
+	review, err := getOrganizationReview(reviewID)
+	if err == sql.ErrNoRows || (err == nil && review.OrganizationID != orgID) {
+		sendJSONError(w, http.StatusNotFound, "Review not found")
+		return
+	}
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+
+	action := ""
+	if len(rest) > 1 {
+		action = rest[1]
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodPut:
+		updateOrganizationReview(w, r, review, userID)
+	case action == "" && r.Method == http.MethodDelete:
+		deleteOrganizationReview(w, r, review, userID)
+	case action == "reply" && (r.Method == http.MethodPut || r.Method == http.MethodDelete):
+		replyToOrganizationReview(w, r, review, userID)
+	case (action == "hide" || action == "unhide") && r.Method == http.MethodPost:
+		moderateOrganizationReview(w, r, review, userID, action == "hide")
+	default:
+		sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func listOrganizationReviews(w http.ResponseWriter, r *http.Request, orgID int) {
+	userID, _ := GetUserIDFromGateway(r)
+
+	limit, offset := 20, 0
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 && v <= 100 {
+		limit = v
+	}
+	if v, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && v > 0 {
+		offset = v
+	}
+
+	summary, err := getOrganizationRatingSummary(orgID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+
+	// Скрытые отзывы видят только модераторы
+	query := organizationReviewSelect + " WHERE rv.organization_id = ?"
+	if userID == 0 || !hasModeratorRights(db.DB, userID) {
+		query += " AND rv.status = 'published'"
+	}
+	query += " ORDER BY rv.created_at DESC LIMIT ? OFFSET ?"
+
+	reviews, err := queryOrganizationReviews(query, orgID, limit, offset)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+
+	response := map[string]interface{}{
+		"summary": summary,
+		"reviews": reviews,
+	}
+
+	// Собственный отзыв - чтобы клиент показал "Изменить отзыв" вместо "Оставить отзыв"
+	if userID != 0 {
+		myReviews, err := queryOrganizationReviews(organizationReviewSelect+" WHERE rv.organization_id = ? AND rv.user_id = ?", orgID, userID)
+		if err == nil && len(myReviews) > 0 {
+			response["my_review"] = myReviews[0]
+		}
+	}
+
+	sendJSONSuccess(w, response)
+}
+
+func createOrganizationReview(w http.ResponseWriter, r *http.Request, orgID int) {
+	userID, ok := RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	var orgName string
+	err := db.DB.QueryRow(ConvertPlaceholders("SELECT name FROM organizations WHERE id = ?"), orgID).Scan(&orgName)
+	if err == sql.ErrNoRows {
+		sendJSONError(w, http.StatusNotFound, "Organization not found")
+		return
+	}
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+
+	// Сотрудники не оценивают собственную организацию
+	if isOrganizationMember(orgID, userID) {
+		sendJSONError(w, http.StatusForbidden, "Members can't review their own organization")
+		return
+	}
+
+	var req models.OrganizationReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+	if msg := validateOrganizationReview(&req); msg != "" {
+		sendJSONError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	// Один отзыв на пользователя - повторная попытка должна редактировать существующий
+	var exists bool
+	err = tx.QueryRow(ConvertPlaceholders(`
+		SELECT EXISTS(SELECT 1 FROM organization_reviews WHERE organization_id = ? AND user_id = ?)
+	`), orgID, userID).Scan(&exists)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	if exists {
+		sendJSONError(w, http.StatusConflict, "You have already reviewed this organization")
+		return
+	}
+
+	var reviewID int64
+	err = tx.QueryRow(ConvertPlaceholders(`
+		INSERT INTO organization_reviews (organization_id, user_id, rating, text)
+		VALUES (?, ?, ?, ?)
+		RETURNING id
+	`), orgID, userID, req.Rating, nullIfEmpty(req.Text)).Scan(&reviewID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to create review: "+err.Error())
+		return
+	}
+
+	if err := refreshOrganizationRating(tx, orgID); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to update rating: "+err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to create review: "+err.Error())
+		return
+	}
+
+	log.Printf("✅ Review %d created: organization %d, user %d, rating %d", reviewID, orgID, userID, req.Rating)
+
+	message := fmt.Sprintf("%s оставил(а) отзыв об организации «%s»: %d из 5", getUserFullName(userID), orgName, req.Rating)
+	notifHandler := &NotificationsHandler{DB: db.DB}
+	for _, managerID := range getOrganizationMemberManagers(orgID) {
+		if err := notifHandler.CreateNotification(managerID, userID, "organization_review", "organization_review", int(reviewID), message); err != nil {
+			log.Printf("⚠️ Failed to notify user %d about review %d: %v", managerID, reviewID, err)
+		}
+	}
+
+	review, err := getOrganizationReview(int(reviewID))
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load review: "+err.Error())
+		return
+	}
+	sendJSONSuccess(w, review)
+}
+
+func updateOrganizationReview(w http.ResponseWriter, r *http.Request, review *models.OrganizationReview, userID int) {
+	if review.UserID != userID {
+		sendJSONError(w, http.StatusForbidden, "You can only edit your own review")
+		return
+	}
+
+	var req models.OrganizationReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+	if msg := validateOrganizationReview(&req); msg != "" {
+		sendJSONError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(ConvertPlaceholders(`
+		UPDATE organization_reviews SET rating = ?, text = ?, updated_at = NOW() WHERE id = ?
+	`), req.Rating, nullIfEmpty(req.Text), review.ID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to update review: "+err.Error())
+		return
+	}
+
+	if err := refreshOrganizationRating(tx, review.OrganizationID); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to update rating: "+err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to update review: "+err.Error())
+		return
+	}
+
+	updated, err := getOrganizationReview(review.ID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load review: "+err.Error())
+		return
+	}
+	sendJSONSuccess(w, updated)
+}
+
+func deleteOrganizationReview(w http.ResponseWriter, r *http.Request, review *models.OrganizationReview, userID int) {
+	if review.UserID != userID && !hasModeratorRights(db.DB, userID) {
+		sendJSONError(w, http.StatusForbidden, "You can only delete your own review")
+		return
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(ConvertPlaceholders("DELETE FROM organization_reviews WHERE id = ?"), review.ID); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to delete review: "+err.Error())
+		return
+	}
+
+	if err := refreshOrganizationRating(tx, review.OrganizationID); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to update rating: "+err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to delete review: "+err.Error())
+		return
+	}
+
+	sendJSONSuccess(w, map[string]interface{}{"message": "Review deleted"})
+}
+
+// replyToOrganizationReview - публичный ответ организации (PUT - создать/изменить, DELETE - удалить)
+func replyToOrganizationReview(w http.ResponseWriter, r *http.Request, review *models.OrganizationReview, userID int) {
+	if !canEditOrganization(review.OrganizationID, userID) {
+		sendJSONError(w, http.StatusForbidden, "You don't have permission to reply on behalf of the organization")
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		_, err := db.DB.Exec(ConvertPlaceholders(`
+			UPDATE organization_reviews SET reply = NULL, reply_author_id = NULL, replied_at = NULL WHERE id = ?
+		`), review.ID)
+		if err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Failed to delete reply: "+err.Error())
+			return
+		}
+		sendJSONSuccess(w, map[string]interface{}{"message": "Reply deleted"})
+		return
+	}
+
+	var req models.OrganizationReviewReplyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+	req.Text = strings.TrimSpace(req.Text)
+	if req.Text == "" {
+		sendJSONError(w, http.StatusBadRequest, "text is required")
+		return
+	}
+	if utf8.RuneCountInString(req.Text) > maxReviewTextLength {
+		sendJSONError(w, http.StatusBadRequest, fmt.Sprintf("text must not exceed %d characters", maxReviewTextLength))
+		return
+	}
+
+	firstReply := review.Reply == nil
+	_, err := db.DB.Exec(ConvertPlaceholders(`
+		UPDATE organization_reviews SET reply = ?, reply_author_id = ?, replied_at = NOW() WHERE id = ?
+	`), req.Text, userID, review.ID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to save reply: "+err.Error())
+		return
+	}
+
+	if firstReply {
+		var orgName string
+		db.DB.QueryRow(ConvertPlaceholders("SELECT name FROM organizations WHERE id = ?"), review.OrganizationID).Scan(&orgName)
+		notifHandler := &NotificationsHandler{DB: db.DB}
+		message := fmt.Sprintf("Организация «%s» ответила на ваш отзыв", orgName)
+		if err := notifHandler.CreateNotification(review.UserID, userID, "organization_review_reply", "organization_review", review.ID, message); err != nil {
+			log.Printf("⚠️ Failed to notify user %d about reply to review %d: %v", review.UserID, review.ID, err)
+		}
+	}
+
+	updated, err := getOrganizationReview(review.ID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load review: "+err.Error())
+		return
+	}
+	sendJSONSuccess(w, updated)
+}
+
+// moderateOrganizationReview скрывает или возвращает отзыв; скрытые отзывы не учитываются в рейтинге
+func moderateOrganizationReview(w http.ResponseWriter, r *http.Request, review *models.OrganizationReview, moderatorID int, hide bool) {
+	if !hasModeratorRights(db.DB, moderatorID) {
+		sendJSONError(w, http.StatusForbidden, "Moderator rights required")
+		return
+	}
+
+	status, action := "published", models.ActionUnhideReview
+	if hide {
+		status, action = "hidden", models.ActionHideReview
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(ConvertPlaceholders("UPDATE organization_reviews SET status = ? WHERE id = ?"), status, review.ID); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to update review: "+err.Error())
+		return
+	}
+
+	if err := refreshOrganizationRating(tx, review.OrganizationID); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to update rating: "+err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to update review: "+err.Error())
+		return
+	}
+
+	var adminEmail string
+	db.DB.QueryRow(ConvertPlaceholders("SELECT email FROM users WHERE id = ?"), moderatorID).Scan(&adminEmail)
+	CreateAdminLog(
+		moderatorID,
+		adminEmail,
+		action,
+		models.TargetReview,
+		review.ID,
+		review.UserName,
+		fmt.Sprintf("organization_id=%d, rating=%d", review.OrganizationID, review.Rating),
+		r.RemoteAddr,
+		r.Header.Get("User-Agent"),
+	)
+
+	updated, err := getOrganizationReview(review.ID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load review: "+err.Error())
+		return
+	}
+	sendJSONSuccess(w, updated)
+}
+
+func validateOrganizationReview(req *models.OrganizationReviewRequest) string {
+	if req.Rating < 1 || req.Rating > 5 {
+		return "rating must be from 1 to 5"
+	}
+	req.Text = strings.TrimSpace(req.Text)
+	if utf8.RuneCountInString(req.Text) > maxReviewTextLength {
+		return fmt.Sprintf("text must not exceed %d characters", maxReviewTextLength)
+	}
+	return ""
+}
+
+// refreshOrganizationRating пересчитывает кэш рейтинга организации по опубликованным отзывам
+func refreshOrganizationRating(tx *sql.Tx, orgID int) error {
+	_, err := tx.Exec(ConvertPlaceholders(`
+		UPDATE organizations SET
+			rating_average = COALESCE((SELECT ROUND(AVG(rating), 2) FROM organization_reviews WHERE organization_id = ? AND status = 'published'), 0),
+			reviews_count = (SELECT COUNT(*) FROM organization_reviews WHERE organization_id = ? AND status = 'published')
+		WHERE id = ?
+	`), orgID, orgID, orgID)
+	return err
+}
+
+func getOrganizationRatingSummary(orgID int) (*models.OrganizationRatingSummary, error) {
+	summary := &models.OrganizationRatingSummary{Distribution: map[int]int{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}}
+
+	err := db.DB.QueryRow(ConvertPlaceholders(`
+		SELECT COALESCE(rating_average, 0), COALESCE(reviews_count, 0) FROM organizations WHERE id = ?
+	`), orgID).Scan(&summary.Average, &summary.Count)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.DB.Query(ConvertPlaceholders(`
+		SELECT rating, COUNT(*) FROM organization_reviews
+		WHERE organization_id = ? AND status = 'published'
+		GROUP BY rating
+	`), orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rating, count int
+		if err := rows.Scan(&rating, &count); err != nil {
+			return nil, err
+		}
+		summary.Distribution[rating] = count
+	}
+	return summary, rows.Err()
+}
+
+const organizationReviewSelect = `
+	SELECT rv.id, rv.organization_id, rv.user_id, rv.rating, rv.text, rv.status,
+	       rv.reply, rv.reply_author_id, rv.replied_at, rv.created_at, rv.updated_at,
+	       u.name || ' ' || COALESCE(u.last_name, ''), u.avatar
+	FROM organization_reviews rv
+	JOIN users u ON rv.user_id = u.id
+`
+
+func getOrganizationReview(reviewID int) (*models.OrganizationReview, error) {
+	reviews, err := queryOrganizationReviews(organizationReviewSelect+" WHERE rv.id = ?", reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if len(reviews) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &reviews[0], nil
+}
+
+func queryOrganizationReviews(query string, args ...interface{}) ([]models.OrganizationReview, error) {
+	rows, err := db.DB.Query(ConvertPlaceholders(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews := []models.OrganizationReview{}
+	for rows.Next() {
+		var rv models.OrganizationReview
+		err := rows.Scan(
+			&rv.ID, &rv.OrganizationID, &rv.UserID, &rv.Rating, &rv.Text, &rv.Status,
+			&rv.Reply, &rv.ReplyAuthorID, &rv.RepliedAt, &rv.CreatedAt, &rv.UpdatedAt,
+			&rv.UserName, &rv.UserAvatar,
+		)
+		if err != nil {
+			return nil, err
+		}
+		rv.UserName = strings.TrimSpace(rv.UserName)
+		reviews = append(reviews, rv)
+	}
+	return reviews, rows.Err()
+}
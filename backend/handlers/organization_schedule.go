@@ -0,0 +1,588 @@
+package handlers
+
+import (
+	"backend/db"
+	"backend/models"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // Часовые пояса встроены в бинарник (в alpine-образе нет tzdata)
+)
+
+// defaultOrganizationTimezone - часовой пояс организаций по умолчанию
+const defaultOrganizationTimezone = "Europe/Moscow"
+
+// OrganizationHoursHandler - режим работы организации
+// GET /api/organizations/{id}/hours
+// PUT /api/organizations/{id}/hours - полная замена расписания (участники с can_edit)
+func OrganizationHoursHandler(w http.ResponseWriter, r *http.Request, orgID int) {
+	switch r.Method {
+	case http.MethodGet:
+		hours, err := loadOpeningHours(orgID, time.Now())
+		if err == sql.ErrNoRows {
+			sendJSONError(w, http.StatusNotFound, "Organization not found")
+			return
+		}
+		if err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Failed to load opening hours: "+err.Error())
+			return
+		}
+		sendJSONSuccess(w, hours)
+	case http.MethodPut:
+		updateOpeningHours(w, r, orgID)
+	default:
+		sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func updateOpeningHours(w http.ResponseWriter, r *http.Request, orgID int) {
+	userID, ok := RequireAuth(w, r)
+	if !ok {
+		return
+	}
+	if !canEditOrganization(orgID, userID) {
+		sendJSONError(w, http.StatusForbidden, "You don't have permission to edit this organization")
+		return
+	}
+
+	var req models.UpdateOpeningHoursRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	if req.Timezone != nil {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil || *req.Timezone == "" {
+			sendJSONError(w, http.StatusBadRequest, "Unknown timezone: "+*req.Timezone)
+			return
+		}
+	}
+
+	for i, interval := range req.Weekly {
+		if interval.Weekday < 1 || interval.Weekday > 7 {
+			sendJSONError(w, http.StatusBadRequest, fmt.Sprintf("weekly[%d]: weekday must be from 1 (Monday) to 7 (Sunday)", i))
+			return
+		}
+		if err := validateClockRange(interval.OpensAt, interval.ClosesAt); err != nil {
+			sendJSONError(w, http.StatusBadRequest, fmt.Sprintf("weekly[%d]: %v", i, err))
+			return
+		}
+	}
+
+	seenDates := make(map[string]bool)
+	for i, exception := range req.Exceptions {
+		if _, err := time.Parse("2006-01-02", exception.Date); err != nil {
+			sendJSONError(w, http.StatusBadRequest, fmt.Sprintf("exceptions[%d]: date must be in YYYY-MM-DD format", i))
+			return
+		}
+		if seenDates[exception.Date] {
+			sendJSONError(w, http.StatusBadRequest, fmt.Sprintf("exceptions[%d]: duplicate date %s", i, exception.Date))
+			return
+		}
+		seenDates[exception.Date] = true
+
+		if !exception.IsClosed {
+			if exception.OpensAt == nil || exception.ClosesAt == nil {
+				sendJSONError(w, http.StatusBadRequest, fmt.Sprintf("exceptions[%d]: opens_at and closes_at are required unless is_closed", i))
+				return
+			}
+			if err := validateClockRange(*exception.OpensAt, *exception.ClosesAt); err != nil {
+				sendJSONError(w, http.StatusBadRequest, fmt.Sprintf("exceptions[%d]: %v", i, err))
+				return
+			}
+		}
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	if req.Timezone != nil {
+		_, err = tx.Exec(ConvertPlaceholders("UPDATE organizations SET timezone = ?, updated_at = NOW() WHERE id = ?"), *req.Timezone, orgID)
+		if err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Failed to update timezone: "+err.Error())
+			return
+		}
+	}
+
+	if _, err := tx.Exec(ConvertPlaceholders("DELETE FROM organization_opening_hours WHERE organization_id = ?"), orgID); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to update opening hours: "+err.Error())
+		return
+	}
+	for _, interval := range req.Weekly {
+		_, err := tx.Exec(ConvertPlaceholders(`
+			INSERT INTO organization_opening_hours (organization_id, weekday, opens_at, closes_at) VALUES (?, ?, ?, ?)
+		`), orgID, interval.Weekday, interval.OpensAt, interval.ClosesAt)
+		if err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Failed to update opening hours: "+err.Error())
+			return
+		}
+	}
+
+	if _, err := tx.Exec(ConvertPlaceholders("DELETE FROM organization_hours_exceptions WHERE organization_id = ?"), orgID); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to update exceptions: "+err.Error())
+		return
+	}
+	for _, exception := range req.Exceptions {
+		var opensAt, closesAt interface{}
+		if !exception.IsClosed {
+			opensAt, closesAt = *exception.OpensAt, *exception.ClosesAt
+		}
+		_, err := tx.Exec(ConvertPlaceholders(`
+			INSERT INTO organization_hours_exceptions (organization_id, date, is_closed, opens_at, closes_at, note)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`), orgID, exception.Date, exception.IsClosed, opensAt, closesAt, exception.Note)
+		if err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Failed to update exceptions: "+err.Error())
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to save opening hours: "+err.Error())
+		return
+	}
+
+	hours, err := loadOpeningHours(orgID, time.Now())
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load opening hours: "+err.Error())
+		return
+	}
+	sendJSONSuccess(w, hours)
+}
+
+// OrganizationServicesHandler - услуги и прайс-лист организации
+// GET /api/organizations/{id}/services[?all=1 - включая скрытые, для редакторов]
+// POST /api/organizations/{id}/services
+// PUT/DELETE /api/organizations/{id}/services/{serviceId}
+func OrganizationServicesHandler(w http.ResponseWriter, r *http.Request, orgID int, rest []string) {
+	if len(rest) == 0 && r.Method == http.MethodGet {
+		userID, _ := GetUserIDFromGateway(r)
+		includeInactive := r.URL.Query().Get("all") == "1" && userID != 0 && canEditOrganization(orgID, userID)
+
+		services, err := loadOrganizationServices(orgID, includeInactive)
+		if err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Failed to load services: "+err.Error())
+			return
+		}
+		sendJSONSuccess(w, services)
+		return
+	}
+
+	userID, ok := RequireAuth(w, r)
+	if !ok {
+		return
+	}
+	if !canEditOrganization(orgID, userID) {
+		sendJSONError(w, http.StatusForbidden, "You don't have permission to edit this organization")
+		return
+	}
+
+	if len(rest) == 0 {
+		if r.Method != http.MethodPost {
+			sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		createOrganizationService(w, r, orgID)
+		return
+	}
+
+	serviceID, err := strconv.Atoi(rest[0])
+	if err != nil {
+		sendJSONError(w, http.StatusBadRequest, "Invalid service ID")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		updateOrganizationService(w, r, orgID, serviceID)
+	case http.MethodDelete:
+		result, err := db.DB.Exec(ConvertPlaceholders(`
+			DELETE FROM organization_services WHERE id = ? AND organization_id = ?
+		`), serviceID, orgID)
+		if err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Failed to delete service: "+err.Error())
+			return
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			sendJSONError(w, http.StatusNotFound, "Service not found")
+			return
+		}
+		sendJSONSuccess(w, map[string]interface{}{"message": "Service deleted"})
+	default:
+		sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func createOrganizationService(w http.ResponseWriter, r *http.Request, orgID int) {
+	var req models.OrganizationServiceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+	if req.Name == nil || strings.TrimSpace(*req.Name) == "" {
+		sendJSONError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	if err := validateServicePrices(req.PriceFrom, req.PriceTo, req.DurationMinutes); err != nil {
+		sendJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+	sortOrder := 0
+	if req.SortOrder != nil {
+		sortOrder = *req.SortOrder
+	}
+
+	var serviceID int64
+	err := db.DB.QueryRow(ConvertPlaceholders(`
+		INSERT INTO organization_services
+			(organization_id, category, name, description, price_from, price_to, duration_minutes, is_active, sort_order)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`), orgID, req.Category, strings.TrimSpace(*req.Name), req.Description, req.PriceFrom, req.PriceTo,
+		req.DurationMinutes, isActive, sortOrder).Scan(&serviceID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to create service: "+err.Error())
+		return
+	}
+
+	service, err := getOrganizationService(orgID, int(serviceID))
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load service: "+err.Error())
+		return
+	}
+	sendJSONSuccess(w, service)
+}
+
+func updateOrganizationService(w http.ResponseWriter, r *http.Request, orgID, serviceID int) {
+	existing, err := getOrganizationService(orgID, serviceID)
+	if err == sql.ErrNoRows {
+		sendJSONError(w, http.StatusNotFound, "Service not found")
+		return
+	}
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+
+	var req models.OrganizationServiceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	// Проверяем цены с учётом уже сохранённых значений
+	priceFrom, priceTo, duration := existing.PriceFrom, existing.PriceTo, existing.DurationMinutes
+	if req.PriceFrom != nil {
+		priceFrom = req.PriceFrom
+	}
+	if req.PriceTo != nil {
+		priceTo = req.PriceTo
+	}
+	if req.DurationMinutes != nil {
+		duration = req.DurationMinutes
+	}
+	if err := validateServicePrices(priceFrom, priceTo, duration); err != nil {
+		sendJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Обновляем только переданные поля
+	query := `UPDATE organization_services SET updated_at = ?`
+	args := []interface{}{time.Now()}
+
+	if req.Name != nil {
+		if strings.TrimSpace(*req.Name) == "" {
+			sendJSONError(w, http.StatusBadRequest, "name can't be empty")
+			return
+		}
+		query += ", name = ?"
+		args = append(args, strings.TrimSpace(*req.Name))
+	}
+	if req.Category != nil {
+		query += ", category = ?"
+		args = append(args, nullIfEmpty(*req.Category))
+	}
+	if req.Description != nil {
+		query += ", description = ?"
+		args = append(args, nullIfEmpty(*req.Description))
+	}
+	if req.PriceFrom != nil {
+		query += ", price_from = ?"
+		args = append(args, *req.PriceFrom)
+	}
+	if req.PriceTo != nil {
+		query += ", price_to = ?"
+		args = append(args, *req.PriceTo)
+	}
+	if req.DurationMinutes != nil {
+		query += ", duration_minutes = ?"
+		args = append(args, *req.DurationMinutes)
+	}
+	if req.IsActive != nil {
+		query += ", is_active = ?"
+		args = append(args, *req.IsActive)
+	}
+	if req.SortOrder != nil {
+		query += ", sort_order = ?"
+		args = append(args, *req.SortOrder)
+	}
+
+	query += " WHERE id = ? AND organization_id = ?"
+	args = append(args, serviceID, orgID)
+
+	if _, err := db.DB.Exec(ConvertPlaceholders(query), args...); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to update service: "+err.Error())
+		return
+	}
+
+	service, err := getOrganizationService(orgID, serviceID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load service: "+err.Error())
+		return
+	}
+	sendJSONSuccess(w, service)
+}
+
+func validateServicePrices(priceFrom, priceTo, duration *int) error {
+	if priceFrom != nil && *priceFrom < 0 || priceTo != nil && *priceTo < 0 {
+		return fmt.Errorf("prices can't be negative")
+	}
+	if priceFrom != nil && priceTo != nil && *priceTo < *priceFrom {
+		return fmt.Errorf("price_to must not be less than price_from")
+	}
+	if duration != nil && *duration <= 0 {
+		return fmt.Errorf("duration_minutes must be positive")
+	}
+	return nil
+}
+
+const organizationServiceColumns = `
+	id, organization_id, category, name, description, price_from, price_to,
+	duration_minutes, is_active, sort_order, created_at, updated_at
+`
+
+func getOrganizationService(orgID, serviceID int) (*models.OrganizationService, error) {
+	services, err := queryOrganizationServices("SELECT "+organizationServiceColumns+
+		" FROM organization_services WHERE id = ? AND organization_id = ?", serviceID, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if len(services) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &services[0], nil
+}
+
+// loadOrganizationServices возвращает прайс-лист, сгруппированный по категориям
+func loadOrganizationServices(orgID int, includeInactive bool) ([]models.OrganizationService, error) {
+	query := "SELECT " + organizationServiceColumns + " FROM organization_services WHERE organization_id = ?"
+	if !includeInactive {
+		query += " AND is_active = TRUE"
+	}
+	query += " ORDER BY COALESCE(category, ''), sort_order, name"
+	return queryOrganizationServices(query, orgID)
+}
+
+func queryOrganizationServices(query string, args ...interface{}) ([]models.OrganizationService, error) {
+	rows, err := db.DB.Query(ConvertPlaceholders(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	services := []models.OrganizationService{}
+	for rows.Next() {
+		var s models.OrganizationService
+		err := rows.Scan(
+			&s.ID, &s.OrganizationID, &s.Category, &s.Name, &s.Description, &s.PriceFrom, &s.PriceTo,
+			&s.DurationMinutes, &s.IsActive, &s.SortOrder, &s.CreatedAt, &s.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		services = append(services, s)
+	}
+	return services, rows.Err()
+}
+
+// loadOpeningHours загружает расписание и вычисляет, открыта ли организация в момент now
+func loadOpeningHours(orgID int, now time.Time) (*models.OpeningHours, error) {
+	hours := &models.OpeningHours{
+		Weekly:     []models.OpeningHoursInterval{},
+		Exceptions: []models.OpeningHoursException{},
+	}
+
+	err := db.DB.QueryRow(ConvertPlaceholders(`
+		SELECT COALESCE(timezone, '') FROM organizations WHERE id = ?
+	`), orgID).Scan(&hours.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	if hours.Timezone == "" {
+		hours.Timezone = defaultOrganizationTimezone
+	}
+	location, err := time.LoadLocation(hours.Timezone)
+	if err != nil {
+		location, _ = time.LoadLocation(defaultOrganizationTimezone)
+	}
+	localNow := now.In(location)
+
+	rows, err := db.DB.Query(ConvertPlaceholders(`
+		SELECT weekday, to_char(opens_at, 'HH24:MI'), to_char(closes_at, 'HH24:MI')
+		FROM organization_opening_hours
+		WHERE organization_id = ?
+		ORDER BY weekday, opens_at
+	`), orgID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var interval models.OpeningHoursInterval
+		if err := rows.Scan(&interval.Weekday, &interval.OpensAt, &interval.ClosesAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		hours.Weekly = append(hours.Weekly, interval)
+	}
+	rows.Close()
+
+	// Вчерашнее исключение нужно, чтобы учесть работу через полночь
+	yesterday := localNow.AddDate(0, 0, -1).Format("2006-01-02")
+	today := localNow.Format("2006-01-02")
+	rows, err = db.DB.Query(ConvertPlaceholders(`
+		SELECT to_char(date, 'YYYY-MM-DD'), is_closed,
+		       to_char(opens_at, 'HH24:MI'), to_char(closes_at, 'HH24:MI'), note
+		FROM organization_hours_exceptions
+		WHERE organization_id = ? AND date >= ?
+		ORDER BY date
+	`), orgID, yesterday)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exceptions := make(map[string]models.OpeningHoursException)
+	for rows.Next() {
+		var e models.OpeningHoursException
+		if err := rows.Scan(&e.Date, &e.IsClosed, &e.OpensAt, &e.ClosesAt, &e.Note); err != nil {
+			return nil, err
+		}
+		exceptions[e.Date] = e
+		if e.Date >= today {
+			hours.Exceptions = append(hours.Exceptions, e)
+		}
+	}
+
+	hours.OpenNow = isOpenAt(hours.Weekly, exceptions, localNow)
+	return hours, nil
+}
+
+// isOpenAt проверяет интервалы текущего дня и ночные интервалы предыдущего
+func isOpenAt(weekly []models.OpeningHoursInterval, exceptions map[string]models.OpeningHoursException, now time.Time) bool {
+	minute := now.Hour()*60 + now.Minute()
+
+	for _, span := range daySpans(weekly, exceptions, now) {
+		if minute >= span[0] && minute < span[1] {
+			return true
+		}
+	}
+	for _, span := range daySpans(weekly, exceptions, now.AddDate(0, 0, -1)) {
+		if span[1] > 24*60 && minute+24*60 < span[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// daySpans возвращает рабочие интервалы дня в минутах от его начала;
+// ночной интервал заканчивается после 24*60
+func daySpans(weekly []models.OpeningHoursInterval, exceptions map[string]models.OpeningHoursException, day time.Time) [][2]int {
+	var spans [][2]int
+
+	if exception, ok := exceptions[day.Format("2006-01-02")]; ok {
+		if exception.IsClosed || exception.OpensAt == nil || exception.ClosesAt == nil {
+			return nil
+		}
+		if span, ok := clockSpan(*exception.OpensAt, *exception.ClosesAt); ok {
+			spans = append(spans, span)
+		}
+		return spans
+	}
+
+	weekday := int(day.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	for _, interval := range weekly {
+		if interval.Weekday != weekday {
+			continue
+		}
+		if span, ok := clockSpan(interval.OpensAt, interval.ClosesAt); ok {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+func clockSpan(opensAt, closesAt string) ([2]int, bool) {
+	opens, ok1 := parseClock(opensAt)
+	closes, ok2 := parseClock(closesAt)
+	if !ok1 || !ok2 {
+		return [2]int{}, false
+	}
+	if closes <= opens {
+		closes += 24 * 60
+	}
+	return [2]int{opens, closes}, true
+}
+
+func validateClockRange(opensAt, closesAt string) error {
+	opens, ok := parseClock(opensAt)
+	if !ok || opens == 24*60 {
+		return fmt.Errorf("opens_at must be in HH:MM format")
+	}
+	closes, ok := parseClock(closesAt)
+	if !ok {
+		return fmt.Errorf("closes_at must be in HH:MM format")
+	}
+	if opens == closes {
+		return fmt.Errorf("opens_at and closes_at must differ")
+	}
+	return nil
+}
+
+// parseClock разбирает HH:MM в минуты от начала дня (допускается 24:00)
+func parseClock(s string) (int, bool) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 || len(parts[0]) != 2 || len(parts[1]) != 2 {
+		return 0, false
+	}
+	h, err1 := strconv.Atoi(parts[0])
+	m, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil || h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, false
+	}
+	return h*60 + m, true
+}
+
+// canEditOrganization - участник с правом редактирования профиля организации
+func canEditOrganization(orgID, userID int) bool {
+	var canEdit bool
+	err := db.DB.QueryRow(ConvertPlaceholders(`
+		SELECT can_edit FROM organization_members WHERE organization_id = ? AND user_id = ?
+	`), orgID, userID).Scan(&canEdit)
+	return err == nil && canEdit
+}
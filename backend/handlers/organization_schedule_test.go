@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"backend/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func strPtr(s string) *string { return &s }
+
+// Будни 09:00-18:00, в пятницу ещё ночная смена 22:00-02:00, суббота до полуночи, воскресенье выходной
+var testWeeklyHours = []models.OpeningHoursInterval{
+	{Weekday: 1, OpensAt: "09:00", ClosesAt: "18:00"},
+	{Weekday: 2, OpensAt: "09:00", ClosesAt: "18:00"},
+	{Weekday: 3, OpensAt: "09:00", ClosesAt: "18:00"},
+	{Weekday: 4, OpensAt: "09:00", ClosesAt: "18:00"},
+	{Weekday: 5, OpensAt: "09:00", ClosesAt: "18:00"},
+	{Weekday: 5, OpensAt: "22:00", ClosesAt: "02:00"},
+	{Weekday: 6, OpensAt: "10:00", ClosesAt: "24:00"},
+}
+
+var testHoursExceptions = map[string]models.OpeningHoursException{
+	// Понедельник - санитарный день
+	"2026-10-19": {Date: "2026-10-19", IsClosed: true},
+	// Вторник - вечернее мероприятие через полночь вместо обычных часов
+	"2026-10-20": {Date: "2026-10-20", OpensAt: strPtr("20:00"), ClosesAt: strPtr("01:00")},
+	// Четверг - исключение без часов считается выходным
+	"2026-10-22": {Date: "2026-10-22"},
+	// Пятница закрыта целиком, вместе с ночной сменой
+	"2026-10-23": {Date: "2026-10-23", IsClosed: true},
+}
+
+func scheduleTime(t *testing.T, value string) time.Time {
+	t.Helper()
+	moment, err := time.ParseInLocation("2006-01-02 15:04", value, time.FixedZone("MSK", 3*3600))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return moment
+}
+
+func TestIsOpenAt(t *testing.T) {
+	tests := []struct {
+		name string
+		at   string
+		want bool
+	}{
+		{"weekday hours", "2026-10-16 10:00", true},
+		{"before opening", "2026-10-16 08:59", false},
+		{"closing minute", "2026-10-16 18:00", false},
+		{"between shifts", "2026-10-16 20:00", false},
+		{"night shift before midnight", "2026-10-16 23:30", true},
+		{"night shift at midnight", "2026-10-17 00:00", true},
+		{"night shift after midnight", "2026-10-17 01:59", true},
+		{"night shift closing minute", "2026-10-17 02:00", false},
+		{"saturday until 24:00", "2026-10-17 23:59", true},
+		{"24:00 does not spill into next day", "2026-10-18 00:00", false},
+		{"sunday", "2026-10-18 12:00", false},
+		{"closed exception", "2026-10-19 10:00", false},
+		{"exception replaces weekly hours", "2026-10-20 10:00", false},
+		{"exception hours", "2026-10-20 20:00", true},
+		{"exception overnight", "2026-10-21 00:30", true},
+		{"exception overnight closing minute", "2026-10-21 01:00", false},
+		{"weekday after overnight exception", "2026-10-21 09:00", true},
+		{"exception without hours", "2026-10-22 10:00", false},
+		{"closed exception cancels night shift", "2026-10-23 23:00", false},
+		{"closed exception cancels night shift after midnight", "2026-10-24 01:00", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isOpenAt(testWeeklyHours, testHoursExceptions, scheduleTime(t, tt.at)); got != tt.want {
+				t.Errorf("isOpenAt(%s) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDaySpans(t *testing.T) {
+	tests := []struct {
+		name string
+		day  string
+		want [][2]int
+	}{
+		{"weekday with night shift", "2026-10-16", [][2]int{{9 * 60, 18 * 60}, {22 * 60, 26 * 60}}},
+		{"until midnight", "2026-10-17", [][2]int{{10 * 60, 24 * 60}}},
+		{"day off", "2026-10-18", nil},
+		{"closed exception", "2026-10-19", nil},
+		{"overnight exception", "2026-10-20", [][2]int{{20 * 60, 25 * 60}}},
+		{"exception without hours", "2026-10-22", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := daySpans(testWeeklyHours, testHoursExceptions, scheduleTime(t, tt.day+" 12:00"))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("daySpans(%s) = %v, want %v", tt.day, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,735 @@
+package handlers
+
+import (
+	"backend/db"
+	"backend/models"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// wishlistCategories - допустимые категории позиций
+var wishlistCategories = map[string]bool{
+	"food":      true,
+	"litter":    true,
+	"medicine":  true,
+	"equipment": true,
+	"other":     true,
+}
+
+// OrganizationWishlistHandler - список нужд приюта (управление)
+// GET    /api/organizations/{id}/wishlist - все позиции (участники организации)
+// POST   /api/organizations/{id}/wishlist - добавить позицию
+// PUT    /api/organizations/{id}/wishlist/{itemId} - изменить позицию
+// DELETE /api/organizations/{id}/wishlist/{itemId} - удалить позицию
+// GET    /api/organizations/{id}/wishlist/{itemId}/pledges - обещания (сотрудники - все, пользователь - свои)
+// POST   /api/organizations/{id}/wishlist/{itemId}/pledges - пообещать привезти
+// DELETE /api/organizations/{id}/wishlist/{itemId}/pledges/{pledgeId} - отменить своё обещание
+// POST   /api/organizations/{id}/wishlist/{itemId}/pledges/{pledgeId}/received - отметить получение
+func OrganizationWishlistHandler(w http.ResponseWriter, r *http.Request, orgID int, rest []string) {
+	userID, ok := RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	if len(rest) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			if !isOrganizationMember(orgID, userID) {
+				sendJSONError(w, http.StatusForbidden, "Only organization members can view the full wishlist")
+				return
+			}
+			items, err := queryWishlistItems(wishlistItemSelect+" WHERE i.organization_id = ? ORDER BY i.status = 'open' DESC, i.is_urgent DESC, i.created_at DESC", orgID)
+			if err != nil {
+				sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+				return
+			}
+			sendJSONSuccess(w, items)
+		case http.MethodPost:
+			createWishlistItem(w, r, orgID, userID)
+		default:
+			sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+		return
+	}
+
+	itemID, err := strconv.Atoi(rest[0])
+	if err != nil {
+		sendJSONError(w, http.StatusBadRequest, "Invalid item ID")
+		return
+	}
+	item, err := getWishlistItem(itemID)
+	if err == sql.ErrNoRows || (err == nil && item.OrganizationID != orgID) {
+		sendJSONError(w, http.StatusNotFound, "Wishlist item not found")
+		return
+	}
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+
+	if len(rest) == 1 {
+		switch r.Method {
+		case http.MethodPut:
+			updateWishlistItem(w, r, item, userID)
+		case http.MethodDelete:
+			if !canEditOrganization(orgID, userID) {
+				sendJSONError(w, http.StatusForbidden, "You don't have permission to manage the wishlist")
+				return
+			}
+			if _, err := db.DB.Exec(ConvertPlaceholders("DELETE FROM organization_wishlist_items WHERE id = ?"), item.ID); err != nil {
+				sendJSONError(w, http.StatusInternalServerError, "Failed to delete item: "+err.Error())
+				return
+			}
+			sendJSONSuccess(w, map[string]interface{}{"message": "Item deleted"})
+		default:
+			sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+		return
+	}
+
+	if rest[1] != "pledges" {
+		sendJSONError(w, http.StatusNotFound, "Not found")
+		return
+	}
+
+	if len(rest) == 2 {
+		switch r.Method {
+		case http.MethodGet:
+			listWishlistPledges(w, item, userID)
+		case http.MethodPost:
+			createWishlistPledge(w, r, item, userID)
+		default:
+			sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+		return
+	}
+
+	pledgeID, err := strconv.Atoi(rest[2])
+	if err != nil {
+		sendJSONError(w, http.StatusBadRequest, "Invalid pledge ID")
+		return
+	}
+
+	switch {
+	case len(rest) == 3 && r.Method == http.MethodDelete:
+		cancelWishlistPledge(w, item, pledgeID, userID)
+	case len(rest) == 4 && rest[3] == "received" && r.Method == http.MethodPost:
+		receiveWishlistPledge(w, r, item, pledgeID, userID)
+	default:
+		sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// OrganizationNeedsHandler - публичный список открытых нужд приюта
+// GET /api/organizations/{id}/needs
+func OrganizationNeedsHandler(w http.ResponseWriter, r *http.Request, orgID int) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	items, err := queryWishlistItems(wishlistItemSelect+`
+		WHERE i.organization_id = ? AND i.status = 'open'
+		ORDER BY i.is_urgent DESC, i.created_at DESC
+	`, orgID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+
+	sendJSONSuccess(w, items)
+}
+
+func createWishlistItem(w http.ResponseWriter, r *http.Request, orgID, userID int) {
+	if !canEditOrganization(orgID, userID) {
+		sendJSONError(w, http.StatusForbidden, "You don't have permission to manage the wishlist")
+		return
+	}
+
+	var req models.WishlistItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+	if msg := validateWishlistItem(&req); msg != "" {
+		sendJSONError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	var itemID int64
+	err := db.DB.QueryRow(ConvertPlaceholders(`
+		INSERT INTO organization_wishlist_items (organization_id, title, description, category, unit, quantity_needed, is_urgent, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`), orgID, req.Title, req.Description, req.Category, req.Unit, req.QuantityNeeded, req.IsUrgent, userID).Scan(&itemID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to create item: "+err.Error())
+		return
+	}
+
+	item, err := getWishlistItem(int(itemID))
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load item: "+err.Error())
+		return
+	}
+	sendJSONSuccess(w, item)
+}
+
+func updateWishlistItem(w http.ResponseWriter, r *http.Request, item *models.WishlistItem, userID int) {
+	if !canEditOrganization(item.OrganizationID, userID) {
+		sendJSONError(w, http.StatusForbidden, "You don't have permission to manage the wishlist")
+		return
+	}
+
+	var req models.WishlistItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+	if msg := validateWishlistItem(&req); msg != "" {
+		sendJSONError(w, http.StatusBadRequest, msg)
+		return
+	}
+	if req.Status != "" && req.Status != "open" && req.Status != "closed" {
+		sendJSONError(w, http.StatusBadRequest, "status must be open or closed")
+		return
+	}
+	if req.QuantityReceived != nil && *req.QuantityReceived < 0 {
+		sendJSONError(w, http.StatusBadRequest, "quantity_received must not be negative")
+		return
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	var received int
+	err = tx.QueryRow(ConvertPlaceholders(`
+		SELECT quantity_received FROM organization_wishlist_items WHERE id = ? FOR UPDATE
+	`), item.ID).Scan(&received)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	if req.QuantityReceived != nil {
+		received = *req.QuantityReceived
+	}
+
+	status := wishlistItemStatus(req.QuantityNeeded, received, req.Status == "closed")
+	_, err = tx.Exec(ConvertPlaceholders(`
+		UPDATE organization_wishlist_items
+		SET title = ?, description = ?, category = ?, unit = ?, quantity_needed = ?, quantity_received = ?,
+		    is_urgent = ?, status = ?, updated_at = NOW()
+		WHERE id = ?
+	`), req.Title, req.Description, req.Category, req.Unit, req.QuantityNeeded, received, req.IsUrgent, status, item.ID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to update item: "+err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to update item: "+err.Error())
+		return
+	}
+
+	updated, err := getWishlistItem(item.ID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load item: "+err.Error())
+		return
+	}
+	sendJSONSuccess(w, updated)
+}
+
+func listWishlistPledges(w http.ResponseWriter, item *models.WishlistItem, userID int) {
+	query := wishlistPledgeSelect + " WHERE p.item_id = ?"
+	args := []interface{}{item.ID}
+	if !isOrganizationMember(item.OrganizationID, userID) {
+		query += " AND p.user_id = ?"
+		args = append(args, userID)
+	}
+	query += " ORDER BY p.created_at DESC"
+
+	pledges, err := queryWishlistPledges(query, args...)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	sendJSONSuccess(w, pledges)
+}
+
+func createWishlistPledge(w http.ResponseWriter, r *http.Request, item *models.WishlistItem, userID int) {
+	var req models.CreatePledgeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+	if req.Quantity <= 0 {
+		sendJSONError(w, http.StatusBadRequest, "quantity must be positive")
+		return
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	// Блокируем позицию, чтобы параллельные обещания не превысили потребность
+	var needed, pledged, received int
+	var status string
+	err = tx.QueryRow(ConvertPlaceholders(`
+		SELECT quantity_needed, quantity_pledged, quantity_received, status FROM organization_wishlist_items WHERE id = ? FOR UPDATE
+	`), item.ID).Scan(&needed, &pledged, &received, &status)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	if status != "open" {
+		sendJSONError(w, http.StatusConflict, "This need is no longer open")
+		return
+	}
+	if remaining := wishlistRemaining(needed, pledged, received); req.Quantity > remaining {
+		sendJSONError(w, http.StatusConflict, fmt.Sprintf("Only %d %s left to pledge", remaining, item.Unit))
+		return
+	}
+
+	var pledgeID int64
+	err = tx.QueryRow(ConvertPlaceholders(`
+		INSERT INTO organization_wishlist_pledges (item_id, user_id, quantity, comment)
+		VALUES (?, ?, ?, ?)
+		RETURNING id
+	`), item.ID, userID, req.Quantity, nullIfEmpty(strings.TrimSpace(req.Comment))).Scan(&pledgeID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to create pledge: "+err.Error())
+		return
+	}
+
+	_, err = tx.Exec(ConvertPlaceholders(`
+		UPDATE organization_wishlist_items SET quantity_pledged = quantity_pledged + ?, updated_at = NOW() WHERE id = ?
+	`), req.Quantity, item.ID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to update item: "+err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to create pledge: "+err.Error())
+		return
+	}
+
+	log.Printf("✅ Wishlist pledge %d: item %d, user %d, quantity %d", pledgeID, item.ID, userID, req.Quantity)
+
+	message := fmt.Sprintf("%s обещает привезти «%s»: %d %s", getUserFullName(userID), item.Title, req.Quantity, item.Unit)
+	notifHandler := &NotificationsHandler{DB: db.DB}
+	for _, managerID := range getOrganizationMemberManagers(item.OrganizationID) {
+		if err := notifHandler.CreateNotification(managerID, userID, "wishlist_pledge", "wishlist_item", item.ID, message); err != nil {
+			log.Printf("⚠️ Failed to notify user %d about pledge %d: %v", managerID, pledgeID, err)
+		}
+	}
+
+	pledges, err := queryWishlistPledges(wishlistPledgeSelect+" WHERE p.id = ?", pledgeID)
+	if err != nil || len(pledges) == 0 {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load pledge")
+		return
+	}
+	sendJSONSuccess(w, pledges[0])
+}
+
+func cancelWishlistPledge(w http.ResponseWriter, item *models.WishlistItem, pledgeID, userID int) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(ConvertPlaceholders("SELECT id FROM organization_wishlist_items WHERE id = ? FOR UPDATE"), item.ID); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+
+	// Отменить можно только своё ещё не доставленное обещание
+	var quantity int
+	err = tx.QueryRow(ConvertPlaceholders(`
+		UPDATE organization_wishlist_pledges SET status = 'cancelled'
+		WHERE id = ? AND item_id = ? AND user_id = ? AND status = 'pledged'
+		RETURNING quantity
+	`), pledgeID, item.ID, userID).Scan(&quantity)
+	if err == sql.ErrNoRows {
+		sendJSONError(w, http.StatusNotFound, "Active pledge not found")
+		return
+	}
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to cancel pledge: "+err.Error())
+		return
+	}
+
+	_, err = tx.Exec(ConvertPlaceholders(`
+		UPDATE organization_wishlist_items SET quantity_pledged = GREATEST(quantity_pledged - ?, 0), updated_at = NOW() WHERE id = ?
+	`), quantity, item.ID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to update item: "+err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to cancel pledge: "+err.Error())
+		return
+	}
+
+	sendJSONSuccess(w, map[string]interface{}{"message": "Pledge cancelled"})
+}
+
+// receiveWishlistPledge - приют отмечает доставку; недовезённая часть снова становится доступной для обещаний
+func receiveWishlistPledge(w http.ResponseWriter, r *http.Request, item *models.WishlistItem, pledgeID, userID int) {
+	if !canEditOrganization(item.OrganizationID, userID) {
+		sendJSONError(w, http.StatusForbidden, "You don't have permission to manage the wishlist")
+		return
+	}
+
+	var req models.ReceivePledgeRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendJSONError(w, http.StatusBadRequest, "Invalid request: "+err.Error())
+			return
+		}
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	var needed, received int
+	var status string
+	err = tx.QueryRow(ConvertPlaceholders(`
+		SELECT quantity_needed, quantity_received, status FROM organization_wishlist_items WHERE id = ? FOR UPDATE
+	`), item.ID).Scan(&needed, &received, &status)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+
+	var pledgerID, pledgedQuantity int
+	var pledgeStatus string
+	err = tx.QueryRow(ConvertPlaceholders(`
+		SELECT user_id, quantity, status FROM organization_wishlist_pledges WHERE id = ? AND item_id = ? FOR UPDATE
+	`), pledgeID, item.ID).Scan(&pledgerID, &pledgedQuantity, &pledgeStatus)
+	if err == sql.ErrNoRows {
+		sendJSONError(w, http.StatusNotFound, "Pledge not found")
+		return
+	}
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	if pledgeStatus != "pledged" {
+		sendJSONError(w, http.StatusConflict, "Pledge is already "+pledgeStatus)
+		return
+	}
+
+	quantity := pledgedQuantity
+	if req.Quantity != nil {
+		quantity = *req.Quantity
+	}
+	if quantity < 0 || quantity > pledgedQuantity {
+		sendJSONError(w, http.StatusBadRequest, fmt.Sprintf("quantity must be from 0 to %d", pledgedQuantity))
+		return
+	}
+
+	_, err = tx.Exec(ConvertPlaceholders(`
+		UPDATE organization_wishlist_pledges
+		SET status = 'received', quantity_received = ?, received_by = ?, received_at = NOW()
+		WHERE id = ?
+	`), quantity, userID, pledgeID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to update pledge: "+err.Error())
+		return
+	}
+
+	// Обещание закрыто целиком: доставленное переходит в quantity_received, недоставленное освобождается
+	received += quantity
+	newStatus := wishlistItemStatus(needed, received, status == "closed")
+	_, err = tx.Exec(ConvertPlaceholders(`
+		UPDATE organization_wishlist_items
+		SET quantity_pledged = GREATEST(quantity_pledged - ?, 0), quantity_received = ?, status = ?, updated_at = NOW()
+		WHERE id = ?
+	`), pledgedQuantity, received, newStatus, item.ID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to update item: "+err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to update pledge: "+err.Error())
+		return
+	}
+
+	if quantity > 0 {
+		var orgName string
+		db.DB.QueryRow(ConvertPlaceholders("SELECT name FROM organizations WHERE id = ?"), item.OrganizationID).Scan(&orgName)
+		message := fmt.Sprintf("«%s» получил(а) «%s»: %d %s. Спасибо за помощь!", orgName, item.Title, quantity, item.Unit)
+		notifHandler := &NotificationsHandler{DB: db.DB}
+		if err := notifHandler.CreateNotification(pledgerID, userID, "wishlist_received", "wishlist_item", item.ID, message); err != nil {
+			log.Printf("⚠️ Failed to notify user %d about received pledge %d: %v", pledgerID, pledgeID, err)
+		}
+	}
+
+	updated, err := getWishlistItem(item.ID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load item: "+err.Error())
+		return
+	}
+	sendJSONSuccess(w, updated)
+}
+
+func validateWishlistItem(req *models.WishlistItemRequest) string {
+	req.Title = strings.TrimSpace(req.Title)
+	req.Unit = strings.TrimSpace(req.Unit)
+	if req.Title == "" {
+		return "title is required"
+	}
+	if req.QuantityNeeded <= 0 {
+		return "quantity_needed must be positive"
+	}
+	if req.Category != nil && !wishlistCategories[*req.Category] {
+		return "Invalid category"
+	}
+	if req.Unit == "" {
+		req.Unit = "шт"
+	}
+	return ""
+}
+
+// wishlistItemStatus - закрытые вручную позиции остаются закрытыми, остальные закрываются по факту получения
+func wishlistItemStatus(needed, received int, closed bool) string {
+	switch {
+	case closed:
+		return "closed"
+	case received >= needed:
+		return "fulfilled"
+	default:
+		return "open"
+	}
+}
+
+// wishlistRemaining - сколько ещё можно пообещать. quantity_pledged - только недоставленные обещания,
+// quantity_received - всё полученное (по обещаниям и ручной корректировкой), поэтому они не пересекаются.
+func wishlistRemaining(needed, pledged, received int) int {
+	return max(needed-pledged-received, 0)
+}
+
+// AnnouncementWishlistHandler - позиции списка нужд, привязанные к сбору средств
+// GET /api/announcements/{id}/wishlist
+// PUT /api/announcements/{id}/wishlist - заменить список (автор объявления)
+func AnnouncementWishlistHandler(w http.ResponseWriter, r *http.Request, announcementID int) {
+	authorID, err := getFundraisingAnnouncementAuthor(announcementID)
+	if err != nil {
+		sendFundraisingLookupError(w, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		items, err := loadLinkedWishlistItems("announcement_wishlist_items", "announcement_id", announcementID)
+		if err != nil {
+			sendError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sendSuccess(w, items)
+	case http.MethodPut:
+		userID, ok := RequireAuth(w, r)
+		if !ok {
+			return
+		}
+		if userID != authorID {
+			sendError(w, "Only the author can attach wishlist items", http.StatusForbidden)
+			return
+		}
+
+		var req models.AttachWishlistItemsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		req.ItemIDs = uniqueInts(req.ItemIDs)
+
+		// Автор может привязать только позиции организаций, которыми управляет
+		orgIDs, err := wishlistItemOrganizations(req.ItemIDs)
+		if err != nil {
+			sendError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, orgID := range orgIDs {
+			if !canEditOrganization(orgID, userID) {
+				sendError(w, "You can only attach items of organizations you manage", http.StatusForbidden)
+				return
+			}
+		}
+
+		if err := replaceLinkedWishlistItems("announcement_wishlist_items", "announcement_id", announcementID, req.ItemIDs); err != nil {
+			sendError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		items, err := loadLinkedWishlistItems("announcement_wishlist_items", "announcement_id", announcementID)
+		if err != nil {
+			sendError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sendSuccess(w, items)
+	default:
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// validatePostWishlistItems проверяет, что к посту организации привязываются только её позиции
+func validatePostWishlistItems(authorType string, authorID int, itemIDs []int) error {
+	if len(itemIDs) == 0 {
+		return nil
+	}
+	if authorType != "organization" {
+		return fmt.Errorf("wishlist items can only be attached to organization posts")
+	}
+	orgIDs, err := wishlistItemOrganizations(itemIDs)
+	if err != nil {
+		return err
+	}
+	for _, orgID := range orgIDs {
+		if orgID != authorID {
+			return fmt.Errorf("wishlist items must belong to the post's organization")
+		}
+	}
+	return nil
+}
+
+// wishlistItemOrganizations возвращает организации позиций и проверяет, что все позиции существуют
+func wishlistItemOrganizations(itemIDs []int) ([]int, error) {
+	var orgIDs []int
+	for _, itemID := range itemIDs {
+		var orgID int
+		err := db.DB.QueryRow(ConvertPlaceholders("SELECT organization_id FROM organization_wishlist_items WHERE id = ?"), itemID).Scan(&orgID)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("wishlist item %d not found", itemID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		orgIDs = append(orgIDs, orgID)
+	}
+	return uniqueInts(orgIDs), nil
+}
+
+// replaceLinkedWishlistItems заменяет привязки позиций у поста или объявления
+func replaceLinkedWishlistItems(table, ownerColumn string, ownerID int, itemIDs []int) error {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(ConvertPlaceholders("DELETE FROM "+table+" WHERE "+ownerColumn+" = ?"), ownerID); err != nil {
+		return err
+	}
+	for _, itemID := range itemIDs {
+		if _, err := tx.Exec(ConvertPlaceholders("INSERT INTO "+table+" ("+ownerColumn+", item_id) VALUES (?, ?)"), ownerID, itemID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func loadLinkedWishlistItems(table, ownerColumn string, ownerID int) ([]models.WishlistItem, error) {
+	return queryWishlistItems(wishlistItemSelect+`
+		JOIN `+table+` l ON l.item_id = i.id
+		WHERE l.`+ownerColumn+` = ?
+		ORDER BY i.status = 'open' DESC, i.is_urgent DESC, i.id
+	`, ownerID)
+}
+
+const wishlistItemSelect = `
+	SELECT i.id, i.organization_id, i.title, i.description, i.category, i.unit,
+	       i.quantity_needed, i.quantity_pledged, i.quantity_received, i.is_urgent, i.status,
+	       i.created_by, i.created_at, i.updated_at, o.name
+	FROM organization_wishlist_items i
+	JOIN organizations o ON i.organization_id = o.id
+`
+
+func getWishlistItem(itemID int) (*models.WishlistItem, error) {
+	items, err := queryWishlistItems(wishlistItemSelect+" WHERE i.id = ?", itemID)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &items[0], nil
+}
+
+func queryWishlistItems(query string, args ...interface{}) ([]models.WishlistItem, error) {
+	rows, err := db.DB.Query(ConvertPlaceholders(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.WishlistItem{}
+	for rows.Next() {
+		var item models.WishlistItem
+		err := rows.Scan(
+			&item.ID, &item.OrganizationID, &item.Title, &item.Description, &item.Category, &item.Unit,
+			&item.QuantityNeeded, &item.QuantityPledged, &item.QuantityReceived, &item.IsUrgent, &item.Status,
+			&item.CreatedBy, &item.CreatedAt, &item.UpdatedAt, &item.OrganizationName,
+		)
+		if err != nil {
+			return nil, err
+		}
+		item.QuantityRemaining = wishlistRemaining(item.QuantityNeeded, item.QuantityPledged, item.QuantityReceived)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+const wishlistPledgeSelect = `
+	SELECT p.id, p.item_id, p.user_id, p.quantity, p.quantity_received, p.comment, p.status,
+	       p.received_by, p.received_at, p.created_at,
+	       u.name || ' ' || COALESCE(u.last_name, ''), i.title
+	FROM organization_wishlist_pledges p
+	JOIN users u ON p.user_id = u.id
+	JOIN organization_wishlist_items i ON p.item_id = i.id
+`
+
+func queryWishlistPledges(query string, args ...interface{}) ([]models.WishlistPledge, error) {
+	rows, err := db.DB.Query(ConvertPlaceholders(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pledges := []models.WishlistPledge{}
+	for rows.Next() {
+		var p models.WishlistPledge
+		err := rows.Scan(
+			&p.ID, &p.ItemID, &p.UserID, &p.Quantity, &p.QuantityReceived, &p.Comment, &p.Status,
+			&p.ReceivedBy, &p.ReceivedAt, &p.CreatedAt,
+			&p.UserName, &p.ItemTitle,
+		)
+		if err != nil {
+			return nil, err
+		}
+		p.UserName = strings.TrimSpace(p.UserName)
+		pledges = append(pledges, p)
+	}
+	return pledges, rows.Err()
+}
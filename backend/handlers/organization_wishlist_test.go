@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"backend/models"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWishlistRemaining(t *testing.T) {
+	tests := []struct {
+		name                      string
+		needed, pledged, received int
+		want                      int
+	}{
+		{"nothing yet", 10, 0, 0, 10},
+		{"partly pledged", 10, 4, 0, 6},
+		{"partly received", 10, 0, 3, 7},
+		{"received and still pledged", 10, 5, 5, 0},
+		{"received and pledged with room", 10, 3, 5, 2},
+		{"over-received manually", 10, 0, 12, 0},
+		{"over-pledged", 10, 8, 4, 0},
+		{"zero needed", 0, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wishlistRemaining(tt.needed, tt.pledged, tt.received); got != tt.want {
+				t.Errorf("wishlistRemaining(%d, %d, %d) = %d, want %d", tt.needed, tt.pledged, tt.received, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWishlistItemStatus(t *testing.T) {
+	tests := []struct {
+		name             string
+		needed, received int
+		closed           bool
+		want             string
+	}{
+		{"open", 10, 9, false, "open"},
+		{"fulfilled", 10, 10, false, "fulfilled"},
+		{"over-fulfilled", 10, 11, false, "fulfilled"},
+		{"closed stays closed", 10, 10, true, "closed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wishlistItemStatus(tt.needed, tt.received, tt.closed); got != tt.want {
+				t.Errorf("wishlistItemStatus(%d, %d, %v) = %q, want %q", tt.needed, tt.received, tt.closed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateWishlistPledgeRespectsReceived(t *testing.T) {
+	tests := []struct {
+		name                      string
+		needed, pledged, received int
+		quantity                  int
+		wantStatus                int
+	}{
+		// Половина получена, половина обещана - больше обещать нечего
+		{"fully covered", 10, 5, 5, 1, http.StatusConflict},
+		{"fits remaining", 10, 3, 5, 2, http.StatusOK},
+		{"exceeds remaining", 10, 3, 5, 3, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inserted bool
+			useFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+				switch {
+				case strings.Contains(query, "FROM organization_wishlist_items WHERE id = ? FOR UPDATE"):
+					return &fakeResult{rows: [][]driver.Value{{int64(tt.needed), int64(tt.pledged), int64(tt.received), "open"}}}, nil
+				case strings.Contains(query, "INSERT INTO organization_wishlist_pledges"):
+					inserted = true
+					return &fakeResult{rows: [][]driver.Value{{int64(1)}}}, nil
+				case strings.Contains(query, "FROM organization_wishlist_pledges p"):
+					now := time.Now()
+					return &fakeResult{rows: [][]driver.Value{{
+						int64(1), int64(1), int64(42), int64(tt.quantity), nil, nil, "pledged", nil, nil, now, "Иван Петров", "Корм",
+					}}}, nil
+				}
+				return &fakeResult{}, nil
+			})
+
+			item := &models.WishlistItem{ID: 1, OrganizationID: 1, Title: "Корм", Unit: "кг"}
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/organizations/1/wishlist/1/pledges",
+				strings.NewReader(`{"quantity": `+strconv.Itoa(tt.quantity)+`}`))
+			createWishlistPledge(rec, req, item, 42)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if inserted != (tt.wantStatus == http.StatusOK) {
+				t.Fatalf("pledge inserted = %v, want %v", inserted, tt.wantStatus == http.StatusOK)
+			}
+		})
+	}
+}
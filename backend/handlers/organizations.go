@@ -117,6 +117,7 @@ func CreateOrganizationHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		log.Printf("✅ User %d added as owner of organization %d", userID, orgID)
+		syncOrganizationStaffChat(int(orgID))
 	} else {
 		log.Printf("ℹ️ Organization %d created without owner (public info)", orgID)
 	}
@@ -126,6 +127,48 @@ func CreateOrganizationHandler(w http.ResponseWriter, r *http.Request) {
 
 // OrganizationHandler обрабатывает запросы к конкретной организации (GET, PUT)
 func OrganizationHandler(w http.ResponseWriter, r *http.Request) {
+	// Вложенные ресурсы: /api/organizations/{id}/{resource}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) >= 4 {
+		orgID, err := strconv.Atoi(parts[2])
+		if err != nil {
+			sendJSONError(w, http.StatusBadRequest, "Invalid organization ID")
+			return
+		}
+
+		switch parts[3] {
+		case "analytics":
+			OrganizationAnalyticsHandler(w, r, orgID)
+		case "invitations":
+			OrganizationInvitationsQueueHandler(w, r, orgID)
+		case "join-requests":
+			OrganizationJoinRequestHandler(w, r, orgID)
+		case "ownership-transfers":
+			OrganizationOwnershipTransfersHandler(w, r, orgID)
+		case "hours":
+			OrganizationHoursHandler(w, r, orgID)
+		case "services":
+			OrganizationServicesHandler(w, r, orgID, parts[4:])
+		case "appointment-slots":
+			OrganizationAppointmentSlotsHandler(w, r, orgID, parts[4:])
+		case "appointments":
+			OrganizationAppointmentsHandler(w, r, orgID)
+		case "reviews":
+			OrganizationReviewsHandler(w, r, orgID, parts[4:])
+		case "wishlist":
+			OrganizationWishlistHandler(w, r, orgID, parts[4:])
+		case "needs":
+			OrganizationNeedsHandler(w, r, orgID)
+		case "fosters":
+			OrganizationFostersHandler(w, r, orgID, parts[4:])
+		case "staff-chat":
+			OrganizationStaffChatHandler(w, r, orgID)
+		default:
+			sendJSONError(w, http.StatusNotFound, "Not found")
+		}
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		GetOrganizationHandler(w, r)
@@ -162,6 +205,8 @@ func GetOrganizationHandler(w http.ResponseWriter, r *http.Request) {
 			owner_user_id,
 			profile_visibility, show_phone, show_email, allow_messages,
 			is_verified, is_active, status,
+			state_status, liquidation_flagged_at,
+			rating_average, reviews_count,
 			created_at, updated_at
 		FROM organizations
 		WHERE id = ?
@@ -180,6 +225,8 @@ func GetOrganizationHandler(w http.ResponseWriter, r *http.Request) {
 		&org.OwnerUserID,
 		&org.ProfileVisibility, &org.ShowPhone, &org.ShowEmail, &org.AllowMessages,
 		&org.IsVerified, &org.IsActive, &org.Status,
+		&org.StateStatus, &org.LiquidationFlaggedAt,
+		&org.RatingAverage, &org.ReviewsCount,
 		&org.CreatedAt, &org.UpdatedAt,
 	)
 
@@ -192,6 +239,18 @@ func GetOrganizationHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Режим работы и прайс-лист (если организация их заполнила)
+	if hours, err := loadOpeningHours(org.ID, time.Now()); err != nil {
+		log.Printf("⚠️ Failed to load opening hours of organization %d: %v", org.ID, err)
+	} else if len(hours.Weekly) > 0 || len(hours.Exceptions) > 0 {
+		org.OpeningHours = hours
+	}
+	if services, err := loadOrganizationServices(org.ID, false); err != nil {
+		log.Printf("⚠️ Failed to load services of organization %d: %v", org.ID, err)
+	} else if len(services) > 0 {
+		org.Services = services
+	}
+
 	sendJSONSuccess(w, org)
 }
 
@@ -205,7 +264,7 @@ func GetAllOrganizationsHandler(w http.ResponseWriter, r *http.Request) {
 	query := `
 		SELECT 
 			id, name, short_name, type, logo, bio,
-			address_city, address_region, is_verified, created_at
+			address_city, address_region, is_verified, rating_average, reviews_count, created_at
 		FROM organizations
 		ORDER BY created_at DESC
 	`
@@ -224,9 +283,11 @@ func GetAllOrganizationsHandler(w http.ResponseWriter, r *http.Request) {
 		var name string
 		var shortName, orgType, logo, bio, city, region sql.NullString
 		var isVerified bool
+		var ratingAverage float64
+		var reviewsCount int
 		var createdAt time.Time
 
-		err := rows.Scan(&id, &name, &shortName, &orgType, &logo, &bio, &city, &region, &isVerified, &createdAt)
+		err := rows.Scan(&id, &name, &shortName, &orgType, &logo, &bio, &city, &region, &isVerified, &ratingAverage, &reviewsCount, &createdAt)
 		if err != nil {
 			log.Printf("❌ Scan error: %v", err)
 			continue
@@ -242,6 +303,8 @@ func GetAllOrganizationsHandler(w http.ResponseWriter, r *http.Request) {
 			"address_city":   city.String,
 			"address_region": region.String,
 			"is_verified":    isVerified,
+			"rating_average": ratingAverage,
+			"reviews_count":  reviewsCount,
 			"created_at":     createdAt,
 		})
 	}
@@ -319,6 +382,13 @@ func UpdateOrganizationHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Название чата сотрудников повторяет название организации
+	if req.Name != nil {
+		if id, err := strconv.Atoi(orgID); err == nil {
+			syncOrganizationStaffChat(id)
+		}
+	}
+
 	sendJSONSuccess(w, map[string]interface{}{"message": "Organization updated successfully"})
 }
 
@@ -544,7 +614,8 @@ func stringOrEmpty(s *string) string {
 	return *s
 }
 
-// AddMemberHandler добавляет участника в организацию
+// AddMemberHandler приглашает пользователя в организацию.
+// Участник добавляется только после того, как приглашённый примет приглашение.
 func AddMemberHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
@@ -557,63 +628,13 @@ func AddMemberHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req struct {
-		OrganizationID int    `json:"organization_id"`
-		UserID         int    `json:"user_id"`
-		Role           string `json:"role"`
-		Position       string `json:"position"`
-	}
-
+	var req models.CreateInvitationRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		sendJSONError(w, http.StatusBadRequest, "Invalid request: "+err.Error())
 		return
 	}
 
-	// Проверяем права доступа (только owner и admin могут добавлять)
-	var canManage bool
-	err := db.DB.QueryRow(ConvertPlaceholders(`
-		SELECT can_manage_members FROM organization_members
-		WHERE organization_id = ? AND user_id = ?
-	`), req.OrganizationID, userID).Scan(&canManage)
-
-	if err == sql.ErrNoRows || !canManage {
-		sendJSONError(w, http.StatusForbidden, "You don't have permission to manage members")
-		return
-	}
-
-	// Проверяем, что пользователь еще не является участником
-	var exists bool
-	err = db.DB.QueryRow(ConvertPlaceholders(`
-		SELECT EXISTS(SELECT 1 FROM organization_members WHERE organization_id = ? AND user_id = ?)
-	`), req.OrganizationID, req.UserID).Scan(&exists)
-
-	if err != nil {
-		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
-		return
-	}
-
-	if exists {
-		sendJSONError(w, http.StatusBadRequest, "User is already a member")
-		return
-	}
-
-	// Устанавливаем права в зависимости от роли
-	canPost := req.Role == "owner" || req.Role == "admin" || req.Role == "moderator"
-	canEdit := req.Role == "owner" || req.Role == "admin"
-	canManageMembers := req.Role == "owner" || req.Role == "admin"
-
-	// Добавляем участника
-	_, err = db.DB.Exec(ConvertPlaceholders(`
-		INSERT INTO organization_members (organization_id, user_id, role, position, can_post, can_edit, can_manage_members)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
-	`), req.OrganizationID, req.UserID, req.Role, req.Position, canPost, canEdit, canManageMembers)
-
-	if err != nil {
-		sendJSONError(w, http.StatusInternalServerError, "Failed to add member: "+err.Error())
-		return
-	}
-
-	sendJSONSuccess(w, map[string]interface{}{"message": "Member added successfully"})
+	createOrganizationInvitation(w, userID, req)
 }
 
 // UpdateMemberHandler обновляет роль участника
@@ -640,14 +661,26 @@ func UpdateMemberHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Получаем organization_id участника
-	var orgID int
-	err := db.DB.QueryRow(ConvertPlaceholders("SELECT organization_id FROM organization_members WHERE id = ?"), req.MemberID).Scan(&orgID)
+	if req.Role != "owner" && req.Role != "admin" && req.Role != "moderator" && req.Role != "member" {
+		sendJSONError(w, http.StatusBadRequest, "Invalid role")
+		return
+	}
+
+	// Получаем organization_id и текущую роль участника
+	var orgID, memberUserID int
+	var memberRole string
+	err := db.DB.QueryRow(ConvertPlaceholders("SELECT organization_id, user_id, role FROM organization_members WHERE id = ?"), req.MemberID).Scan(&orgID, &memberUserID, &memberRole)
 	if err == sql.ErrNoRows {
 		sendJSONError(w, http.StatusNotFound, "Member not found")
 		return
 	}
 
+	// Владельцем можно стать только через передачу владения с согласием получателя
+	if req.Role == "owner" && memberRole != "owner" {
+		sendJSONError(w, http.StatusBadRequest, "Use ownership transfer to make a member an owner")
+		return
+	}
+
 	// Проверяем права доступа
 	var canManage bool
 	err = db.DB.QueryRow(ConvertPlaceholders(`
@@ -660,13 +693,43 @@ func UpdateMemberHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Роль владельца могут менять только другие владельцы
+	if memberRole == "owner" && !isOrganizationOwner(orgID, userID) {
+		sendJSONError(w, http.StatusForbidden, "Only owners can change an owner's role")
+		return
+	}
+
 	// Устанавливаем права в зависимости от роли
 	canPost := req.Role == "owner" || req.Role == "admin" || req.Role == "moderator"
 	canEdit := req.Role == "owner" || req.Role == "admin"
 	canManageMembers := req.Role == "owner" || req.Role == "admin"
 
+	tx, err := db.DB.Begin()
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	// У организации всегда должен оставаться хотя бы один владелец
+	if memberRole == "owner" && req.Role != "owner" {
+		if err := lockOrganization(tx, orgID); err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+			return
+		}
+		owners, err := countOrganizationOwners(tx, orgID)
+		if err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+			return
+		}
+		if owners <= 1 {
+			sendJSONError(w, http.StatusBadRequest, "Organization must have at least one owner. Transfer ownership first")
+			return
+		}
+	}
+
 	// Обновляем участника
-	_, err = db.DB.Exec(ConvertPlaceholders(`
+	_, err = tx.Exec(ConvertPlaceholders(`
 		UPDATE organization_members 
 		SET role = ?, position = ?, can_post = ?, can_edit = ?, can_manage_members = ?
 		WHERE id = ?
@@ -677,6 +740,25 @@ func UpdateMemberHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if memberRole == "owner" && req.Role != "owner" {
+		if err := syncOrganizationOwnerUserID(tx, orgID, 0); err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Failed to update organization: "+err.Error())
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to update member: "+err.Error())
+		return
+	}
+
+	if memberRole == "owner" && req.Role != "owner" {
+		logOrganizationOwnerChange(r, userID, orgID, models.ActionDemoteOwner,
+			fmt.Sprintf("Owner user_id=%d (member #%d) demoted to %s", memberUserID, req.MemberID, req.Role))
+	}
+
+	syncOrganizationStaffChat(orgID)
+
 	sendJSONSuccess(w, map[string]interface{}{"message": "Member updated successfully"})
 }
 
@@ -714,8 +796,8 @@ func RemoveMemberHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Нельзя удалить owner
-	if memberRole == "owner" {
+	// Владельца может удалить только владелец (в том числе сам себя)
+	if memberRole == "owner" && !isOrganizationOwner(orgID, userID) {
 		sendJSONError(w, http.StatusForbidden, "Cannot remove organization owner")
 		return
 	}
@@ -732,109 +814,57 @@ func RemoveMemberHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Удаляем участника
-	_, err = db.DB.Exec(ConvertPlaceholders("DELETE FROM organization_members WHERE id = ?"), req.MemberID)
+	tx, err := db.DB.Begin()
 	if err != nil {
-		sendJSONError(w, http.StatusInternalServerError, "Failed to remove member: "+err.Error())
-		return
-	}
-
-	sendJSONSuccess(w, map[string]interface{}{"message": "Member removed successfully"})
-}
-
-// ClaimOwnershipHandler - заявка на владение организацией
-func ClaimOwnershipHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
-		return
-	}
-
-	userID := r.Context().Value("userID").(int)
-	if userID == 0 {
-		sendJSONError(w, http.StatusUnauthorized, "Unauthorized")
-		return
-	}
-
-	// Извлекаем ID из URL
-	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
-	if len(parts) < 4 {
-		sendJSONError(w, http.StatusBadRequest, "Invalid URL")
-		return
-	}
-	orgIDStr := parts[len(parts)-2] // /api/organizations/{id}/claim-ownership
-	orgID, err := strconv.Atoi(orgIDStr)
-	if err != nil {
-		sendJSONError(w, http.StatusBadRequest, "Invalid organization ID")
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
 		return
 	}
+	defer tx.Rollback()
 
-	// Проверяем что организация существует
-	var exists bool
-	err = db.DB.QueryRow(ConvertPlaceholders(`
-		SELECT EXISTS(SELECT 1 FROM organizations WHERE id = ?)
-	`), orgID).Scan(&exists)
-
-	if err != nil || !exists {
-		sendJSONError(w, http.StatusNotFound, "Organization not found")
-		return
+	// Последнего владельца удалить нельзя
+	if memberRole == "owner" {
+		if err := lockOrganization(tx, orgID); err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+			return
+		}
+		owners, err := countOrganizationOwners(tx, orgID)
+		if err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+			return
+		}
+		if owners <= 1 {
+			sendJSONError(w, http.StatusBadRequest, "Cannot remove the last owner. Transfer ownership first")
+			return
+		}
 	}
 
-	// Проверяем что у организации нет owner
-	var hasOwner bool
-	err = db.DB.QueryRow(ConvertPlaceholders(`
-		SELECT EXISTS(
-			SELECT 1 FROM organization_members 
-			WHERE organization_id = ? AND role = 'owner'
-		)
-	`), orgID).Scan(&hasOwner)
-
+	// Удаляем участника
+	_, err = tx.Exec(ConvertPlaceholders("DELETE FROM organization_members WHERE id = ?"), req.MemberID)
 	if err != nil {
-		log.Printf("❌ Error checking owner: %v", err)
-		sendJSONError(w, http.StatusInternalServerError, "Database error")
+		sendJSONError(w, http.StatusInternalServerError, "Failed to remove member: "+err.Error())
 		return
 	}
 
-	if hasOwner {
-		sendJSONError(w, http.StatusBadRequest, "Organization already has an owner")
-		return
+	if memberRole == "owner" {
+		if err := syncOrganizationOwnerUserID(tx, orgID, 0); err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Failed to update organization: "+err.Error())
+			return
+		}
 	}
 
-	// Проверяем что пользователь еще не является участником
-	var isMember bool
-	err = db.DB.QueryRow(ConvertPlaceholders(`
-		SELECT EXISTS(
-			SELECT 1 FROM organization_members 
-			WHERE organization_id = ? AND user_id = ?
-		)
-	`), orgID, userID).Scan(&isMember)
-
-	if err != nil {
-		log.Printf("❌ Error checking membership: %v", err)
-		sendJSONError(w, http.StatusInternalServerError, "Database error")
+	if err := tx.Commit(); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to remove member: "+err.Error())
 		return
 	}
 
-	if isMember {
-		sendJSONError(w, http.StatusBadRequest, "You are already a member of this organization")
-		return
+	if memberRole == "owner" {
+		logOrganizationOwnerChange(r, userID, orgID, models.ActionRemoveOwner,
+			fmt.Sprintf("Owner user_id=%d (member #%d) removed from organization", memberUserID, req.MemberID))
 	}
 
-	// Добавляем пользователя как owner
-	_, err = db.DB.Exec(ConvertPlaceholders(`
-		INSERT INTO organization_members (organization_id, user_id, role, can_post, can_edit, can_manage_members, joined_at)
-		VALUES (?, ?, 'owner', true, true, true, ?)
-	`), orgID, userID, time.Now())
+	syncOrganizationStaffChat(orgID)
 
-	if err != nil {
-		log.Printf("❌ Failed to add owner: %v", err)
-		sendJSONError(w, http.StatusInternalServerError, "Failed to claim ownership")
-		return
-	}
-
-	log.Printf("✅ User %d claimed ownership of organization %d", userID, orgID)
-	sendJSONSuccess(w, map[string]interface{}{
-		"message": "You are now the owner of this organization",
-	})
+	sendJSONSuccess(w, map[string]interface{}{"message": "Member removed successfully"})
 }
 
 // GetMyOrganizationsHandler возвращает организации пользователя где он owner или admin
@@ -974,21 +1004,40 @@ func CheckOrganizationByInnHandler(w http.ResponseWriter, r *http.Request) {
 		SELECT id, name FROM organizations WHERE inn = ? LIMIT 1
 	`), inn).Scan(&id, &name)
 
-	if err == sql.ErrNoRows {
-		// Организация не найдена - это нормально
-		sendJSONSuccess(w, nil)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("❌ Error checking organization by INN: %v", err)
+		sendJSONError(w, http.StatusInternalServerError, "Database error")
 		return
 	}
 
-	if err != nil {
-		log.Printf("❌ Error checking organization by INN: %v", err)
-		sendJSONError(w, http.StatusInternalServerError, "Database error")
+	var existing map[string]interface{}
+	if err == nil {
+		existing = map[string]interface{}{
+			"id":   id,
+			"name": name,
+		}
+	}
+
+	// ?prefill=1 - дополнительно возвращаем данные из реестра для заполнения формы.
+	// Запрос к реестру платный, поэтому только для авторизованных
+	if r.URL.Query().Get("prefill") == "1" {
+		if userID, ok := r.Context().Value("userID").(int); !ok || userID == 0 {
+			sendJSONError(w, http.StatusUnauthorized, "Authentication required")
+			return
+		}
+		sendJSONSuccess(w, map[string]interface{}{
+			"organization": existing,
+			"prefill":      lookupCompanyForPrefill(inn),
+		})
+		return
+	}
+
+	if existing == nil {
+		// Организация не найдена - это нормально
+		sendJSONSuccess(w, nil)
 		return
 	}
 
 	// Организация найдена
-	sendJSONSuccess(w, map[string]interface{}{
-		"id":   id,
-		"name": name,
-	})
+	sendJSONSuccess(w, existing)
 }
@@ -0,0 +1,626 @@
+package handlers
+
+import (
+	"backend/db"
+	"backend/models"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxClaimDocuments ограничивает число документов в одной заявке
+const maxClaimDocuments = 10
+
+// ClaimOwnershipHandler - подача заявки на владение организацией без владельца.
+// Организация остаётся без владельца, пока модератор не одобрит заявку.
+// POST /api/organizations/claim-ownership/{id}
+func ClaimOwnershipHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	// Извлекаем ID из URL
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 4 {
+		sendJSONError(w, http.StatusBadRequest, "Invalid URL")
+		return
+	}
+	orgID, err := strconv.Atoi(parts[3]) // /api/organizations/claim-ownership/{id}
+	if err != nil {
+		sendJSONError(w, http.StatusBadRequest, "Invalid organization ID")
+		return
+	}
+
+	var req models.CreateOwnershipClaimRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	var orgName string
+	var orgINN, orgOGRN sql.NullString
+	err = db.DB.QueryRow(ConvertPlaceholders(`
+		SELECT name, inn, ogrn FROM organizations WHERE id = ?
+	`), orgID).Scan(&orgName, &orgINN, &orgOGRN)
+	if err == sql.ErrNoRows {
+		sendJSONError(w, http.StatusNotFound, "Organization not found")
+		return
+	}
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+
+	hasOwner, err := organizationHasOwner(orgID)
+	if err != nil {
+		log.Printf("❌ Error checking owner: %v", err)
+		sendJSONError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if hasOwner {
+		sendJSONError(w, http.StatusBadRequest, "Organization already has an owner")
+		return
+	}
+
+	// ИНН и ОГРН должны совпадать с данными организации
+	if !orgINN.Valid || orgINN.String == "" || !orgOGRN.Valid || orgOGRN.String == "" {
+		sendJSONError(w, http.StatusBadRequest, "Organization has no INN/OGRN on record and can't be claimed")
+		return
+	}
+	if strings.TrimSpace(req.INN) != orgINN.String || strings.TrimSpace(req.OGRN) != orgOGRN.String {
+		sendJSONError(w, http.StatusBadRequest, "INN or OGRN doesn't match the organization record")
+		return
+	}
+
+	// Документы (устав, доверенность) должны быть загружены в медиатеку заявителя
+	documentIDs := uniqueInts(req.DocumentMediaIDs)
+	if len(documentIDs) == 0 {
+		sendJSONError(w, http.StatusBadRequest, "At least one supporting document is required")
+		return
+	}
+	if len(documentIDs) > maxClaimDocuments {
+		sendJSONError(w, http.StatusBadRequest, fmt.Sprintf("No more than %d documents are allowed", maxClaimDocuments))
+		return
+	}
+	for _, mediaID := range documentIDs {
+		var mediaOwnerID int
+		err := db.DB.QueryRow(ConvertPlaceholders("SELECT user_id FROM user_media WHERE id = ?"), mediaID).Scan(&mediaOwnerID)
+		if err != nil || mediaOwnerID != userID {
+			sendJSONError(w, http.StatusBadRequest, fmt.Sprintf("Document %d not found", mediaID))
+			return
+		}
+	}
+
+	var pending bool
+	err = db.DB.QueryRow(ConvertPlaceholders(`
+		SELECT EXISTS(SELECT 1 FROM organization_ownership_claims WHERE organization_id = ? AND user_id = ? AND status = 'pending')
+	`), orgID, userID).Scan(&pending)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	if pending {
+		sendJSONError(w, http.StatusConflict, "You already have a pending claim for this organization")
+		return
+	}
+
+	var comment *string
+	if strings.TrimSpace(req.Comment) != "" {
+		comment = &req.Comment
+	}
+
+	// Заявка и документы сохраняются вместе: заявка без документов заблокировала бы повторную подачу
+	tx, err := db.DB.Begin()
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	var claimID int64
+	err = tx.QueryRow(ConvertPlaceholders(`
+		INSERT INTO organization_ownership_claims (organization_id, user_id, inn, ogrn, comment, status)
+		VALUES (?, ?, ?, ?, ?, 'pending')
+		RETURNING id
+	`), orgID, userID, orgINN.String, orgOGRN.String, comment).Scan(&claimID)
+	if err != nil {
+		log.Printf("❌ Failed to create ownership claim: %v", err)
+		sendJSONError(w, http.StatusInternalServerError, "Failed to create ownership claim")
+		return
+	}
+
+	for _, mediaID := range documentIDs {
+		_, err := tx.Exec(ConvertPlaceholders(`
+			INSERT INTO organization_ownership_claim_documents (claim_id, media_id) VALUES (?, ?)
+		`), claimID, mediaID)
+		if err != nil {
+			log.Printf("❌ Failed to attach document %d to claim %d: %v", mediaID, claimID, err)
+			sendJSONError(w, http.StatusInternalServerError, "Failed to attach documents")
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("❌ Failed to commit ownership claim: %v", err)
+		sendJSONError(w, http.StatusInternalServerError, "Failed to create ownership claim")
+		return
+	}
+
+	log.Printf("📝 User %d submitted ownership claim %d for organization %d", userID, claimID, orgID)
+
+	claim, err := getOwnershipClaim(int(claimID))
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load claim: "+err.Error())
+		return
+	}
+
+	sendJSONSuccess(w, map[string]interface{}{
+		"message": "Your claim has been submitted for moderator review",
+		"claim":   claim,
+	})
+}
+
+// OwnershipClaimsHandler - очередь заявок для модераторов, для остальных - собственные заявки
+// GET /api/organizations/ownership-claims?status=pending
+func OwnershipClaimsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	query := ownershipClaimSelect + " WHERE 1 = 1"
+	var args []interface{}
+
+	isModerator := hasModeratorRights(db.DB, userID)
+	if !isModerator || r.URL.Query().Get("mine") == "true" {
+		query += " AND c.user_id = ?"
+		args = append(args, userID)
+	}
+
+	status := r.URL.Query().Get("status")
+	if status == "" && isModerator {
+		status = "pending"
+	}
+	if status != "" && status != "all" {
+		query += " AND c.status = ?"
+		args = append(args, status)
+	}
+	if orgID, err := strconv.Atoi(r.URL.Query().Get("organization_id")); err == nil {
+		query += " AND c.organization_id = ?"
+		args = append(args, orgID)
+	}
+
+	// Модераторы разбирают очередь от старых заявок к новым
+	if isModerator {
+		query += " ORDER BY c.created_at ASC"
+	} else {
+		query += " ORDER BY c.created_at DESC"
+	}
+
+	claims, err := queryOwnershipClaims(query, args...)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+
+	sendJSONSuccess(w, claims)
+}
+
+// OwnershipClaimHandler - просмотр и рассмотрение заявки
+// GET /api/organizations/ownership-claims/{id}
+// POST /api/organizations/ownership-claims/{id}/approve
+// POST /api/organizations/ownership-claims/{id}/reject
+// DELETE /api/organizations/ownership-claims/{id} - отзыв заявки автором
+func OwnershipClaimHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 4 {
+		sendJSONError(w, http.StatusBadRequest, "Invalid URL")
+		return
+	}
+	claimID, err := strconv.Atoi(parts[3])
+	if err != nil {
+		sendJSONError(w, http.StatusBadRequest, "Invalid claim ID")
+		return
+	}
+
+	claim, err := getOwnershipClaim(claimID)
+	if err == sql.ErrNoRows {
+		sendJSONError(w, http.StatusNotFound, "Claim not found")
+		return
+	}
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+
+	action := ""
+	if len(parts) > 4 {
+		action = parts[4]
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		if claim.UserID != userID && !hasModeratorRights(db.DB, userID) {
+			sendJSONError(w, http.StatusForbidden, "Access denied")
+			return
+		}
+		sendJSONSuccess(w, claim)
+	case action == "" && r.Method == http.MethodDelete:
+		cancelOwnershipClaim(w, claim, userID)
+	case (action == "approve" || action == "reject") && r.Method == http.MethodPost:
+		if !hasModeratorRights(db.DB, userID) {
+			sendJSONError(w, http.StatusForbidden, "Only moderators can review ownership claims")
+			return
+		}
+		var req models.ReviewOwnershipClaimRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				sendJSONError(w, http.StatusBadRequest, "Invalid request: "+err.Error())
+				return
+			}
+		}
+		if action == "approve" {
+			approveOwnershipClaim(w, r, claim, userID, req.Comment)
+		} else {
+			rejectOwnershipClaim(w, r, claim, userID, req.Comment)
+		}
+	default:
+		sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// approveOwnershipClaim назначает заявителя владельцем и отклоняет остальные заявки на организацию
+func approveOwnershipClaim(w http.ResponseWriter, r *http.Request, claim *models.OwnershipClaim, moderatorID int, comment string) {
+	if claim.Status != "pending" {
+		sendJSONError(w, http.StatusConflict, "Claim is already "+claim.Status)
+		return
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	// Блокируем организацию, чтобы две заявки не были одобрены одновременно
+	var lockedID int
+	err = tx.QueryRow(ConvertPlaceholders("SELECT id FROM organizations WHERE id = ? FOR UPDATE"), claim.OrganizationID).Scan(&lockedID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+
+	var hasOwner bool
+	err = tx.QueryRow(ConvertPlaceholders(`
+		SELECT EXISTS(SELECT 1 FROM organization_members WHERE organization_id = ? AND role = 'owner')
+	`), claim.OrganizationID).Scan(&hasOwner)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	if hasOwner {
+		sendJSONError(w, http.StatusConflict, "Organization already has an owner")
+		return
+	}
+
+	result, err := tx.Exec(ConvertPlaceholders(`
+		UPDATE organization_ownership_claims
+		SET status = 'approved', reviewed_by = ?, review_comment = ?, reviewed_at = NOW()
+		WHERE id = ? AND status = 'pending'
+	`), moderatorID, nullIfEmpty(comment), claim.ID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to update claim: "+err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		sendJSONError(w, http.StatusConflict, "Claim is no longer pending")
+		return
+	}
+
+	// Заявитель мог уже состоять в организации - тогда повышаем роль
+	result, err = tx.Exec(ConvertPlaceholders(`
+		UPDATE organization_members
+		SET role = 'owner', can_post = true, can_edit = true, can_manage_members = true
+		WHERE organization_id = ? AND user_id = ?
+	`), claim.OrganizationID, claim.UserID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to assign owner: "+err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		_, err = tx.Exec(ConvertPlaceholders(`
+			INSERT INTO organization_members (organization_id, user_id, role, can_post, can_edit, can_manage_members, joined_at)
+			VALUES (?, ?, 'owner', true, true, true, ?)
+		`), claim.OrganizationID, claim.UserID, time.Now())
+		if err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Failed to assign owner: "+err.Error())
+			return
+		}
+	}
+
+	_, err = tx.Exec(ConvertPlaceholders(`
+		UPDATE organizations SET owner_user_id = ?, updated_at = NOW() WHERE id = ?
+	`), claim.UserID, claim.OrganizationID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to assign owner: "+err.Error())
+		return
+	}
+
+	// Остальные заявки на эту организацию теряют смысл
+	rows, err := tx.Query(ConvertPlaceholders(`
+		UPDATE organization_ownership_claims
+		SET status = 'rejected', reviewed_by = ?, review_comment = 'Организация получила подтверждённого владельца', reviewed_at = NOW()
+		WHERE organization_id = ? AND status = 'pending' AND id <> ?
+		RETURNING id, user_id
+	`), moderatorID, claim.OrganizationID, claim.ID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to update claims: "+err.Error())
+		return
+	}
+	type rejectedClaim struct{ id, userID int }
+	var rejected []rejectedClaim
+	for rows.Next() {
+		var rc rejectedClaim
+		if err := rows.Scan(&rc.id, &rc.userID); err == nil {
+			rejected = append(rejected, rc)
+		}
+	}
+	rows.Close()
+
+	if err := tx.Commit(); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to approve claim: "+err.Error())
+		return
+	}
+
+	log.Printf("✅ Ownership claim %d approved: user %d is now owner of organization %d (moderator %d)", claim.ID, claim.UserID, claim.OrganizationID, moderatorID)
+	syncOrganizationStaffChat(claim.OrganizationID)
+
+	logOwnershipClaimReview(r, moderatorID, models.ActionApproveOwnershipClaim, claim,
+		fmt.Sprintf("Ownership claim #%d approved, owner user_id=%d. %s", claim.ID, claim.UserID, comment))
+
+	notifHandler := &NotificationsHandler{DB: db.DB}
+	message := fmt.Sprintf("Ваша заявка на владение организацией «%s» одобрена", claim.OrganizationName)
+	if err := notifHandler.CreateNotification(claim.UserID, moderatorID, "ownership_claim_approved", "organization", claim.OrganizationID, message); err != nil {
+		log.Printf("⚠️ Failed to notify user %d about claim %d: %v", claim.UserID, claim.ID, err)
+	}
+	for _, rc := range rejected {
+		message := fmt.Sprintf("Ваша заявка на владение организацией «%s» отклонена: владелец уже подтверждён", claim.OrganizationName)
+		if err := notifHandler.CreateNotification(rc.userID, moderatorID, "ownership_claim_rejected", "organization", claim.OrganizationID, message); err != nil {
+			log.Printf("⚠️ Failed to notify user %d about claim %d: %v", rc.userID, rc.id, err)
+		}
+	}
+
+	updated, err := getOwnershipClaim(claim.ID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load claim: "+err.Error())
+		return
+	}
+	sendJSONSuccess(w, updated)
+}
+
+func rejectOwnershipClaim(w http.ResponseWriter, r *http.Request, claim *models.OwnershipClaim, moderatorID int, comment string) {
+	if strings.TrimSpace(comment) == "" {
+		sendJSONError(w, http.StatusBadRequest, "comment with the rejection reason is required")
+		return
+	}
+
+	result, err := db.DB.Exec(ConvertPlaceholders(`
+		UPDATE organization_ownership_claims
+		SET status = 'rejected', reviewed_by = ?, review_comment = ?, reviewed_at = NOW()
+		WHERE id = ? AND status = 'pending'
+	`), moderatorID, comment, claim.ID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to update claim: "+err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		sendJSONError(w, http.StatusConflict, "Claim is already "+claim.Status)
+		return
+	}
+
+	log.Printf("🚫 Ownership claim %d rejected by moderator %d", claim.ID, moderatorID)
+
+	logOwnershipClaimReview(r, moderatorID, models.ActionRejectOwnershipClaim, claim,
+		fmt.Sprintf("Ownership claim #%d of user_id=%d rejected: %s", claim.ID, claim.UserID, comment))
+
+	notifHandler := &NotificationsHandler{DB: db.DB}
+	message := fmt.Sprintf("Ваша заявка на владение организацией «%s» отклонена: %s", claim.OrganizationName, comment)
+	if err := notifHandler.CreateNotification(claim.UserID, moderatorID, "ownership_claim_rejected", "organization", claim.OrganizationID, message); err != nil {
+		log.Printf("⚠️ Failed to notify user %d about claim %d: %v", claim.UserID, claim.ID, err)
+	}
+
+	updated, err := getOwnershipClaim(claim.ID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load claim: "+err.Error())
+		return
+	}
+	sendJSONSuccess(w, updated)
+}
+
+func cancelOwnershipClaim(w http.ResponseWriter, claim *models.OwnershipClaim, userID int) {
+	if claim.UserID != userID {
+		sendJSONError(w, http.StatusForbidden, "You can only cancel your own claims")
+		return
+	}
+
+	result, err := db.DB.Exec(ConvertPlaceholders(`
+		UPDATE organization_ownership_claims SET status = 'cancelled' WHERE id = ? AND status = 'pending'
+	`), claim.ID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to cancel claim: "+err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		sendJSONError(w, http.StatusConflict, "Claim is already "+claim.Status)
+		return
+	}
+
+	sendJSONSuccess(w, map[string]interface{}{"message": "Claim cancelled"})
+}
+
+func logOwnershipClaimReview(r *http.Request, moderatorID int, action string, claim *models.OwnershipClaim, details string) {
+	var adminEmail string
+	db.DB.QueryRow(ConvertPlaceholders("SELECT email FROM users WHERE id = ?"), moderatorID).Scan(&adminEmail)
+
+	CreateAdminLog(
+		moderatorID,
+		adminEmail,
+		action,
+		models.TargetOrganization,
+		claim.OrganizationID,
+		claim.OrganizationName,
+		strings.TrimSpace(details),
+		r.RemoteAddr,
+		r.Header.Get("User-Agent"),
+	)
+}
+
+const ownershipClaimSelect = `
+	SELECT c.id, c.organization_id, c.user_id, c.inn, c.ogrn, c.comment, c.status,
+	       c.reviewed_by, c.review_comment, c.reviewed_at, c.created_at,
+	       o.name, u.name || ' ' || COALESCE(u.last_name, ''), u.email
+	FROM organization_ownership_claims c
+	JOIN organizations o ON c.organization_id = o.id
+	JOIN users u ON c.user_id = u.id
+`
+
+func getOwnershipClaim(claimID int) (*models.OwnershipClaim, error) {
+	claims, err := queryOwnershipClaims(ownershipClaimSelect+" WHERE c.id = ?", claimID)
+	if err != nil {
+		return nil, err
+	}
+	if len(claims) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &claims[0], nil
+}
+
+func queryOwnershipClaims(query string, args ...interface{}) ([]models.OwnershipClaim, error) {
+	rows, err := db.DB.Query(ConvertPlaceholders(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	claims := []models.OwnershipClaim{}
+	for rows.Next() {
+		var c models.OwnershipClaim
+		var reviewedBy sql.NullInt64
+		var comment, reviewComment sql.NullString
+		var reviewedAt sql.NullTime
+		err := rows.Scan(
+			&c.ID, &c.OrganizationID, &c.UserID, &c.INN, &c.OGRN, &comment, &c.Status,
+			&reviewedBy, &reviewComment, &reviewedAt, &c.CreatedAt,
+			&c.OrganizationName, &c.UserName, &c.UserEmail,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if comment.Valid {
+			c.Comment = &comment.String
+		}
+		if reviewedBy.Valid {
+			id := int(reviewedBy.Int64)
+			c.ReviewedBy = &id
+		}
+		if reviewComment.Valid {
+			c.ReviewComment = &reviewComment.String
+		}
+		if reviewedAt.Valid {
+			c.ReviewedAt = &reviewedAt.Time
+		}
+		c.UserName = strings.TrimSpace(c.UserName)
+		claims = append(claims, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range claims {
+		documents, err := loadOwnershipClaimDocuments(claims[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		claims[i].Documents = documents
+	}
+
+	return claims, nil
+}
+
+func loadOwnershipClaimDocuments(claimID int) ([]models.UserMedia, error) {
+	rows, err := db.DB.Query(ConvertPlaceholders(`
+		SELECT m.id, m.user_id, m.file_name, m.original_name, m.file_path, m.file_size,
+		       m.mime_type, m.media_type, m.uploaded_at
+		FROM organization_ownership_claim_documents d
+		JOIN user_media m ON d.media_id = m.id
+		WHERE d.claim_id = ?
+		ORDER BY m.id
+	`), claimID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	documents := []models.UserMedia{}
+	for rows.Next() {
+		var m models.UserMedia
+		err := rows.Scan(&m.ID, &m.UserID, &m.FileName, &m.OriginalName, &m.FilePath, &m.FileSize,
+			&m.MimeType, &m.MediaType, &m.UploadedAt)
+		if err != nil {
+			return nil, err
+		}
+		m.URL = "/api/media/file/" + strconv.Itoa(m.ID)
+		documents = append(documents, m)
+	}
+
+	return documents, rows.Err()
+}
+
+func organizationHasOwner(orgID int) (bool, error) {
+	var hasOwner bool
+	err := db.DB.QueryRow(ConvertPlaceholders(`
+		SELECT EXISTS(SELECT 1 FROM organization_members WHERE organization_id = ? AND role = 'owner')
+	`), orgID).Scan(&hasOwner)
+	return hasOwner, err
+}
+
+func uniqueInts(values []int) []int {
+	seen := make(map[int]bool, len(values))
+	var result []int
+	for _, v := range values {
+		if v > 0 && !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func nullIfEmpty(s string) interface{} {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	return s
+}
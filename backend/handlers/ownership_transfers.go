@@ -0,0 +1,472 @@
+package handlers
+
+import (
+	"backend/db"
+	"backend/models"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Срок, в течение которого получатель может принять владение.
+// Отсчитывается от NOW() базы данных, с которым сравнивается при принятии и истечении
+const ownershipTransferTTL = 7 * 24 * time.Hour
+
+// OrganizationOwnershipTransfersHandler - передачи владения организации
+// GET /api/organizations/{id}/ownership-transfers - история (для владельцев)
+// POST /api/organizations/{id}/ownership-transfers - предложить передачу владения
+func OrganizationOwnershipTransfersHandler(w http.ResponseWriter, r *http.Request, orgID int) {
+	userID, ok := RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	if !isOrganizationOwner(orgID, userID) {
+		sendJSONError(w, http.StatusForbidden, "Only owners can manage ownership transfers")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		expireOwnershipTransfers()
+		transfers, err := queryOwnershipTransfers(ownershipTransferSelect+" WHERE t.organization_id = ? ORDER BY t.created_at DESC", orgID)
+		if err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+			return
+		}
+		sendJSONSuccess(w, transfers)
+	case http.MethodPost:
+		createOwnershipTransfer(w, r, orgID, userID)
+	default:
+		sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func createOwnershipTransfer(w http.ResponseWriter, r *http.Request, orgID, userID int) {
+	var req models.CreateOwnershipTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	if req.PreviousOwnerRole == "" {
+		req.PreviousOwnerRole = "admin"
+	}
+	switch req.PreviousOwnerRole {
+	case "owner", "admin", "member", "leave":
+	default:
+		sendJSONError(w, http.StatusBadRequest, "previous_owner_role must be owner, admin, member or leave")
+		return
+	}
+
+	if req.ToUserID == 0 || req.ToUserID == userID {
+		sendJSONError(w, http.StatusBadRequest, "to_user_id must be another user")
+		return
+	}
+
+	var exists bool
+	err := db.DB.QueryRow(ConvertPlaceholders("SELECT EXISTS(SELECT 1 FROM users WHERE id = ?)"), req.ToUserID).Scan(&exists)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	if !exists {
+		sendJSONError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	if isOrganizationOwner(orgID, req.ToUserID) {
+		sendJSONError(w, http.StatusBadRequest, "User is already an owner")
+		return
+	}
+
+	expireOwnershipTransfers()
+
+	var message *string
+	if strings.TrimSpace(req.Message) != "" {
+		message = &req.Message
+	}
+
+	var transferID int64
+	err = db.DB.QueryRow(ConvertPlaceholders(`
+		INSERT INTO organization_ownership_transfers
+			(organization_id, from_user_id, to_user_id, previous_owner_role, message, status, expires_at)
+		SELECT ?, ?, ?, ?, ?, 'pending', NOW() + CAST(? AS INTEGER) * INTERVAL '1 second'
+		WHERE NOT EXISTS (SELECT 1 FROM organization_ownership_transfers WHERE organization_id = ? AND status = 'pending')
+		RETURNING id
+	`), orgID, userID, req.ToUserID, req.PreviousOwnerRole, message, int(ownershipTransferTTL.Seconds()), orgID).Scan(&transferID)
+	// Параллельный запрос мог вставить свою передачу после проверки NOT EXISTS -
+	// тогда срабатывает уникальный индекс uniq_ownership_transfers_pending
+	if err == sql.ErrNoRows || isUniqueViolation(err) {
+		sendJSONError(w, http.StatusConflict, "There is already a pending ownership transfer for this organization")
+		return
+	}
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to create transfer: "+err.Error())
+		return
+	}
+
+	transfer, err := getOwnershipTransfer(int(transferID))
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load transfer: "+err.Error())
+		return
+	}
+
+	notifText := fmt.Sprintf("%s предлагает вам стать владельцем организации «%s»", transfer.FromUserName, transfer.OrganizationName)
+	notifHandler := &NotificationsHandler{DB: db.DB}
+	if err := notifHandler.CreateNotification(req.ToUserID, userID, "organization_ownership_transfer", "organization_ownership_transfer", transfer.ID, notifText); err != nil {
+		log.Printf("⚠️ Failed to notify user %d about ownership transfer %d: %v", req.ToUserID, transfer.ID, err)
+	}
+
+	log.Printf("📝 Ownership transfer %d: organization %d from user %d to user %d", transfer.ID, orgID, userID, req.ToUserID)
+	sendJSONSuccess(w, transfer)
+}
+
+// OwnershipTransferHandler - ответ на передачу владения
+// GET /api/organizations/ownership-transfers - входящие предложения текущего пользователя
+// POST /api/organizations/ownership-transfers/{id}/accept
+// POST /api/organizations/ownership-transfers/{id}/decline
+// DELETE /api/organizations/ownership-transfers/{id} - отмена инициатором
+func OwnershipTransferHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	expireOwnershipTransfers()
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 4 || parts[3] == "" {
+		if r.Method != http.MethodGet {
+			sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		transfers, err := queryOwnershipTransfers(ownershipTransferSelect+" WHERE t.to_user_id = ? AND t.status = 'pending' ORDER BY t.created_at DESC", userID)
+		if err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+			return
+		}
+		sendJSONSuccess(w, transfers)
+		return
+	}
+
+	transferID, err := strconv.Atoi(parts[3])
+	if err != nil {
+		sendJSONError(w, http.StatusBadRequest, "Invalid transfer ID")
+		return
+	}
+
+	transfer, err := getOwnershipTransfer(transferID)
+	if err == sql.ErrNoRows {
+		sendJSONError(w, http.StatusNotFound, "Transfer not found")
+		return
+	}
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+
+	action := ""
+	if len(parts) > 4 {
+		action = parts[4]
+	}
+
+	switch {
+	case action == "accept" && r.Method == http.MethodPost:
+		acceptOwnershipTransfer(w, r, transfer, userID)
+	case action == "decline" && r.Method == http.MethodPost:
+		finishOwnershipTransfer(w, transfer, userID, transfer.ToUserID, "declined")
+	case action == "" && r.Method == http.MethodDelete:
+		finishOwnershipTransfer(w, transfer, userID, transfer.FromUserID, "cancelled")
+	default:
+		sendJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// acceptOwnershipTransfer назначает получателя владельцем и меняет роль прежнего владельца
+func acceptOwnershipTransfer(w http.ResponseWriter, r *http.Request, transfer *models.OwnershipTransfer, userID int) {
+	if transfer.ToUserID != userID {
+		sendJSONError(w, http.StatusForbidden, "This transfer is not addressed to you")
+		return
+	}
+	if transfer.Status != "pending" {
+		sendJSONError(w, http.StatusConflict, "Transfer is already "+transfer.Status)
+		return
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	if err := lockOrganization(tx, transfer.OrganizationID); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+
+	result, err := tx.Exec(ConvertPlaceholders(`
+		UPDATE organization_ownership_transfers SET status = 'accepted', responded_at = NOW()
+		WHERE id = ? AND status = 'pending' AND expires_at > NOW()
+	`), transfer.ID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to update transfer: "+err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		sendJSONError(w, http.StatusConflict, "Transfer is no longer pending")
+		return
+	}
+
+	// Инициатор мог перестать быть владельцем, пока предложение ждало ответа
+	var fromRole string
+	err = tx.QueryRow(ConvertPlaceholders(`
+		SELECT role FROM organization_members WHERE organization_id = ? AND user_id = ?
+	`), transfer.OrganizationID, transfer.FromUserID).Scan(&fromRole)
+	if err != nil && err != sql.ErrNoRows {
+		sendJSONError(w, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	if fromRole != "owner" {
+		sendJSONError(w, http.StatusConflict, "The initiator is no longer an owner of this organization")
+		return
+	}
+
+	// Получатель становится владельцем (новым участником или с повышением роли)
+	result, err = tx.Exec(ConvertPlaceholders(`
+		UPDATE organization_members
+		SET role = 'owner', can_post = true, can_edit = true, can_manage_members = true
+		WHERE organization_id = ? AND user_id = ?
+	`), transfer.OrganizationID, transfer.ToUserID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to assign owner: "+err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		_, err = tx.Exec(ConvertPlaceholders(`
+			INSERT INTO organization_members (organization_id, user_id, role, can_post, can_edit, can_manage_members, joined_at)
+			VALUES (?, ?, 'owner', true, true, true, ?)
+		`), transfer.OrganizationID, transfer.ToUserID, time.Now())
+		if err != nil {
+			sendJSONError(w, http.StatusInternalServerError, "Failed to assign owner: "+err.Error())
+			return
+		}
+	}
+
+	// Прежний владелец: остаётся совладельцем, понижается или покидает организацию
+	switch transfer.PreviousOwnerRole {
+	case "admin":
+		_, err = tx.Exec(ConvertPlaceholders(`
+			UPDATE organization_members SET role = 'admin', can_post = true, can_edit = true, can_manage_members = true
+			WHERE organization_id = ? AND user_id = ?
+		`), transfer.OrganizationID, transfer.FromUserID)
+	case "member":
+		_, err = tx.Exec(ConvertPlaceholders(`
+			UPDATE organization_members SET role = 'member', can_post = false, can_edit = false, can_manage_members = false
+			WHERE organization_id = ? AND user_id = ?
+		`), transfer.OrganizationID, transfer.FromUserID)
+	case "leave":
+		_, err = tx.Exec(ConvertPlaceholders(`
+			DELETE FROM organization_members WHERE organization_id = ? AND user_id = ?
+		`), transfer.OrganizationID, transfer.FromUserID)
+	}
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to update previous owner: "+err.Error())
+		return
+	}
+
+	if err := syncOrganizationOwnerUserID(tx, transfer.OrganizationID, transfer.ToUserID); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to update organization: "+err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to accept transfer: "+err.Error())
+		return
+	}
+
+	log.Printf("✅ Ownership of organization %d transferred from user %d to user %d", transfer.OrganizationID, transfer.FromUserID, transfer.ToUserID)
+	syncOrganizationStaffChat(transfer.OrganizationID)
+
+	var userEmail string
+	db.DB.QueryRow(ConvertPlaceholders("SELECT email FROM users WHERE id = ?"), userID).Scan(&userEmail)
+	CreateAdminLog(
+		userID,
+		userEmail,
+		models.ActionTransferOwnership,
+		models.TargetOrganization,
+		transfer.OrganizationID,
+		transfer.OrganizationName,
+		fmt.Sprintf("Ownership transfer #%d accepted: from user_id=%d to user_id=%d, previous owner role: %s",
+			transfer.ID, transfer.FromUserID, transfer.ToUserID, transfer.PreviousOwnerRole),
+		r.RemoteAddr,
+		r.Header.Get("User-Agent"),
+	)
+
+	notifText := fmt.Sprintf("%s принял владение организацией «%s»", transfer.ToUserName, transfer.OrganizationName)
+	notifHandler := &NotificationsHandler{DB: db.DB}
+	if err := notifHandler.CreateNotification(transfer.FromUserID, userID, "organization_ownership_accepted", "organization", transfer.OrganizationID, notifText); err != nil {
+		log.Printf("⚠️ Failed to notify user %d about ownership transfer %d: %v", transfer.FromUserID, transfer.ID, err)
+	}
+
+	updated, err := getOwnershipTransfer(transfer.ID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to load transfer: "+err.Error())
+		return
+	}
+	sendJSONSuccess(w, updated)
+}
+
+// finishOwnershipTransfer - отклонение получателем или отмена инициатором
+func finishOwnershipTransfer(w http.ResponseWriter, transfer *models.OwnershipTransfer, userID, allowedUserID int, status string) {
+	if userID != allowedUserID {
+		sendJSONError(w, http.StatusForbidden, "Access denied")
+		return
+	}
+
+	result, err := db.DB.Exec(ConvertPlaceholders(`
+		UPDATE organization_ownership_transfers SET status = ?, responded_at = NOW()
+		WHERE id = ? AND status = 'pending'
+	`), status, transfer.ID)
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "Failed to update transfer: "+err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		sendJSONError(w, http.StatusConflict, "Transfer is already "+transfer.Status)
+		return
+	}
+
+	if status == "declined" {
+		notifText := fmt.Sprintf("%s отказался от владения организацией «%s»", transfer.ToUserName, transfer.OrganizationName)
+		notifHandler := &NotificationsHandler{DB: db.DB}
+		if err := notifHandler.CreateNotification(transfer.FromUserID, userID, "organization_ownership_declined", "organization", transfer.OrganizationID, notifText); err != nil {
+			log.Printf("⚠️ Failed to notify user %d about ownership transfer %d: %v", transfer.FromUserID, transfer.ID, err)
+		}
+	}
+
+	sendJSONSuccess(w, map[string]interface{}{"message": "Transfer " + status})
+}
+
+// lockOrganization блокирует строку организации до конца транзакции,
+// чтобы изменения состава владельцев выполнялись последовательно
+func lockOrganization(tx *sql.Tx, orgID int) error {
+	var id int
+	return tx.QueryRow(ConvertPlaceholders("SELECT id FROM organizations WHERE id = ? FOR UPDATE"), orgID).Scan(&id)
+}
+
+func countOrganizationOwners(tx *sql.Tx, orgID int) (int, error) {
+	var count int
+	err := tx.QueryRow(ConvertPlaceholders(`
+		SELECT COUNT(*) FROM organization_members WHERE organization_id = ? AND role = 'owner'
+	`), orgID).Scan(&count)
+	return count, err
+}
+
+// syncOrganizationOwnerUserID поддерживает organizations.owner_user_id в соответствии с участниками:
+// предпочитает preferredUserID, иначе - самого давнего из оставшихся владельцев
+func syncOrganizationOwnerUserID(tx *sql.Tx, orgID, preferredUserID int) error {
+	_, err := tx.Exec(ConvertPlaceholders(`
+		UPDATE organizations SET owner_user_id = (
+			SELECT user_id FROM organization_members
+			WHERE organization_id = ? AND role = 'owner'
+			ORDER BY (user_id = ?) DESC, joined_at ASC
+			LIMIT 1
+		), updated_at = NOW()
+		WHERE id = ?
+	`), orgID, preferredUserID, orgID)
+	return err
+}
+
+func isOrganizationOwner(orgID, userID int) bool {
+	var role string
+	err := db.DB.QueryRow(ConvertPlaceholders(`
+		SELECT role FROM organization_members WHERE organization_id = ? AND user_id = ?
+	`), orgID, userID).Scan(&role)
+	return err == nil && role == "owner"
+}
+
+func expireOwnershipTransfers() {
+	_, err := db.DB.Exec(`
+		UPDATE organization_ownership_transfers SET status = 'expired'
+		WHERE status = 'pending' AND expires_at <= NOW()
+	`)
+	if err != nil {
+		log.Printf("⚠️ Failed to expire ownership transfers: %v", err)
+	}
+}
+
+const ownershipTransferSelect = `
+	SELECT t.id, t.organization_id, t.from_user_id, t.to_user_id, t.previous_owner_role, t.message,
+	       t.status, t.expires_at, t.responded_at, t.created_at,
+	       o.name,
+	       fu.name || ' ' || COALESCE(fu.last_name, ''),
+	       tu.name || ' ' || COALESCE(tu.last_name, '')
+	FROM organization_ownership_transfers t
+	JOIN organizations o ON t.organization_id = o.id
+	JOIN users fu ON t.from_user_id = fu.id
+	JOIN users tu ON t.to_user_id = tu.id
+`
+
+func getOwnershipTransfer(transferID int) (*models.OwnershipTransfer, error) {
+	transfers, err := queryOwnershipTransfers(ownershipTransferSelect+" WHERE t.id = ?", transferID)
+	if err != nil {
+		return nil, err
+	}
+	if len(transfers) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &transfers[0], nil
+}
+
+func queryOwnershipTransfers(query string, args ...interface{}) ([]models.OwnershipTransfer, error) {
+	rows, err := db.DB.Query(ConvertPlaceholders(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transfers := []models.OwnershipTransfer{}
+	for rows.Next() {
+		var t models.OwnershipTransfer
+		var message sql.NullString
+		var respondedAt sql.NullTime
+		err := rows.Scan(
+			&t.ID, &t.OrganizationID, &t.FromUserID, &t.ToUserID, &t.PreviousOwnerRole, &message,
+			&t.Status, &t.ExpiresAt, &respondedAt, &t.CreatedAt,
+			&t.OrganizationName, &t.FromUserName, &t.ToUserName,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if message.Valid {
+			t.Message = &message.String
+		}
+		if respondedAt.Valid {
+			t.RespondedAt = &respondedAt.Time
+		}
+		t.FromUserName = strings.TrimSpace(t.FromUserName)
+		t.ToUserName = strings.TrimSpace(t.ToUserName)
+		transfers = append(transfers, t)
+	}
+
+	return transfers, rows.Err()
+}
+
+// logOrganizationOwnerChange записывает в журнал администратора изменение состава владельцев
+// (понижение или удаление владельца другим владельцем)
+func logOrganizationOwnerChange(r *http.Request, userID, orgID int, action, details string) {
+	var userEmail, orgName string
+	db.DB.QueryRow(ConvertPlaceholders("SELECT email FROM users WHERE id = ?"), userID).Scan(&userEmail)
+	db.DB.QueryRow(ConvertPlaceholders("SELECT name FROM organizations WHERE id = ?"), orgID).Scan(&orgName)
+
+	if err := CreateAdminLog(userID, userEmail, action, models.TargetOrganization, orgID, orgName, details,
+		r.RemoteAddr, r.Header.Get("User-Agent")); err != nil {
+		log.Printf("⚠️ Failed to log owner change in organization %d: %v", orgID, err)
+	}
+}
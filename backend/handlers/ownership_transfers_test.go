@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestCreateOwnershipTransferConflicts(t *testing.T) {
+	tests := []struct {
+		name       string
+		insertErr  error // nil - NOT EXISTS отсёк вставку, строк нет
+		wantStatus int
+	}{
+		{"pending transfer exists", nil, http.StatusConflict},
+		{"concurrent transfer hits unique index", &pq.Error{Code: "23505", Constraint: "uniq_ownership_transfers_pending"}, http.StatusConflict},
+		{"other database error", errors.New("connection reset"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ttlArg driver.Value
+			useFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+				switch {
+				case strings.Contains(query, "SELECT role FROM organization_members"):
+					// Владелец - только инициатор
+					if args[1] == int64(1) {
+						return &fakeResult{rows: [][]driver.Value{{"owner"}}}, nil
+					}
+				case strings.Contains(query, "FROM users WHERE id"):
+					return &fakeResult{rows: [][]driver.Value{{true}}}, nil
+				case strings.Contains(query, "INSERT INTO organization_ownership_transfers"):
+					ttlArg = args[5]
+					if tt.insertErr != nil {
+						return nil, tt.insertErr
+					}
+					return &fakeResult{}, nil
+				}
+				return &fakeResult{}, nil
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/organizations/3/ownership-transfers", strings.NewReader(`{"to_user_id":2}`))
+			req = req.WithContext(context.WithValue(req.Context(), "userID", 1))
+			rec := httptest.NewRecorder()
+			OrganizationOwnershipTransfersHandler(rec, req, 3)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			// Срок считается в базе от NOW(), из Go передаётся только длительность
+			if ttlArg != int64(ownershipTransferTTL.Seconds()) {
+				t.Errorf("expires_at argument %#v, want TTL in seconds", ttlArg)
+			}
+		})
+	}
+}
@@ -0,0 +1,479 @@
+package handlers
+
+import (
+	"backend/db"
+	"backend/logger"
+	"backend/mail"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Самостоятельный сброс пароля:
+//
+//	POST /api/auth/password/forgot {"email": "..."}            - письмо со ссылкой (ответ одинаковый, есть аккаунт или нет)
+//	POST /api/auth/password/reset  {"token": "...", "password": "..."}
+//
+// В БД хранится только SHA-256 токена; токен одноразовый и действует passwordResetTokenTTL.
+// После смены пароля все сеансы пользователя отзываются (users.sessions_revoked_at, см. middleware.IsTokenRevoked).
+const (
+	passwordResetTokenTTL = time.Hour
+	passwordMinLength     = 8
+	passwordMaxBytes      = 72 // Ограничение bcrypt
+
+	// Ограничения частоты запросов (password_reset_attempts)
+	passwordForgotPerIP    = 5
+	passwordForgotPerEmail = 3
+	passwordResetPerIP     = 10
+	passwordRateWindow     = 15 * time.Minute
+	passwordEmailWindow    = time.Hour
+)
+
+const passwordForgotResponse = "Если аккаунт с таким email существует, мы отправили на него письмо со ссылкой для смены пароля"
+
+type forgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// ForgotPasswordHandler - POST /api/auth/password/forgot
+func ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req forgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	if email == "" || !strings.Contains(email, "@") {
+		sendError(w, "Укажите email", http.StatusBadRequest)
+		return
+	}
+
+	ip := clientIP(r)
+	// Лимит по email считается для любого адреса, поэтому 429 не выдаёт, есть ли аккаунт
+	if !takePasswordAttempt(w, "forgot", ip,
+		passwordRateLimit{column: "ip_address", value: ip, limit: passwordForgotPerIP, window: passwordRateWindow},
+		passwordRateLimit{column: "email_hash", value: hashEmail(email), limit: passwordForgotPerEmail, window: passwordEmailWindow},
+	) {
+		return
+	}
+
+	// Письмо готовится в фоне: время ответа не зависит от того, есть ли аккаунт
+	go sendPasswordResetEmail(email, ip)
+
+	sendSuccess(w, map[string]string{"message": passwordForgotResponse})
+}
+
+// ResetPasswordHandler - POST /api/auth/password/reset
+func ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req resetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	ip := clientIP(r)
+	if !takePasswordAttempt(w, "reset", ip,
+		passwordRateLimit{column: "ip_address", value: ip, limit: passwordResetPerIP, window: passwordRateWindow},
+	) {
+		return
+	}
+
+	if req.Token == "" {
+		sendError(w, "Ссылка недействительна или устарела", http.StatusBadRequest)
+		return
+	}
+	if len([]rune(req.Password)) < passwordMinLength {
+		sendError(w, fmt.Sprintf("Пароль должен быть не короче %d символов", passwordMinLength), http.StatusBadRequest)
+		return
+	}
+	if len(req.Password) > passwordMaxBytes {
+		sendError(w, "Пароль слишком длинный", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := consumePasswordResetToken(hashToken(req.Token), req.Password)
+	if err == errInvalidResetToken {
+		logger.SecurityEvent("password_reset_invalid_token", "Попытка сброса пароля с недействительным токеном", ip, nil)
+		sendError(w, "Ссылка недействительна или устарела", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Password reset failed: %v", err)
+		sendError(w, "Failed to reset password", http.StatusInternalServerError)
+		return
+	}
+
+	logger.SecurityEvent("password_reset", "Пароль сброшен по ссылке из письма, все сеансы отозваны", ip, &userID)
+	CreateUserLog(db.DB, userID, "password_reset", "Пароль сброшен по ссылке из письма", ip, r.Header.Get("User-Agent"))
+
+	// Открытые вкладки получают событие и отключаются
+	DisconnectUser(userID, "password_reset")
+
+	var name, email string
+	if err := db.DB.QueryRow(ConvertPlaceholders("SELECT name, email FROM users WHERE id = ?"), userID).Scan(&name, &email); err == nil {
+		if _, err := mail.Enqueue(email, mail.TemplatePasswordChanged, mail.DefaultLocale, map[string]interface{}{
+			"Name": name,
+			"URL":  mail.AppURL() + "/forgot-password",
+		}); err != nil {
+			log.Printf("⚠️ Failed to enqueue password changed email for user %d: %v", userID, err)
+		}
+	}
+
+	// Текущий браузер тоже выходит: старый токен уже отозван
+	http.SetCookie(w, &http.Cookie{
+		Name:     "auth_token",
+		Value:    "",
+		Path:     "/",
+		Domain:   "localhost",
+		HttpOnly: true,
+		Secure:   false,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   -1,
+	})
+
+	sendSuccess(w, map[string]string{"message": "Пароль изменён. Войдите с новым паролем"})
+}
+
+var errInvalidResetToken = fmt.Errorf("invalid or expired reset token")
+
+// hashResetPassword - bcrypt нового пароля (в тестах подменяется)
+var hashResetPassword = func(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hashed), err
+}
+
+// consumePasswordResetToken меняет пароль по токену и гасит его вместе с остальными токенами пользователя.
+// Пароль хешируется только после проверки токена: иначе любой мог бы нагружать CPU запросами с мусорными токенами
+func consumePasswordResetToken(tokenHash, password string) (int, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Блокировка строки: параллельный запрос с тем же токеном дождётся и увидит used_at
+	var tokenID, userID int
+	var expiresAt time.Time
+	var usedAt sql.NullTime
+	err = tx.QueryRow(ConvertPlaceholders(`
+		SELECT id, user_id, expires_at, used_at FROM password_reset_tokens WHERE token_hash = ? FOR UPDATE
+	`), tokenHash).Scan(&tokenID, &userID, &expiresAt, &usedAt)
+	if err == sql.ErrNoRows {
+		return 0, errInvalidResetToken
+	}
+	if err != nil {
+		return 0, err
+	}
+	// Время в таблицах - TIMESTAMP без зоны в UTC (lib/pq читает его как UTC)
+	now := time.Now().UTC()
+	if usedAt.Valid || now.After(expiresAt) {
+		return 0, errInvalidResetToken
+	}
+
+	hashedPassword, err := hashResetPassword(password)
+	if err != nil {
+		return 0, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	if _, err := tx.Exec(ConvertPlaceholders(`
+		UPDATE users SET password = ?, sessions_revoked_at = ? WHERE id = ?
+	`), hashedPassword, now, userID); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(ConvertPlaceholders(`
+		UPDATE password_reset_tokens SET used_at = ? WHERE user_id = ? AND used_at IS NULL
+	`), now, userID); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
+
+// sendPasswordResetEmail создаёт токен и ставит письмо в очередь, если аккаунт существует
+func sendPasswordResetEmail(email, ip string) {
+	var userID int
+	var name, userEmail string
+	err := db.DB.QueryRow(ConvertPlaceholders(`
+		SELECT id, name, email FROM users WHERE LOWER(email) = ?
+	`), email).Scan(&userID, &name, &userEmail)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("❌ Password reset: failed to find user: %v", err)
+		}
+		return
+	}
+
+	token, err := generateResetToken()
+	if err != nil {
+		log.Printf("❌ Password reset: failed to generate token: %v", err)
+		return
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Printf("❌ Password reset: %v", err)
+		return
+	}
+	defer tx.Rollback()
+
+	// Действует только последняя ссылка
+	now := time.Now().UTC()
+	if _, err := tx.Exec(ConvertPlaceholders(`
+		UPDATE password_reset_tokens SET used_at = ? WHERE user_id = ? AND used_at IS NULL
+	`), now, userID); err != nil {
+		log.Printf("❌ Password reset: failed to invalidate old tokens: %v", err)
+		return
+	}
+	if _, err := tx.Exec(ConvertPlaceholders(`
+		INSERT INTO password_reset_tokens (user_id, token_hash, expires_at, request_ip, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`), userID, hashToken(token), now.Add(passwordResetTokenTTL), ip, now); err != nil {
+		log.Printf("❌ Password reset: failed to save token: %v", err)
+		return
+	}
+
+	// Письмо в той же транзакции: ссылка не уйдёт, если токен не сохранился
+	_, err = mail.EnqueueTx(tx, userEmail, mail.TemplatePasswordReset, mail.DefaultLocale, map[string]interface{}{
+		"Name":      name,
+		"URL":       mail.AppURL() + "/reset-password?token=" + token,
+		"ExpiresIn": "1 час",
+	})
+	if err != nil {
+		log.Printf("❌ Password reset: failed to enqueue email: %v", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("❌ Password reset: %v", err)
+		return
+	}
+
+	logger.SecurityEvent("password_reset_requested", "Запрошена ссылка для сброса пароля", ip, &userID)
+}
+
+// passwordRateLimit - лимит попыток по одному столбцу password_reset_attempts (ip_address или email_hash)
+type passwordRateLimit struct {
+	column string
+	value  string
+	limit  int
+	window time.Duration
+}
+
+// takePasswordAttempt проверяет лимиты и сразу записывает попытку. Проверка и запись идут в одной транзакции
+// под advisory-блокировкой каждого ключа, иначе параллельные запросы успевали бы пройти проверку до записи.
+// При превышении отвечает 429, при сбое БД - 503
+func takePasswordAttempt(w http.ResponseWriter, action, ip string, limits ...passwordRateLimit) bool {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return passwordRateLimitFailed(w, err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	emailHash := ""
+	for _, l := range limits {
+		if l.column == "email_hash" {
+			emailHash = l.value
+		}
+		// Ключи блокируются в порядке limits - у всех вызовов он одинаковый
+		if _, err := tx.Exec(ConvertPlaceholders(`SELECT pg_advisory_xact_lock(hashtext(?))`),
+			"password_"+action+":"+l.column+":"+l.value); err != nil {
+			return passwordRateLimitFailed(w, err)
+		}
+
+		var count int
+		var oldest sql.NullTime
+		err := tx.QueryRow(ConvertPlaceholders(fmt.Sprintf(`
+			SELECT COUNT(*), MIN(created_at) FROM password_reset_attempts
+			WHERE action = ? AND %s = ? AND created_at > ?
+		`, l.column)), action, l.value, now.Add(-l.window)).Scan(&count, &oldest)
+		if err != nil {
+			return passwordRateLimitFailed(w, err)
+		}
+		if count >= l.limit {
+			retryAfter := l.window
+			if oldest.Valid {
+				retryAfter = oldest.Time.Add(l.window).Sub(now)
+			}
+			w.Header().Set("Retry-After", fmt.Sprintf("%d", int(retryAfter.Seconds())+1))
+			logger.SecurityEvent("password_"+action+"_rate_limited", "Превышен лимит запросов сброса пароля", ip, nil)
+			sendError(w, "Слишком много попыток. Попробуйте позже", http.StatusTooManyRequests)
+			return false
+		}
+	}
+
+	if _, err := tx.Exec(ConvertPlaceholders(`
+		INSERT INTO password_reset_attempts (action, ip_address, email_hash, created_at) VALUES (?, ?, ?, ?)
+	`), action, ip, nullIfEmpty(emailHash), now); err != nil {
+		return passwordRateLimitFailed(w, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return passwordRateLimitFailed(w, err)
+	}
+	return true
+}
+
+// passwordRateLimitFailed - сбой БД не должен открывать перебор
+func passwordRateLimitFailed(w http.ResponseWriter, err error) bool {
+	log.Printf("❌ Password rate limit check failed: %v", err)
+	sendError(w, "Попробуйте позже", http.StatusServiceUnavailable)
+	return false
+}
+
+// StartPasswordResetCleanup периодически удаляет старые токены и записи о попытках
+func StartPasswordResetCleanup(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			cutoff := time.Now().UTC().Add(-24 * time.Hour)
+			if _, err := db.DB.Exec(ConvertPlaceholders("DELETE FROM password_reset_tokens WHERE expires_at < ?"), cutoff); err != nil {
+				log.Printf("⚠️ Password reset cleanup failed: %v", err)
+			}
+			if _, err := db.DB.Exec(ConvertPlaceholders("DELETE FROM password_reset_attempts WHERE created_at < ?"), cutoff); err != nil {
+				log.Printf("⚠️ Password reset cleanup failed: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+func generateResetToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func hashEmail(email string) string {
+	return hashToken(strings.ToLower(strings.TrimSpace(email)))
+}
+
+// clientIP - адрес клиента для лимитов и журнала. X-Forwarded-For и X-Real-IP присылает сам клиент,
+// поэтому им верим, только если запрос пришёл с адреса из TRUSTED_PROXIES (Gateway)
+func clientIP(r *http.Request) string {
+	return clientIPFrom(r, trustedProxyNets())
+}
+
+var (
+	trustedProxiesOnce sync.Once
+	trustedProxies     []*net.IPNet
+)
+
+// trustedProxyNets - TRUSTED_PROXIES: адреса или подсети через запятую (читается при первом запросе, после .env)
+func trustedProxyNets() []*net.IPNet {
+	trustedProxiesOnce.Do(func() {
+		trustedProxies = parseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+	})
+	return trustedProxies
+}
+
+func parseTrustedProxies(value string) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			log.Printf("⚠️ TRUSTED_PROXIES: invalid entry %q: %v", entry, err)
+			continue
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets
+}
+
+func isTrustedProxy(ip string, proxies []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, ipNet := range proxies {
+		if ipNet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIPFrom - адрес клиента при заданных доверенных прокси. X-Forwarded-For разбирается справа налево:
+// последний адрес дописал наш прокси, а всё левее первого недоверенного адреса мог подставить клиент
+func clientIPFrom(r *http.Request, proxies []*net.IPNet) string {
+	remote := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		remote = host
+	}
+	if !isTrustedProxy(remote, proxies) {
+		return remote
+	}
+
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		client := remote
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				// Мусор в заголовке - дальше верить цепочке нельзя
+				break
+			}
+			client = hop
+			if !isTrustedProxy(hop, proxies) {
+				break
+			}
+		}
+		return client
+	}
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+	return remote
+}
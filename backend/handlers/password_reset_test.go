@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"backend/middleware"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestClientIPFrom(t *testing.T) {
+	gateway := parseTrustedProxies("10.0.0.0/8, 192.168.1.5")
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		realIP     string
+		proxies    string
+		want       string
+	}{
+		{"no proxy", "203.0.113.7:5123", nil, "", "", "203.0.113.7"},
+		{"spoofed header without trusted proxies", "203.0.113.7:5123", []string{"1.2.3.4"}, "", "", "203.0.113.7"},
+		{"spoofed real ip without trusted proxies", "203.0.113.7:5123", nil, "1.2.3.4", "", "203.0.113.7"},
+		{"spoofed header from untrusted address", "203.0.113.7:5123", []string{"1.2.3.4"}, "", "gateway", "203.0.113.7"},
+		{"gateway appends client", "10.0.0.2:4000", []string{"203.0.113.7"}, "", "gateway", "203.0.113.7"},
+		{"client prepends fake hops", "10.0.0.2:4000", []string{"1.2.3.4, 5.6.7.8, 203.0.113.7"}, "", "gateway", "203.0.113.7"},
+		{"fake hops in separate header", "10.0.0.2:4000", []string{"1.2.3.4", "203.0.113.7"}, "", "gateway", "203.0.113.7"},
+		{"client spoofs trusted address", "10.0.0.2:4000", []string{"10.0.0.9, 203.0.113.7"}, "", "gateway", "203.0.113.7"},
+		{"chain of trusted proxies", "10.0.0.2:4000", []string{"203.0.113.7, 192.168.1.5"}, "", "gateway", "203.0.113.7"},
+		{"garbage hop", "10.0.0.2:4000", []string{"203.0.113.7, not-an-ip"}, "", "gateway", "10.0.0.2"},
+		{"real ip from gateway", "10.0.0.2:4000", nil, "203.0.113.7", "gateway", "203.0.113.7"},
+		{"ipv6 client", "[2001:db8::1]:443", []string{"1.2.3.4"}, "", "gateway", "2001:db8::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/auth/password/forgot", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+
+			proxies := gateway
+			if tt.proxies == "" {
+				proxies = nil
+			}
+			if got := clientIPFrom(req, proxies); got != tt.want {
+				t.Errorf("clientIPFrom = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTakePasswordAttempt(t *testing.T) {
+	ipLimit := passwordRateLimit{column: "ip_address", value: "203.0.113.7", limit: 5, window: 15 * time.Minute}
+	emailLimit := passwordRateLimit{column: "email_hash", value: "abc", limit: 3, window: time.Hour}
+
+	tests := []struct {
+		name           string
+		limits         []passwordRateLimit
+		counts         map[string]int64 // столбец -> число попыток в окне
+		oldestAgo      time.Duration
+		dbErr          error
+		wantAllowed    bool
+		wantStatus     int
+		wantRetryAfter int
+		wantInserted   bool
+	}{
+		{"below limit", []passwordRateLimit{ipLimit}, map[string]int64{"ip_address": 4}, 10 * time.Minute, nil, true, http.StatusOK, 0, true},
+		{"limit reached", []passwordRateLimit{ipLimit}, map[string]int64{"ip_address": 5}, 10 * time.Minute, nil, false, http.StatusTooManyRequests, 301, false},
+		{"limit by email", []passwordRateLimit{ipLimit, emailLimit}, map[string]int64{"ip_address": 1, "email_hash": 3}, 30 * time.Minute, nil, false, http.StatusTooManyRequests, 1801, false},
+		{"both below limit", []passwordRateLimit{ipLimit, emailLimit}, map[string]int64{"ip_address": 1, "email_hash": 2}, time.Minute, nil, true, http.StatusOK, 0, true},
+		{"database error", []passwordRateLimit{ipLimit}, nil, 0, errors.New("connection refused"), false, http.StatusServiceUnavailable, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var statements []string
+			var inserted []driver.Value
+			useFakeDB(t, func(q string, args []driver.Value) (*fakeResult, error) {
+				switch {
+				case strings.Contains(q, "pg_advisory_xact_lock"):
+					statements = append(statements, "lock "+args[0].(string))
+				case strings.Contains(q, "SELECT COUNT(*)"):
+					if tt.dbErr != nil {
+						return nil, tt.dbErr
+					}
+					column := "ip_address"
+					if strings.Contains(q, "email_hash = ?") {
+						column = "email_hash"
+					}
+					statements = append(statements, "count "+column)
+					return &fakeResult{rows: [][]driver.Value{{tt.counts[column], time.Now().UTC().Add(-tt.oldestAgo)}}}, nil
+				case strings.Contains(q, "INSERT INTO password_reset_attempts"):
+					statements = append(statements, "insert")
+					inserted = args
+				}
+				return &fakeResult{}, nil
+			})
+
+			rec := httptest.NewRecorder()
+			allowed := takePasswordAttempt(rec, "forgot", "203.0.113.7", tt.limits...)
+
+			if allowed != tt.wantAllowed {
+				t.Fatalf("allowed = %v, want %v (%v)", allowed, tt.wantAllowed, statements)
+			}
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if (inserted != nil) != tt.wantInserted {
+				t.Errorf("attempt recorded = %v, want %v", inserted != nil, tt.wantInserted)
+			}
+			// Каждый ключ блокируется до подсчёта, запись - после всех проверок
+			for i, l := range tt.limits {
+				if tt.dbErr != nil || 2*i+1 >= len(statements) {
+					break
+				}
+				if statements[2*i] != "lock password_forgot:"+l.column+":"+l.value || statements[2*i+1] != "count "+l.column {
+					t.Fatalf("statements %v: %s is not locked before counting", statements, l.column)
+				}
+			}
+			if tt.wantInserted && len(tt.limits) == 2 && inserted[2] != "abc" {
+				t.Errorf("email hash recorded as %v", inserted[2])
+			}
+			if tt.wantRetryAfter > 0 {
+				got, _ := strconv.Atoi(rec.Header().Get("Retry-After"))
+				if got < tt.wantRetryAfter-2 || got > tt.wantRetryAfter {
+					t.Errorf("Retry-After %d, want about %d", got, tt.wantRetryAfter)
+				}
+			}
+		})
+	}
+}
+
+// countPasswordHashes подменяет bcrypt до конца теста и считает вызовы
+func countPasswordHashes(t *testing.T) *int {
+	t.Helper()
+	calls := 0
+	prev := hashResetPassword
+	hashResetPassword = func(password string) (string, error) {
+		calls++
+		return "hashed:" + password, nil
+	}
+	t.Cleanup(func() { hashResetPassword = prev })
+	return &calls
+}
+
+func TestConsumePasswordResetToken(t *testing.T) {
+	now := time.Now().UTC()
+
+	tests := []struct {
+		name        string
+		token       []driver.Value // nil - токен не найден
+		wantErr     error
+		wantUpdates int
+	}{
+		{"unknown token", nil, errInvalidResetToken, 0},
+		{"used token", []driver.Value{int64(1), int64(7), now.Add(time.Hour), now.Add(-time.Minute)}, errInvalidResetToken, 0},
+		{"expired token", []driver.Value{int64(1), int64(7), now.Add(-time.Minute), nil}, errInvalidResetToken, 0},
+		{"valid token", []driver.Value{int64(1), int64(7), now.Add(time.Hour), nil}, nil, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hashes := countPasswordHashes(t)
+			var updates []string
+			var newPassword driver.Value
+			useFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+				switch {
+				case strings.Contains(query, "FROM password_reset_tokens WHERE token_hash"):
+					if tt.token == nil {
+						return &fakeResult{}, nil
+					}
+					return &fakeResult{rows: [][]driver.Value{tt.token}}, nil
+				case strings.HasPrefix(query, "UPDATE"):
+					updates = append(updates, query)
+					if strings.Contains(query, "UPDATE users") {
+						newPassword = args[0]
+					}
+				}
+				return &fakeResult{affected: 1}, nil
+			})
+
+			userID, err := consumePasswordResetToken(hashToken("token"), "new-password")
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if len(updates) != tt.wantUpdates {
+				t.Fatalf("updates = %q, want %d", updates, tt.wantUpdates)
+			}
+			if tt.wantErr != nil {
+				// Недействительный токен отсекается до bcrypt
+				if *hashes != 0 {
+					t.Fatalf("password hashed %d times for invalid token", *hashes)
+				}
+				return
+			}
+			if userID != 7 {
+				t.Errorf("userID = %d, want 7", userID)
+			}
+			if *hashes != 1 || newPassword != "hashed:new-password" {
+				t.Errorf("password %v hashed %d times", newPassword, *hashes)
+			}
+			// Пароль меняется вместе с отзывом сеансов, а гасятся все токены пользователя
+			if !strings.Contains(updates[0], "sessions_revoked_at") {
+				t.Errorf("password update %q does not revoke sessions", updates[0])
+			}
+			if !strings.Contains(updates[1], "WHERE user_id = ? AND used_at IS NULL") {
+				t.Errorf("token update %q does not invalidate all user tokens", updates[1])
+			}
+		})
+	}
+}
+
+// asTimestamp - значение после записи в TIMESTAMP без зоны и чтения через lib/pq:
+// часы сохраняются как есть, а смещение теряется и при чтении считается UTC
+func asTimestamp(v time.Time) time.Time {
+	return time.Date(v.Year(), v.Month(), v.Day(), v.Hour(), v.Minute(), v.Second(), v.Nanosecond(), time.UTC)
+}
+
+func TestPasswordResetRevokesSessionsOnNonUTCServer(t *testing.T) {
+	for _, offset := range []int{5, -7} {
+		t.Run(fmt.Sprintf("UTC%+d", offset), func(t *testing.T) {
+			prevLocal := time.Local
+			time.Local = time.FixedZone("server", offset*3600)
+			t.Cleanup(func() { time.Local = prevLocal })
+			countPasswordHashes(t)
+
+			var revokedAt time.Time
+			useFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+				switch {
+				case strings.Contains(query, "FROM password_reset_tokens WHERE token_hash"):
+					// Токен выдан 10 минут назад и хранится так же, как его записал sendPasswordResetEmail
+					expiresAt := asTimestamp(time.Now().UTC().Add(50 * time.Minute))
+					return &fakeResult{rows: [][]driver.Value{{int64(1), int64(7), expiresAt, nil}}}, nil
+				case strings.Contains(query, "UPDATE users SET password"):
+					revokedAt = asTimestamp(args[1].(time.Time))
+				case strings.Contains(query, "SELECT sessions_revoked_at"):
+					return &fakeResult{rows: [][]driver.Value{{revokedAt}}}, nil
+				}
+				return &fakeResult{affected: 1}, nil
+			})
+
+			if _, err := consumePasswordResetToken(hashToken("token"), "new-password"); err != nil {
+				t.Fatalf("consume: %v", err)
+			}
+
+			oldToken := jwt.MapClaims{"iat": float64(time.Now().Add(-time.Minute).Unix())}
+			newToken := jwt.MapClaims{"iat": float64(time.Now().Add(time.Second).Unix())}
+			if !middleware.IsTokenRevoked(7, oldToken) {
+				t.Error("token issued before the reset is still valid")
+			}
+			if middleware.IsTokenRevoked(7, newToken) {
+				t.Error("token issued after the reset is revoked")
+			}
+		})
+	}
+}
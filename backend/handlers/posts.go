@@ -570,6 +570,12 @@ func createPost(w http.ResponseWriter, r *http.Request) {
 		authorID = *req.OrganizationID
 	}
 
+	// Позиции списка нужд можно привязать только к посту своей организации
+	if err := validatePostWishlistItems(authorType, authorID, req.WishlistItems); err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	query := `INSERT INTO posts (author_id, author_type, content, attached_pets, attachments, tags, status, scheduled_at, location_lat, location_lon, location_name) 
 	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
@@ -600,6 +606,13 @@ func createPost(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Привязываем позиции списка нужд организации
+	if len(req.WishlistItems) > 0 {
+		if err := replaceLinkedWishlistItems("post_wishlist_items", "post_id", int(postID), uniqueInts(req.WishlistItems)); err != nil {
+			log.Printf("⚠️ createPost: failed to attach wishlist items to post %d: %v", postID, err)
+		}
+	}
+
 	// Создаем опрос, если он есть
 	if req.Poll != nil {
 		err := createPollForPost(int(postID), req.Poll)
@@ -694,6 +707,13 @@ func updatePost(w http.ResponseWriter, r *http.Request, postID int) {
 		log.Printf("      - multiple_choice: %v", req.Poll.MultipleChoice)
 	}
 
+	if req.WishlistItems != nil {
+		if err := validatePostWishlistItems(post.AuthorType, post.AuthorID, req.WishlistItems); err != nil {
+			sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Сериализуем массивы в JSON
 	attachedPetsJSON, _ := json.Marshal(req.AttachedPets)
 	attachmentsJSON, _ := json.Marshal(req.Attachments)
@@ -717,6 +737,13 @@ func updatePost(w http.ResponseWriter, r *http.Request, postID int) {
 	}
 	log.Printf("✅ updatePost: post_pets updated")
 
+	// Обновляем привязку позиций списка нужд (только если поле передано)
+	if req.WishlistItems != nil {
+		if err := replaceLinkedWishlistItems("post_wishlist_items", "post_id", postID, uniqueInts(req.WishlistItems)); err != nil {
+			log.Printf("❌ updatePost: Error updating wishlist items: %v", err)
+		}
+	}
+
 	// Создаем опрос, если он есть (только если опроса еще нет)
 	if req.Poll != nil {
 		log.Printf("📊 updatePost: Poll data received, checking if poll exists...")
@@ -962,6 +989,16 @@ func getPostByID(postID int, userID int) (models.Post, error) {
 		post.HasPoll = false
 	}
 
+	// Загружаем привязанные позиции списка нужд
+	if post.AuthorType == "organization" {
+		items, err := loadLinkedWishlistItems("post_wishlist_items", "post_id", postID)
+		if err != nil {
+			log.Printf("⚠️ getPostByID: failed to load wishlist items for post %d: %v", postID, err)
+		} else if len(items) > 0 {
+			post.WishlistItems = items
+		}
+	}
+
 	return post, nil
 }
 
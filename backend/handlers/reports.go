@@ -8,12 +8,22 @@ import (
 )
 
 type CreateReportRequest struct {
-	TargetType  string `json:"target_type"` // post, comment, user, organization, pet
+	TargetType  string `json:"target_type"` // post, comment, user, organization, pet, organization_review
 	TargetID    int    `json:"target_id"`
 	Reason      string `json:"reason"` // spam, harassment, violence, etc.
 	Description string `json:"description"`
 }
 
+// reportTargetTypes - объекты, на которые можно пожаловаться
+var reportTargetTypes = map[string]bool{
+	"post":                true,
+	"comment":             true,
+	"user":                true,
+	"organization":        true,
+	"pet":                 true,
+	"organization_review": true,
+}
+
 // CreateReportHandler - создать жалобу
 func CreateReportHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -40,6 +50,10 @@ func CreateReportHandler(w http.ResponseWriter, r *http.Request) {
 		sendErrorResponse(w, "Заполните все обязательные поля", http.StatusBadRequest)
 		return
 	}
+	if !reportTargetTypes[req.TargetType] {
+		sendErrorResponse(w, "Неизвестный тип объекта жалобы", http.StatusBadRequest)
+		return
+	}
 
 	// Проверяем, не жаловался ли пользователь уже на этот объект
 	var existingReport int
@@ -0,0 +1,580 @@
+package handlers
+
+import (
+	"backend/db"
+	"backend/models"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AnnouncementSearchSessionsHandler - поисковые выходы по объявлению о потере
+// GET  /api/announcements/{id}/search-sessions
+// POST /api/announcements/{id}/search-sessions
+func AnnouncementSearchSessionsHandler(w http.ResponseWriter, r *http.Request, announcementID int) {
+	switch r.Method {
+	case http.MethodGet:
+		handleGetSearchSessions(w, announcementID)
+	case http.MethodPost:
+		handleCreateSearchSession(w, r, announcementID)
+	default:
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// AnnouncementSearchCoverageHandler - сводка прочёсанных участков по всем выходам
+// GET /api/announcements/{id}/search-coverage
+func AnnouncementSearchCoverageHandler(w http.ResponseWriter, r *http.Request, announcementID int) {
+	if r.Method != http.MethodGet {
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	summary, err := buildSearchCoverageSummary("s.announcement_id = ?", announcementID)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sendSuccess(w, summary)
+}
+
+// SearchSessionHandler - работа с конкретным поисковым выходом
+// GET  /api/search-sessions/{id}
+// PUT  /api/search-sessions/{id}
+// POST /api/search-sessions/{id}/signup, DELETE /api/search-sessions/{id}/signup
+// POST /api/search-sessions/{id}/check-in
+// POST /api/search-sessions/{id}/check-out
+// GET  /api/search-sessions/{id}/reports, POST /api/search-sessions/{id}/reports
+// GET  /api/search-sessions/{id}/summary
+func SearchSessionHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 3 {
+		sendError(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+	sessionID, err := strconv.Atoi(parts[2])
+	if err != nil {
+		sendError(w, "Invalid search session ID", http.StatusBadRequest)
+		return
+	}
+
+	action := ""
+	if len(parts) > 3 {
+		action = parts[3]
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		handleGetSearchSession(w, sessionID)
+	case action == "" && r.Method == http.MethodPut:
+		handleUpdateSearchSession(w, r, sessionID)
+	case action == "signup" && r.Method == http.MethodPost:
+		handleSearchSessionSignup(w, r, sessionID)
+	case action == "signup" && r.Method == http.MethodDelete:
+		handleSearchSessionWithdraw(w, r, sessionID)
+	case action == "check-in" && r.Method == http.MethodPost:
+		handleSearchSessionCheck(w, r, sessionID, "checked_in")
+	case action == "check-out" && r.Method == http.MethodPost:
+		handleSearchSessionCheck(w, r, sessionID, "checked_out")
+	case action == "reports" && r.Method == http.MethodGet:
+		handleGetCoverageReports(w, sessionID)
+	case action == "reports" && r.Method == http.MethodPost:
+		handleCreateCoverageReport(w, r, sessionID)
+	case action == "summary" && r.Method == http.MethodGet:
+		summary, err := buildSearchCoverageSummary("s.id = ?", sessionID)
+		if err != nil {
+			sendError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sendSuccess(w, summary)
+	default:
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleGetSearchSessions - список выходов по объявлению
+func handleGetSearchSessions(w http.ResponseWriter, announcementID int) {
+	rows, err := db.DB.Query(ConvertPlaceholders(`
+		SELECT s.id, s.announcement_id, s.leader_id, s.starts_at, s.meeting_point, s.meeting_lat, s.meeting_lon,
+		       s.area_description, s.area_geojson, s.status, s.notes, s.created_at, s.updated_at,
+		       (SELECT COUNT(*) FROM search_session_participants p WHERE p.session_id = s.id),
+		       u.name, u.last_name, u.avatar
+		FROM announcement_search_sessions s
+		LEFT JOIN users u ON s.leader_id = u.id
+		WHERE s.announcement_id = ?
+		ORDER BY s.starts_at ASC
+	`), announcementID)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	sessions := []models.SearchSession{}
+	for rows.Next() {
+		s, err := scanSearchSession(rows)
+		if err != nil {
+			sendError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sessions = append(sessions, *s)
+	}
+
+	sendSuccess(w, sessions)
+}
+
+// handleGetSearchSession - выход вместе со списком участников
+func handleGetSearchSession(w http.ResponseWriter, sessionID int) {
+	session, err := getSearchSession(sessionID)
+	if err == sql.ErrNoRows {
+		sendError(w, "Search session not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := db.DB.Query(ConvertPlaceholders(`
+		SELECT p.id, p.session_id, p.user_id, p.status, p.signed_up_at, p.checked_in_at, p.checked_out_at,
+		       u.name, u.last_name, u.avatar
+		FROM search_session_participants p
+		JOIN users u ON p.user_id = u.id
+		WHERE p.session_id = ?
+		ORDER BY p.signed_up_at ASC
+	`), sessionID)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	session.Participants = []models.SearchSessionParticipant{}
+	for rows.Next() {
+		var p models.SearchSessionParticipant
+		var user models.User
+		var lastName, avatar sql.NullString
+		if err := rows.Scan(&p.ID, &p.SessionID, &p.UserID, &p.Status, &p.SignedUpAt, &p.CheckedInAt, &p.CheckedOutAt,
+			&user.Name, &lastName, &avatar); err != nil {
+			sendError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		user.ID = p.UserID
+		user.LastName = lastName.String
+		user.Avatar = avatar.String
+		p.User = &user
+		session.Participants = append(session.Participants, p)
+	}
+
+	sendSuccess(w, session)
+}
+
+// handleCreateSearchSession - создать выход (только волонтёры и верифицированные пользователи)
+func handleCreateSearchSession(w http.ResponseWriter, r *http.Request, announcementID int) {
+	userID, ok := RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	var announcementType, announcementStatus string
+	err := db.DB.QueryRow(ConvertPlaceholders("SELECT type, status FROM pet_announcements WHERE id = ?"), announcementID).
+		Scan(&announcementType, &announcementStatus)
+	if err != nil {
+		sendError(w, "Announcement not found", http.StatusNotFound)
+		return
+	}
+	if announcementType != "lost" {
+		sendError(w, "Search sessions are only available for lost announcements", http.StatusBadRequest)
+		return
+	}
+	if announcementStatus != "active" {
+		sendError(w, "Announcement is closed", http.StatusBadRequest)
+		return
+	}
+
+	if !hasRole(db.DB, userID, models.RoleVolunteer) && !isUserVerified(db.DB, userID) {
+		sendError(w, "Only volunteers or verified users can lead search sessions", http.StatusForbidden)
+		return
+	}
+
+	var req models.CreateSearchSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.StartsAt == "" || req.MeetingPoint == "" || req.AreaDescription == "" {
+		sendError(w, "starts_at, meeting_point and area_description are required", http.StatusBadRequest)
+		return
+	}
+	startsAt, err := time.Parse(time.RFC3339, req.StartsAt)
+	if err != nil {
+		sendError(w, "starts_at must be in RFC3339 format", http.StatusBadRequest)
+		return
+	}
+	// starts_at - TIMESTAMP без зоны: храним UTC, иначе смещение клиента теряется
+	startsAt = startsAt.UTC()
+
+	var id int64
+	err = db.DB.QueryRow(ConvertPlaceholders(`
+		INSERT INTO announcement_search_sessions (
+			announcement_id, leader_id, starts_at, meeting_point, meeting_lat, meeting_lon,
+			area_description, area_geojson, notes
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id
+	`), announcementID, userID, startsAt, req.MeetingPoint, req.MeetingLat, req.MeetingLon,
+		req.AreaDescription, rawJSONOrNil(req.AreaGeoJSON), req.Notes).Scan(&id)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Организатор сразу числится участником
+	_, err = db.DB.Exec(ConvertPlaceholders(`
+		INSERT INTO search_session_participants (session_id, user_id) VALUES (?, ?)
+	`), id, userID)
+	if err != nil {
+		log.Printf("⚠️ Failed to add leader %d to search session %d: %v", userID, id, err)
+	}
+
+	log.Printf("✅ Search session %d created for announcement %d by user %d", id, announcementID, userID)
+	sendSuccess(w, map[string]interface{}{"id": id, "message": "Search session created successfully"})
+}
+
+// handleUpdateSearchSession - смена статуса выхода (только организатор)
+func handleUpdateSearchSession(w http.ResponseWriter, r *http.Request, sessionID int) {
+	userID, ok := RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	session, err := getSearchSession(sessionID)
+	if err != nil {
+		sendError(w, "Search session not found", http.StatusNotFound)
+		return
+	}
+	if session.LeaderID != userID {
+		sendError(w, "Access denied", http.StatusForbidden)
+		return
+	}
+
+	var req models.UpdateSearchSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	validStatuses := map[string]bool{"planned": true, "active": true, "finished": true, "cancelled": true}
+	if req.Status != nil && !validStatuses[*req.Status] {
+		sendError(w, "Invalid status", http.StatusBadRequest)
+		return
+	}
+
+	query := "UPDATE announcement_search_sessions SET updated_at = ?"
+	args := []interface{}{time.Now()}
+	if req.Status != nil {
+		query += ", status = ?"
+		args = append(args, *req.Status)
+	}
+	if req.Notes != nil {
+		query += ", notes = ?"
+		args = append(args, *req.Notes)
+	}
+	query += " WHERE id = ?"
+	args = append(args, sessionID)
+
+	if _, err := db.DB.Exec(ConvertPlaceholders(query), args...); err != nil {
+		sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sendSuccess(w, map[string]string{"message": "Search session updated successfully"})
+}
+
+// handleSearchSessionSignup - записаться на выход
+func handleSearchSessionSignup(w http.ResponseWriter, r *http.Request, sessionID int) {
+	userID, ok := RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	session, err := getSearchSession(sessionID)
+	if err != nil {
+		sendError(w, "Search session not found", http.StatusNotFound)
+		return
+	}
+	if session.Status == "finished" || session.Status == "cancelled" {
+		sendError(w, "Search session is closed", http.StatusBadRequest)
+		return
+	}
+
+	result, err := db.DB.Exec(ConvertPlaceholders(`
+		INSERT INTO search_session_participants (session_id, user_id) VALUES (?, ?)
+		ON CONFLICT (session_id, user_id) DO NOTHING
+	`), sessionID, userID)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		sendError(w, "You are already signed up", http.StatusConflict)
+		return
+	}
+
+	sendSuccess(w, map[string]string{"message": "Signed up successfully"})
+}
+
+// handleSearchSessionWithdraw - отказаться от участия (до отметки о прибытии)
+func handleSearchSessionWithdraw(w http.ResponseWriter, r *http.Request, sessionID int) {
+	userID, ok := RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := db.DB.Exec(ConvertPlaceholders(`
+		DELETE FROM search_session_participants
+		WHERE session_id = ? AND user_id = ? AND status = 'signed_up'
+		AND user_id <> (SELECT leader_id FROM announcement_search_sessions WHERE id = ?)
+	`), sessionID, userID, sessionID)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		sendError(w, "Nothing to withdraw from", http.StatusBadRequest)
+		return
+	}
+
+	sendSuccess(w, map[string]string{"message": "Withdrawn successfully"})
+}
+
+// handleSearchSessionCheck - отметка о прибытии на место сбора или уходе с поиска
+func handleSearchSessionCheck(w http.ResponseWriter, r *http.Request, sessionID int, newStatus string) {
+	userID, ok := RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	session, err := getSearchSession(sessionID)
+	if err != nil {
+		sendError(w, "Search session not found", http.StatusNotFound)
+		return
+	}
+	if session.Status == "finished" || session.Status == "cancelled" {
+		sendError(w, "Search session is closed", http.StatusBadRequest)
+		return
+	}
+
+	var query, fromStatus string
+	if newStatus == "checked_in" {
+		fromStatus = "signed_up"
+		query = "UPDATE search_session_participants SET status = 'checked_in', checked_in_at = ? WHERE session_id = ? AND user_id = ? AND status = ?"
+	} else {
+		fromStatus = "checked_in"
+		query = "UPDATE search_session_participants SET status = 'checked_out', checked_out_at = ? WHERE session_id = ? AND user_id = ? AND status = ?"
+	}
+
+	// Отметка и запуск выхода - одна транзакция: участник не отмечается в выходе, который не стал активным
+	tx, err := db.DB.Begin()
+	if err != nil {
+		sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(ConvertPlaceholders(query), time.Now(), sessionID, userID, fromStatus)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		sendError(w, "You must be "+strings.ReplaceAll(fromStatus, "_", " ")+" first", http.StatusBadRequest)
+		return
+	}
+
+	// Первый прибывший запускает выход
+	if newStatus == "checked_in" && session.Status == "planned" {
+		if _, err := tx.Exec(ConvertPlaceholders(`
+			UPDATE announcement_search_sessions SET status = 'active', updated_at = ? WHERE id = ? AND status = 'planned'
+		`), time.Now(), sessionID); err != nil {
+			log.Printf("❌ Failed to start search session %d: %v", sessionID, err)
+			sendError(w, "Failed to start search session", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sendSuccess(w, map[string]string{"message": "Status updated", "status": newStatus})
+}
+
+// handleGetCoverageReports - отчёты участников по выходу
+func handleGetCoverageReports(w http.ResponseWriter, sessionID int) {
+	rows, err := db.DB.Query(ConvertPlaceholders(`
+		SELECT c.id, c.session_id, c.user_id, c.area_name, c.area_geojson, c.found_traces, c.notes, c.created_at,
+		       u.name, u.last_name, u.avatar
+		FROM search_coverage_reports c
+		JOIN users u ON c.user_id = u.id
+		WHERE c.session_id = ?
+		ORDER BY c.created_at ASC
+	`), sessionID)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	reports := []models.SearchCoverageReport{}
+	for rows.Next() {
+		var c models.SearchCoverageReport
+		var user models.User
+		var lastName, avatar sql.NullString
+		if err := rows.Scan(&c.ID, &c.SessionID, &c.UserID, &c.AreaName, &c.AreaGeoJSON, &c.FoundTraces, &c.Notes, &c.CreatedAt,
+			&user.Name, &lastName, &avatar); err != nil {
+			sendError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		user.ID = c.UserID
+		user.LastName = lastName.String
+		user.Avatar = avatar.String
+		c.User = &user
+		reports = append(reports, c)
+	}
+
+	sendSuccess(w, reports)
+}
+
+// handleCreateCoverageReport - отчёт о прочёсанном участке (только отметившиеся участники)
+func handleCreateCoverageReport(w http.ResponseWriter, r *http.Request, sessionID int) {
+	userID, ok := RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	var status string
+	err := db.DB.QueryRow(ConvertPlaceholders(`
+		SELECT status FROM search_session_participants WHERE session_id = ? AND user_id = ?
+	`), sessionID, userID).Scan(&status)
+	if err != nil || status == "signed_up" {
+		sendError(w, "Only checked-in participants can post coverage reports", http.StatusForbidden)
+		return
+	}
+
+	var req models.CreateCoverageReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.AreaName) == "" {
+		sendError(w, "area_name is required", http.StatusBadRequest)
+		return
+	}
+
+	var id int64
+	err = db.DB.QueryRow(ConvertPlaceholders(`
+		INSERT INTO search_coverage_reports (session_id, user_id, area_name, area_geojson, found_traces, notes)
+		VALUES (?, ?, ?, ?, ?, ?) RETURNING id
+	`), sessionID, userID, strings.TrimSpace(req.AreaName), rawJSONOrNil(req.AreaGeoJSON), req.FoundTraces, req.Notes).Scan(&id)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sendSuccess(w, map[string]interface{}{"id": id, "message": "Coverage report created successfully"})
+}
+
+// buildSearchCoverageSummary собирает сводку по участкам для выходов, отобранных условием
+func buildSearchCoverageSummary(where string, arg int) (*models.SearchCoverageSummary, error) {
+	summary := &models.SearchCoverageSummary{Areas: []models.SearchCoverageArea{}}
+
+	err := db.DB.QueryRow(ConvertPlaceholders(`
+		SELECT
+			COUNT(DISTINCT s.id),
+			COUNT(DISTINCT p.id),
+			COUNT(DISTINCT CASE WHEN p.status <> 'signed_up' THEN p.id END)
+		FROM announcement_search_sessions s
+		LEFT JOIN search_session_participants p ON p.session_id = s.id
+		WHERE `+where), arg).Scan(&summary.SessionsCount, &summary.ParticipantsCount, &summary.CheckedInCount)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.DB.Query(ConvertPlaceholders(`
+		SELECT c.area_name, COUNT(*), BOOL_OR(c.found_traces), MAX(c.created_at)
+		FROM search_coverage_reports c
+		JOIN announcement_search_sessions s ON c.session_id = s.id
+		WHERE `+where+`
+		GROUP BY c.area_name
+		ORDER BY MAX(c.created_at) DESC
+	`), arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var area models.SearchCoverageArea
+		if err := rows.Scan(&area.AreaName, &area.ReportsCount, &area.FoundTraces, &area.LastCoverage); err != nil {
+			return nil, err
+		}
+		summary.ReportsCount += area.ReportsCount
+		summary.Areas = append(summary.Areas, area)
+	}
+
+	return summary, nil
+}
+
+// getSearchSession загружает выход по ID
+func getSearchSession(sessionID int) (*models.SearchSession, error) {
+	row := db.DB.QueryRow(ConvertPlaceholders(`
+		SELECT s.id, s.announcement_id, s.leader_id, s.starts_at, s.meeting_point, s.meeting_lat, s.meeting_lon,
+		       s.area_description, s.area_geojson, s.status, s.notes, s.created_at, s.updated_at,
+		       (SELECT COUNT(*) FROM search_session_participants p WHERE p.session_id = s.id),
+		       u.name, u.last_name, u.avatar
+		FROM announcement_search_sessions s
+		LEFT JOIN users u ON s.leader_id = u.id
+		WHERE s.id = ?
+	`), sessionID)
+	return scanSearchSession(row)
+}
+
+// scanSearchSession сканирует выход вместе с данными организатора
+func scanSearchSession(row interface {
+	Scan(dest ...interface{}) error
+}) (*models.SearchSession, error) {
+	var s models.SearchSession
+	var name, lastName, avatar sql.NullString
+
+	err := row.Scan(
+		&s.ID, &s.AnnouncementID, &s.LeaderID, &s.StartsAt, &s.MeetingPoint, &s.MeetingLat, &s.MeetingLon,
+		&s.AreaDescription, &s.AreaGeoJSON, &s.Status, &s.Notes, &s.CreatedAt, &s.UpdatedAt,
+		&s.ParticipantsCount,
+		&name, &lastName, &avatar,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if name.Valid {
+		s.Leader = &models.User{ID: s.LeaderID, Name: name.String, LastName: lastName.String, Avatar: avatar.String}
+	}
+
+	return &s, nil
+}
+
+// rawJSONOrNil передаёт JSON в JSONB-колонку строкой (или NULL)
+func rawJSONOrNil(raw *json.RawMessage) interface{} {
+	if raw == nil || len(*raw) == 0 || string(*raw) == "null" {
+		return nil
+	}
+	return string(*raw)
+}
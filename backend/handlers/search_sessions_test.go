@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSearchSessionCheckIn(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     string
+		startErr   error
+		wantStatus int
+		wantStmts  []string
+	}{
+		{"first arrival starts the session", "planned", nil, http.StatusOK,
+			[]string{"BEGIN", "check in", "start session", "COMMIT"}},
+		{"session already active", "active", nil, http.StatusOK,
+			[]string{"BEGIN", "check in", "COMMIT"}},
+		{"failed start rolls back the check-in", "planned", errors.New("connection reset"), http.StatusInternalServerError,
+			[]string{"BEGIN", "check in", "start session", "ROLLBACK"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stmts []string
+			useFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+				switch {
+				case query == "BEGIN" || query == "COMMIT" || query == "ROLLBACK":
+					stmts = append(stmts, query)
+				case strings.Contains(query, "FROM announcement_search_sessions s"):
+					now := time.Now().UTC()
+					return &fakeResult{rows: [][]driver.Value{{
+						int64(3), int64(5), int64(1), now, "У входа в парк", nil, nil,
+						"Парк", nil, tt.status, nil, now, now,
+						int64(2), "Анна", nil, nil,
+					}}}, nil
+				case strings.HasPrefix(query, "UPDATE search_session_participants SET status = 'checked_in'"):
+					stmts = append(stmts, "check in")
+					return &fakeResult{affected: 1}, nil
+				case strings.HasPrefix(query, "UPDATE announcement_search_sessions SET status = 'active'"):
+					stmts = append(stmts, "start session")
+					if tt.startErr != nil {
+						return nil, tt.startErr
+					}
+					return &fakeResult{affected: 1}, nil
+				}
+				return &fakeResult{}, nil
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/search-sessions/3/check-in", nil)
+			req = req.WithContext(context.WithValue(req.Context(), "userID", 2))
+			rec := httptest.NewRecorder()
+			handleSearchSessionCheck(rec, req, 3, "checked_in")
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if strings.Join(stmts, ", ") != strings.Join(tt.wantStmts, ", ") {
+				t.Errorf("statements %q, want %q", stmts, tt.wantStmts)
+			}
+		})
+	}
+}
@@ -321,3 +321,9 @@ func hasModeratorRights(db *sql.DB, userID int) bool {
 
 	return err == nil && count > 0
 }
+
+func isUserVerified(db *sql.DB, userID int) bool {
+	var verified bool
+	err := db.QueryRow(ConvertPlaceholders("SELECT verified FROM users WHERE id = ?"), userID).Scan(&verified)
+	return err == nil && verified
+}
@@ -4,9 +4,12 @@ import (
 	"database/sql"
 	"log"
 	"net/http"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 )
 
@@ -36,6 +39,7 @@ var upgrader = websocket.Upgrader{
 type WebSocketMessage struct {
 	Type string      `json:"type"` // "unread_count", "new_message", etc.
 	Data interface{} `json:"data"`
+	Seq  int64       `json:"seq,omitempty"` // Номер события пользователя для повтора (websocket_replay.go)
 }
 
 // Client - WebSocket клиент
@@ -43,16 +47,38 @@ type Client struct {
 	UserID int
 	Conn   *websocket.Conn
 	Send   chan WebSocketMessage
+
+	presenceSubscriptions map[int]bool      // На чей онлайн-статус подписан (под hub.mu)
+	lastTypingAt          map[int]time.Time // chatID -> последний typing_start (только в readPump)
+
+	// Пока идёт повтор пропущенных событий, живые события копятся в pending
+	replayMu  sync.Mutex
+	replaying bool
+	pending   []WebSocketMessage
+	// ?since= при подключении; -1 - клиент не передал since
+	replaySince int64
 }
 
-// Hub - управляет WebSocket подключениями
+// Hub - управляет WebSocket подключениями.
+// У пользователя может быть несколько подключений (вкладки, мобильное приложение) - события получают все.
 type Hub struct {
-	clients    map[int]*Client // userID -> Client
-	register   chan *Client
-	unregister chan *Client
-	broadcast  chan WebSocketMessage
-	mu         sync.RWMutex
-	db         *sql.DB
+	clients     map[int]map[*Client]bool // userID -> подключения пользователя
+	connections int                      // Всего подключений
+	register    chan *Client
+	unregister  chan *Client
+	broadcast   chan WebSocketMessage
+	mu          sync.RWMutex
+	db          *sql.DB
+
+	presenceSubscribers map[int]map[*Client]bool // userID -> подписанные на его статус подключения
+
+	// Порядок доставки событий по seq (websocket_replay.go). Берётся раньше mu, не наоборот
+	orderMu sync.Mutex
+	order   map[int]*seqOrder
+
+	// Шина между экземплярами backend (WS_BACKPLANE) и ID этого экземпляра в ней
+	backplane  Backplane
+	instanceID string
 }
 
 var hub *Hub
@@ -60,12 +86,29 @@ var hub *Hub
 // InitWebSocketHub - инициализирует WebSocket hub
 func InitWebSocketHub(db *sql.DB) {
 	hub = &Hub{
-		clients:    make(map[int]*Client),
+		clients:    make(map[int]map[*Client]bool),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
 		broadcast:  make(chan WebSocketMessage),
 		db:         db,
+
+		presenceSubscribers: make(map[int]map[*Client]bool),
+		order:               make(map[int]*seqOrder),
+		instanceID:          uuid.New().String(),
+	}
+
+	backplane, err := newBackplaneFromEnv(db, hub.instanceID)
+	if err != nil {
+		log.Printf("⚠️ WebSocket backplane unavailable, events stay on this instance: %v", err)
+		backplane = NewMemoryBackplane(NewMemoryBus())
+	}
+	if err := backplane.Start(hub.receiveFromBackplane); err != nil {
+		log.Printf("⚠️ WebSocket backplane failed to start: %v", err)
 	}
+	hub.backplane = backplane
+	log.Printf("🔌 WebSocket backplane: %T (instance %s)", backplane, hub.instanceID)
+
+	hub.startReplayCleanup(time.Hour)
 	go hub.run()
 }
 
@@ -75,62 +118,188 @@ func (h *Hub) run() {
 		select {
 		case client := <-h.register:
 			h.mu.Lock()
-			h.clients[client.UserID] = client
+			conns := h.clients[client.UserID]
+			if conns == nil {
+				conns = make(map[*Client]bool)
+				h.clients[client.UserID] = conns
+			}
+			conns[client] = true
+			h.connections++
+			if len(conns) == 1 {
+				h.backplane.Subscribe(client.UserID)
+			}
+			userConnections, users, total := len(conns), len(h.clients), h.connections
 			h.mu.Unlock()
-			log.Printf("🔌 WebSocket: User %d connected (total: %d)", client.UserID, len(h.clients))
+			log.Printf("🔌 WebSocket: User %d connected (user connections: %d, users: %d, connections: %d)",
+				client.UserID, userConnections, users, total)
 
-			// Отправляем текущее количество непрочитанных сообщений
+			// Досылаем пропущенные события и текущее количество непрочитанных сообщений и уведомлений
+			go h.replay(client, client.replaySince)
 			go h.sendUnreadCount(client.UserID)
+			go sendNotificationUnreadCount(h.db, client.UserID)
+			if userConnections == 1 {
+				go h.publishPresence(client.UserID)
+			}
 
 		case client := <-h.unregister:
+			// Подключение могло быть уже удалено как медленное - тогда removeClientLocked ничего не делает
 			h.mu.Lock()
-			if _, ok := h.clients[client.UserID]; ok {
-				delete(h.clients, client.UserID)
-				close(client.Send)
-			}
+			removed, lastConnection := h.removeClientLocked(client)
+			users, total := len(h.clients), h.connections
 			h.mu.Unlock()
-			log.Printf("🔌 WebSocket: User %d disconnected (total: %d)", client.UserID, len(h.clients))
+			if removed {
+				log.Printf("🔌 WebSocket: User %d disconnected (users: %d, connections: %d)", client.UserID, users, total)
+			}
+			if lastConnection {
+				go h.publishPresence(client.UserID)
+			}
 
 		case message := <-h.broadcast:
-			// Broadcast to all clients
-			h.mu.RLock()
-			for _, client := range h.clients {
-				select {
-				case client.Send <- message:
-				default:
-					close(client.Send)
-					delete(h.clients, client.UserID)
+			// Broadcast to all clients; переполненные подключения отключаются
+			var offline []int
+			h.mu.Lock()
+			for _, conns := range h.clients {
+				for client := range conns {
+					if !client.enqueue(message) {
+						log.Printf("⚠️ WebSocket: send buffer full for user %d, dropping connection", client.UserID)
+						if _, last := h.removeClientLocked(client); last {
+							offline = append(offline, client.UserID)
+						}
+					}
 				}
 			}
-			h.mu.RUnlock()
+			h.mu.Unlock()
+			for _, userID := range offline {
+				go h.publishPresence(userID)
+			}
 		}
 	}
 }
 
-// sendUnreadCount - отправляет количество непрочитанных сообщений пользователю
-func (h *Hub) sendUnreadCount(userID int) {
-	var count int
-	err := h.db.QueryRow(`
-		SELECT COUNT(*)
-		FROM messages
-		WHERE receiver_id = $1 AND is_read = FALSE
-	`, userID).Scan(&count)
+// removeClientLocked удаляет подключение и закрывает его канал (writePump завершится и закроет соединение,
+// readPump получит ошибку чтения). Вызывается под h.mu.Lock.
+// Возвращает, было ли подключение удалено и было ли оно последним у пользователя.
+func (h *Hub) removeClientLocked(client *Client) (removed, lastConnection bool) {
+	conns := h.clients[client.UserID]
+	if !conns[client] {
+		return false, false
+	}
 
-	if err != nil {
-		log.Printf("❌ Error getting unread count for user %d: %v", userID, err)
-		return
+	delete(conns, client)
+	close(client.Send)
+	h.connections--
+
+	subscriptions := make([]int, 0, len(client.presenceSubscriptions))
+	for id := range client.presenceSubscriptions {
+		subscriptions = append(subscriptions, id)
 	}
+	h.removePresenceSubscriptionsLocked(client, subscriptions)
+
+	if len(conns) == 0 {
+		delete(h.clients, client.UserID)
+		h.backplane.Unsubscribe(client.UserID)
+		return true, true
+	}
+	return true, false
+}
+
+// dropClient отключает подключение, которое не успевает читать события
+func (h *Hub) dropClient(client *Client) {
+	h.mu.Lock()
+	removed, lastConnection := h.removeClientLocked(client)
+	h.mu.Unlock()
+
+	if removed {
+		log.Printf("⚠️ WebSocket: send buffer full for user %d, dropping connection", client.UserID)
+	}
+	if lastConnection {
+		go h.publishPresence(client.UserID)
+	}
+}
+
+// deliverNow отправляет событие во все подключения пользователя, не блокируясь на медленных.
+// Порядок seq соблюдает deliver (websocket_replay.go).
+func (h *Hub) deliverNow(userID int, message WebSocketMessage) {
+	var slow []*Client
 
 	h.mu.RLock()
-	client, ok := h.clients[userID]
+	for client := range h.clients[userID] {
+		if !client.enqueue(message) {
+			slow = append(slow, client)
+		}
+	}
 	h.mu.RUnlock()
 
-	if ok {
-		client.Send <- WebSocketMessage{
-			Type: "unread_count",
-			Data: map[string]int{"count": count},
+	for _, client := range slow {
+		h.dropClient(client)
+	}
+}
+
+// dispatch доставляет событие локальным подключениям пользователя и публикует его для остальных экземпляров.
+// Неэфемерные события получают seq и сохраняются для повтора при переподключении.
+func (h *Hub) dispatch(userID int, message WebSocketMessage) {
+	h.dispatchMany([]int{userID}, message)
+}
+
+// dispatchMany - то же для рассылки одного события нескольким пользователям (участникам чата):
+// seq для всех получателей выдаются одной транзакцией
+func (h *Hub) dispatchMany(userIDs []int, message WebSocketMessage) {
+	if len(userIDs) == 0 {
+		return
+	}
+
+	var seqs map[int]int64
+	if !ephemeralEventTypes[message.Type] {
+		var err error
+		seqs, err = h.recordEvents(userIDs, message)
+		if err != nil {
+			// Событие всё равно доставляется, но повторить его не получится
+			log.Printf("⚠️ WebSocket replay: failed to record %s for %d users: %v", message.Type, len(userIDs), err)
 		}
 	}
+
+	recipients := uniqueInts(userIDs)
+	for _, userID := range recipients {
+		userMessage := message
+		userMessage.Seq = seqs[userID]
+		h.deliver(userID, userMessage)
+	}
+	// Остальным экземплярам - одной публикацией на всех получателей
+	if err := h.backplane.Publish(recipients, message, seqs); err != nil {
+		log.Printf("⚠️ WebSocket backplane: failed to publish %s for %d users: %v", message.Type, len(recipients), err)
+	}
+}
+
+// receiveFromBackplane - событие с другого экземпляра: только локальная доставка
+func (h *Hub) receiveFromBackplane(userID int, message WebSocketMessage) {
+	if message.Type == presenceChangedEvent {
+		if changedID, ok := presenceChangedUserID(message.Data); ok {
+			go h.notifyPresenceSubscribers(changedID)
+		}
+		return
+	}
+	if userID == BroadcastUserID {
+		h.broadcast <- message
+		return
+	}
+	h.deliver(userID, message)
+	if message.Type == sessionRevokedEvent {
+		h.disconnectUser(userID)
+	}
+}
+
+// sendUnreadCount - отправляет количество непрочитанных сообщений пользователю
+func (h *Hub) sendUnreadCount(userID int) {
+	count, err := countUnreadMessages(h.db, userID)
+	if err != nil {
+		log.Printf("❌ Error getting unread count for user %d: %v", userID, err)
+		return
+	}
+
+	h.dispatch(userID, WebSocketMessage{
+		Type: "unread_count",
+		Data: map[string]int{"count": count},
+	})
 }
 
 // NotifyUnreadCount - уведомляет пользователя об изменении количества непрочитанных
@@ -141,21 +310,72 @@ func NotifyUnreadCount(userID int) {
 	go hub.sendUnreadCount(userID)
 }
 
-// NotifyNewMessage - уведомляет пользователя о новом сообщении
+// NotifyNewMessage - уведомляет пользователя о новом сообщении (во всех его подключениях)
 func NotifyNewMessage(userID int, message interface{}) {
 	if hub == nil {
 		return
 	}
 
-	hub.mu.RLock()
-	client, ok := hub.clients[userID]
-	hub.mu.RUnlock()
+	hub.dispatch(userID, WebSocketMessage{
+		Type: "new_message",
+		Data: message,
+	})
+}
 
-	if ok {
-		client.Send <- WebSocketMessage{
-			Type: "new_message",
-			Data: message,
-		}
+// NotifyUsers - отправляет одно событие нескольким пользователям (участникам чата и т.п.)
+func NotifyUsers(userIDs []int, messageType string, data interface{}) {
+	if hub == nil {
+		return
+	}
+
+	hub.dispatchMany(userIDs, WebSocketMessage{
+		Type: messageType,
+		Data: data,
+	})
+}
+
+// NotifyUser - отправляет пользователю произвольное событие (во все его подключения)
+func NotifyUser(userID int, messageType string, data interface{}) {
+	if hub == nil {
+		return
+	}
+
+	hub.dispatch(userID, WebSocketMessage{
+		Type: messageType,
+		Data: data,
+	})
+}
+
+// sessionRevokedEvent - сеансы пользователя отозваны (сброс пароля); после него подключения закрываются
+// на всех экземплярах
+const sessionRevokedEvent = "session_revoked"
+
+// DisconnectUser сообщает всем подключениям пользователя об отзыве сеансов и закрывает их
+func DisconnectUser(userID int, reason string) {
+	if hub == nil {
+		return
+	}
+
+	hub.dispatch(userID, WebSocketMessage{
+		Type: sessionRevokedEvent,
+		Data: map[string]string{"reason": reason},
+	})
+	hub.disconnectUser(userID)
+}
+
+// disconnectUser закрывает локальные подключения пользователя; уже поставленные в очередь события writePump успеет отправить
+func (h *Hub) disconnectUser(userID int) {
+	h.mu.Lock()
+	var removed bool
+	for client := range h.clients[userID] {
+		ok, _ := h.removeClientLocked(client)
+		removed = removed || ok
+	}
+	h.mu.Unlock()
+
+	if removed {
+		log.Printf("🔌 WebSocket: disconnected user %d (sessions revoked)", userID)
+		go h.publishPresence(userID)
 	}
 }
 
@@ -169,6 +389,8 @@ func HandleWebSocket(db *sql.DB) http.HandlerFunc {
 			r.URL.Query().Get("token"))
 
 		// Получаем userID из контекста (установлен middleware)
+		var conn *websocket.Conn
+		var err error
 		userID, ok := r.Context().Value("userID").(int)
 		if !ok || userID == 0 {
 			log.Printf("❌ WebSocket: No userID in context")
@@ -178,8 +400,19 @@ func HandleWebSocket(db *sql.DB) http.HandlerFunc {
 
 		log.Printf("✅ WebSocket: userID=%d from context", userID)
 
+		// ?since=<seq> - последний полученный клиентом seq, пропущенные события будут досланы
+		var since int64
+		sinceParam := r.URL.Query().Get("since")
+		if sinceParam != "" {
+			since, err = strconv.ParseInt(sinceParam, 10, 64)
+			if err != nil || since < 0 {
+				http.Error(w, "Invalid since", http.StatusBadRequest)
+				return
+			}
+		}
+
 		// Upgrade HTTP connection to WebSocket
-		conn, err := upgrader.Upgrade(w, r, nil)
+		conn, err = upgrader.Upgrade(w, r, nil)
 		if err != nil {
 			log.Printf("❌ WebSocket upgrade error: %v", err)
 			return
@@ -191,10 +424,18 @@ func HandleWebSocket(db *sql.DB) http.HandlerFunc {
 		client := &Client{
 			UserID: userID,
 			Conn:   conn,
-			Send:   make(chan WebSocketMessage, 256),
+			Send:   make(chan WebSocketMessage, clientSendBuffer+replayBufferPerUser),
+
+			presenceSubscriptions: make(map[int]bool),
+			lastTypingAt:          make(map[int]time.Time),
+			replaying:             true,
+			replaySince:           -1,
+		}
+		if sinceParam != "" {
+			client.replaySince = since
 		}
 
-		// Регистрируем клиента
+		// Регистрируем клиента; до конца повтора живые события ждут в client.pending
 		hub.register <- client
 
 		// Запускаем горутины для чтения и записи
@@ -251,8 +492,7 @@ func (c *Client) readPump() {
 	})
 
 	for {
-		var msg map[string]interface{}
-		err := c.Conn.ReadJSON(&msg)
+		_, raw, err := c.Conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Printf("❌ WebSocket read error: %v", err)
@@ -260,8 +500,8 @@ func (c *Client) readPump() {
 			break
 		}
 
-		// Обрабатываем сообщения от клиента (если нужно)
-		log.Printf("📨 WebSocket message from user %d: %v", c.UserID, msg)
+		// Обрабатываем сообщения от клиента (протокол - websocket_protocol.go)
+		c.handleClientMessage(raw)
 	}
 }
 
@@ -271,10 +511,14 @@ func BroadcastToAll(messageType string, data interface{}) {
 		return
 	}
 
-	hub.broadcast <- WebSocketMessage{
+	message := WebSocketMessage{
 		Type: messageType,
 		Data: data,
 	}
+	hub.broadcast <- message
+	if err := hub.backplane.Publish([]int{BroadcastUserID}, message, nil); err != nil {
+		log.Printf("⚠️ WebSocket backplane: failed to publish broadcast %s: %v", messageType, err)
+	}
 }
 
 // GetConnectedUsersCount - возвращает количество подключенных пользователей
@@ -287,3 +531,88 @@ func GetConnectedUsersCount() int {
 	defer hub.mu.RUnlock()
 	return len(hub.clients)
 }
+
+// GetConnectionsCount - возвращает общее количество WebSocket подключений
+func GetConnectionsCount() int {
+	if hub == nil {
+		return 0
+	}
+
+	hub.mu.RLock()
+	defer hub.mu.RUnlock()
+	return hub.connections
+}
+
+// GetUserConnectionsCount - возвращает количество подключений пользователя
+func GetUserConnectionsCount(userID int) int {
+	if hub == nil {
+		return 0
+	}
+
+	hub.mu.RLock()
+	defer hub.mu.RUnlock()
+	return len(hub.clients[userID])
+}
+
+// GetConnectionsPerUser - снимок userID -> количество подключений (для мониторинга)
+func GetConnectionsPerUser() map[int]int {
+	result := make(map[int]int)
+	if hub == nil {
+		return result
+	}
+
+	hub.mu.RLock()
+	defer hub.mu.RUnlock()
+	for userID, conns := range hub.clients {
+		result[userID] = len(conns)
+	}
+	return result
+}
+
+// WebSocketStatsHandler - GET /api/admin/websocket/stats: подключения по пользователям (только модераторы).
+// ?user_id= - количество подключений конкретного пользователя.
+func WebSocketStatsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	userID, ok := RequireAuth(w, r)
+	if !ok {
+		return
+	}
+	if hub == nil || !hasModeratorRights(hub.db, userID) {
+		sendError(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	if raw := r.URL.Query().Get("user_id"); raw != "" {
+		targetID, err := strconv.Atoi(raw)
+		if err != nil {
+			sendError(w, "Invalid user_id", http.StatusBadRequest)
+			return
+		}
+		sendSuccess(w, map[string]int{"user_id": targetID, "connections": GetUserConnectionsCount(targetID)})
+		return
+	}
+
+	type userConnections struct {
+		UserID      int `json:"user_id"`
+		Connections int `json:"connections"`
+	}
+	perUser := []userConnections{}
+	for id, count := range GetConnectionsPerUser() {
+		perUser = append(perUser, userConnections{UserID: id, Connections: count})
+	}
+	sort.Slice(perUser, func(i, j int) bool {
+		if perUser[i].Connections != perUser[j].Connections {
+			return perUser[i].Connections > perUser[j].Connections
+		}
+		return perUser[i].UserID < perUser[j].UserID
+	})
+
+	sendSuccess(w, map[string]interface{}{
+		"users":       len(perUser),
+		"connections": GetConnectionsCount(),
+		"per_user":    perUser,
+	})
+}
@@ -0,0 +1,516 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Backplane - шина событий WebSocket между экземплярами backend.
+// Событие доставляется локальным подключениям сразу, а через шину - подключениям пользователя
+// на остальных экземплярах. Экземпляр получает события только тех пользователей, на которых подписан
+// (у которых есть подключения к нему), и все широковещательные события (BroadcastUserID).
+type Backplane interface {
+	// Publish отправляет одно событие нескольким пользователям на остальные экземпляры;
+	// seqs - номер события у каждого получателя (websocket_replay.go), у эфемерных событий nil
+	Publish(userIDs []int, message WebSocketMessage, seqs map[int]int64) error
+	// Subscribe / Unsubscribe - получать ли события пользователя с других экземпляров
+	Subscribe(userID int)
+	Unsubscribe(userID int)
+	// SyncPresence отмечает в общем состоянии, подключён ли пользователь к этому экземпляру
+	// (по текущей подписке), и сообщает остальным экземплярам, что его статус мог измениться
+	SyncPresence(userID int) error
+	// ConnectedElsewhere - у кого из пользователей есть подключения к другим экземплярам
+	ConnectedElsewhere(userIDs []int) (map[int]bool, error)
+	// Start запускает приём событий; handler вызывается для каждого события с другого экземпляра
+	Start(handler BackplaneHandler) error
+	Close() error
+}
+
+// BackplaneHandler - обработчик события, пришедшего с другого экземпляра
+type BackplaneHandler func(userID int, message WebSocketMessage)
+
+// BroadcastUserID - адресат широковещательных событий (BroadcastToAll)
+const BroadcastUserID = 0
+
+// presenceChangedEvent - служебное широковещательное событие шины {"user_id": N}: у пользователя появились
+// или закончились подключения к одному из экземпляров. Клиентам не доставляется - каждый экземпляр
+// пересчитывает статус и рассылает его своим подписчикам.
+const presenceChangedEvent = "presence_changed"
+
+func presenceChangedMessage(userID int) WebSocketMessage {
+	return WebSocketMessage{Type: presenceChangedEvent, Data: map[string]int{"user_id": userID}}
+}
+
+// newBackplaneFromEnv выбирает реализацию по WS_BACKPLANE: memory (по умолчанию) или postgres
+func newBackplaneFromEnv(db *sql.DB, origin string) (Backplane, error) {
+	switch kind := os.Getenv("WS_BACKPLANE"); kind {
+	case "", "memory":
+		return NewMemoryBackplane(defaultMemoryBus), nil
+	case "postgres":
+		dsn := os.Getenv("DATABASE_URL")
+		if dsn == "" {
+			return nil, fmt.Errorf("DATABASE_URL is required for postgres backplane")
+		}
+		return NewPostgresBackplane(db, dsn, origin)
+	default:
+		return nil, fmt.Errorf("unknown WS_BACKPLANE %q (expected memory or postgres)", kind)
+	}
+}
+
+// subscriptionSet - пользователи, на события которых подписан экземпляр
+type subscriptionSet struct {
+	mu    sync.RWMutex
+	users map[int]bool
+}
+
+func newSubscriptionSet() *subscriptionSet {
+	return &subscriptionSet{users: make(map[int]bool)}
+}
+
+func (s *subscriptionSet) add(userID int) {
+	s.mu.Lock()
+	s.users[userID] = true
+	s.mu.Unlock()
+}
+
+func (s *subscriptionSet) remove(userID int) {
+	s.mu.Lock()
+	delete(s.users, userID)
+	s.mu.Unlock()
+}
+
+func (s *subscriptionSet) has(userID int) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.users[userID]
+}
+
+func (s *subscriptionSet) accepts(userID int) bool {
+	if userID == BroadcastUserID {
+		return true
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.users[userID]
+}
+
+// ===== In-memory =====
+
+// MemoryBus - общая шина для MemoryBackplane в одном процессе.
+// С одним экземпляром hub публикация ничего не делает; несколько hub на одной шине ведут себя как реплики.
+type MemoryBus struct {
+	mu      sync.RWMutex
+	members map[*MemoryBackplane]bool
+}
+
+// NewMemoryBus создаёт отдельную шину
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{members: make(map[*MemoryBackplane]bool)}
+}
+
+var defaultMemoryBus = NewMemoryBus()
+
+// MemoryBackplane - реализация Backplane внутри процесса
+type MemoryBackplane struct {
+	bus           *MemoryBus
+	subscriptions *subscriptionSet
+	handler       BackplaneHandler
+}
+
+// NewMemoryBackplane подключает новый экземпляр к шине
+func NewMemoryBackplane(bus *MemoryBus) *MemoryBackplane {
+	return &MemoryBackplane{bus: bus, subscriptions: newSubscriptionSet()}
+}
+
+func (b *MemoryBackplane) Publish(userIDs []int, message WebSocketMessage, seqs map[int]int64) error {
+	type delivery struct {
+		target *MemoryBackplane
+		userID int
+	}
+	var deliveries []delivery
+	b.bus.mu.RLock()
+	for member := range b.bus.members {
+		if member == b {
+			continue
+		}
+		for _, userID := range userIDs {
+			if member.subscriptions.accepts(userID) {
+				deliveries = append(deliveries, delivery{member, userID})
+			}
+		}
+	}
+	b.bus.mu.RUnlock()
+
+	// Обработчики вызываются без блокировки шины
+	for _, d := range deliveries {
+		userMessage := message
+		userMessage.Seq = seqs[d.userID]
+		d.target.handler(d.userID, userMessage)
+	}
+	return nil
+}
+
+func (b *MemoryBackplane) Subscribe(userID int)   { b.subscriptions.add(userID) }
+func (b *MemoryBackplane) Unsubscribe(userID int) { b.subscriptions.remove(userID) }
+
+// SyncPresence: подписки экземпляров на шине и есть общее состояние, остаётся только сообщить об изменении
+func (b *MemoryBackplane) SyncPresence(userID int) error {
+	return b.Publish([]int{BroadcastUserID}, presenceChangedMessage(userID), nil)
+}
+
+func (b *MemoryBackplane) ConnectedElsewhere(userIDs []int) (map[int]bool, error) {
+	connected := make(map[int]bool)
+	b.bus.mu.RLock()
+	defer b.bus.mu.RUnlock()
+	for member := range b.bus.members {
+		if member == b {
+			continue
+		}
+		for _, id := range userIDs {
+			if member.subscriptions.has(id) {
+				connected[id] = true
+			}
+		}
+	}
+	return connected, nil
+}
+
+func (b *MemoryBackplane) Start(handler BackplaneHandler) error {
+	b.handler = handler
+	b.bus.mu.Lock()
+	b.bus.members[b] = true
+	b.bus.mu.Unlock()
+	return nil
+}
+
+func (b *MemoryBackplane) Close() error {
+	b.bus.mu.Lock()
+	delete(b.bus.members, b)
+	b.bus.mu.Unlock()
+	return nil
+}
+
+// ===== PostgreSQL LISTEN/NOTIFY =====
+
+const (
+	backplaneChannel = "ws_events"
+	// NOTIFY ограничивает payload 8000 байтами; события крупнее кладутся в ws_backplane_events,
+	// а в уведомлении передаётся ссылка
+	backplaneInlinePayloadLimit = 7500
+	// Верхняя оценка размера одного получателя {"u":...,"s":...} в JSON: по ней получатели
+	// делятся на уведомления, помещающиеся в лимит
+	backplaneRecipientSize = 48
+	// Если рядом с данными события в одно уведомление помещается меньше получателей,
+	// а получателей больше, данные сохраняются один раз в ws_backplane_events
+	backplaneMinInlineRecipients = 50
+	// Сколько хранятся крупные события (получатели читают их сразу после NOTIFY)
+	backplaneEventsRetention = 5 * time.Minute
+	// Экземпляр обновляет свои записи в ws_presence раз в минуту (cleanupLoop); записи, не обновлявшиеся
+	// дольше этого срока, остались от упавшего экземпляра и не учитываются
+	backplanePresenceTTL = 3 * time.Minute
+)
+
+// backplaneEnvelope - событие в канале ws_events: одно уведомление на событие и его получателей
+// (при большом числе получателей - на их часть)
+type backplaneEnvelope struct {
+	Origin     string               `json:"o"`            // Экземпляр-отправитель: свои события пропускаются
+	Recipients []backplaneRecipient `json:"us,omitempty"` // Получатели события
+	Type       string               `json:"t,omitempty"`  // WebSocketMessage.Type
+	Data       json.RawMessage      `json:"d,omitempty"`  // WebSocketMessage.Data
+	EventID    int64                `json:"r,omitempty"`  // Ссылка на ws_backplane_events для крупных событий
+}
+
+// backplaneRecipient - получатель события и номер события у него
+type backplaneRecipient struct {
+	UserID int   `json:"u"`           // BroadcastUserID - всем
+	Seq    int64 `json:"s,omitempty"` // WebSocketMessage.Seq
+}
+
+// PostgresBackplane - реализация Backplane через LISTEN/NOTIFY
+type PostgresBackplane struct {
+	db            *sql.DB
+	listener      *pq.Listener
+	origin        string
+	subscriptions *subscriptionSet
+	done          chan struct{}
+	closeOnce     sync.Once
+
+	// Запись статуса и чтение подписки в SyncPresence не должны перемежаться между горутинами,
+	// иначе в ws_presence может остаться устаревшее значение
+	presenceMu sync.Mutex
+}
+
+// NewPostgresBackplane открывает отдельное соединение для LISTEN (пул database/sql для этого не подходит)
+func NewPostgresBackplane(db *sql.DB, dsn, origin string) (*PostgresBackplane, error) {
+	listener := pq.NewListener(dsn, 10*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		switch event {
+		case pq.ListenerEventDisconnected:
+			log.Printf("⚠️ WebSocket backplane: LISTEN connection lost: %v", err)
+		case pq.ListenerEventReconnected:
+			log.Printf("🔄 WebSocket backplane: LISTEN reconnected (events sent while disconnected are lost)")
+		case pq.ListenerEventConnectionAttemptFailed:
+			log.Printf("⚠️ WebSocket backplane: reconnect failed: %v", err)
+		}
+	})
+	if err := listener.Listen(backplaneChannel); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to LISTEN %s: %w", backplaneChannel, err)
+	}
+
+	return &PostgresBackplane{
+		db:            db,
+		listener:      listener,
+		origin:        origin,
+		subscriptions: newSubscriptionSet(),
+		done:          make(chan struct{}),
+	}, nil
+}
+
+func (b *PostgresBackplane) Publish(userIDs []int, message WebSocketMessage, seqs map[int]int64) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(message.Data)
+	if err != nil {
+		return err
+	}
+
+	envelope := backplaneEnvelope{Origin: b.origin, Type: message.Type, Data: data}
+	perNotify, err := backplaneRecipientsPerNotify(envelope)
+	if err != nil {
+		return err
+	}
+	if perNotify < len(userIDs) && perNotify < backplaneMinInlineRecipients {
+		// Данные не помещаются рядом с получателями - сохраняем их один раз, в уведомлениях только ссылка
+		event, err := json.Marshal(backplaneEnvelope{Type: message.Type, Data: data})
+		if err != nil {
+			return err
+		}
+		var eventID int64
+		err = b.db.QueryRow(ConvertPlaceholders(`
+			INSERT INTO ws_backplane_events (payload, created_at) VALUES (?, ?) RETURNING id
+		`), string(event), time.Now()).Scan(&eventID)
+		if err != nil {
+			return fmt.Errorf("failed to store large event: %w", err)
+		}
+		envelope = backplaneEnvelope{Origin: b.origin, EventID: eventID}
+		if perNotify, err = backplaneRecipientsPerNotify(envelope); err != nil {
+			return err
+		}
+	}
+
+	for start := 0; start < len(userIDs); start += perNotify {
+		end := start + perNotify
+		if end > len(userIDs) {
+			end = len(userIDs)
+		}
+		chunk := envelope
+		chunk.Recipients = make([]backplaneRecipient, 0, end-start)
+		for _, userID := range userIDs[start:end] {
+			chunk.Recipients = append(chunk.Recipients, backplaneRecipient{UserID: userID, Seq: seqs[userID]})
+		}
+
+		payload, err := json.Marshal(chunk)
+		if err != nil {
+			return err
+		}
+		if _, err := b.db.Exec(ConvertPlaceholders("SELECT pg_notify(?, ?)"), backplaneChannel, string(payload)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backplaneRecipientsPerNotify - сколько получателей помещается в одно уведомление рядом с envelope
+func backplaneRecipientsPerNotify(envelope backplaneEnvelope) (int, error) {
+	base, err := json.Marshal(envelope)
+	if err != nil {
+		return 0, err
+	}
+	perNotify := (backplaneInlinePayloadLimit - len(base)) / backplaneRecipientSize
+	if perNotify < 1 {
+		return 0, nil
+	}
+	return perNotify, nil
+}
+
+func (b *PostgresBackplane) Subscribe(userID int)   { b.subscriptions.add(userID) }
+func (b *PostgresBackplane) Unsubscribe(userID int) { b.subscriptions.remove(userID) }
+
+// SyncPresence записывает в ws_presence текущее состояние подписки: Subscribe/Unsubscribe вызываются
+// под блокировкой hub, поэтому сама запись в базу происходит здесь
+func (b *PostgresBackplane) SyncPresence(userID int) error {
+	b.presenceMu.Lock()
+	var err error
+	if b.subscriptions.has(userID) {
+		_, err = b.db.Exec(ConvertPlaceholders(`
+			INSERT INTO ws_presence (instance_id, user_id, heartbeat_at) VALUES (?, ?, NOW())
+			ON CONFLICT (instance_id, user_id) DO UPDATE SET heartbeat_at = NOW()
+		`), b.origin, userID)
+	} else {
+		_, err = b.db.Exec(ConvertPlaceholders("DELETE FROM ws_presence WHERE instance_id = ? AND user_id = ?"), b.origin, userID)
+	}
+	b.presenceMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to store presence: %w", err)
+	}
+
+	return b.Publish([]int{BroadcastUserID}, presenceChangedMessage(userID), nil)
+}
+
+func (b *PostgresBackplane) ConnectedElsewhere(userIDs []int) (map[int]bool, error) {
+	connected := make(map[int]bool)
+	if len(userIDs) == 0 {
+		return connected, nil
+	}
+
+	placeholders := make([]string, len(userIDs))
+	args := []interface{}{b.origin, int(backplanePresenceTTL.Seconds())}
+	for i, id := range userIDs {
+		placeholders[i] = "?"
+		args = append(args, id)
+	}
+
+	rows, err := b.db.Query(ConvertPlaceholders(fmt.Sprintf(`
+		SELECT DISTINCT user_id FROM ws_presence
+		WHERE instance_id <> ? AND heartbeat_at > NOW() - CAST(? AS INTEGER) * INTERVAL '1 second'
+		AND user_id IN (%s)
+	`, strings.Join(placeholders, ","))), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		connected[id] = true
+	}
+	return connected, rows.Err()
+}
+
+func (b *PostgresBackplane) Start(handler BackplaneHandler) error {
+	go b.listen(handler)
+	go b.cleanupLoop()
+	return nil
+}
+
+func (b *PostgresBackplane) Close() error {
+	var err error
+	b.closeOnce.Do(func() {
+		close(b.done)
+		// Подключения этого экземпляра закрываются вместе с ним
+		if _, dbErr := b.db.Exec(ConvertPlaceholders("DELETE FROM ws_presence WHERE instance_id = ?"), b.origin); dbErr != nil {
+			log.Printf("⚠️ WebSocket backplane: failed to clear presence: %v", dbErr)
+		}
+		err = b.listener.Close()
+	})
+	return err
+}
+
+func (b *PostgresBackplane) listen(handler BackplaneHandler) {
+	// pq рекомендует периодически пинговать соединение, чтобы быстрее замечать обрыв
+	ticker := time.NewTicker(90 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case notification, ok := <-b.listener.Notify:
+			if !ok {
+				return
+			}
+			// nil приходит после переподключения
+			if notification == nil {
+				continue
+			}
+			b.handleNotification(notification.Extra, handler)
+
+		case <-ticker.C:
+			go b.listener.Ping()
+
+		case <-b.done:
+			return
+		}
+	}
+}
+
+func (b *PostgresBackplane) handleNotification(payload string, handler BackplaneHandler) {
+	var envelope backplaneEnvelope
+	if err := json.Unmarshal([]byte(payload), &envelope); err != nil {
+		log.Printf("⚠️ WebSocket backplane: invalid payload: %v", err)
+		return
+	}
+	if envelope.Origin == b.origin {
+		return
+	}
+
+	// Экземпляр обрабатывает только своих получателей; если их нет, крупное событие не загружается
+	var recipients []backplaneRecipient
+	for _, recipient := range envelope.Recipients {
+		if b.subscriptions.accepts(recipient.UserID) {
+			recipients = append(recipients, recipient)
+		}
+	}
+	if len(recipients) == 0 {
+		return
+	}
+
+	if envelope.EventID != 0 {
+		var stored string
+		err := b.db.QueryRow(ConvertPlaceholders("SELECT payload FROM ws_backplane_events WHERE id = ?"), envelope.EventID).Scan(&stored)
+		if err != nil {
+			log.Printf("⚠️ WebSocket backplane: failed to load event %d: %v", envelope.EventID, err)
+			return
+		}
+		if err := json.Unmarshal([]byte(stored), &envelope); err != nil {
+			log.Printf("⚠️ WebSocket backplane: invalid stored event %d: %v", envelope.EventID, err)
+			return
+		}
+	}
+
+	for _, recipient := range recipients {
+		handler(recipient.UserID, WebSocketMessage{Type: envelope.Type, Data: envelope.Data, Seq: recipient.Seq})
+	}
+}
+
+func (b *PostgresBackplane) cleanupLoop() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_, err := b.db.Exec(ConvertPlaceholders("DELETE FROM ws_backplane_events WHERE created_at < ?"), time.Now().Add(-backplaneEventsRetention))
+			if err != nil {
+				log.Printf("⚠️ WebSocket backplane: cleanup failed: %v", err)
+			}
+			b.refreshPresence()
+		case <-b.done:
+			return
+		}
+	}
+}
+
+// refreshPresence продлевает записи этого экземпляра в ws_presence и удаляет записи упавших экземпляров
+func (b *PostgresBackplane) refreshPresence() {
+	_, err := b.db.Exec(ConvertPlaceholders("UPDATE ws_presence SET heartbeat_at = NOW() WHERE instance_id = ?"), b.origin)
+	if err != nil {
+		log.Printf("⚠️ WebSocket backplane: presence heartbeat failed: %v", err)
+	}
+	_, err = b.db.Exec(ConvertPlaceholders(`
+		DELETE FROM ws_presence WHERE heartbeat_at < NOW() - CAST(? AS INTEGER) * INTERVAL '1 second'
+	`), int(backplanePresenceTTL.Seconds()))
+	if err != nil {
+		log.Printf("⚠️ WebSocket backplane: presence cleanup failed: %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"backend/db"
+	"database/sql/driver"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestPostgresBackplanePublishBatchesRecipients(t *testing.T) {
+	users := func(n int) []int {
+		ids := make([]int, n)
+		for i := range ids {
+			ids[i] = 1000000 + i
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name        string
+		userIDs     []int
+		dataSize    int
+		wantNotifys int
+		wantStored  bool
+	}{
+		{"chat participants", users(3), 100, 1, false},
+		{"many recipients", users(500), 100, 4, false},
+		{"many recipients of a large event", users(500), 7000, 4, true},
+		{"one recipient of a large event", users(1), 9000, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var payloads []string
+			stored := 0
+			useFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+				switch {
+				case strings.Contains(query, "INSERT INTO ws_backplane_events"):
+					stored++
+					return &fakeResult{rows: [][]driver.Value{{int64(42)}}}, nil
+				case strings.Contains(query, "pg_notify"):
+					payloads = append(payloads, args[1].(string))
+				}
+				return &fakeResult{}, nil
+			})
+
+			seqs := make(map[int]int64)
+			for i, id := range tt.userIDs {
+				seqs[id] = int64(9000000000 + i)
+			}
+			b := &PostgresBackplane{db: db.DB, origin: "instance-a", subscriptions: newSubscriptionSet()}
+			message := WebSocketMessage{Type: "new_message", Data: map[string]string{"content": strings.Repeat("x", tt.dataSize)}}
+			if err := b.Publish(tt.userIDs, message, seqs); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(payloads) != tt.wantNotifys {
+				t.Fatalf("%d notifications, want %d", len(payloads), tt.wantNotifys)
+			}
+			if (stored > 0) != tt.wantStored || stored > 1 {
+				t.Errorf("event stored %d times, want stored = %v", stored, tt.wantStored)
+			}
+
+			got := make(map[int]int64)
+			for _, payload := range payloads {
+				if len(payload) > 8000 {
+					t.Fatalf("payload of %d bytes exceeds the NOTIFY limit", len(payload))
+				}
+				var envelope backplaneEnvelope
+				if err := json.Unmarshal([]byte(payload), &envelope); err != nil {
+					t.Fatal(err)
+				}
+				if tt.wantStored != (envelope.EventID == 42 && envelope.Data == nil) {
+					t.Errorf("event inline = %v, want stored = %v", envelope.Data != nil, tt.wantStored)
+				}
+				for _, r := range envelope.Recipients {
+					got[r.UserID] = r.Seq
+				}
+			}
+			if len(got) != len(tt.userIDs) {
+				t.Fatalf("%d recipients published, want %d", len(got), len(tt.userIDs))
+			}
+			for id, seq := range seqs {
+				if got[id] != seq {
+					t.Errorf("user %d published with seq %d, want %d", id, got[id], seq)
+				}
+			}
+		})
+	}
+}
+
+func TestPostgresBackplaneDeliversOnlySubscribedRecipients(t *testing.T) {
+	loads := 0
+	useFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+		if strings.Contains(query, "FROM ws_backplane_events") {
+			loads++
+			return &fakeResult{rows: [][]driver.Value{{`{"t":"new_message","d":{"id":5}}`}}}, nil
+		}
+		return &fakeResult{}, nil
+	})
+
+	b := &PostgresBackplane{db: db.DB, origin: "instance-b", subscriptions: newSubscriptionSet()}
+	b.Subscribe(2)
+
+	type received struct {
+		userID int
+		seq    int64
+	}
+	var got []received
+	handler := func(userID int, message WebSocketMessage) {
+		if message.Type != "new_message" {
+			t.Errorf("type %q", message.Type)
+		}
+		got = append(got, received{userID, message.Seq})
+	}
+
+	b.handleNotification(`{"o":"instance-a","us":[{"u":1,"s":10},{"u":2,"s":20},{"u":3,"s":30}],"t":"new_message","d":{"id":5}}`, handler)
+	// Крупное событие без местных получателей не загружается
+	b.handleNotification(`{"o":"instance-a","us":[{"u":1,"s":11},{"u":3,"s":31}],"r":42}`, handler)
+	b.handleNotification(`{"o":"instance-a","us":[{"u":2,"s":21}],"r":42}`, handler)
+	// Свои события пропускаются
+	b.handleNotification(`{"o":"instance-b","us":[{"u":2,"s":22}],"t":"new_message"}`, handler)
+
+	if len(got) != 2 || got[0] != (received{2, 20}) || got[1] != (received{2, 21}) {
+		t.Errorf("delivered %v, want user 2 with seq 20 and 21", got)
+	}
+	if loads != 1 {
+		t.Errorf("stored event loaded %d times, want 1", loads)
+	}
+}
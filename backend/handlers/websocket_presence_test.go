@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"backend/db"
+	"database/sql/driver"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newBusHub - hub, подключённый к общей шине, как отдельный экземпляр backend
+func newBusHub(t *testing.T, bus *MemoryBus) *Hub {
+	t.Helper()
+	h := newTestHub(t)
+	h.db = db.DB
+	h.backplane = NewMemoryBackplane(bus)
+	h.backplane.Start(h.receiveFromBackplane)
+	t.Cleanup(func() { h.backplane.Close() })
+	return h
+}
+
+// receivePresence ждёт событие "presence" и возвращает статус из него
+func receivePresence(t *testing.T, c *Client) PresenceState {
+	t.Helper()
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case m := <-c.Send:
+			if m.Type != "presence" {
+				continue
+			}
+			states := m.Data.(map[string]interface{})["users"].([]PresenceState)
+			return states[0]
+		case <-deadline:
+			t.Fatal("no presence event")
+		}
+	}
+}
+
+func TestPresenceAcrossInstances(t *testing.T) {
+	useFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+		if strings.Contains(query, "show_online") {
+			return &fakeResult{rows: [][]driver.Value{{"yes"}}}, nil
+		}
+		return &fakeResult{}, nil
+	})
+
+	bus := NewMemoryBus()
+	first, second := newBusHub(t, bus), newBusHub(t, bus)
+
+	// Подписчик на первом экземпляре, пользователь 2 подключается ко второму
+	subscriber := addTestClient(first, 1)
+	subscriber.presenceSubscriptions = make(map[int]bool)
+	if err := first.subscribePresence(subscriber, []int{2}); err != nil {
+		t.Fatal(err)
+	}
+
+	conn := addTestClient(second, 2)
+	second.backplane.Subscribe(2)
+	second.publishPresence(2)
+
+	if state := receivePresence(t, subscriber); !state.IsOnline || state.UserID != 2 {
+		t.Fatalf("presence after connect on another instance: %+v", state)
+	}
+	if !first.connectedUsers([]int{2})[2] {
+		t.Error("user connected to another instance is reported offline")
+	}
+
+	second.mu.Lock()
+	second.removeClientLocked(conn)
+	second.mu.Unlock()
+	second.publishPresence(2)
+
+	if state := receivePresence(t, subscriber); state.IsOnline || state.LastSeen == nil {
+		t.Fatalf("presence after disconnect on another instance: %+v", state)
+	}
+	if first.connectedUsers([]int{2})[2] {
+		t.Error("disconnected user is still reported online")
+	}
+}
+
+func TestPostgresBackplanePresence(t *testing.T) {
+	var statements []string
+	var args [][]driver.Value
+	useFakeDB(t, func(query string, a []driver.Value) (*fakeResult, error) {
+		statements = append(statements, query)
+		args = append(args, a)
+		if strings.Contains(query, "SELECT DISTINCT user_id FROM ws_presence") {
+			return &fakeResult{rows: [][]driver.Value{{int64(8)}}}, nil
+		}
+		return &fakeResult{}, nil
+	})
+
+	b := &PostgresBackplane{db: db.DB, origin: "instance-a", subscriptions: newSubscriptionSet()}
+
+	b.Subscribe(7)
+	if err := b.SyncPresence(7); err != nil {
+		t.Fatal(err)
+	}
+	b.Unsubscribe(7)
+	if err := b.SyncPresence(7); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(statements) != 4 ||
+		!strings.HasPrefix(statements[0], "INSERT INTO ws_presence") ||
+		!strings.HasPrefix(statements[2], "DELETE FROM ws_presence") {
+		t.Fatalf("statements %q, want upsert, notify, delete, notify", statements)
+	}
+	for _, i := range []int{1, 3} {
+		payload := args[i][1].(string)
+		if !strings.Contains(statements[i], "pg_notify") || !strings.Contains(payload, `"t":"presence_changed"`) || !strings.Contains(payload, `"u":0`) {
+			t.Errorf("presence change is not broadcast: %s %s", statements[i], payload)
+		}
+	}
+
+	connected, err := b.ConnectedElsewhere([]int{7, 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(connected) != 1 || !connected[8] {
+		t.Errorf("connected elsewhere = %v, want only 8", connected)
+	}
+	// Свои записи не учитываются: о своих подключениях экземпляр знает сам
+	if last := args[len(args)-1]; last[0] != "instance-a" {
+		t.Errorf("query excludes %v, want own instance", last[0])
+	}
+}
+
+func TestPresenceChangedUserID(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   interface{}
+		want   int
+		wantOk bool
+	}{
+		{"memory backplane", map[string]int{"user_id": 5}, 5, true},
+		{"postgres backplane", json.RawMessage(`{"user_id":5}`), 5, true},
+		{"missing user", map[string]int{}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := presenceChangedUserID(tt.data)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("presenceChangedUserID = %d, %v; want %d, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Протокол клиент -> сервер поверх /ws. Каждое сообщение: {"type": "...", "data": {...}}
+//
+//	typing_start / typing_stop  {"chat_id": 1}          - набор текста, рассылается собеседникам как "typing"
+//	chat_opened                 {"chat_id": 1}          - чат открыт, сообщения отмечаются прочитанными
+//	presence_subscribe          {"user_ids": [1, 2]}    - подписка на онлайн-статус (ответ - "presence")
+//	presence_unsubscribe        {"user_ids": [1, 2]}
+//	ping                                                - ответ "pong"
+//
+// Ошибки возвращаются событием "error" {"message": "...", "request_type": "..."}.
+// Сервер -> клиент: события с полем seq повторяются при переподключении с /ws?since=<seq>
+// (см. websocket_replay.go), первым после подключения приходит "connected" {"last_seq": N}.
+const (
+	// Повторный typing_start по тому же чату раньше этого интервала не рассылается
+	typingThrottle = 3 * time.Second
+	// Через сколько клиенту считать набор прекращённым, если не пришёл typing_stop
+	typingExpiresIn = 6 * time.Second
+	// Максимум пользователей в подписках на присутствие у одного подключения
+	presenceSubscriptionsLimit = 500
+	// Пользователь без подключения считается онлайн, если был активен за последние 5 минут
+	presenceOnlineWindow = 5 * time.Minute
+)
+
+// clientWSMessage - сообщение от клиента
+type clientWSMessage struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+type wsChatPayload struct {
+	ChatID int `json:"chat_id"`
+}
+
+type wsPresencePayload struct {
+	UserIDs []int `json:"user_ids"`
+}
+
+// PresenceState - онлайн-статус пользователя для подписчиков
+type PresenceState struct {
+	UserID   int        `json:"user_id"`
+	IsOnline bool       `json:"is_online"`
+	LastSeen *time.Time `json:"last_seen,omitempty"`
+}
+
+// handleClientMessage разбирает и выполняет сообщение клиента
+func (c *Client) handleClientMessage(raw []byte) {
+	var msg clientWSMessage
+	if err := json.Unmarshal(raw, &msg); err != nil || msg.Type == "" {
+		c.sendError("", "Invalid message format")
+		return
+	}
+
+	switch msg.Type {
+	case "typing_start", "typing_stop":
+		var payload wsChatPayload
+		if err := json.Unmarshal(msg.Data, &payload); err != nil || payload.ChatID <= 0 {
+			c.sendError(msg.Type, "chat_id is required")
+			return
+		}
+		c.handleTyping(payload.ChatID, msg.Type == "typing_start")
+
+	case "chat_opened":
+		var payload wsChatPayload
+		if err := json.Unmarshal(msg.Data, &payload); err != nil || payload.ChatID <= 0 {
+			c.sendError(msg.Type, "chat_id is required")
+			return
+		}
+		if !isUserInChat(hub.db, payload.ChatID, c.UserID) {
+			c.sendError(msg.Type, "Access denied")
+			return
+		}
+		go markMessagesAsRead(hub.db, payload.ChatID, c.UserID)
+
+	case "presence_subscribe", "presence_unsubscribe":
+		var payload wsPresencePayload
+		if err := json.Unmarshal(msg.Data, &payload); err != nil {
+			c.sendError(msg.Type, "user_ids is required")
+			return
+		}
+		userIDs := uniqueInts(payload.UserIDs)
+		if msg.Type == "presence_unsubscribe" {
+			hub.unsubscribePresence(c, userIDs)
+			return
+		}
+		if err := hub.subscribePresence(c, userIDs); err != nil {
+			c.sendError(msg.Type, err.Error())
+			return
+		}
+		hub.sendToClient(c, WebSocketMessage{
+			Type: "presence",
+			Data: map[string]interface{}{"users": loadPresenceStates(userIDs)},
+		})
+
+	case "ping":
+		hub.sendToClient(c, WebSocketMessage{Type: "pong", Data: map[string]int64{"server_time": time.Now().Unix()}})
+
+	default:
+		c.sendError(msg.Type, "Unknown message type")
+	}
+}
+
+// handleTyping рассылает остальным участникам чата событие "typing"
+func (c *Client) handleTyping(chatID int, isTyping bool) {
+	if isTyping {
+		if last, ok := c.lastTypingAt[chatID]; ok && time.Since(last) < typingThrottle {
+			return
+		}
+	}
+	if !isUserInChat(hub.db, chatID, c.UserID) {
+		c.sendError("typing", "Access denied")
+		return
+	}
+
+	if isTyping {
+		c.lastTypingAt[chatID] = time.Now()
+	} else {
+		delete(c.lastTypingAt, chatID)
+	}
+
+	event := map[string]interface{}{
+		"chat_id":    chatID,
+		"user_id":    c.UserID,
+		"is_typing":  isTyping,
+		"expires_in": int(typingExpiresIn.Seconds()),
+	}
+	for _, participantID := range getChatParticipantIDs(hub.db, chatID) {
+		if participantID != c.UserID {
+			NotifyUser(participantID, "typing", event)
+		}
+	}
+}
+
+func (c *Client) sendError(requestType, message string) {
+	hub.sendToClient(c, WebSocketMessage{
+		Type: "error",
+		Data: map[string]string{"message": message, "request_type": requestType},
+	})
+}
+
+// sendToClient отправляет событие конкретному подключению (а не всем подключениям пользователя), не блокируясь.
+// Блокировка hub удерживается, чтобы канал не закрылся во время отправки.
+func (h *Hub) sendToClient(c *Client, message WebSocketMessage) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if !h.clients[c.UserID][c] {
+		return
+	}
+	if !c.enqueue(message) {
+		log.Printf("⚠️ WebSocket: send buffer full for user %d, dropping %s", c.UserID, message.Type)
+	}
+}
+
+func (h *Hub) subscribePresence(c *Client, userIDs []int) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Отключённое подключение не должно оставлять подписок
+	if !h.clients[c.UserID][c] {
+		return fmt.Errorf("Connection is closed")
+	}
+
+	added := 0
+	for _, id := range userIDs {
+		if !c.presenceSubscriptions[id] {
+			added++
+		}
+	}
+	if len(c.presenceSubscriptions)+added > presenceSubscriptionsLimit {
+		return fmt.Errorf("Cannot subscribe to more than %d users", presenceSubscriptionsLimit)
+	}
+
+	for _, id := range userIDs {
+		c.presenceSubscriptions[id] = true
+		if h.presenceSubscribers[id] == nil {
+			h.presenceSubscribers[id] = make(map[*Client]bool)
+		}
+		h.presenceSubscribers[id][c] = true
+	}
+	return nil
+}
+
+func (h *Hub) unsubscribePresence(c *Client, userIDs []int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.removePresenceSubscriptionsLocked(c, userIDs)
+}
+
+// removePresenceSubscriptionsLocked вызывается под h.mu.Lock
+func (h *Hub) removePresenceSubscriptionsLocked(c *Client, userIDs []int) {
+	for _, id := range userIDs {
+		delete(c.presenceSubscriptions, id)
+		if subs := h.presenceSubscribers[id]; subs != nil {
+			delete(subs, c)
+			if len(subs) == 0 {
+				delete(h.presenceSubscribers, id)
+			}
+		}
+	}
+}
+
+// publishPresence вызывается, когда у пользователя появилось первое или закончились подключения
+// к этому экземпляру: статус сохраняется в шине, а подписчики на всех экземплярах получают "presence"
+// (остальные экземпляры - через presenceChangedEvent).
+func (h *Hub) publishPresence(userID int) {
+	if err := h.backplane.SyncPresence(userID); err != nil {
+		log.Printf("⚠️ WebSocket backplane: failed to sync presence of user %d: %v", userID, err)
+	}
+	h.notifyPresenceSubscribers(userID)
+}
+
+// notifyPresenceSubscribers сообщает подписчикам этого экземпляра текущий статус пользователя
+// (есть ли у него подключения к любому экземпляру). Статус берётся в момент рассылки, поэтому быстрые
+// переподключения не дают устаревших событий.
+// Пользователям со скрытым онлайн-статусом (show_online = 'no') ничего не рассылается.
+func (h *Hub) notifyPresenceSubscribers(userID int) {
+	h.mu.RLock()
+	hasSubscribers := len(h.presenceSubscribers[userID]) > 0
+	h.mu.RUnlock()
+	if !hasSubscribers || !isOnlineStatusVisible(h.db, userID) {
+		return
+	}
+
+	state := PresenceState{UserID: userID, IsOnline: h.connectedUsers([]int{userID})[userID]}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if !state.IsOnline {
+		now := time.Now()
+		state.LastSeen = &now
+	}
+	message := WebSocketMessage{Type: "presence", Data: map[string]interface{}{"users": []PresenceState{state}}}
+
+	for client := range h.presenceSubscribers[userID] {
+		if client.UserID == userID {
+			continue
+		}
+		client.enqueue(message)
+	}
+}
+
+// presenceChangedUserID достаёт user_id из presenceChangedEvent: из MemoryBackplane данные приходят
+// как есть, из PostgresBackplane - в виде JSON
+func presenceChangedUserID(data interface{}) (int, bool) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return 0, false
+	}
+	var payload struct {
+		UserID int `json:"user_id"`
+	}
+	if err := json.Unmarshal(raw, &payload); err != nil || payload.UserID <= 0 {
+		return 0, false
+	}
+	return payload.UserID, true
+}
+
+func isOnlineStatusVisible(db *sql.DB, userID int) bool {
+	var showOnline string
+	err := db.QueryRow(ConvertPlaceholders(`
+		SELECT COALESCE(show_online, 'yes') FROM users WHERE id = ?
+	`), userID).Scan(&showOnline)
+	return err == nil && showOnline != "no"
+}
+
+// connectedUsers - у кого из пользователей есть подключения к этому или другим экземплярам
+func (h *Hub) connectedUsers(userIDs []int) map[int]bool {
+	connected := make(map[int]bool)
+	var remote []int
+	h.mu.RLock()
+	for _, id := range userIDs {
+		if len(h.clients[id]) > 0 {
+			connected[id] = true
+		} else {
+			remote = append(remote, id)
+		}
+	}
+	h.mu.RUnlock()
+
+	if len(remote) == 0 {
+		return connected
+	}
+	elsewhere, err := h.backplane.ConnectedElsewhere(remote)
+	if err != nil {
+		// Без шины статус считается по этому экземпляру и last_seen
+		log.Printf("⚠️ WebSocket backplane: failed to load presence: %v", err)
+		return connected
+	}
+	for id := range elsewhere {
+		connected[id] = true
+	}
+	return connected
+}
+
+// loadPresenceStates - текущие статусы; скрывшие онлайн-статус всегда "не в сети" без last_seen
+func loadPresenceStates(userIDs []int) []PresenceState {
+	states := []PresenceState{}
+	if len(userIDs) == 0 {
+		return states
+	}
+
+	placeholders := make([]string, len(userIDs))
+	args := make([]interface{}, len(userIDs))
+	for i, id := range userIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	connected := hub.connectedUsers(userIDs)
+	rows, err := hub.db.Query(ConvertPlaceholders(fmt.Sprintf(`
+		SELECT u.id, COALESCE(u.show_online, 'yes'), ua.last_seen
+		FROM users u
+		LEFT JOIN user_activity ua ON ua.user_id = u.id
+		WHERE u.id IN (%s)
+	`, strings.Join(placeholders, ","))), args...)
+	if err != nil {
+		log.Printf("❌ Error loading presence: %v", err)
+		return states
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var state PresenceState
+		var showOnline string
+		var lastSeen *time.Time
+		if err := rows.Scan(&state.UserID, &showOnline, &lastSeen); err != nil {
+			continue
+		}
+		if showOnline != "no" {
+			state.IsOnline = connected[state.UserID] ||
+				(lastSeen != nil && time.Since(*lastSeen) < presenceOnlineWindow)
+			state.LastSeen = lastSeen
+		}
+		states = append(states, state)
+	}
+	return states
+}
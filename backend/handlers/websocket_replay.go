@@ -0,0 +1,464 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Повтор пропущенных событий при переподключении.
+//
+// Каждое событие пользователю (кроме эфемерных - набор текста, присутствие и т.п.) получает
+// seq - номер, монотонно растущий для этого пользователя. Номера и сами события хранятся в БД,
+// поэтому последовательность общая для всех экземпляров backend и переживает перезапуск.
+// Клиент запоминает последний полученный seq и переподключается с /ws?since=<seq>:
+// сервер досылает пропущенные события по порядку, а если их уже нет в буфере -
+// отправляет "resync_required" (клиент перечитывает состояние через REST).
+// Новое подключение всегда получает "connected" с текущим last_seq.
+const (
+	// Сколько последних событий хранится на пользователя
+	replayBufferPerUser = 200
+	// Сколько хранятся события
+	replayRetention = 24 * time.Hour
+	// Буфер отправки подключения сверх повторяемых событий
+	clientSendBuffer = 256
+	// Получателей на одну транзакцию recordEvents (5 параметров на получателя, лимит PostgreSQL - 65535)
+	recordBatchSize = 1000
+	// Сколько событие, пришедшее раньше своего seq, ждёт пропущенные перед ним (см. deliver)
+	seqReorderWait = 500 * time.Millisecond
+)
+
+// ephemeralEventTypes - события без seq: не хранятся и не повторяются
+var ephemeralEventTypes = map[string]bool{
+	"typing":                    true,
+	"presence":                  true,
+	"pong":                      true,
+	"error":                     true,
+	"unread_count":              true, // Актуальные счётчики отправляются при каждом подключении
+	"notification_unread_count": true,
+	"connected":                 true,
+	"resync_required":           true,
+	sessionRevokedEvent:         true, // Токен уже недействителен, повторять нечего
+}
+
+// recordEvents присваивает событию следующий seq каждого получателя и сохраняет его в буфер.
+// Вся рассылка (например, сообщение в группу) - одна транзакция на recordBatchSize получателей
+// с тремя запросами, а не транзакция на каждого. Возвращает seq по userID.
+func (h *Hub) recordEvents(userIDs []int, message WebSocketMessage) (map[int]int64, error) {
+	data, err := json.Marshal(message.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Строки счётчиков блокируются в порядке user_id - параллельные рассылки не ждут друг друга по кругу
+	ids := uniqueInts(userIDs)
+	sort.Ints(ids)
+
+	seqs := make(map[int]int64, len(ids))
+	for start := 0; start < len(ids); start += recordBatchSize {
+		end := min(start+recordBatchSize, len(ids))
+		if err := h.recordEventBatch(ids[start:end], message.Type, string(data), seqs); err != nil {
+			return seqs, err
+		}
+	}
+	return seqs, nil
+}
+
+func (h *Hub) recordEventBatch(ids []int, eventType, data string, seqs map[int]int64) error {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Блокировка строки счётчика упорядочивает параллельные события одного пользователя
+	args := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	rows, err := tx.Query(ConvertPlaceholders(`
+		INSERT INTO ws_user_sequences (user_id, last_seq) VALUES `+placeholderRows("(?, 1)", len(ids))+`
+		ON CONFLICT (user_id) DO UPDATE SET last_seq = ws_user_sequences.last_seq + 1
+		RETURNING user_id, last_seq
+	`), args...)
+	if err != nil {
+		return err
+	}
+	batch := make(map[int]int64, len(ids))
+	for rows.Next() {
+		var userID int
+		var seq int64
+		if err := rows.Scan(&userID, &seq); err != nil {
+			rows.Close()
+			return err
+		}
+		batch[userID] = seq
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(batch) != len(ids) {
+		return fmt.Errorf("allocated %d sequence numbers for %d users", len(batch), len(ids))
+	}
+
+	now := time.Now()
+	eventArgs := make([]interface{}, 0, len(ids)*5)
+	trimArgs := make([]interface{}, 0, len(ids)*2)
+	for _, id := range ids {
+		eventArgs = append(eventArgs, id, batch[id], eventType, data, now)
+		trimArgs = append(trimArgs, id, batch[id]-replayBufferPerUser)
+	}
+
+	if _, err := tx.Exec(ConvertPlaceholders(`
+		INSERT INTO ws_user_events (user_id, seq, type, data, created_at) VALUES `+placeholderRows("(?, ?, ?, ?, ?)", len(ids))), eventArgs...); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ConvertPlaceholders(`
+		DELETE FROM ws_user_events e
+		USING (VALUES `+placeholderRows("(?::int, ?::bigint)", len(ids))+`) AS t(user_id, max_seq)
+		WHERE e.user_id = t.user_id AND e.seq <= t.max_seq
+	`), trimArgs...); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for id, seq := range batch {
+		seqs[id] = seq
+	}
+	return nil
+}
+
+// placeholderRows - "(?, ?), (?, ?), ..." для многострочного INSERT/VALUES
+func placeholderRows(row string, n int) string {
+	rows := make([]string, n)
+	for i := range rows {
+		rows[i] = row
+	}
+	return strings.Join(rows, ", ")
+}
+
+// startReplayCleanup периодически удаляет события старше replayRetention
+func (h *Hub) startReplayCleanup(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			result, err := h.db.Exec(ConvertPlaceholders("DELETE FROM ws_user_events WHERE created_at < ?"), time.Now().Add(-replayRetention))
+			if err != nil {
+				log.Printf("⚠️ WebSocket replay cleanup failed: %v", err)
+				continue
+			}
+			if affected, _ := result.RowsAffected(); affected > 0 {
+				log.Printf("🧹 WebSocket replay: removed %d expired events", affected)
+			}
+		}
+	}()
+}
+
+// replay досылает подключению события после since (или resync_required) и отправляет "connected".
+// since < 0 - клиент подключается впервые, повторять нечего.
+// Пока повтор не завершён, живые события копятся в client.pending, чтобы не нарушать порядок.
+func (h *Hub) replay(c *Client, since int64) {
+	lastSeq, minSeq, err := getReplayBounds(h.db, c.UserID)
+
+	var events []WebSocketMessage
+	resync := false
+	if err != nil {
+		log.Printf("❌ WebSocket replay: failed to load bounds for user %d: %v", c.UserID, err)
+		resync = since >= 0
+	} else {
+		var load bool
+		load, resync = planReplay(since, lastSeq, minSeq)
+		if load {
+			events, err = loadReplayEvents(h.db, c.UserID, since, lastSeq)
+			if err != nil {
+				log.Printf("❌ WebSocket replay: failed to load events for user %d: %v", c.UserID, err)
+				resync = true
+			}
+		}
+	}
+
+	out := []WebSocketMessage{{
+		Type: "connected",
+		Data: map[string]interface{}{"last_seq": lastSeq, "instance_id": h.instanceID},
+	}}
+	if resync {
+		out = append(out, WebSocketMessage{
+			Type: "resync_required",
+			Data: map[string]interface{}{"last_seq": lastSeq, "since": since},
+		})
+	}
+	out = append(out, events...)
+
+	if len(events) > 0 || resync {
+		log.Printf("🔁 WebSocket replay for user %d: since=%d last_seq=%d replayed=%d resync=%v",
+			c.UserID, since, lastSeq, len(events), resync)
+	}
+
+	if !h.finishReplay(c, out, lastSeq) {
+		h.dropClient(c)
+	}
+}
+
+// planReplay решает, что делать с клиентом, пришедшим с since: досылать события из буфера (load)
+// или просить полную синхронизацию (resync). since < 0 - первое подключение, ничего не нужно.
+func planReplay(since, lastSeq, minSeq int64) (load, resync bool) {
+	switch {
+	case since < 0, since == lastSeq:
+		// Первое подключение или ничего не пропущено
+		return false, false
+	case since > lastSeq, minSeq == 0, since+1 < minSeq:
+		// seq из чужой истории или пропущенные события уже вытеснены из буфера
+		return false, true
+	default:
+		return true, false
+	}
+}
+
+// finishReplay отправляет повтор и накопленные за это время живые события.
+// false - буфер подключения переполнен.
+func (h *Hub) finishReplay(c *Client, replayed []WebSocketMessage, lastSeq int64) bool {
+	// Живые события после повтора идут с lastSeq+1
+	h.syncDeliveryOrder(c.UserID, lastSeq)
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if !h.clients[c.UserID][c] {
+		return true
+	}
+
+	c.replayMu.Lock()
+	defer c.replayMu.Unlock()
+
+	out := replayed
+	for _, message := range c.pending {
+		// Событие могло попасть и в повтор, и в живой поток
+		if message.Seq == 0 || message.Seq > lastSeq {
+			out = append(out, message)
+		}
+	}
+	c.pending = nil
+	c.replaying = false
+
+	for _, message := range out {
+		select {
+		case c.Send <- message:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// enqueue ставит событие в очередь подключения, не блокируясь. Вызывается под hub.mu.
+// false - буфер переполнен, подключение нужно отключить.
+func (c *Client) enqueue(message WebSocketMessage) bool {
+	c.replayMu.Lock()
+	defer c.replayMu.Unlock()
+
+	if c.replaying {
+		if len(c.pending) >= clientSendBuffer {
+			return false
+		}
+		c.pending = append(c.pending, message)
+		return true
+	}
+
+	select {
+	case c.Send <- message:
+		return true
+	default:
+		return false
+	}
+}
+
+// getReplayBounds - последний выданный seq пользователя и самый старый seq в буфере (0 - буфер пуст)
+func getReplayBounds(db *sql.DB, userID int) (lastSeq, minSeq int64, err error) {
+	err = db.QueryRow(ConvertPlaceholders(`
+		SELECT
+			COALESCE((SELECT last_seq FROM ws_user_sequences WHERE user_id = ?), 0),
+			COALESCE((SELECT MIN(seq) FROM ws_user_events WHERE user_id = ?), 0)
+	`), userID, userID).Scan(&lastSeq, &minSeq)
+	return lastSeq, minSeq, err
+}
+
+func loadReplayEvents(db *sql.DB, userID int, since, lastSeq int64) ([]WebSocketMessage, error) {
+	rows, err := db.Query(ConvertPlaceholders(`
+		SELECT seq, type, data FROM ws_user_events
+		WHERE user_id = ? AND seq > ? AND seq <= ?
+		ORDER BY seq ASC
+	`), userID, since, lastSeq)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []WebSocketMessage
+	expected := since + 1
+	for rows.Next() {
+		var message WebSocketMessage
+		var data string
+		if err := rows.Scan(&message.Seq, &message.Type, &data); err != nil {
+			return nil, err
+		}
+		// Дыра в последовательности (событие не записалось) - честнее попросить полную синхронизацию
+		if message.Seq != expected {
+			return nil, fmt.Errorf("event %d is missing", expected)
+		}
+		expected++
+		message.Data = json.RawMessage(data)
+		events = append(events, message)
+	}
+	return events, rows.Err()
+}
+
+// ===== Порядок доставки =====
+
+// Параллельные рассылки (и события с других экземпляров через шину) могут прийти в hub не в порядке seq.
+// deliver держит событие, пришедшее раньше своей очереди, пока не придут предыдущие,
+// но не дольше seqReorderWait - потерянное событие не должно задерживать остальные.
+
+// seqOrder - состояние порядка доставки для одного пользователя (под hub.orderMu)
+type seqOrder struct {
+	next  int64                      // Следующий ожидаемый seq
+	held  map[int64]WebSocketMessage // Пришедшие раньше своей очереди
+	timer *time.Timer
+}
+
+// deliver отправляет событие локальным подключениям пользователя в порядке seq
+func (h *Hub) deliver(userID int, message WebSocketMessage) {
+	if message.Seq == 0 {
+		h.deliverNow(userID, message)
+		return
+	}
+
+	h.orderMu.Lock()
+	defer h.orderMu.Unlock()
+
+	if !h.hasLocalClients(userID) {
+		// Подключений нет - при следующем порядок начнётся с повтора
+		h.resetOrderLocked(userID)
+		return
+	}
+
+	o := h.order[userID]
+	if o == nil {
+		o = &seqOrder{next: message.Seq, held: make(map[int64]WebSocketMessage)}
+		h.order[userID] = o
+	}
+
+	switch {
+	case message.Seq < o.next:
+		// Опоздало дольше seqReorderWait - отправляем как есть, чтобы не потерять
+		h.deliverNow(userID, message)
+	case message.Seq == o.next:
+		h.deliverNow(userID, message)
+		o.next++
+		h.releaseLocked(userID, o)
+	default:
+		o.held[message.Seq] = message
+		if len(o.held) >= replayBufferPerUser {
+			h.flushLocked(userID, o)
+			return
+		}
+		if o.timer == nil {
+			o.timer = time.AfterFunc(seqReorderWait, func() { h.flushOrder(userID) })
+		}
+	}
+}
+
+// releaseLocked отправляет удерживаемые события, дождавшиеся своей очереди
+func (h *Hub) releaseLocked(userID int, o *seqOrder) {
+	for {
+		message, ok := o.held[o.next]
+		if !ok {
+			break
+		}
+		delete(o.held, o.next)
+		h.deliverNow(userID, message)
+		o.next++
+	}
+	if len(o.held) == 0 && o.timer != nil {
+		o.timer.Stop()
+		o.timer = nil
+	}
+}
+
+// flushLocked отправляет все удерживаемые события по порядку, пропуская недошедшие
+func (h *Hub) flushLocked(userID int, o *seqOrder) {
+	seqs := make([]int64, 0, len(o.held))
+	for seq := range o.held {
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+
+	for _, seq := range seqs {
+		h.deliverNow(userID, o.held[seq])
+		o.next = seq + 1
+	}
+	o.held = make(map[int64]WebSocketMessage)
+	if o.timer != nil {
+		o.timer.Stop()
+		o.timer = nil
+	}
+}
+
+func (h *Hub) flushOrder(userID int) {
+	h.orderMu.Lock()
+	defer h.orderMu.Unlock()
+
+	o := h.order[userID]
+	if o == nil || len(o.held) == 0 {
+		return
+	}
+	log.Printf("⚠️ WebSocket: events before seq %d for user %d did not arrive in %s, delivering out of order",
+		o.next, userID, seqReorderWait)
+	o.timer = nil
+	h.flushLocked(userID, o)
+}
+
+// syncDeliveryOrder - после повтора до lastSeq живые события ожидаются начиная с lastSeq+1
+func (h *Hub) syncDeliveryOrder(userID int, lastSeq int64) {
+	h.orderMu.Lock()
+	defer h.orderMu.Unlock()
+
+	o := h.order[userID]
+	if o == nil {
+		h.order[userID] = &seqOrder{next: lastSeq + 1, held: make(map[int64]WebSocketMessage)}
+		return
+	}
+	if o.next > lastSeq {
+		return
+	}
+	// Удерживаемые события до lastSeq уже есть в повторе
+	for seq := range o.held {
+		if seq <= lastSeq {
+			delete(o.held, seq)
+		}
+	}
+	o.next = lastSeq + 1
+	h.releaseLocked(userID, o)
+}
+
+func (h *Hub) resetOrderLocked(userID int) {
+	if o := h.order[userID]; o != nil {
+		if o.timer != nil {
+			o.timer.Stop()
+		}
+		delete(h.order, userID)
+	}
+}
+
+func (h *Hub) hasLocalClients(userID int) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients[userID]) > 0
+}
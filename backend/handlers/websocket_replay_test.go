@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"backend/db"
+	"database/sql/driver"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestHub(t *testing.T) *Hub {
+	t.Helper()
+	h := &Hub{
+		clients:             make(map[int]map[*Client]bool),
+		presenceSubscribers: make(map[int]map[*Client]bool),
+		order:               make(map[int]*seqOrder),
+		backplane:           NewMemoryBackplane(NewMemoryBus()),
+	}
+	return h
+}
+
+func addTestClient(h *Hub, userID int) *Client {
+	c := &Client{UserID: userID, Send: make(chan WebSocketMessage, clientSendBuffer+replayBufferPerUser)}
+	h.mu.Lock()
+	if h.clients[userID] == nil {
+		h.clients[userID] = make(map[*Client]bool)
+	}
+	h.clients[userID][c] = true
+	h.mu.Unlock()
+	return c
+}
+
+// receiveSeqs читает n событий из подключения (с таймаутом) и возвращает их seq
+func receiveSeqs(t *testing.T, c *Client, n int, timeout time.Duration) []int64 {
+	t.Helper()
+	var seqs []int64
+	deadline := time.After(timeout)
+	for len(seqs) < n {
+		select {
+		case m := <-c.Send:
+			seqs = append(seqs, m.Seq)
+		case <-deadline:
+			t.Fatalf("received %v, want %d events", seqs, n)
+		}
+	}
+	return seqs
+}
+
+func assertNoEvent(t *testing.T, c *Client) {
+	t.Helper()
+	select {
+	case m := <-c.Send:
+		t.Fatalf("unexpected event seq=%d type=%s", m.Seq, m.Type)
+	default:
+	}
+}
+
+func TestPlanReplay(t *testing.T) {
+	tests := []struct {
+		name                   string
+		since, lastSeq, minSeq int64
+		wantLoad, wantResync   bool
+	}{
+		{"first connection", -1, 50, 1, false, false},
+		{"nothing missed", 50, 50, 1, false, false},
+		{"missed events in buffer", 40, 50, 1, true, false},
+		{"missed right after oldest", 9, 50, 10, true, false},
+		{"oldest already evicted", 8, 50, 10, false, true},
+		{"since from another history", 60, 50, 1, false, true},
+		{"buffer empty", 40, 50, 0, false, true},
+		{"fresh user reconnects with 0", 0, 0, 0, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			load, resync := planReplay(tt.since, tt.lastSeq, tt.minSeq)
+			if load != tt.wantLoad || resync != tt.wantResync {
+				t.Errorf("planReplay(%d, %d, %d) = (load %v, resync %v), want (%v, %v)",
+					tt.since, tt.lastSeq, tt.minSeq, load, resync, tt.wantLoad, tt.wantResync)
+			}
+		})
+	}
+}
+
+func TestLoadReplayEvents(t *testing.T) {
+	tests := []struct {
+		name    string
+		seqs    []int64
+		wantErr bool
+	}{
+		{"contiguous", []int64{11, 12, 13}, false},
+		{"gap in the middle", []int64{11, 13}, true},
+		{"first event missing", []int64{12, 13}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+				res := &fakeResult{}
+				for _, seq := range tt.seqs {
+					res.rows = append(res.rows, []driver.Value{seq, "new_message", `{"id":1}`})
+				}
+				return res, nil
+			})
+			h := newTestHub(t)
+			h.db = db.DB
+
+			events, err := loadReplayEvents(h.db, 1, 10, 13)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected gap error, got %d events", len(events))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(events) != len(tt.seqs) || events[0].Seq != tt.seqs[0] {
+				t.Fatalf("events = %+v", events)
+			}
+		})
+	}
+}
+
+// seqDB - фейковые ws_user_sequences: выдаёт seq по возрастанию и считает запросы
+type seqDB struct {
+	mu        sync.Mutex
+	lastSeq   map[int]int64
+	statement map[string]int
+}
+
+func newSeqDB() *seqDB {
+	return &seqDB{lastSeq: make(map[int]int64), statement: make(map[string]int)}
+}
+
+func (s *seqDB) query(query string, args []driver.Value) (*fakeResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case strings.Contains(query, "INSERT INTO ws_user_sequences"):
+		s.statement["sequences"]++
+		res := &fakeResult{}
+		for _, arg := range args {
+			userID := int(arg.(int64))
+			s.lastSeq[userID]++
+			res.rows = append(res.rows, []driver.Value{int64(userID), s.lastSeq[userID]})
+		}
+		return res, nil
+	case strings.Contains(query, "INSERT INTO ws_user_events"):
+		s.statement["events"]++
+	case strings.Contains(query, "DELETE FROM ws_user_events"):
+		s.statement["trim"]++
+	}
+	return &fakeResult{}, nil
+}
+
+func TestRecordEventsBatchesFanOut(t *testing.T) {
+	tests := []struct {
+		name           string
+		recipients     int
+		wantStatements int // на каждый из трёх видов запросов
+	}{
+		{"single user", 1, 1},
+		{"group chat", 200, 1},
+		{"duplicates are merged", 200, 1},
+		{"more than one batch", recordBatchSize*2 + 1, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seqs := newSeqDB()
+			useFakeDB(t, seqs.query)
+			h := newTestHub(t)
+			h.db = db.DB
+
+			ids := make([]int, 0, tt.recipients)
+			for i := tt.recipients; i >= 1; i-- {
+				ids = append(ids, i)
+			}
+			if tt.name == "duplicates are merged" {
+				ids = append(ids, ids[:50]...)
+			}
+
+			got, err := h.recordEvents(ids, WebSocketMessage{Type: "new_message", Data: map[string]int{"id": 1}})
+			if err != nil {
+				t.Fatalf("recordEvents: %v", err)
+			}
+			if len(got) != tt.recipients {
+				t.Fatalf("got seq for %d users, want %d", len(got), tt.recipients)
+			}
+			for _, kind := range []string{"sequences", "events", "trim"} {
+				if seqs.statement[kind] != tt.wantStatements {
+					t.Errorf("%s statements = %d, want %d", kind, seqs.statement[kind], tt.wantStatements)
+				}
+			}
+			for userID, seq := range got {
+				if seq != 1 {
+					t.Fatalf("user %d: seq %d, want 1", userID, seq)
+				}
+			}
+		})
+	}
+}
+
+func TestDeliverReordersBySeq(t *testing.T) {
+	h := newTestHub(t)
+	c := addTestClient(h, 1)
+
+	h.deliver(1, WebSocketMessage{Type: "new_message", Seq: 3})
+	h.deliver(1, WebSocketMessage{Type: "new_message", Seq: 5})
+	h.deliver(1, WebSocketMessage{Type: "new_message", Seq: 4})
+	h.deliver(1, WebSocketMessage{Type: "typing"}) // Без seq - сразу
+
+	got := receiveSeqs(t, c, 4, time.Second)
+	want := []int64{3, 4, 5, 0}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("delivered %v, want %v", got, want)
+		}
+	}
+}
+
+func TestDeliverFlushesAfterLostEvent(t *testing.T) {
+	h := newTestHub(t)
+	c := addTestClient(h, 1)
+
+	h.deliver(1, WebSocketMessage{Type: "new_message", Seq: 1})
+	receiveSeqs(t, c, 1, time.Second)
+
+	// seq 2 потерялся (например, в шине) - 3 и 4 ждут не дольше seqReorderWait
+	h.deliver(1, WebSocketMessage{Type: "new_message", Seq: 4})
+	h.deliver(1, WebSocketMessage{Type: "new_message", Seq: 3})
+	assertNoEvent(t, c)
+
+	got := receiveSeqs(t, c, 2, 4*seqReorderWait)
+	if got[0] != 3 || got[1] != 4 {
+		t.Fatalf("delivered %v, want [3 4]", got)
+	}
+
+	// Опоздавший seq 2 не теряется
+	h.deliver(1, WebSocketMessage{Type: "new_message", Seq: 2})
+	if got := receiveSeqs(t, c, 1, time.Second); got[0] != 2 {
+		t.Fatalf("delivered %v, want [2]", got)
+	}
+}
+
+func TestSyncDeliveryOrderAfterReplay(t *testing.T) {
+	h := newTestHub(t)
+	c := addTestClient(h, 1)
+
+	// Повтор отдал события до 10 включительно
+	h.syncDeliveryOrder(1, 10)
+	h.deliver(1, WebSocketMessage{Type: "new_message", Seq: 12})
+	assertNoEvent(t, c)
+	h.deliver(1, WebSocketMessage{Type: "new_message", Seq: 11})
+
+	got := receiveSeqs(t, c, 2, time.Second)
+	if got[0] != 11 || got[1] != 12 {
+		t.Fatalf("delivered %v, want [11 12]", got)
+	}
+}
+
+func TestConcurrentDispatchKeepsSeqOrder(t *testing.T) {
+	seqs := newSeqDB()
+	useFakeDB(t, seqs.query)
+	h := newTestHub(t)
+	h.db = db.DB
+	c := addTestClient(h, 1)
+	h.syncDeliveryOrder(1, 0)
+
+	const events = 100
+	var wg sync.WaitGroup
+	for i := 0; i < events; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.dispatchMany([]int{1, 2}, WebSocketMessage{Type: "new_message", Data: map[string]int{"id": 1}})
+		}()
+	}
+	wg.Wait()
+
+	got := receiveSeqs(t, c, events, 4*seqReorderWait)
+	for i, seq := range got {
+		if seq != int64(i+1) {
+			t.Fatalf("event %d has seq %d, want %d (delivered %v)", i, seq, i+1, got)
+		}
+	}
+}
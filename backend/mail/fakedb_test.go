@@ -0,0 +1,118 @@
+package mail
+
+import (
+	"backend/db"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Минимальный драйвер database/sql для тестов очереди без PostgreSQL:
+// запросы записываются, SELECT возвращает пустой результат.
+
+type execCall struct {
+	query string
+	args  []driver.Value
+}
+
+type fakeDB struct {
+	mu    sync.Mutex
+	execs []execCall
+}
+
+func (f *fakeDB) calls() []execCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]execCall(nil), f.execs...)
+}
+
+type fakeDriver struct {
+	mu  sync.Mutex
+	dbs map[string]*fakeDB
+}
+
+var fakeDrv = &fakeDriver{dbs: make(map[string]*fakeDB)}
+
+func init() {
+	sql.Register("mail_fakedb", fakeDrv)
+}
+
+// useFakeDB подменяет db.DB до конца теста и возвращает журнал запросов
+func useFakeDB(t *testing.T) *fakeDB {
+	t.Helper()
+
+	f := &fakeDB{}
+	fakeDrv.mu.Lock()
+	fakeDrv.dbs[t.Name()] = f
+	fakeDrv.mu.Unlock()
+
+	conn, err := sql.Open("mail_fakedb", t.Name())
+	if err != nil {
+		t.Fatalf("open fake db: %v", err)
+	}
+
+	prev := db.DB
+	db.DB = conn
+	t.Cleanup(func() {
+		conn.Close()
+		db.DB = prev
+		fakeDrv.mu.Lock()
+		delete(fakeDrv.dbs, t.Name())
+		fakeDrv.mu.Unlock()
+	})
+	return f
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	d.mu.Lock()
+	f, ok := d.dbs[name]
+	d.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("fake db %q is not registered", name)
+	}
+	return &fakeConn{db: f}, nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: strings.Join(strings.Fields(query), " ")}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.conn.db.mu.Lock()
+	s.conn.db.execs = append(s.conn.db.execs, execCall{query: s.query, args: args})
+	s.conn.db.mu.Unlock()
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return emptyRows{}, nil
+}
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string              { return nil }
+func (emptyRows) Close() error                   { return nil }
+func (emptyRows) Next(dest []driver.Value) error { return io.EOF }
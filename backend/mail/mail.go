@@ -0,0 +1,130 @@
+package mail
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Message - готовое к отправке письмо
+type Message struct {
+	ID       int64 // ID в email_outbox (0 - письмо отправляется мимо очереди)
+	Template string
+	To       string
+	Subject  string
+	Text     string
+	HTML     string // Необязательно: без HTML письмо уходит только текстом
+}
+
+// Transport - способ доставки писем
+type Transport interface {
+	Send(msg *Message) error
+}
+
+// Sender - адрес отправителя
+type Sender struct {
+	Name    string
+	Address string
+}
+
+// Global - транспорт, выбранный при запуске
+var Global Transport = NewLogTransport()
+
+// From - отправитель всех писем
+var From = Sender{Name: "ЗооПлатформа", Address: "no-reply@localhost"}
+
+// Init выбирает транспорт по переменным окружения:
+// MAIL_TRANSPORT=smtp - SMTP (SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD),
+// MAIL_TRANSPORT=file - письма сохраняются в MAIL_DIR как .eml (разработка и тесты),
+// MAIL_TRANSPORT=log - в лог пишутся только получатель, шаблон и тема.
+// В production MAIL_TRANSPORT обязателен. При ошибке настройки письма только пишутся в лог,
+// а ошибка возвращается вызывающему (в production запуск останавливается)
+func Init() error {
+	if address := os.Getenv("MAIL_FROM"); address != "" {
+		From.Address = address
+	}
+	if name := os.Getenv("MAIL_FROM_NAME"); name != "" {
+		From.Name = name
+	}
+
+	transport, err := newTransportFromEnv()
+	if err != nil {
+		Global = NewLogTransport()
+		return err
+	}
+	Global = transport
+	return nil
+}
+
+func newTransportFromEnv() (Transport, error) {
+	switch kind := os.Getenv("MAIL_TRANSPORT"); kind {
+	case "smtp":
+		host := os.Getenv("SMTP_HOST")
+		if host == "" {
+			return nil, fmt.Errorf("SMTP_HOST is required for MAIL_TRANSPORT=smtp")
+		}
+		port := 587
+		if p := os.Getenv("SMTP_PORT"); p != "" {
+			var err error
+			if port, err = strconv.Atoi(p); err != nil {
+				return nil, fmt.Errorf("invalid SMTP_PORT %q", p)
+			}
+		}
+		log.Printf("📧 Mail transport: SMTP %s:%d", host, port)
+		return NewSMTPTransport(host, port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD")), nil
+	case "file":
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = "./mail"
+		}
+		transport, err := NewFileTransport(dir)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("📧 Mail transport: files in %s", dir)
+		return transport, nil
+	case "":
+		if isProduction() {
+			return nil, fmt.Errorf("MAIL_TRANSPORT is required in production (smtp, file or log)")
+		}
+		log.Println("📧 Mail transport: log (письма не отправляются)")
+		return NewLogTransport(), nil
+	case "log":
+		log.Println("📧 Mail transport: log (письма не отправляются)")
+		return NewLogTransport(), nil
+	default:
+		return nil, fmt.Errorf("unknown MAIL_TRANSPORT %q (expected smtp, file or log)", kind)
+	}
+}
+
+func isProduction() bool {
+	return os.Getenv("ENVIRONMENT") == "production"
+}
+
+// AppURL - адрес фронтенда для ссылок в письмах
+func AppURL() string {
+	if url := os.Getenv("APP_URL"); url != "" {
+		return strings.TrimRight(url, "/")
+	}
+	return "http://localhost:3000"
+}
+
+// convertPlaceholdersMail конвертирует ? в $1, $2, $3 для PostgreSQL
+func convertPlaceholdersMail(query string) string {
+	if !isProduction() {
+		return query
+	}
+	var b strings.Builder
+	paramNum := 1
+	for _, char := range query {
+		if char == '?' {
+			fmt.Fprintf(&b, "$%d", paramNum)
+			paramNum++
+		} else {
+			b.WriteRune(char)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,221 @@
+package mail
+
+import (
+	"backend/db"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Очередь писем (email_outbox): письмо сохраняется в БД вместе с остальными изменениями,
+// а фоновый обработчик отправляет его через Global с повторами при ошибках.
+// Несколько экземпляров backend разбирают очередь без дублей (FOR UPDATE SKIP LOCKED).
+const (
+	StatusPending = "pending"
+	StatusSending = "sending"
+	StatusSent    = "sent"
+	StatusFailed  = "failed"
+
+	outboxBatchSize = 20
+	// Письмо в статусе sending дольше этого времени считается брошенным (экземпляр упал во время отправки)
+	outboxSendingTimeout = 10 * time.Minute
+	// Отправленные и окончательно неотправленные письма хранятся столько (без текста - см. deliverOutboxItem)
+	outboxRetention = 30 * 24 * time.Hour
+)
+
+// retryDelays - паузы перед повторами; после последней письмо помечается failed
+var retryDelays = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	15 * time.Minute,
+	time.Hour,
+	3 * time.Hour,
+	6 * time.Hour,
+}
+
+// queryRower - *sql.DB или *sql.Tx: письмо можно поставить в очередь в той же транзакции
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// Enqueue ставит письмо по шаблону в очередь и возвращает его ID
+func Enqueue(to, templateKey, locale string, data map[string]interface{}) (int64, error) {
+	return EnqueueTx(db.DB, to, templateKey, locale, data)
+}
+
+// EnqueueTx - то же в транзакции: письмо уйдёт, только если транзакция закоммичена
+func EnqueueTx(q queryRower, to, templateKey, locale string, data map[string]interface{}) (int64, error) {
+	if to == "" {
+		return 0, fmt.Errorf("recipient is empty")
+	}
+	if locale == "" {
+		locale = DefaultLocale
+	}
+	// Шаблон применяется сразу: ошибка в данных видна вызывающему, а не в фоне
+	rendered, err := Render(templateKey, locale, data)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int64
+	err = q.QueryRow(convertPlaceholdersMail(`
+		INSERT INTO email_outbox (to_email, template_key, locale, subject, text_body, html_body, status, next_attempt_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`), to, templateKey, locale, rendered.Subject, rendered.Text, rendered.HTML, StatusPending, time.Now(), time.Now()).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue mail: %w", err)
+	}
+	return id, nil
+}
+
+// StartOutboxWorker периодически отправляет письма из очереди
+func StartOutboxWorker(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		lastCleanup := time.Time{}
+		for {
+			processOutbox()
+			if time.Since(lastCleanup) > time.Hour {
+				cleanupOutbox()
+				lastCleanup = time.Now()
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// processOutbox отправляет пачки писем, пока очередь не опустеет
+func processOutbox() {
+	for {
+		batch, err := claimOutboxBatch()
+		if err != nil {
+			log.Printf("❌ Mail outbox: %v", err)
+			return
+		}
+		for _, item := range batch {
+			deliverOutboxItem(item)
+		}
+		if len(batch) < outboxBatchSize {
+			return
+		}
+	}
+}
+
+type outboxItem struct {
+	msg      Message
+	attempts int
+}
+
+// claimOutboxBatch забирает готовые к отправке письма, переводя их в sending
+func claimOutboxBatch() ([]outboxItem, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	rows, err := tx.Query(convertPlaceholdersMail(`
+		SELECT id, template_key, to_email, subject, text_body, COALESCE(html_body, ''), attempts
+		FROM email_outbox
+		WHERE (status = ? AND next_attempt_at <= ?)
+		OR (status = ? AND locked_at < ?)
+		ORDER BY next_attempt_at ASC
+		LIMIT ?
+		FOR UPDATE SKIP LOCKED
+	`), StatusPending, now, StatusSending, now.Add(-outboxSendingTimeout), outboxBatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var batch []outboxItem
+	for rows.Next() {
+		var item outboxItem
+		if err := rows.Scan(&item.msg.ID, &item.msg.Template, &item.msg.To, &item.msg.Subject, &item.msg.Text, &item.msg.HTML, &item.attempts); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		batch = append(batch, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, item := range batch {
+		if _, err := tx.Exec(convertPlaceholdersMail(`
+			UPDATE email_outbox SET status = ?, locked_at = ? WHERE id = ?
+		`), StatusSending, now, item.msg.ID); err != nil {
+			return nil, err
+		}
+	}
+	return batch, tx.Commit()
+}
+
+// deliverOutboxItem отправляет письмо и сохраняет результат. Текст отправленного или окончательно
+// неотправленного письма стирается: в нём бывают одноразовые ссылки (сброс пароля, подтверждение почты),
+// а в очереди остаются только получатель, шаблон и статус
+func deliverOutboxItem(item outboxItem) {
+	sendErr := Global.Send(&item.msg)
+	attempts := item.attempts + 1
+
+	if sendErr == nil {
+		_, err := db.DB.Exec(convertPlaceholdersMail(`
+			UPDATE email_outbox SET status = ?, attempts = ?, sent_at = ?, locked_at = NULL, last_error = NULL,
+				text_body = '', html_body = NULL
+			WHERE id = ?
+		`), StatusSent, attempts, time.Now(), item.msg.ID)
+		if err != nil {
+			log.Printf("⚠️ Mail outbox: mail %d sent but status not saved: %v", item.msg.ID, err)
+		}
+		return
+	}
+
+	status, nextAttempt := nextOutboxAttempt(attempts, time.Now())
+	if status == StatusFailed {
+		log.Printf("❌ Mail outbox: mail %d to %s failed after %d attempts: %v", item.msg.ID, item.msg.To, attempts, sendErr)
+		_, err := db.DB.Exec(convertPlaceholdersMail(`
+			UPDATE email_outbox SET status = ?, attempts = ?, locked_at = NULL, last_error = ?,
+				text_body = '', html_body = NULL
+			WHERE id = ?
+		`), status, attempts, sendErr.Error(), item.msg.ID)
+		if err != nil {
+			log.Printf("⚠️ Mail outbox: failed to save status of mail %d: %v", item.msg.ID, err)
+		}
+		return
+	}
+
+	log.Printf("⚠️ Mail outbox: mail %d to %s failed (attempt %d), retry at %s: %v",
+		item.msg.ID, item.msg.To, attempts, nextAttempt.Format(time.RFC3339), sendErr)
+	_, err := db.DB.Exec(convertPlaceholdersMail(`
+		UPDATE email_outbox SET status = ?, attempts = ?, next_attempt_at = ?, locked_at = NULL, last_error = ? WHERE id = ?
+	`), status, attempts, nextAttempt, sendErr.Error(), item.msg.ID)
+	if err != nil {
+		log.Printf("⚠️ Mail outbox: failed to save status of mail %d: %v", item.msg.ID, err)
+	}
+}
+
+// nextOutboxAttempt - статус письма после неудачной попытки номер attempts и время следующей попытки
+func nextOutboxAttempt(attempts int, now time.Time) (string, time.Time) {
+	if attempts > len(retryDelays) {
+		return StatusFailed, now
+	}
+	return StatusPending, now.Add(retryDelays[attempts-1])
+}
+
+func cleanupOutbox() {
+	result, err := db.DB.Exec(convertPlaceholdersMail(`
+		DELETE FROM email_outbox WHERE status IN (?, ?) AND created_at < ?
+	`), StatusSent, StatusFailed, time.Now().Add(-outboxRetention))
+	if err != nil {
+		log.Printf("⚠️ Mail outbox cleanup failed: %v", err)
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected > 0 {
+		log.Printf("🧹 Mail outbox: removed %d old mails", affected)
+	}
+}
@@ -0,0 +1,123 @@
+package mail
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNextOutboxAttempt(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		attempts   int
+		wantStatus string
+		wantDelay  time.Duration
+	}{
+		{1, StatusPending, time.Minute},
+		{2, StatusPending, 5 * time.Minute},
+		{3, StatusPending, 15 * time.Minute},
+		{4, StatusPending, time.Hour},
+		{5, StatusPending, 3 * time.Hour},
+		{6, StatusPending, 6 * time.Hour},
+		{7, StatusFailed, 0},
+		{10, StatusFailed, 0},
+	}
+
+	for _, tt := range tests {
+		status, next := nextOutboxAttempt(tt.attempts, now)
+		if status != tt.wantStatus || next.Sub(now) != tt.wantDelay {
+			t.Errorf("attempt %d: got %s after %s, want %s after %s",
+				tt.attempts, status, next.Sub(now), tt.wantStatus, tt.wantDelay)
+		}
+	}
+}
+
+// withFileTransport подменяет Global на FileTransport во временном каталоге
+func withFileTransport(t *testing.T) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "mail")
+	transport, err := NewFileTransport(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := Global
+	Global = transport
+	t.Cleanup(func() { Global = prev })
+	return dir
+}
+
+func testOutboxItem(attempts int) outboxItem {
+	return outboxItem{
+		msg: Message{
+			ID:       42,
+			Template: TemplatePasswordReset,
+			To:       "anna@example.com",
+			Subject:  "Восстановление пароля",
+			Text:     "https://example.com/reset-password?token=secret",
+		},
+		attempts: attempts,
+	}
+}
+
+func TestDeliverOutboxItemWithFileTransport(t *testing.T) {
+	tests := []struct {
+		name         string
+		attempts     int // попыток до этой
+		unavailable  bool
+		wantStatus   string
+		wantDelay    time.Duration
+		wantCleared  bool
+		wantEMLFiles int
+	}{
+		{"sent on first attempt", 0, false, StatusSent, 0, true, 1},
+		{"first failure is retried in a minute", 0, true, StatusPending, time.Minute, false, 0},
+		{"backoff grows", 3, true, StatusPending, time.Hour, false, 0},
+		{"last retry sent", len(retryDelays), false, StatusSent, 0, true, 1},
+		{"gives up after last retry", len(retryDelays), true, StatusFailed, 0, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := useFakeDB(t)
+			dir := withFileTransport(t)
+			if tt.unavailable {
+				// Каталог исчез - FileTransport не может записать письмо
+				if err := os.RemoveAll(dir); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			before := time.Now()
+			deliverOutboxItem(testOutboxItem(tt.attempts))
+
+			calls := fake.calls()
+			if len(calls) != 1 {
+				t.Fatalf("got %d updates, want 1", len(calls))
+			}
+			update := calls[0]
+			if status := update.args[0]; status != tt.wantStatus {
+				t.Fatalf("status %v, want %s (query %s)", status, tt.wantStatus, update.query)
+			}
+			if attempts := update.args[1]; attempts != int64(tt.attempts+1) {
+				t.Errorf("attempts %v, want %d", attempts, tt.attempts+1)
+			}
+			if cleared := strings.Contains(update.query, "text_body = ''"); cleared != tt.wantCleared {
+				t.Errorf("body cleared = %v, want %v (query %s)", cleared, tt.wantCleared, update.query)
+			}
+			if tt.wantStatus == StatusPending {
+				next := update.args[2].(time.Time)
+				if delay := next.Sub(before); delay < tt.wantDelay || delay > tt.wantDelay+time.Minute {
+					t.Errorf("next attempt in %s, want %s", delay, tt.wantDelay)
+				}
+			}
+
+			files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+			if len(files) != tt.wantEMLFiles {
+				t.Fatalf("got %d .eml files, want %d", len(files), tt.wantEMLFiles)
+			}
+		})
+	}
+}
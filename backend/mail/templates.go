@@ -0,0 +1,237 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+// DefaultLocale - язык писем по умолчанию; для остальных языков при отсутствии перевода используется он
+const DefaultLocale = "ru"
+
+// Ключи шаблонов
+const (
+	TemplatePasswordReset      = "auth.password_reset"
+	TemplatePasswordChanged    = "auth.password_changed"
+	TemplateEmailVerification  = "auth.email_verification"
+	TemplateNotification       = "notifications.new"
+	TemplateNotificationDigest = "notifications.digest"
+)
+
+// localizedTemplate - письмо на одном языке. Subject и Text - text/template, HTML - html/template
+// (данные экранируются). HTML оборачивается в общий макет.
+type localizedTemplate struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// templates: ключ -> язык -> шаблон
+var templates = map[string]map[string]localizedTemplate{
+	TemplatePasswordReset: {
+		"ru": {
+			Subject: "Восстановление пароля",
+			Text: `Здравствуйте, {{.Name}}!
+
+Мы получили запрос на смену пароля. Чтобы задать новый пароль, перейдите по ссылке:
+{{.URL}}
+
+Ссылка действует {{.ExpiresIn}} и сработает один раз.
+Если вы не запрашивали смену пароля, просто проигнорируйте это письмо.`,
+			HTML: `<p>Здравствуйте, {{.Name}}!</p>
+<p>Мы получили запрос на смену пароля. Чтобы задать новый пароль, нажмите на кнопку:</p>
+<p><a href="{{.URL}}" style="{{buttonStyle}}">Задать новый пароль</a></p>
+<p>Ссылка действует {{.ExpiresIn}} и сработает один раз.<br>Если вы не запрашивали смену пароля, просто проигнорируйте это письмо.</p>`,
+		},
+		"en": {
+			Subject: "Password reset",
+			Text: `Hello, {{.Name}}!
+
+We received a request to reset your password. To set a new password, follow the link:
+{{.URL}}
+
+The link is valid for {{.ExpiresIn}} and can be used once.
+If you did not request a password reset, just ignore this email.`,
+			HTML: `<p>Hello, {{.Name}}!</p>
+<p>We received a request to reset your password. To set a new password, click the button:</p>
+<p><a href="{{.URL}}" style="{{buttonStyle}}">Set a new password</a></p>
+<p>The link is valid for {{.ExpiresIn}} and can be used once.<br>If you did not request a password reset, just ignore this email.</p>`,
+		},
+	},
+	TemplatePasswordChanged: {
+		"ru": {
+			Subject: "Пароль изменён",
+			Text: `Здравствуйте, {{.Name}}!
+
+Пароль вашей учётной записи был изменён, все активные сеансы завершены.
+Если это сделали не вы, срочно восстановите доступ: {{.URL}}`,
+			HTML: `<p>Здравствуйте, {{.Name}}!</p>
+<p>Пароль вашей учётной записи был изменён, все активные сеансы завершены.</p>
+<p>Если это сделали не вы, срочно <a href="{{.URL}}">восстановите доступ</a>.</p>`,
+		},
+		"en": {
+			Subject: "Your password was changed",
+			Text: `Hello, {{.Name}}!
+
+The password of your account was changed and all active sessions were signed out.
+If it wasn't you, recover access immediately: {{.URL}}`,
+			HTML: `<p>Hello, {{.Name}}!</p>
+<p>The password of your account was changed and all active sessions were signed out.</p>
+<p>If it wasn't you, <a href="{{.URL}}">recover access</a> immediately.</p>`,
+		},
+	},
+	TemplateEmailVerification: {
+		"ru": {
+			Subject: "Подтвердите адрес почты",
+			Text: `Здравствуйте, {{.Name}}!
+
+Подтвердите адрес почты, перейдя по ссылке:
+{{.URL}}`,
+			HTML: `<p>Здравствуйте, {{.Name}}!</p>
+<p>Подтвердите адрес почты:</p>
+<p><a href="{{.URL}}" style="{{buttonStyle}}">Подтвердить</a></p>`,
+		},
+		"en": {
+			Subject: "Confirm your email",
+			Text: `Hello, {{.Name}}!
+
+Please confirm your email address by following the link:
+{{.URL}}`,
+			HTML: `<p>Hello, {{.Name}}!</p>
+<p>Please confirm your email address:</p>
+<p><a href="{{.URL}}" style="{{buttonStyle}}">Confirm</a></p>`,
+		},
+	},
+	TemplateNotification: {
+		"ru": {
+			Subject: "{{.Message}}",
+			Text: `Здравствуйте, {{.Name}}!
+
+{{.Message}}
+
+Открыть уведомления: {{.URL}}`,
+			HTML: `<p>Здравствуйте, {{.Name}}!</p>
+<p>{{.Message}}</p>
+<p><a href="{{.URL}}" style="{{buttonStyle}}">Открыть уведомления</a></p>`,
+		},
+		"en": {
+			Subject: "{{.Message}}",
+			Text: `Hello, {{.Name}}!
+
+{{.Message}}
+
+Open notifications: {{.URL}}`,
+			HTML: `<p>Hello, {{.Name}}!</p>
+<p>{{.Message}}</p>
+<p><a href="{{.URL}}" style="{{buttonStyle}}">Open notifications</a></p>`,
+		},
+	},
+	TemplateNotificationDigest: {
+		"ru": {
+			Subject: "Сводка за сутки",
+			Text: `Здравствуйте, {{.Name}}!
+
+{{.Message}}
+
+Открыть уведомления: {{.URL}}`,
+			HTML: `<p>Здравствуйте, {{.Name}}!</p>
+<p>{{.Message}}</p>
+<p><a href="{{.URL}}" style="{{buttonStyle}}">Открыть уведомления</a></p>`,
+		},
+	},
+}
+
+const htmlLayout = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"></head>
+<body style="margin:0;padding:24px;background:#f5f5f5;font-family:Arial,sans-serif;color:#222;">
+<div style="max-width:560px;margin:0 auto;background:#fff;border-radius:8px;padding:24px;">
+%s
+<p style="margin-top:32px;font-size:12px;color:#888;">%s</p>
+</div>
+</body>
+</html>`
+
+// layoutFooter - подпись под письмом (тоже по ключу языка)
+var layoutFooter = map[string]string{
+	"ru": "Это автоматическое письмо, отвечать на него не нужно.",
+	"en": "This is an automated email, please do not reply.",
+}
+
+var templateFuncs = map[string]interface{}{
+	"buttonStyle": func() htmltemplate.CSS {
+		return "display:inline-block;padding:12px 20px;background:#2e7d32;color:#fff;border-radius:6px;text-decoration:none;"
+	},
+}
+
+// Rendered - письмо после подстановки данных
+type Rendered struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// HasTemplate - есть ли шаблон с таким ключом
+func HasTemplate(key string) bool {
+	_, ok := templates[key]
+	return ok
+}
+
+// Render подставляет данные в шаблон на нужном языке (или на DefaultLocale, если перевода нет)
+func Render(key, locale string, data map[string]interface{}) (*Rendered, error) {
+	translations, ok := templates[key]
+	if !ok {
+		return nil, fmt.Errorf("unknown mail template %q", key)
+	}
+	tpl, ok := translations[locale]
+	if !ok {
+		locale = DefaultLocale
+		tpl = translations[locale]
+	}
+
+	subject, err := renderText(key+".subject", tpl.Subject, data)
+	if err != nil {
+		return nil, err
+	}
+	text, err := renderText(key+".text", tpl.Text, data)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered := &Rendered{Subject: strings.TrimSpace(subject), Text: text}
+	if tpl.HTML != "" {
+		body, err := renderHTML(key+".html", tpl.HTML, data)
+		if err != nil {
+			return nil, err
+		}
+		footer := htmltemplate.HTMLEscapeString(layoutFooter[locale])
+		rendered.HTML = fmt.Sprintf(htmlLayout, body, footer)
+	}
+	return rendered, nil
+}
+
+func renderText(name, source string, data map[string]interface{}) (string, error) {
+	t, err := texttemplate.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(source)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func renderHTML(name, source string, data map[string]interface{}) (string, error) {
+	t, err := htmltemplate.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(source)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
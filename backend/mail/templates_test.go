@@ -0,0 +1,54 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderAllTemplates(t *testing.T) {
+	data := map[string]interface{}{
+		"Name":      "Анна <b>",
+		"URL":       "https://example.com/reset-password?token=abc&x=1",
+		"ExpiresIn": "1 час",
+		"Message":   "Новый комментарий",
+	}
+
+	for key, translations := range templates {
+		for _, locale := range []string{"ru", "en"} {
+			t.Run(key+"/"+locale, func(t *testing.T) {
+				rendered, err := Render(key, locale, data)
+				if err != nil {
+					t.Fatalf("Render: %v", err)
+				}
+
+				// Шаблон без перевода берётся на языке по умолчанию
+				wantLocale := locale
+				if _, ok := translations[locale]; !ok {
+					wantLocale = DefaultLocale
+				}
+
+				if rendered.Subject == "" || strings.ContainsAny(rendered.Subject, "\r\n") {
+					t.Errorf("subject %q must be a single non-empty line", rendered.Subject)
+				}
+				if !strings.Contains(rendered.Text, "Анна <b>") {
+					t.Errorf("text does not contain the name:\n%s", rendered.Text)
+				}
+				if strings.Contains(rendered.HTML, "Анна <b>") || !strings.Contains(rendered.HTML, "Анна &lt;b&gt;") {
+					t.Errorf("HTML does not escape the name:\n%s", rendered.HTML)
+				}
+				if !strings.Contains(rendered.HTML, layoutFooter[wantLocale]) {
+					t.Errorf("HTML has no %s footer", wantLocale)
+				}
+			})
+		}
+	}
+}
+
+func TestRenderMissingData(t *testing.T) {
+	if _, err := Render(TemplatePasswordReset, "ru", map[string]interface{}{"Name": "Анна"}); err == nil {
+		t.Fatal("expected error for missing URL")
+	}
+	if _, err := Render("unknown.template", "ru", nil); err == nil {
+		t.Fatal("expected error for unknown template")
+	}
+}
@@ -0,0 +1,217 @@
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ===== SMTP =====
+
+// SMTPTransport отправляет письма через SMTP: порт 465 - сразу TLS, остальные - STARTTLS, если сервер его поддерживает
+type SMTPTransport struct {
+	host     string
+	port     int
+	username string
+	password string
+	timeout  time.Duration
+}
+
+// NewSMTPTransport создаёт SMTP-транспорт; без username авторизация не выполняется
+func NewSMTPTransport(host string, port int, username, password string) *SMTPTransport {
+	return &SMTPTransport{host: host, port: port, username: username, password: password, timeout: 30 * time.Second}
+}
+
+func (t *SMTPTransport) Send(msg *Message) error {
+	raw, err := buildMIME(msg)
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(t.host, strconv.Itoa(t.port))
+	var conn net.Conn
+	if t.port == 465 {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: t.timeout}, "tcp", addr, &tls.Config{ServerName: t.host})
+	} else {
+		conn, err = net.DialTimeout("tcp", addr, t.timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	conn.SetDeadline(time.Now().Add(t.timeout))
+
+	client, err := smtp.NewClient(conn, t.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if t.port != 465 {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: t.host}); err != nil {
+				return fmt.Errorf("STARTTLS failed: %w", err)
+			}
+		}
+	}
+	if t.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", t.username, t.password, t.host)); err != nil {
+			return fmt.Errorf("SMTP auth failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(From.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(raw); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// ===== Файлы =====
+
+// FileTransport сохраняет письма в каталог как .eml - для разработки и тестов
+type FileTransport struct {
+	dir string
+}
+
+// NewFileTransport создаёт каталог, если его нет
+func NewFileTransport(dir string) (*FileTransport, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create mail dir %s: %w", dir, err)
+	}
+	return &FileTransport{dir: dir}, nil
+}
+
+func (t *FileTransport) Send(msg *Message) error {
+	raw, err := buildMIME(msg)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%d-%s.eml", time.Now().Format("20060102-150405"), msg.ID, sanitizeFileName(msg.To))
+	return os.WriteFile(filepath.Join(t.dir, name), raw, 0644)
+}
+
+func sanitizeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '@' || r == '.' || r == '-' || r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// ===== Лог =====
+
+// LogTransport только пишет письма в лог (транспорт по умолчанию вне production).
+// Тело письма не логируется: в нём бывают ссылки со сброса пароля и другие токены
+type LogTransport struct{}
+
+func NewLogTransport() *LogTransport {
+	return &LogTransport{}
+}
+
+func (t *LogTransport) Send(msg *Message) error {
+	log.Printf("📧 [mail] to=%s template=%s subject=%q", msg.To, msg.Template, msg.Subject)
+	return nil
+}
+
+// ===== MIME =====
+
+// buildMIME собирает письмо: multipart/alternative (текст + HTML) или только текст, UTF-8, quoted-printable
+func buildMIME(msg *Message) ([]byte, error) {
+	if _, err := netmail.ParseAddress(msg.To); err != nil {
+		return nil, fmt.Errorf("invalid recipient %q: %w", msg.To, err)
+	}
+
+	var buf bytes.Buffer
+	from := netmail.Address{Name: From.Name, Address: From.Address}
+	writeHeader(&buf, "From", from.String())
+	writeHeader(&buf, "To", msg.To)
+	writeHeader(&buf, "Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	writeHeader(&buf, "Date", time.Now().Format(time.RFC1123Z))
+	writeHeader(&buf, "Message-ID", fmt.Sprintf("<%s@%s>", randomToken(), messageIDDomain()))
+	writeHeader(&buf, "MIME-Version", "1.0")
+
+	if msg.HTML == "" {
+		writeHeader(&buf, "Content-Type", "text/plain; charset=utf-8")
+		writeHeader(&buf, "Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, msg.Text); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	boundary := "zp-" + randomToken()
+	writeHeader(&buf, "Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", boundary))
+	buf.WriteString("\r\n")
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		fmt.Fprintf(&buf, "--%s\r\n", boundary)
+		writeHeader(&buf, "Content-Type", part.contentType)
+		writeHeader(&buf, "Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, part.body); err != nil {
+			return nil, err
+		}
+		buf.WriteString("\r\n")
+	}
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+	return buf.Bytes(), nil
+}
+
+func writeHeader(buf *bytes.Buffer, name, value string) {
+	// Переводы строк в значении позволили бы подставить чужие заголовки
+	value = strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+	fmt.Fprintf(buf, "%s: %s\r\n", name, value)
+}
+
+func writeQuotedPrintable(buf *bytes.Buffer, body string) error {
+	w := quotedprintable.NewWriter(buf)
+	body = strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n")
+	if _, err := w.Write([]byte(body)); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+func messageIDDomain() string {
+	if at := strings.LastIndex(From.Address, "@"); at >= 0 && at < len(From.Address)-1 {
+		return From.Address[at+1:]
+	}
+	return "localhost"
+}
+
+func randomToken() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,88 @@
+package mail
+
+import (
+	"bytes"
+	"log"
+	"mime"
+	netmail "net/mail"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWriteHeaderStripsLineBreaks(t *testing.T) {
+	tests := []struct {
+		name, value, want string
+	}{
+		{"crlf", "Привет\r\nBcc: victim@example.com", "X-Test: Привет  Bcc: victim@example.com\r\n"},
+		{"lf", "Привет\nBcc: victim@example.com", "X-Test: Привет Bcc: victim@example.com\r\n"},
+		{"cr", "Привет\rBcc: victim@example.com", "X-Test: Привет Bcc: victim@example.com\r\n"},
+		{"plain", "Привет", "X-Test: Привет\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			writeHeader(&buf, "X-Test", tt.value)
+			if buf.String() != tt.want {
+				t.Fatalf("header %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildMIMEHeaderInjection(t *testing.T) {
+	prev := From
+	From = Sender{Name: "Zoo\r\nBcc: from@example.com", Address: "no-reply@example.com"}
+	t.Cleanup(func() { From = prev })
+
+	raw, err := buildMIME(&Message{
+		To:      "anna@example.com",
+		Subject: "Тема\r\nBcc: subject@example.com",
+		Text:    "Текст",
+		HTML:    "<p>Текст</p>",
+	})
+	if err != nil {
+		t.Fatalf("buildMIME: %v", err)
+	}
+
+	msg, err := netmail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("parse built mail: %v", err)
+	}
+	if bcc := msg.Header.Get("Bcc"); bcc != "" {
+		t.Fatalf("injected Bcc header: %q", bcc)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || !strings.HasPrefix(subject, "Тема") {
+		t.Fatalf("subject %q (%v)", subject, err)
+	}
+
+	if _, err := buildMIME(&Message{To: "anna@example.com\r\nBcc: to@example.com", Text: "x"}); err == nil {
+		t.Fatal("expected error for recipient with line break")
+	}
+}
+
+func TestLogTransportOmitsBody(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	NewLogTransport().Send(&Message{
+		Template: TemplatePasswordReset,
+		To:       "anna@example.com",
+		Subject:  "Восстановление пароля",
+		Text:     "https://example.com/reset-password?token=secret",
+		HTML:     "<a href=\"https://example.com/reset-password?token=secret\">",
+	})
+
+	out := buf.String()
+	for _, want := range []string{"anna@example.com", TemplatePasswordReset, "Восстановление пароля"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log %q does not contain %q", out, want)
+		}
+	}
+	if strings.Contains(out, "secret") {
+		t.Errorf("log contains the mail body: %q", out)
+	}
+}
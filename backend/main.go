@@ -3,13 +3,16 @@ package main
 import (
 	"backend/db"
 	"backend/handlers"
+	"backend/mail"
 	"backend/middleware"
+	"backend/registry"
 	"backend/storage"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -31,6 +34,7 @@ func enableCORS(next http.HandlerFunc) http.HandlerFunc {
 			w.Header().Set("Access-Control-Allow-Credentials", "true")
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, PATCH, OPTIONS")
 			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			w.Header().Set("Access-Control-Expose-Headers", "X-Has-More, X-Has-Newer, X-Oldest-Id, X-Newest-Id")
 		}
 
 		// Обрабатываем preflight запрос
@@ -61,6 +65,7 @@ func enableCORSHandler(next http.Handler) http.Handler {
 			w.Header().Set("Access-Control-Allow-Credentials", "true")
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, PATCH, OPTIONS")
 			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			w.Header().Set("Access-Control-Expose-Headers", "X-Has-More, X-Has-Newer, X-Oldest-Id, X-Newest-Id")
 		}
 
 		// Обрабатываем preflight запрос
@@ -120,6 +125,25 @@ func main() {
 		log.Println("📁 Falling back to local file storage")
 	}
 
+	// Initialize company registry (DaData)
+	registry.Init()
+	handlers.StartOrganizationRegistryRefresh(time.Hour, 7*24*time.Hour)
+
+	// Почта: транспорт (MAIL_TRANSPORT) и отправка из очереди email_outbox
+	// В production без рабочего транспорта письма (сброс пароля и т.п.) терялись бы молча
+	if err := mail.Init(); err != nil {
+		if env == "production" {
+			log.Fatalf("❌ Mail initialization failed: %v", err)
+		}
+		log.Printf("⚠️  Mail initialization failed, mails are only logged: %v", err)
+	}
+	mail.StartOutboxWorker(30 * time.Second)
+
+	// Напоминания о записях в ветклиники
+	handlers.StartAppointmentReminders(10 * time.Minute)
+	handlers.StartNotificationDigest(time.Hour)
+	handlers.StartPasswordResetCleanup(time.Hour)
+
 	// Initialize WebSocket hub
 	log.Println("🔌 Initializing WebSocket hub...")
 	handlers.InitWebSocketHub(db.DB)
@@ -137,6 +161,8 @@ func main() {
 	http.HandleFunc("/api/auth/logout", enableCORS(handlers.LogoutHandler))
 	http.HandleFunc("/api/auth/me", enableCORS(handlers.MeHandler))
 	http.HandleFunc("/api/auth/verify", enableCORS(handlers.VerifyTokenHandler))
+	http.HandleFunc("/api/auth/password/forgot", enableCORS(handlers.ForgotPasswordHandler))
+	http.HandleFunc("/api/auth/password/reset", enableCORS(handlers.ResetPasswordHandler))
 
 	// Public user profile endpoint
 	http.HandleFunc("/api/users/", enableCORS(handlers.UserHandler))               // Публичный просмотр профилей пользователей
@@ -247,6 +273,9 @@ func main() {
 	http.HandleFunc("/api/announcements/posts/", enableCORS(handlers.AnnouncementPostsHandler))
 	http.HandleFunc("/api/announcements/donations/", enableCORS(handlers.AnnouncementDonationsHandler))
 
+	// Поисковые выходы волонтёров по объявлениям о потере (Gateway проверяет авторизацию)
+	http.HandleFunc("/api/search-sessions/", enableCORS(handlers.SearchSessionHandler))
+
 	// Friends (требует авторизацию)
 	http.HandleFunc("/api/friends", protectedRoute(handlers.GetFriendsHandler))
 	http.HandleFunc("/api/friends/requests", protectedRoute(handlers.GetFriendRequestsHandler))
@@ -261,6 +290,10 @@ func main() {
 	http.HandleFunc("/api/notifications", protectedRoute(notificationsHandler.GetNotifications))
 	http.HandleFunc("/api/notifications/unread", protectedRoute(notificationsHandler.GetUnreadCount))
 	http.HandleFunc("/api/notifications/read-all", protectedRoute(notificationsHandler.MarkAllAsRead))
+	http.HandleFunc("/api/notifications/digest", protectedRoute(notificationsHandler.DigestSettings))
+	http.HandleFunc("/api/notifications/digest/preview", protectedRoute(notificationsHandler.DigestPreview))
+	http.HandleFunc("/api/notifications/preferences", protectedRoute(notificationsHandler.Preferences))
+	http.HandleFunc("/api/notifications/mutes", protectedRoute(notificationsHandler.Mutes))
 	http.HandleFunc("/api/notifications/", protectedRoute(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == "PUT" {
 			notificationsHandler.MarkAsRead(w, r)
@@ -277,8 +310,24 @@ func main() {
 	http.HandleFunc("/api/organizations/members/update", protectedRoute(handlers.UpdateMemberHandler))                                       // Требует авторизацию
 	http.HandleFunc("/api/organizations/members/remove", protectedRoute(handlers.RemoveMemberHandler))                                       // Требует авторизацию
 	http.HandleFunc("/api/organizations/members/", enableCORS(middleware.DevOptionalAuthMiddleware(handlers.GetOrganizationMembersHandler))) // Опциональная авторизация
+	http.HandleFunc("/api/organizations/invitations", protectedRoute(handlers.GetMyInvitationsHandler))                                      // Требует авторизацию
+	http.HandleFunc("/api/organizations/invitations/", protectedRoute(handlers.OrganizationInvitationHandler))                               // Требует авторизацию
 	http.HandleFunc("/api/organizations/claim-ownership/", protectedRoute(handlers.ClaimOwnershipHandler))                                   // Требует авторизацию
-	http.HandleFunc("/api/organizations/check-inn/", enableCORS(handlers.CheckOrganizationByInnHandler))                                     // Публичный endpoint
+	http.HandleFunc("/api/organizations/ownership-claims", protectedRoute(handlers.OwnershipClaimsHandler))                                  // Требует авторизацию
+	http.HandleFunc("/api/organizations/ownership-claims/", protectedRoute(handlers.OwnershipClaimHandler))                                  // Требует авторизацию
+	http.HandleFunc("/api/organizations/ownership-transfers", protectedRoute(handlers.OwnershipTransferHandler))                             // Требует авторизацию
+	http.HandleFunc("/api/organizations/ownership-transfers/", protectedRoute(handlers.OwnershipTransferHandler))                            // Требует авторизацию
+
+	// Публичный endpoint; ?prefill=1 (запрос к платному реестру) - только с авторизацией
+	http.HandleFunc("/api/organizations/check-inn/", enableCORS(middleware.DevOptionalAuthMiddleware(handlers.CheckOrganizationByInnHandler)))
+
+	// Записи на приём в ветклиники (окна и календарь - в /api/organizations/{id}/...)
+	http.HandleFunc("/api/appointments", protectedRoute(handlers.AppointmentHandler))  // Требует авторизацию
+	http.HandleFunc("/api/appointments/", protectedRoute(handlers.AppointmentHandler)) // Требует авторизацию
+
+	// Сеть передержек (размещения организаций - в /api/organizations/{id}/fosters)
+	http.HandleFunc("/api/foster-homes", protectedRoute(handlers.FosterHomesHandler))  // Требует авторизацию
+	http.HandleFunc("/api/foster-homes/", protectedRoute(handlers.FosterHomesHandler)) // Требует авторизацию
 
 	// Organizations CRUD - должны быть после более специфичных роутов
 	http.HandleFunc("/api/organizations", enableCORS(func(w http.ResponseWriter, r *http.Request) {
@@ -301,10 +350,11 @@ func main() {
 
 	// Messenger (личные чаты 1-1) (требует авторизацию)
 	http.HandleFunc("/api/chats", protectedRoute(handlers.GetChatsHandler(db.DB)))
-	http.HandleFunc("/api/chats/", protectedRoute(handlers.GetChatMessagesHandler(db.DB)))
+	http.HandleFunc("/api/chats/", protectedRoute(handlers.ChatHandler(db.DB)))
 	http.HandleFunc("/api/messages/send", protectedRoute(handlers.SendMessageHandler(db.DB)))
 	http.HandleFunc("/api/messages/send-media", protectedRoute(handlers.SendMediaMessageHandler(db.DB)))
 	http.HandleFunc("/api/messages/unread", protectedRoute(handlers.GetUnreadCountHandler(db.DB)))
+	http.HandleFunc("/api/messages/", protectedRoute(handlers.MessageHandler(db.DB))) // PUT - редактировать, DELETE - удалить
 
 	// WebSocket для real-time уведомлений (требует авторизацию)
 	http.HandleFunc("/ws", protectedRoute(handlers.HandleWebSocket(db.DB)))
@@ -328,6 +378,7 @@ func main() {
 	// Admin Logs (логи действий администраторов) (Gateway проверяет авторизацию)
 	http.HandleFunc("/api/admin/logs", enableCORS(handlers.AdminLogsHandler))
 	http.HandleFunc("/api/admin/logs/stats", enableCORS(handlers.GetAdminLogStats))
+	http.HandleFunc("/api/admin/websocket/stats", protectedRoute(handlers.WebSocketStatsHandler)) // Подключения WebSocket по пользователям
 
 	// User Activity (отслеживание активности пользователей) (Gateway проверяет авторизацию для защищенных endpoints)
 	http.HandleFunc("/api/activity/update", enableCORS(handlers.UpdateUserActivityHandler(db.DB)))
@@ -37,6 +37,15 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
+		// Токен выдан до сброса пароля - все сеансы пользователя отозваны
+		if isGatewaySessionRevoked(r, userID) {
+			log.Printf("❌ Session revoked (Gateway): user %d", userID)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"success":false,"error":"Session revoked"}`))
+			return
+		}
+
 		// ✅ Обновляем активность пользователя
 		updateUserActivity(userID)
 
@@ -98,7 +107,8 @@ func OptionalAuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			userRole := r.Header.Get("X-User-Role")
 
 			var userID int
-			if _, err := fmt.Sscanf(userIDStr, "%d", &userID); err == nil {
+			// Отозванный сеанс - как запрос без авторизации
+			if _, err := fmt.Sscanf(userIDStr, "%d", &userID); err == nil && !isGatewaySessionRevoked(r, userID) {
 				// ✅ Обновляем активность пользователя
 				updateUserActivity(userID)
 
@@ -108,6 +108,15 @@ func DevAuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		userEmail := claims["email"].(string)
 		userRole := claims["role"].(string)
 
+		// Токен выдан до сброса пароля - все сеансы пользователя отозваны
+		if IsTokenRevoked(userID, claims) {
+			log.Printf("❌ Token revoked (dev mode): user %d", userID)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"success":false,"error":"Session revoked"}`))
+			return
+		}
+
 		// ✅ Обновляем активность пользователя
 		updateDevUserActivity(userID)
 
@@ -223,6 +232,12 @@ func DevOptionalAuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		userEmail := claims["email"].(string)
 		userRole := claims["role"].(string)
 
+		// Отозванный токен - как отсутствующий
+		if IsTokenRevoked(userID, claims) {
+			next(w, r)
+			return
+		}
+
 		// ✅ Обновляем активность пользователя
 		updateDevUserActivity(userID)
 
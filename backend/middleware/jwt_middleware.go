@@ -89,6 +89,12 @@ func JWTMiddleware(config JWTConfig) func(http.HandlerFunc) http.HandlerFunc {
 				}
 			}
 
+			// Токен выдан до сброса пароля
+			if userID, ok := claims["user_id"].(float64); ok && IsTokenRevoked(int(userID), claims) {
+				config.ErrorHandler(w, r, fmt.Errorf("session revoked"))
+				return
+			}
+
 			// Вызываем success handler
 			if config.SuccessHandler != nil {
 				config.SuccessHandler(w, r, claims)
@@ -144,6 +150,12 @@ func OptionalJWTMiddleware(config JWTConfig) func(http.HandlerFunc) http.Handler
 				}
 			}
 
+			// Отозванный токен - пропускаем без авторизации
+			if userID, ok := claims["user_id"].(float64); ok && IsTokenRevoked(int(userID), claims) {
+				next(w, r)
+				return
+			}
+
 			// Обновляем активность
 			if config.SuccessHandler != nil {
 				config.SuccessHandler(w, r, claims)
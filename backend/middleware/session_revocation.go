@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"backend/db"
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// loadSessionsRevokedAt - момент отзыва всех сеансов пользователя (в тестах подменяется)
+var loadSessionsRevokedAt = func(userID int) (sql.NullTime, error) {
+	var revokedAt sql.NullTime
+	err := db.DB.QueryRow(convertPlaceholdersDevAuth("SELECT sessions_revoked_at FROM users WHERE id = ?"), userID).Scan(&revokedAt)
+	return revokedAt, err
+}
+
+// IsTokenRevoked - выдан ли JWT до отзыва всех сеансов пользователя (users.sessions_revoked_at,
+// выставляется при сбросе пароля). Токен без iat после отзыва тоже считается отозванным.
+func IsTokenRevoked(userID int, claims jwt.MapClaims) bool {
+	revokedAt, err := loadSessionsRevokedAt(userID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("⚠️ Failed to check session revocation for user %d: %v", userID, err)
+		}
+		return false
+	}
+	if !revokedAt.Valid {
+		return false
+	}
+	return issuedBefore(claims, revokedAt.Time)
+}
+
+// issuedBefore - выдан ли токен раньше revokedAt. sessions_revoked_at хранится в UTC (TIMESTAMP без зоны,
+// lib/pq читает его как UTC), iat - Unix-время, так что сравнение не зависит от часового пояса сервера.
+// iat хранится с точностью до секунды, поэтому токен, выданный в ту же секунду, что и отзыв
+// (вход сразу после сброса пароля), остаётся действительным
+func issuedBefore(claims jwt.MapClaims, revokedAt time.Time) bool {
+	iat, ok := claims["iat"].(float64)
+	if !ok {
+		return true
+	}
+	return time.Unix(int64(iat), 0).Before(revokedAt.Truncate(time.Second))
+}
+
+// isGatewaySessionRevoked - отозван ли сеанс запроса через Gateway. Gateway проверяет подпись и срок JWT,
+// но не знает о сбросе пароля, поэтому iat берётся из пересылаемого им токена (Authorization, cookie auth_token
+// или query token). Без токена claims пусты: после сброса пароля такой запрос считается отозванным
+func isGatewaySessionRevoked(r *http.Request, userID int) bool {
+	claims := jwt.MapClaims{}
+	config := DefaultJWTConfig()
+	if token := extractToken(r, config); token != "" && config.Secret != "" {
+		parsed, err := validateToken(token, config.Secret)
+		if err != nil {
+			log.Printf("❌ Invalid token forwarded by Gateway for user %d: %v", userID, err)
+			return true
+		}
+		if tokenUserID, ok := parsed["user_id"].(float64); !ok || int(tokenUserID) != userID {
+			log.Printf("❌ Token user does not match X-User-ID %d", userID)
+			return true
+		}
+		claims = parsed
+	}
+	return IsTokenRevoked(userID, claims)
+}
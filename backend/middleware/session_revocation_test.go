@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"backend/db"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestIssuedBefore(t *testing.T) {
+	revokedAt := time.Date(2026, 10, 19, 12, 0, 0, 500_000_000, time.UTC)
+	unix := func(t time.Time) float64 { return float64(t.Unix()) }
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		want   bool
+	}{
+		{"issued an hour before", jwt.MapClaims{"iat": unix(revokedAt.Add(-time.Hour))}, true},
+		{"issued a second before", jwt.MapClaims{"iat": unix(revokedAt.Add(-time.Second))}, true},
+		{"issued in the same second", jwt.MapClaims{"iat": unix(revokedAt)}, false},
+		{"issued after", jwt.MapClaims{"iat": unix(revokedAt.Add(time.Second))}, false},
+		{"no iat", jwt.MapClaims{}, true},
+		{"iat is not a number", jwt.MapClaims{"iat": "1700000000"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := issuedBefore(tt.claims, revokedAt); got != tt.want {
+				t.Errorf("issuedBefore(%v) = %v, want %v", tt.claims, got, tt.want)
+			}
+		})
+	}
+}
+
+// nopDriver - база, которая принимает любые запросы (updateUserActivity)
+type nopDriver struct{}
+
+func (nopDriver) Open(string) (driver.Conn, error) { return nopConn{}, nil }
+
+type nopConn struct{}
+
+func (nopConn) Prepare(string) (driver.Stmt, error) { return nopStmt{}, nil }
+func (nopConn) Close() error                        { return nil }
+func (nopConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+type nopStmt struct{}
+
+func (nopStmt) Close() error                               { return nil }
+func (nopStmt) NumInput() int                              { return -1 }
+func (nopStmt) Exec([]driver.Value) (driver.Result, error) { return driver.RowsAffected(1), nil }
+func (nopStmt) Query([]driver.Value) (driver.Rows, error)  { return nil, errors.New("not supported") }
+
+func init() {
+	sql.Register("middleware_nopdb", nopDriver{})
+}
+
+func signTestToken(t *testing.T, secret string, userID int, issuedAt time.Time) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": float64(userID),
+		"email":   "anna@example.com",
+		"role":    "user",
+		"iat":     issuedAt.Unix(),
+		"exp":     issuedAt.Add(24 * time.Hour).Unix(),
+	}).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestGatewayAuthChecksRevocation(t *testing.T) {
+	const secret = "test-secret"
+	t.Setenv("JWT_SECRET", secret)
+
+	conn, err := sql.Open("middleware_nopdb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prevDB := db.DB
+	db.DB = conn
+	t.Cleanup(func() { db.DB = prevDB })
+
+	// Пароль сброшен минуту назад
+	revokedAt := time.Now().UTC().Add(-time.Minute)
+	prevLoad := loadSessionsRevokedAt
+	loadSessionsRevokedAt = func(userID int) (sql.NullTime, error) {
+		if userID != 7 {
+			return sql.NullTime{}, nil
+		}
+		return sql.NullTime{Time: revokedAt, Valid: true}, nil
+	}
+	t.Cleanup(func() { loadSessionsRevokedAt = prevLoad })
+
+	tests := []struct {
+		name      string
+		userID    string
+		token     string
+		wantCode  int
+		wantOptID int // userID в контексте OptionalAuthMiddleware
+	}{
+		{"token issued before reset", "7", signTestToken(t, secret, 7, revokedAt.Add(-time.Hour)), http.StatusUnauthorized, 0},
+		{"token issued after reset", "7", signTestToken(t, secret, 7, revokedAt.Add(time.Second)), http.StatusOK, 7},
+		{"no forwarded token after reset", "7", "", http.StatusUnauthorized, 0},
+		{"no forwarded token, never reset", "8", "", http.StatusOK, 8},
+		{"token of another user", "8", signTestToken(t, secret, 7, revokedAt.Add(time.Second)), http.StatusUnauthorized, 7}, // Без Gateway-заголовков - по самому токену
+		{"forged token", "7", signTestToken(t, "other-secret", 7, revokedAt.Add(time.Second)), http.StatusUnauthorized, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newRequest := func() *http.Request {
+				req := httptest.NewRequest(http.MethodGet, "/api/profile", nil)
+				req.Header.Set("X-User-ID", tt.userID)
+				req.Header.Set("X-User-Email", "anna@example.com")
+				if tt.token != "" {
+					req.Header.Set("Authorization", "Bearer "+tt.token)
+				}
+				return req
+			}
+
+			rec := httptest.NewRecorder()
+			DevAuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})(rec, newRequest())
+			if rec.Code != tt.wantCode {
+				t.Fatalf("DevAuthMiddleware status %d, want %d: %s", rec.Code, tt.wantCode, rec.Body.String())
+			}
+
+			gotID := 0
+			OptionalAuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+				gotID, _ = r.Context().Value("userID").(int)
+			})(httptest.NewRecorder(), newRequest())
+			if gotID != tt.wantOptID {
+				t.Fatalf("OptionalAuthMiddleware userID %d, want %d", gotID, tt.wantOptID)
+			}
+		})
+	}
+}
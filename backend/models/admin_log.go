@@ -27,6 +27,14 @@ const (
 	ActionDeleteUser   = "delete_user"
 	ActionUpdateOrg    = "update_organization"
 	ActionDeleteOrg    = "delete_organization"
+
+	ActionApproveOwnershipClaim = "approve_ownership_claim"
+	ActionRejectOwnershipClaim  = "reject_ownership_claim"
+	ActionTransferOwnership     = "transfer_ownership"
+	ActionDemoteOwner           = "demote_organization_owner"
+	ActionRemoveOwner           = "remove_organization_owner"
+	ActionHideReview            = "hide_organization_review"
+	ActionUnhideReview          = "unhide_organization_review"
 )
 
 // Типы целей
@@ -35,6 +43,7 @@ const (
 	TargetPost         = "post"
 	TargetRole         = "role"
 	TargetOrganization = "organization"
+	TargetReview       = "organization_review"
 )
 
 // AdminLogResponse для API ответа
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// AnalyticsBucket - показатели организации за один интервал (день, неделя, месяц)
+type AnalyticsBucket struct {
+	PeriodStart     time.Time `json:"period_start"`
+	Posts           int       `json:"posts"`
+	Likes           int       `json:"likes"`
+	Comments        int       `json:"comments"`
+	Reach           int       `json:"reach"` // Уникальные пользователи, отреагировавшие на посты
+	PetsAdded       int       `json:"pets_added"`
+	Donations       int       `json:"donations"`
+	DonationsAmount int       `json:"donations_amount"`
+}
+
+// AnalyticsTotals - итоги за выбранный период и текущее состояние
+type AnalyticsTotals struct {
+	Posts               int            `json:"posts"`
+	Likes               int            `json:"likes"`
+	Comments            int            `json:"comments"`
+	Reach               int            `json:"reach"`
+	AnnouncementViews   int            `json:"announcement_views"`
+	PetsCurated         int            `json:"pets_curated"` // Все питомцы организации
+	PetsAdopted         int            `json:"pets_adopted"` // Питомцы со статусом adopted
+	Donations           int            `json:"donations"`
+	DonationsAmount     int            `json:"donations_amount"`
+	AnnouncementsByType map[string]int `json:"announcements_by_type"`
+	AnnouncementOutcome map[string]int `json:"announcement_outcomes"` // Объявления по статусу
+}
+
+// OrganizationAnalytics - дашборд аналитики организации
+type OrganizationAnalytics struct {
+	OrganizationID int               `json:"organization_id"`
+	From           time.Time         `json:"from"`
+	To             time.Time         `json:"to"`
+	Granularity    string            `json:"granularity"` // day, week, month
+	Totals         AnalyticsTotals   `json:"totals"`
+	Series         []AnalyticsBucket `json:"series"`
+}
@@ -58,6 +58,10 @@ type PetAnnouncement struct {
 	Pet           *PetDetail             `json:"pet,omitempty"`
 	Posts         []AnnouncementPost     `json:"posts,omitempty"`
 	Donations     []AnnouncementDonation `json:"donations,omitempty"`
+
+	// Отчётность по сбору средств
+	Expenses           []AnnouncementExpense `json:"expenses,omitempty"`
+	FundraisingSummary *FundraisingSummary   `json:"fundraising_summary,omitempty"`
 }
 
 // AnnouncementPost - публикация (обновление) к объявлению
@@ -71,6 +75,11 @@ type AnnouncementPost struct {
 	DonationAmount *int      `json:"donation_amount,omitempty"`
 	CreatedAt      time.Time `json:"created_at"`
 
+	// Место и время наблюдения (для 'observation' и 'search')
+	SightingLat *float64   `json:"sighting_lat,omitempty"`
+	SightingLon *float64   `json:"sighting_lon,omitempty"`
+	SightedAt   *time.Time `json:"sighted_at,omitempty"`
+
 	// Связанные данные
 	Author *User `json:"author,omitempty"`
 }
@@ -148,6 +157,11 @@ type CreateAnnouncementPostRequest struct {
 	Content        string   `json:"content"`
 	MediaURLs      []string `json:"media_urls,omitempty"`
 	DonationAmount *int     `json:"donation_amount,omitempty"`
+
+	// Геометка наблюдения
+	SightingLat *float64 `json:"sighting_lat,omitempty"`
+	SightingLon *float64 `json:"sighting_lon,omitempty"`
+	SightedAt   *string  `json:"sighted_at,omitempty"` // RFC3339
 }
 
 // CreateDonationRequest - запрос на создание пожертвования
@@ -157,3 +171,23 @@ type CreateDonationRequest struct {
 	IsAnonymous bool    `json:"is_anonymous"`
 	DonorName   *string `json:"donor_name,omitempty"` // Для анонимных или незарегистрированных
 }
+
+// GeoJSONFeatureCollection - коллекция точек для карты (RFC 7946)
+type GeoJSONFeatureCollection struct {
+	Type     string           `json:"type"` // "FeatureCollection"
+	Features []GeoJSONFeature `json:"features"`
+}
+
+// GeoJSONFeature - точка с произвольными свойствами
+type GeoJSONFeature struct {
+	Type       string                 `json:"type"` // "Feature"
+	ID         int                    `json:"id"`
+	Geometry   GeoJSONPoint           `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// GeoJSONPoint - геометрия точки, координаты в порядке [lon, lat]
+type GeoJSONPoint struct {
+	Type        string     `json:"type"` // "Point"
+	Coordinates [2]float64 `json:"coordinates"`
+}
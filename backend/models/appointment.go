@@ -0,0 +1,81 @@
+package models
+
+import "time"
+
+// AppointmentSlot - окно приёма врача ветклиники
+type AppointmentSlot struct {
+	ID             int       `json:"id"`
+	OrganizationID int       `json:"organization_id"`
+	DoctorID       int       `json:"doctor_id"` // Пользователь - участник организации
+	ServiceID      *int      `json:"service_id,omitempty"`
+	StartsAt       time.Time `json:"starts_at"`
+	EndsAt         time.Time `json:"ends_at"`
+	Status         string    `json:"status"` // open, closed
+	IsBooked       bool      `json:"is_booked"`
+	CreatedAt      time.Time `json:"created_at"`
+
+	// Дополнительная информация (для отображения)
+	DoctorName  string  `json:"doctor_name,omitempty"`
+	ServiceName *string `json:"service_name,omitempty"`
+
+	// Запись на слот - только в календаре сотрудников клиники
+	Appointment *Appointment `json:"appointment,omitempty"`
+}
+
+// Appointment - запись питомца на приём
+type Appointment struct {
+	ID             int        `json:"id"`
+	SlotID         int        `json:"slot_id"`
+	OrganizationID int        `json:"organization_id"`
+	PetID          int        `json:"pet_id"`
+	OwnerID        int        `json:"owner_id"`
+	Status         string     `json:"status"` // booked, cancelled, completed, no_show
+	Comment        *string    `json:"comment,omitempty"`
+	CancelledBy    *int       `json:"cancelled_by,omitempty"`
+	CancelReason   *string    `json:"cancel_reason,omitempty"`
+	CancelledAt    *time.Time `json:"cancelled_at,omitempty"`
+	ReminderSentAt *time.Time `json:"reminder_sent_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Дополнительная информация (для отображения)
+	StartsAt         time.Time `json:"starts_at"`
+	EndsAt           time.Time `json:"ends_at"`
+	DoctorID         int       `json:"doctor_id"`
+	DoctorName       string    `json:"doctor_name,omitempty"`
+	ServiceName      *string   `json:"service_name,omitempty"`
+	PetName          string    `json:"pet_name,omitempty"`
+	OwnerName        string    `json:"owner_name,omitempty"`
+	OrganizationName string    `json:"organization_name,omitempty"`
+}
+
+// CreateAppointmentSlotsRequest - создание одного или нескольких последовательных слотов
+type CreateAppointmentSlotsRequest struct {
+	DoctorID        int    `json:"doctor_id"`
+	ServiceID       *int   `json:"service_id"`
+	StartsAt        string `json:"starts_at"`        // RFC3339
+	DurationMinutes int    `json:"duration_minutes"` // По умолчанию - длительность услуги или 30 минут
+	Count           int    `json:"count"`            // Количество слотов подряд, по умолчанию 1
+}
+
+// BookAppointmentRequest - запись питомца на слот
+type BookAppointmentRequest struct {
+	SlotID  int    `json:"slot_id"`
+	PetID   int    `json:"pet_id"`
+	Comment string `json:"comment"`
+}
+
+// RescheduleAppointmentRequest - перенос записи на другой слот той же клиники
+type RescheduleAppointmentRequest struct {
+	SlotID int `json:"slot_id"`
+}
+
+// CancelAppointmentRequest - отмена записи
+type CancelAppointmentRequest struct {
+	Reason string `json:"reason"`
+}
+
+// UpdateAppointmentStatusRequest - отметка клиники по итогам приёма
+type UpdateAppointmentStatusRequest struct {
+	Status string `json:"status"` // completed, no_show
+}
@@ -4,19 +4,67 @@ import (
 	"time"
 )
 
-// Chat представляет диалог между двумя пользователями
+// Chat представляет диалог между двумя пользователями или групповой чат
 type Chat struct {
 	ID            int        `json:"id"`
-	User1ID       int        `json:"user1_id"`
-	User2ID       int        `json:"user2_id"`
+	Type          string     `json:"type"`     // direct, group, organization_staff
+	User1ID       int        `json:"user1_id"` // Только для direct
+	User2ID       int        `json:"user2_id"` // Только для direct
 	LastMessageID *int       `json:"last_message_id"`
 	LastMessageAt *time.Time `json:"last_message_at"`
 	CreatedAt     time.Time  `json:"created_at"`
 
+	// Групповые чаты
+	Title          *string `json:"title,omitempty"`
+	Avatar         *string `json:"avatar,omitempty"`
+	CreatedBy      *int    `json:"created_by,omitempty"`
+	OrganizationID *int    `json:"organization_id,omitempty"` // Для чата сотрудников организации
+
 	// Дополнительные поля для UI
-	OtherUser   *User    `json:"other_user,omitempty"`
-	LastMessage *Message `json:"last_message,omitempty"`
-	UnreadCount int      `json:"unread_count"`
+	OtherUser    *User        `json:"other_user,omitempty"`
+	LastMessage  *Message     `json:"last_message,omitempty"`
+	UnreadCount  int          `json:"unread_count"`
+	MembersCount int          `json:"members_count,omitempty"`
+	MyRole       string       `json:"my_role,omitempty"` // member, admin
+	Members      []ChatMember `json:"members,omitempty"`
+}
+
+// ChatMember - участник группового чата
+type ChatMember struct {
+	ChatID            int        `json:"chat_id"`
+	UserID            int        `json:"user_id"`
+	Role              string     `json:"role"` // member, admin
+	InvitedBy         *int       `json:"invited_by,omitempty"`
+	JoinedAt          time.Time  `json:"joined_at"`
+	LastReadMessageID *int       `json:"last_read_message_id,omitempty"`
+	LastReadAt        *time.Time `json:"last_read_at,omitempty"`
+
+	// Дополнительная информация (для отображения)
+	UserName   string  `json:"user_name,omitempty"`
+	UserAvatar *string `json:"user_avatar,omitempty"`
+}
+
+// CreateGroupChatRequest - создание группового чата
+type CreateGroupChatRequest struct {
+	Title     string  `json:"title"`
+	Avatar    *string `json:"avatar,omitempty"`
+	MemberIDs []int   `json:"member_ids"`
+}
+
+// UpdateGroupChatRequest - изменение названия и аватара
+type UpdateGroupChatRequest struct {
+	Title  *string `json:"title,omitempty"`
+	Avatar *string `json:"avatar,omitempty"`
+}
+
+// InviteChatMembersRequest - приглашение участников
+type InviteChatMembersRequest struct {
+	UserIDs []int `json:"user_ids"`
+}
+
+// UpdateChatMemberRequest - назначение или снятие администратора
+type UpdateChatMemberRequest struct {
+	Role string `json:"role"` // member, admin
 }
 
 // Message представляет сообщение в чате
@@ -24,15 +72,36 @@ type Message struct {
 	ID         int        `json:"id"`
 	ChatID     int        `json:"chat_id"`
 	SenderID   int        `json:"sender_id"`
-	ReceiverID int        `json:"receiver_id"`
+	ReceiverID int        `json:"receiver_id"` // 0 в групповых чатах
 	Content    string     `json:"content"`
 	IsRead     bool       `json:"is_read"`
 	ReadAt     *time.Time `json:"read_at"`
 	CreatedAt  *time.Time `json:"created_at"` // Используем указатель для поддержки NULL
 
+	EditedAt         *time.Time `json:"edited_at,omitempty"`
+	IsDeleted        bool       `json:"is_deleted"` // Удалено для всех: текст и вложения стёрты
+	ReplyToMessageID *int       `json:"reply_to_message_id,omitempty"`
+	Silent           bool       `json:"silent,omitempty"` // Только в WebSocket: получатель заглушил чат или отключил уведомления о сообщениях
+
 	// Дополнительные поля для UI
 	Sender      *User               `json:"sender,omitempty"`
 	Attachments []MessageAttachment `json:"attachments,omitempty"`
+	ReplyTo     *MessagePreview     `json:"reply_to,omitempty"` // Цитата сообщения, на которое отвечают
+}
+
+// MessagePreview - краткое представление сообщения для цитаты в ответе
+type MessagePreview struct {
+	ID             int    `json:"id"`
+	SenderID       int    `json:"sender_id"`
+	SenderName     string `json:"sender_name"`
+	Content        string `json:"content"` // Обрезанный текст
+	HasAttachments bool   `json:"has_attachments"`
+	IsDeleted      bool   `json:"is_deleted"`
+}
+
+// EditMessageRequest - редактирование текста сообщения
+type EditMessageRequest struct {
+	Content string `json:"content"`
 }
 
 // MessageAttachment представляет вложение в сообщении
@@ -0,0 +1,107 @@
+package models
+
+import "time"
+
+// FosterHome - анкета волонтёра, готового взять животное на передержку
+type FosterHome struct {
+	ID         int       `json:"id"`
+	UserID     int       `json:"user_id"`
+	Capacity   int       `json:"capacity"` // Сколько животных одновременно
+	Species    []string  `json:"species"`  // Предпочтения по видам; пусто - любые
+	City       *string   `json:"city,omitempty"`
+	Conditions *string   `json:"conditions,omitempty"` // Условия: квартира/дом, другие животные, дети
+	IsActive   bool      `json:"is_active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+
+	// Дополнительная информация (для отображения)
+	ActiveCount  int     `json:"active_count"`  // Текущих размещений
+	FreeCapacity int     `json:"free_capacity"` // Сколько ещё животных можно разместить
+	UserName     string  `json:"user_name,omitempty"`
+	UserAvatar   *string `json:"user_avatar,omitempty"`
+}
+
+// FosterHomeRequest - регистрация или изменение анкеты передержки
+type FosterHomeRequest struct {
+	Capacity   int      `json:"capacity"`
+	Species    []string `json:"species"`
+	City       *string  `json:"city,omitempty"`
+	Conditions *string  `json:"conditions,omitempty"`
+	IsActive   *bool    `json:"is_active,omitempty"`
+}
+
+// FosterPlacement - размещение питомца организации на передержке
+type FosterPlacement struct {
+	ID                  int        `json:"id"`
+	OrganizationID      int        `json:"organization_id"`
+	PetID               int        `json:"pet_id"`
+	FosterHomeID        int        `json:"foster_home_id"`
+	FosterUserID        int        `json:"foster_user_id"`
+	StartDate           time.Time  `json:"start_date"`
+	EndDate             *time.Time `json:"end_date,omitempty"` // Планируемая дата окончания
+	EndedAt             *time.Time `json:"ended_at,omitempty"`
+	Status              string     `json:"status"` // active, completed, cancelled
+	Notes               *string    `json:"notes,omitempty"`
+	CheckinIntervalDays int        `json:"checkin_interval_days"`
+	CreatedBy           *int       `json:"created_by,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+
+	// Дополнительная информация (для отображения)
+	PetName          string          `json:"pet_name,omitempty"`
+	PetSpecies       string          `json:"pet_species,omitempty"`
+	PetPhoto         *string         `json:"pet_photo,omitempty"`
+	FosterName       string          `json:"foster_name,omitempty"`
+	OrganizationName string          `json:"organization_name,omitempty"`
+	LastCheckinAt    *time.Time      `json:"last_checkin_at,omitempty"`
+	CheckinOverdue   bool            `json:"checkin_overdue"`
+	Checkins         []FosterCheckin `json:"checkins,omitempty"`
+}
+
+// CreateFosterPlacementRequest - назначение питомца на передержку
+type CreateFosterPlacementRequest struct {
+	PetID               int     `json:"pet_id"`
+	FosterHomeID        int     `json:"foster_home_id"`
+	StartDate           string  `json:"start_date"`         // YYYY-MM-DD, по умолчанию - сегодня
+	EndDate             *string `json:"end_date,omitempty"` // YYYY-MM-DD
+	Notes               *string `json:"notes,omitempty"`
+	CheckinIntervalDays int     `json:"checkin_interval_days"` // По умолчанию 7
+}
+
+// UpdateFosterPlacementRequest - изменение условий размещения
+type UpdateFosterPlacementRequest struct {
+	EndDate             *string `json:"end_date,omitempty"` // YYYY-MM-DD, пустая строка - без даты окончания
+	Notes               *string `json:"notes,omitempty"`
+	CheckinIntervalDays *int    `json:"checkin_interval_days,omitempty"`
+}
+
+// EndFosterPlacementRequest - завершение размещения
+type EndFosterPlacementRequest struct {
+	Status string `json:"status"` // completed, cancelled
+}
+
+// FosterCheckin - периодический отчёт передержки о питомце
+type FosterCheckin struct {
+	ID          int          `json:"id"`
+	PlacementID int          `json:"placement_id"`
+	AuthorID    int          `json:"author_id"`
+	Wellbeing   string       `json:"wellbeing"` // good, attention, urgent
+	Text        string       `json:"text"`
+	Attachments []Attachment `json:"attachments"`
+	CreatedAt   time.Time    `json:"created_at"`
+	AuthorName  string       `json:"author_name,omitempty"`
+}
+
+// CreateFosterCheckinRequest - отчёт передержки
+type CreateFosterCheckinRequest struct {
+	Wellbeing   string       `json:"wellbeing"`
+	Text        string       `json:"text"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// FosterOverview - где сейчас находятся питомцы организации
+type FosterOverview struct {
+	Placements    []FosterPlacement `json:"placements"`      // Питомцы на передержке
+	AtShelter     []Pet             `json:"at_shelter"`      // Питомцы в приюте
+	OverdueCount  int               `json:"overdue_count"`   // Передержки без свежего отчёта
+	EndingSoonIDs []int             `json:"ending_soon_ids"` // Размещения, заканчивающиеся в ближайшие 7 дней
+}
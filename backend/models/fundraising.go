@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// AnnouncementExpense - статья расходов по сбору средств (отчёт о тратах)
+type AnnouncementExpense struct {
+	ID             int        `json:"id"`
+	AnnouncementID int        `json:"announcement_id"`
+	AuthorID       int        `json:"author_id"`
+	Title          string     `json:"title"`
+	Description    *string    `json:"description,omitempty"`
+	Amount         int        `json:"amount"`
+	ReceiptMediaID *int       `json:"receipt_media_id,omitempty"`
+	SpentAt        *time.Time `json:"spent_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+
+	// Связанные данные
+	Receipt *UserMedia `json:"receipt,omitempty"`
+}
+
+// FundraisingSummary - сводка по сбору: собрано, потрачено, остаток
+type FundraisingSummary struct {
+	GoalAmount     *int `json:"goal_amount,omitempty"`
+	Raised         int  `json:"raised"`
+	Spent          int  `json:"spent"`
+	Remaining      int  `json:"remaining"`
+	DonationsCount int  `json:"donations_count"`
+	ExpensesCount  int  `json:"expenses_count"`
+}
+
+// CreateExpenseRequest - запрос на добавление статьи расходов
+type CreateExpenseRequest struct {
+	Title          string  `json:"title"`
+	Description    *string `json:"description,omitempty"`
+	Amount         int     `json:"amount"`
+	ReceiptMediaID *int    `json:"receipt_media_id,omitempty"`
+	SpentAt        *string `json:"spent_at,omitempty"` // YYYY-MM-DD
+}
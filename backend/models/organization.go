@@ -60,6 +60,18 @@ type Organization struct {
 	IsActive   bool   `json:"is_active"`
 	Status     string `json:"status"` // active, inactive, blocked
 
+	// Сверка с реестром (ЕГРЮЛ)
+	StateStatus          *string    `json:"state_status,omitempty"`
+	LiquidationFlaggedAt *time.Time `json:"liquidation_flagged_at,omitempty"` // Реестр сообщил о ликвидации
+
+	// Режим работы и услуги (ветклиники, зоомагазины)
+	OpeningHours *OpeningHours         `json:"opening_hours,omitempty"`
+	Services     []OrganizationService `json:"services,omitempty"`
+
+	// Рейтинг по опубликованным отзывам (кэш, пересчитывается при изменении отзывов)
+	RatingAverage float64 `json:"rating_average"`
+	ReviewsCount  int     `json:"reviews_count"`
+
 	// Метаданные
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// OrganizationInvitation - приглашение в организацию или заявка на вступление
+type OrganizationInvitation struct {
+	ID               int        `json:"id"`
+	OrganizationID   int        `json:"organization_id"`
+	Kind             string     `json:"kind"`              // invite (приглашение от организации), request (заявка пользователя)
+	UserID           *int       `json:"user_id,omitempty"` // Пусто, если приглашён email без аккаунта
+	Email            *string    `json:"email,omitempty"`
+	Role             string     `json:"role"` // admin, moderator, member
+	Position         *string    `json:"position,omitempty"`
+	CanPost          bool       `json:"can_post"`
+	CanEdit          bool       `json:"can_edit"`
+	CanManageMembers bool       `json:"can_manage_members"`
+	Message          *string    `json:"message,omitempty"`
+	Status           string     `json:"status"` // pending, accepted, declined, expired, cancelled
+	InvitedBy        *int       `json:"invited_by,omitempty"`
+	RespondedBy      *int       `json:"responded_by,omitempty"`
+	ExpiresAt        time.Time  `json:"expires_at"`
+	RespondedAt      *time.Time `json:"responded_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+
+	// Дополнительная информация (для отображения)
+	OrganizationName string `json:"organization_name,omitempty"`
+	UserName         string `json:"user_name,omitempty"`
+}
+
+// CreateInvitationRequest - приглашение участника по ID пользователя или email
+type CreateInvitationRequest struct {
+	OrganizationID   int    `json:"organization_id"`
+	UserID           int    `json:"user_id"`
+	Email            string `json:"email"`
+	Role             string `json:"role"`
+	Position         string `json:"position"`
+	CanPost          *bool  `json:"can_post"` // Если не указано - по умолчанию для роли
+	CanEdit          *bool  `json:"can_edit"`
+	CanManageMembers *bool  `json:"can_manage_members"`
+}
+
+// JoinOrganizationRequest - заявка пользователя на вступление в организацию
+type JoinOrganizationRequest struct {
+	Message string `json:"message"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// OrganizationReview - отзыв пользователя об организации (один на пользователя)
+type OrganizationReview struct {
+	ID             int        `json:"id"`
+	OrganizationID int        `json:"organization_id"`
+	UserID         int        `json:"user_id"`
+	Rating         int        `json:"rating"` // 1-5
+	Text           *string    `json:"text,omitempty"`
+	Status         string     `json:"status"` // published, hidden
+	Reply          *string    `json:"reply,omitempty"`
+	ReplyAuthorID  *int       `json:"reply_author_id,omitempty"`
+	RepliedAt      *time.Time `json:"replied_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Дополнительная информация (для отображения)
+	UserName   string  `json:"user_name,omitempty"`
+	UserAvatar *string `json:"user_avatar,omitempty"`
+}
+
+// OrganizationRatingSummary - сводный рейтинг организации
+type OrganizationRatingSummary struct {
+	Average      float64     `json:"average"`
+	Count        int         `json:"count"`
+	Distribution map[int]int `json:"distribution"` // оценка -> количество отзывов
+}
+
+// OrganizationReviewRequest - создание или редактирование отзыва
+type OrganizationReviewRequest struct {
+	Rating int    `json:"rating"`
+	Text   string `json:"text"`
+}
+
+// OrganizationReviewReplyRequest - публичный ответ организации на отзыв
+type OrganizationReviewReplyRequest struct {
+	Text string `json:"text"`
+}
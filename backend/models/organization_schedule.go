@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// OpeningHoursInterval - рабочий интервал в обычный день недели.
+// Если closes_at не позже opens_at, интервал заканчивается на следующий день (ночной режим).
+type OpeningHoursInterval struct {
+	Weekday  int    `json:"weekday"`   // 1 - понедельник ... 7 - воскресенье
+	OpensAt  string `json:"opens_at"`  // HH:MM
+	ClosesAt string `json:"closes_at"` // HH:MM, 24:00 - до конца дня
+}
+
+// OpeningHoursException - особый режим на конкретную дату (праздник, санитарный день)
+type OpeningHoursException struct {
+	Date     string  `json:"date"` // YYYY-MM-DD
+	IsClosed bool    `json:"is_closed"`
+	OpensAt  *string `json:"opens_at,omitempty"`
+	ClosesAt *string `json:"closes_at,omitempty"`
+	Note     *string `json:"note,omitempty"`
+}
+
+// OpeningHours - расписание организации с вычисленным признаком "открыто сейчас"
+type OpeningHours struct {
+	Timezone   string                  `json:"timezone"`
+	Weekly     []OpeningHoursInterval  `json:"weekly"`
+	Exceptions []OpeningHoursException `json:"exceptions"` // Только текущие и будущие даты
+	OpenNow    bool                    `json:"open_now"`
+}
+
+// UpdateOpeningHoursRequest - полная замена расписания
+type UpdateOpeningHoursRequest struct {
+	Timezone   *string                 `json:"timezone"`
+	Weekly     []OpeningHoursInterval  `json:"weekly"`
+	Exceptions []OpeningHoursException `json:"exceptions"`
+}
+
+// OrganizationService - услуга или товарная позиция в прайс-листе организации
+type OrganizationService struct {
+	ID              int       `json:"id"`
+	OrganizationID  int       `json:"organization_id"`
+	Category        *string   `json:"category,omitempty"`
+	Name            string    `json:"name"`
+	Description     *string   `json:"description,omitempty"`
+	PriceFrom       *int      `json:"price_from,omitempty"` // В рублях; пусто - цена по запросу
+	PriceTo         *int      `json:"price_to,omitempty"`
+	DurationMinutes *int      `json:"duration_minutes,omitempty"`
+	IsActive        bool      `json:"is_active"`
+	SortOrder       int       `json:"sort_order"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// OrganizationServiceRequest - создание или изменение услуги (изменяются только переданные поля)
+type OrganizationServiceRequest struct {
+	Category        *string `json:"category"`
+	Name            *string `json:"name"`
+	Description     *string `json:"description"`
+	PriceFrom       *int    `json:"price_from"`
+	PriceTo         *int    `json:"price_to"`
+	DurationMinutes *int    `json:"duration_minutes"`
+	IsActive        *bool   `json:"is_active"`
+	SortOrder       *int    `json:"sort_order"`
+}
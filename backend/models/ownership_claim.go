@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// OwnershipClaim - заявка на владение организацией, проходящая модерацию
+type OwnershipClaim struct {
+	ID             int        `json:"id"`
+	OrganizationID int        `json:"organization_id"`
+	UserID         int        `json:"user_id"`
+	INN            string     `json:"inn"`
+	OGRN           string     `json:"ogrn"`
+	Comment        *string    `json:"comment,omitempty"`
+	Status         string     `json:"status"` // pending, approved, rejected, cancelled
+	ReviewedBy     *int       `json:"reviewed_by,omitempty"`
+	ReviewComment  *string    `json:"review_comment,omitempty"`
+	ReviewedAt     *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+
+	// Подтверждающие документы (устав, доверенность) из медиатеки заявителя
+	Documents []UserMedia `json:"documents"`
+
+	// Дополнительная информация (для отображения)
+	OrganizationName string `json:"organization_name,omitempty"`
+	UserName         string `json:"user_name,omitempty"`
+	UserEmail        string `json:"user_email,omitempty"`
+}
+
+// CreateOwnershipClaimRequest - запрос на владение организацией
+type CreateOwnershipClaimRequest struct {
+	INN              string `json:"inn"`
+	OGRN             string `json:"ogrn"`
+	DocumentMediaIDs []int  `json:"document_media_ids"`
+	Comment          string `json:"comment"`
+}
+
+// ReviewOwnershipClaimRequest - решение модератора по заявке
+type ReviewOwnershipClaimRequest struct {
+	Comment string `json:"comment"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// OwnershipTransfer - передача владения организацией, вступает в силу после согласия получателя
+type OwnershipTransfer struct {
+	ID                int        `json:"id"`
+	OrganizationID    int        `json:"organization_id"`
+	FromUserID        int        `json:"from_user_id"`
+	ToUserID          int        `json:"to_user_id"`
+	PreviousOwnerRole string     `json:"previous_owner_role"` // owner (совладелец), admin, member, leave
+	Message           *string    `json:"message,omitempty"`
+	Status            string     `json:"status"` // pending, accepted, declined, cancelled, expired
+	ExpiresAt         time.Time  `json:"expires_at"`
+	RespondedAt       *time.Time `json:"responded_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+
+	// Дополнительная информация (для отображения)
+	OrganizationName string `json:"organization_name,omitempty"`
+	FromUserName     string `json:"from_user_name,omitempty"`
+	ToUserName       string `json:"to_user_name,omitempty"`
+}
+
+// CreateOwnershipTransferRequest - запрос на передачу владения
+type CreateOwnershipTransferRequest struct {
+	ToUserID          int    `json:"to_user_id"`
+	PreviousOwnerRole string `json:"previous_owner_role"` // По умолчанию admin
+	Message           string `json:"message"`
+}
@@ -10,29 +10,30 @@ type Attachment struct {
 
 // Post - универсальный пост в стиле Threads
 type Post struct {
-	ID            int           `json:"id"`
-	AuthorID      int           `json:"author_id"`
-	AuthorType    string        `json:"author_type"` // "user" или "organization"
-	Content       string        `json:"content"`
-	AttachedPets  []int         `json:"attached_pets"`          // Массив PetID
-	Attachments   []Attachment  `json:"attachments"`            // Массив медиа-файлов
-	Tags          []string      `json:"tags"`                   // Метки: "ищет дом", "потерян", "найден"
-	Status        string        `json:"status"`                 // "published", "scheduled", "draft"
-	ScheduledAt   *string       `json:"scheduled_at,omitempty"` // Время публикации (ISO 8601)
-	CreatedAt     string        `json:"created_at"`
-	UpdatedAt     string        `json:"updated_at"`
-	IsDeleted     bool          `json:"is_deleted"`
-	User          *User         `json:"user,omitempty"`          // Автор (если user)
-	Organization  *Organization `json:"organization,omitempty"`  // Автор (если organization)
-	Pets          []Pet         `json:"pets,omitempty"`          // Прикреплённые питомцы (полные данные)
-	Poll          *Poll         `json:"poll,omitempty"`          // Опрос (если есть)
-	HasPoll       bool          `json:"has_poll"`                // Есть ли опрос у поста (для оптимизации)
-	LikesCount    int           `json:"likes_count"`             // Количество лайков
-	CommentsCount int           `json:"comments_count"`          // Количество комментариев
-	CanEdit       bool          `json:"can_edit"`                // Может ли текущий пользователь редактировать пост
-	LocationLat   *float64      `json:"location_lat,omitempty"`  // Широта местоположения
-	LocationLon   *float64      `json:"location_lon,omitempty"`  // Долгота местоположения
-	LocationName  *string       `json:"location_name,omitempty"` // Название места
+	ID            int            `json:"id"`
+	AuthorID      int            `json:"author_id"`
+	AuthorType    string         `json:"author_type"` // "user" или "organization"
+	Content       string         `json:"content"`
+	AttachedPets  []int          `json:"attached_pets"`          // Массив PetID
+	Attachments   []Attachment   `json:"attachments"`            // Массив медиа-файлов
+	Tags          []string       `json:"tags"`                   // Метки: "ищет дом", "потерян", "найден"
+	Status        string         `json:"status"`                 // "published", "scheduled", "draft"
+	ScheduledAt   *string        `json:"scheduled_at,omitempty"` // Время публикации (ISO 8601)
+	CreatedAt     string         `json:"created_at"`
+	UpdatedAt     string         `json:"updated_at"`
+	IsDeleted     bool           `json:"is_deleted"`
+	User          *User          `json:"user,omitempty"`           // Автор (если user)
+	Organization  *Organization  `json:"organization,omitempty"`   // Автор (если organization)
+	Pets          []Pet          `json:"pets,omitempty"`           // Прикреплённые питомцы (полные данные)
+	Poll          *Poll          `json:"poll,omitempty"`           // Опрос (если есть)
+	HasPoll       bool           `json:"has_poll"`                 // Есть ли опрос у поста (для оптимизации)
+	LikesCount    int            `json:"likes_count"`              // Количество лайков
+	CommentsCount int            `json:"comments_count"`           // Количество комментариев
+	CanEdit       bool           `json:"can_edit"`                 // Может ли текущий пользователь редактировать пост
+	LocationLat   *float64       `json:"location_lat,omitempty"`   // Широта местоположения
+	LocationLon   *float64       `json:"location_lon,omitempty"`   // Долгота местоположения
+	LocationName  *string        `json:"location_name,omitempty"`  // Название места
+	WishlistItems []WishlistItem `json:"wishlist_items,omitempty"` // Привязанные позиции списка нужд (посты организаций)
 }
 
 // CreatePostRequest - запрос на создание поста
@@ -49,16 +50,18 @@ type CreatePostRequest struct {
 	LocationLat    *float64           `json:"location_lat,omitempty"`    // Широта местоположения
 	LocationLon    *float64           `json:"location_lon,omitempty"`    // Долгота местоположения
 	LocationName   *string            `json:"location_name,omitempty"`   // Название места
+	WishlistItems  []int              `json:"wishlist_items,omitempty"`  // ID позиций списка нужд организации
 }
 
 // UpdatePostRequest - запрос на обновление поста
 type UpdatePostRequest struct {
-	Content      string             `json:"content,omitempty"`
-	AttachedPets []int              `json:"attached_pets,omitempty"`
-	Attachments  []Attachment       `json:"attachments,omitempty"`
-	Tags         []string           `json:"tags,omitempty"`
-	Poll         *CreatePollRequest `json:"poll,omitempty"`          // Опрос (если добавляется)
-	LocationLat  *float64           `json:"location_lat,omitempty"`  // Широта местоположения
-	LocationLon  *float64           `json:"location_lon,omitempty"`  // Долгота местоположения
-	LocationName *string            `json:"location_name,omitempty"` // Название места
+	Content       string             `json:"content,omitempty"`
+	AttachedPets  []int              `json:"attached_pets,omitempty"`
+	Attachments   []Attachment       `json:"attachments,omitempty"`
+	Tags          []string           `json:"tags,omitempty"`
+	Poll          *CreatePollRequest `json:"poll,omitempty"`          // Опрос (если добавляется)
+	LocationLat   *float64           `json:"location_lat,omitempty"`  // Широта местоположения
+	LocationLon   *float64           `json:"location_lon,omitempty"`  // Долгота местоположения
+	LocationName  *string            `json:"location_name,omitempty"` // Название места
+	WishlistItems []int              `json:"wishlist_items"`          // nil - не менять привязки
 }
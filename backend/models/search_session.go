@@ -0,0 +1,97 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// SearchSession - поисковый выход волонтёров по объявлению о потере
+type SearchSession struct {
+	ID              int              `json:"id"`
+	AnnouncementID  int              `json:"announcement_id"`
+	LeaderID        int              `json:"leader_id"`
+	StartsAt        time.Time        `json:"starts_at"`
+	MeetingPoint    string           `json:"meeting_point"`
+	MeetingLat      *float64         `json:"meeting_lat,omitempty"`
+	MeetingLon      *float64         `json:"meeting_lon,omitempty"`
+	AreaDescription string           `json:"area_description"`
+	AreaGeoJSON     *json.RawMessage `json:"area_geojson,omitempty"` // Полигон района поиска
+	Status          string           `json:"status"`                 // planned, active, finished, cancelled
+	Notes           *string          `json:"notes,omitempty"`
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+
+	// Дополнительные поля для UI
+	Leader            *User                      `json:"leader,omitempty"`
+	ParticipantsCount int                        `json:"participants_count"`
+	Participants      []SearchSessionParticipant `json:"participants,omitempty"`
+}
+
+// SearchSessionParticipant - участник поискового выхода
+type SearchSessionParticipant struct {
+	ID           int        `json:"id"`
+	SessionID    int        `json:"session_id"`
+	UserID       int        `json:"user_id"`
+	Status       string     `json:"status"` // signed_up, checked_in, checked_out
+	SignedUpAt   time.Time  `json:"signed_up_at"`
+	CheckedInAt  *time.Time `json:"checked_in_at,omitempty"`
+	CheckedOutAt *time.Time `json:"checked_out_at,omitempty"`
+
+	User *User `json:"user,omitempty"`
+}
+
+// SearchCoverageReport - отчёт участника о прочёсанном участке
+type SearchCoverageReport struct {
+	ID          int              `json:"id"`
+	SessionID   int              `json:"session_id"`
+	UserID      int              `json:"user_id"`
+	AreaName    string           `json:"area_name"`
+	AreaGeoJSON *json.RawMessage `json:"area_geojson,omitempty"`
+	FoundTraces bool             `json:"found_traces"` // Найдены следы / питомца видели
+	Notes       *string          `json:"notes,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+
+	User *User `json:"user,omitempty"`
+}
+
+// SearchCoverageArea - сводка по участку для организатора
+type SearchCoverageArea struct {
+	AreaName     string    `json:"area_name"`
+	ReportsCount int       `json:"reports_count"`
+	FoundTraces  bool      `json:"found_traces"`
+	LastCoverage time.Time `json:"last_coverage"`
+}
+
+// SearchCoverageSummary - какие участки уже прочёсаны
+type SearchCoverageSummary struct {
+	SessionsCount     int                  `json:"sessions_count"`
+	ParticipantsCount int                  `json:"participants_count"`
+	CheckedInCount    int                  `json:"checked_in_count"`
+	ReportsCount      int                  `json:"reports_count"`
+	Areas             []SearchCoverageArea `json:"areas"`
+}
+
+// CreateSearchSessionRequest - запрос на создание поискового выхода
+type CreateSearchSessionRequest struct {
+	StartsAt        string           `json:"starts_at"` // RFC3339
+	MeetingPoint    string           `json:"meeting_point"`
+	MeetingLat      *float64         `json:"meeting_lat,omitempty"`
+	MeetingLon      *float64         `json:"meeting_lon,omitempty"`
+	AreaDescription string           `json:"area_description"`
+	AreaGeoJSON     *json.RawMessage `json:"area_geojson,omitempty"`
+	Notes           *string          `json:"notes,omitempty"`
+}
+
+// UpdateSearchSessionRequest - запрос на изменение статуса поискового выхода
+type UpdateSearchSessionRequest struct {
+	Status *string `json:"status"`
+	Notes  *string `json:"notes"`
+}
+
+// CreateCoverageReportRequest - запрос на отчёт о прочёсанном участке
+type CreateCoverageReportRequest struct {
+	AreaName    string           `json:"area_name"`
+	AreaGeoJSON *json.RawMessage `json:"area_geojson,omitempty"`
+	FoundTraces bool             `json:"found_traces"`
+	Notes       *string          `json:"notes,omitempty"`
+}
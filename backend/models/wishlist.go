@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// WishlistItem - позиция списка нужд приюта (корм, наполнитель, лекарства и т.д.)
+type WishlistItem struct {
+	ID               int       `json:"id"`
+	OrganizationID   int       `json:"organization_id"`
+	Title            string    `json:"title"`
+	Description      *string   `json:"description,omitempty"`
+	Category         *string   `json:"category,omitempty"` // food, litter, medicine, equipment, other
+	Unit             string    `json:"unit"`               // шт, кг, упак.
+	QuantityNeeded   int       `json:"quantity_needed"`
+	QuantityPledged  int       `json:"quantity_pledged"`  // Обещано и ещё не доставлено
+	QuantityReceived int       `json:"quantity_received"` // Получено приютом
+	IsUrgent         bool      `json:"is_urgent"`
+	Status           string    `json:"status"` // open, fulfilled, closed
+	CreatedBy        *int      `json:"created_by,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+
+	// Дополнительная информация (для отображения)
+	QuantityRemaining int    `json:"quantity_remaining"` // Сколько ещё можно пообещать
+	OrganizationName  string `json:"organization_name,omitempty"`
+}
+
+// WishlistPledge - обещание пользователя привезти часть позиции
+type WishlistPledge struct {
+	ID               int        `json:"id"`
+	ItemID           int        `json:"item_id"`
+	UserID           int        `json:"user_id"`
+	Quantity         int        `json:"quantity"`
+	QuantityReceived *int       `json:"quantity_received,omitempty"`
+	Comment          *string    `json:"comment,omitempty"`
+	Status           string     `json:"status"` // pledged, received, cancelled
+	ReceivedBy       *int       `json:"received_by,omitempty"`
+	ReceivedAt       *time.Time `json:"received_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+
+	// Дополнительная информация (для отображения)
+	UserName  string `json:"user_name,omitempty"`
+	ItemTitle string `json:"item_title,omitempty"`
+}
+
+// WishlistItemRequest - создание или изменение позиции
+type WishlistItemRequest struct {
+	Title            string  `json:"title"`
+	Description      *string `json:"description,omitempty"`
+	Category         *string `json:"category,omitempty"`
+	Unit             string  `json:"unit"`
+	QuantityNeeded   int     `json:"quantity_needed"`
+	QuantityReceived *int    `json:"quantity_received,omitempty"` // Ручная корректировка (передали без обещания)
+	IsUrgent         bool    `json:"is_urgent"`
+	Status           string  `json:"status,omitempty"` // open, closed (только при изменении)
+}
+
+// CreatePledgeRequest - обещание привезти позицию
+type CreatePledgeRequest struct {
+	Quantity int    `json:"quantity"`
+	Comment  string `json:"comment"`
+}
+
+// ReceivePledgeRequest - отметка приюта о получении (по умолчанию - всё обещанное количество)
+type ReceivePledgeRequest struct {
+	Quantity *int `json:"quantity,omitempty"`
+}
+
+// AttachWishlistItemsRequest - привязка позиций к объявлению о сборе
+type AttachWishlistItemsRequest struct {
+	ItemIDs []int `json:"item_ids"`
+}
@@ -0,0 +1,180 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const dadataFindPartyURL = "https://suggestions.dadata.ru/suggestions/api/4_1/rs/findById/party"
+
+// DaDataRegistry - реестр на основе API DaData (поиск организации по ИНН)
+type DaDataRegistry struct {
+	apiKey  string
+	baseURL string
+	client  *http.Client
+}
+
+// NewDaDataRegistry создаёт клиент DaData
+func NewDaDataRegistry(apiKey string) *DaDataRegistry {
+	return &DaDataRegistry{
+		apiKey:  apiKey,
+		baseURL: dadataFindPartyURL,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Ответ DaData findById/party (только используемые поля)
+type dadataPartyResponse struct {
+	Suggestions []struct {
+		Value string `json:"value"`
+		Data  struct {
+			INN      string `json:"inn"`
+			KPP      string `json:"kpp"`
+			OGRN     string `json:"ogrn"`
+			OGRNDate *int64 `json:"ogrn_date"`
+			Name     struct {
+				FullWithOPF  string `json:"full_with_opf"`
+				ShortWithOPF string `json:"short_with_opf"`
+			} `json:"name"`
+			OPF struct {
+				Code  string `json:"code"`
+				Short string `json:"short"`
+			} `json:"opf"`
+			Management *struct {
+				Name string `json:"name"`
+				Post string `json:"post"`
+			} `json:"management"`
+			State struct {
+				Status           string `json:"status"`
+				RegistrationDate *int64 `json:"registration_date"`
+				LiquidationDate  *int64 `json:"liquidation_date"`
+			} `json:"state"`
+			OKVED         string `json:"okved"`
+			OKVEDType     string `json:"okved_type"`
+			OKPO          string `json:"okpo"`
+			OKTMO         string `json:"oktmo"`
+			OKATO         string `json:"okato"`
+			OKOGU         string `json:"okogu"`
+			OKFS          string `json:"okfs"`
+			BranchType    string `json:"branch_type"`
+			BranchCount   *int   `json:"branch_count"`
+			EmployeeCount *int   `json:"employee_count"`
+			Address       *struct {
+				Value string `json:"value"`
+				Data  struct {
+					PostalCode     string `json:"postal_code"`
+					RegionWithType string `json:"region_with_type"`
+					City           string `json:"city"`
+					StreetWithType string `json:"street_with_type"`
+					House          string `json:"house"`
+					Flat           string `json:"flat"`
+					GeoLat         string `json:"geo_lat"`
+					GeoLon         string `json:"geo_lon"`
+				} `json:"data"`
+			} `json:"address"`
+		} `json:"data"`
+	} `json:"suggestions"`
+}
+
+// FindByINN реализует CompanyRegistry
+func (d *DaDataRegistry) FindByINN(inn string) (*CompanyInfo, error) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"query":       inn,
+		"branch_type": "MAIN", // Только головная организация
+	})
+
+	req, err := http.NewRequest(http.MethodPost, d.baseURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Token "+d.apiKey)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("dadata request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("dadata returned status %d", resp.StatusCode)
+	}
+
+	var parsed dadataPartyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("failed to decode dadata response: %w", err)
+	}
+	if len(parsed.Suggestions) == 0 {
+		return nil, ErrNotFound
+	}
+
+	s := parsed.Suggestions[0]
+	data := s.Data
+	info := &CompanyInfo{
+		Name:                  data.Name.FullWithOPF,
+		ShortName:             data.Name.ShortWithOPF,
+		LegalForm:             data.OPF.Short,
+		INN:                   data.INN,
+		OGRN:                  data.OGRN,
+		KPP:                   data.KPP,
+		RegistrationDate:      msToDate(data.OGRNDate),
+		StateStatus:           data.State.Status,
+		StateRegistrationDate: msToDate(data.State.RegistrationDate),
+		StateLiquidationDate:  msToDate(data.State.LiquidationDate),
+		EmployeeCount:         data.EmployeeCount,
+		OKVED:                 data.OKVED,
+		OKVEDType:             data.OKVEDType,
+		OKPO:                  data.OKPO,
+		OKTMO:                 data.OKTMO,
+		OKATO:                 data.OKATO,
+		OKOGU:                 data.OKOGU,
+		OKFS:                  data.OKFS,
+		OKOPF:                 data.OPF.Code,
+		BranchType:            data.BranchType,
+		BranchCount:           data.BranchCount,
+	}
+	if info.Name == "" {
+		info.Name = s.Value
+	}
+	if data.Management != nil {
+		info.DirectorName = data.Management.Name
+		info.DirectorPosition = data.Management.Post
+	}
+	if data.Address != nil {
+		info.AddressFull = data.Address.Value
+		info.AddressPostalCode = data.Address.Data.PostalCode
+		info.AddressRegion = data.Address.Data.RegionWithType
+		info.AddressCity = data.Address.Data.City
+		info.AddressStreet = data.Address.Data.StreetWithType
+		info.AddressHouse = data.Address.Data.House
+		info.AddressOffice = data.Address.Data.Flat
+		info.GeoLat = parseCoordinate(data.Address.Data.GeoLat)
+		info.GeoLon = parseCoordinate(data.Address.Data.GeoLon)
+	}
+
+	return info, nil
+}
+
+// msToDate переводит дату DaData (миллисекунды Unix) в YYYY-MM-DD
+func msToDate(ms *int64) string {
+	if ms == nil {
+		return ""
+	}
+	return time.UnixMilli(*ms).UTC().Format("2006-01-02")
+}
+
+func parseCoordinate(s string) *float64 {
+	if s == "" {
+		return nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil
+	}
+	return &v
+}
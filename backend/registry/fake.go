@@ -0,0 +1,46 @@
+package registry
+
+import "sync"
+
+// FakeRegistry - реестр в памяти для тестов и локальной разработки
+type FakeRegistry struct {
+	mu        sync.RWMutex
+	companies map[string]CompanyInfo
+	calls     int
+}
+
+// NewFakeRegistry создаёт фейковый реестр с заданными организациями
+func NewFakeRegistry(companies ...CompanyInfo) *FakeRegistry {
+	f := &FakeRegistry{companies: make(map[string]CompanyInfo)}
+	for _, c := range companies {
+		f.companies[c.INN] = c
+	}
+	return f
+}
+
+// Set добавляет или заменяет организацию (например, чтобы сменить её статус)
+func (f *FakeRegistry) Set(company CompanyInfo) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.companies[company.INN] = company
+}
+
+// Calls возвращает число обращений к реестру
+func (f *FakeRegistry) Calls() int {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.calls
+}
+
+// FindByINN реализует CompanyRegistry
+func (f *FakeRegistry) FindByINN(inn string) (*CompanyInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+
+	company, ok := f.companies[inn]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &company, nil
+}
@@ -0,0 +1,90 @@
+package registry
+
+import (
+	"errors"
+	"log"
+	"os"
+)
+
+// Статусы юридического лица в ЕГРЮЛ
+const (
+	StatusActive       = "ACTIVE"
+	StatusLiquidating  = "LIQUIDATING"
+	StatusLiquidated   = "LIQUIDATED"
+	StatusBankrupt     = "BANKRUPT"
+	StatusReorganizing = "REORGANIZING"
+)
+
+// ErrNotFound - организация с таким ИНН не найдена в реестре
+var ErrNotFound = errors.New("company not found in registry")
+
+// CompanyInfo - данные организации из реестра.
+// JSON-теги совпадают с CreateOrganizationRequest, чтобы фронтенд мог сразу заполнить форму.
+type CompanyInfo struct {
+	Name             string `json:"name"`
+	ShortName        string `json:"short_name,omitempty"`
+	LegalForm        string `json:"legal_form,omitempty"`
+	INN              string `json:"inn"`
+	OGRN             string `json:"ogrn,omitempty"`
+	KPP              string `json:"kpp,omitempty"`
+	RegistrationDate string `json:"registration_date,omitempty"` // YYYY-MM-DD
+
+	AddressFull       string   `json:"address_full,omitempty"`
+	AddressPostalCode string   `json:"address_postal_code,omitempty"`
+	AddressRegion     string   `json:"address_region,omitempty"`
+	AddressCity       string   `json:"address_city,omitempty"`
+	AddressStreet     string   `json:"address_street,omitempty"`
+	AddressHouse      string   `json:"address_house,omitempty"`
+	AddressOffice     string   `json:"address_office,omitempty"`
+	GeoLat            *float64 `json:"geo_lat,omitempty"`
+	GeoLon            *float64 `json:"geo_lon,omitempty"`
+
+	DirectorName     string `json:"director_name,omitempty"`
+	DirectorPosition string `json:"director_position,omitempty"`
+
+	StateStatus           string `json:"state_status,omitempty"`           // ACTIVE, LIQUIDATING, LIQUIDATED, BANKRUPT, REORGANIZING
+	StateLiquidationDate  string `json:"state_liquidation_date,omitempty"` // YYYY-MM-DD
+	StateRegistrationDate string `json:"state_registration_date,omitempty"`
+
+	EmployeeCount *int   `json:"employee_count,omitempty"`
+	OKVED         string `json:"okved,omitempty"`
+	OKVEDType     string `json:"okved_type,omitempty"`
+	OKPO          string `json:"okpo,omitempty"`
+	OKTMO         string `json:"oktmo,omitempty"`
+	OKATO         string `json:"okato,omitempty"`
+	OKOGU         string `json:"okogu,omitempty"`
+	OKFS          string `json:"okfs,omitempty"`
+	OKOPF         string `json:"okopf,omitempty"`
+	BranchType    string `json:"branch_type,omitempty"`
+	BranchCount   *int   `json:"branch_count,omitempty"`
+}
+
+// CompanyRegistry - источник сведений об организациях (ЕГРЮЛ)
+type CompanyRegistry interface {
+	// FindByINN возвращает ErrNotFound, если организации нет в реестре
+	FindByINN(inn string) (*CompanyInfo, error)
+}
+
+// Global - реестр, выбранный при запуске (nil, если не настроен)
+var Global CompanyRegistry
+
+// Init выбирает реализацию реестра по переменным окружения:
+// COMPANY_REGISTRY=fake - фейковый реестр для локальной разработки,
+// иначе DaData при наличии DADATA_API_KEY
+func Init() {
+	if os.Getenv("COMPANY_REGISTRY") == "fake" {
+		log.Println("🧪 Using fake company registry (COMPANY_REGISTRY=fake)")
+		Global = NewFakeRegistry()
+		return
+	}
+
+	apiKey := os.Getenv("DADATA_API_KEY")
+	if apiKey == "" {
+		log.Println("⚠️  DADATA_API_KEY is not set, company registry is disabled")
+		Global = nil
+		return
+	}
+
+	Global = NewDaDataRegistry(apiKey)
+	log.Println("✅ Company registry: DaData")
+}
@@ -0,0 +1,20 @@
+-- Отчёты о расходах по сборам средств
+-- Дата: 2026-10-19
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS announcement_expenses (
+    id SERIAL PRIMARY KEY,
+    announcement_id INTEGER NOT NULL REFERENCES pet_announcements(id) ON DELETE CASCADE,
+    author_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    description TEXT,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    receipt_media_id INTEGER REFERENCES user_media(id) ON DELETE SET NULL, -- Чек из медиатеки автора
+    spent_at DATE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_announcement_expenses_announcement ON announcement_expenses(announcement_id);
+
+COMMIT;
@@ -0,0 +1,13 @@
+-- Геометки наблюдений в публикациях к объявлениям о потере
+-- Дата: 2026-10-19
+
+BEGIN;
+
+ALTER TABLE announcement_posts ADD COLUMN IF NOT EXISTS sighting_lat DECIMAL(10, 8);
+ALTER TABLE announcement_posts ADD COLUMN IF NOT EXISTS sighting_lon DECIMAL(11, 8);
+ALTER TABLE announcement_posts ADD COLUMN IF NOT EXISTS sighted_at TIMESTAMP; -- Когда питомца видели (а не когда опубликовали)
+
+CREATE INDEX IF NOT EXISTS idx_announcement_posts_sightings ON announcement_posts(announcement_id, sighted_at)
+    WHERE sighting_lat IS NOT NULL AND sighting_lon IS NOT NULL;
+
+COMMIT;
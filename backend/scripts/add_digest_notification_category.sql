@@ -0,0 +1,11 @@
+-- Категория настроек "digest": каналы доставки ежедневной сводки (список, push, письмо)
+-- Дата: 2026-10-19
+
+BEGIN;
+
+ALTER TABLE notification_preferences DROP CONSTRAINT IF EXISTS notification_preferences_category_check;
+ALTER TABLE notification_preferences ADD CONSTRAINT notification_preferences_category_check CHECK (category IN (
+    'comment', 'like', 'friend_request', 'message', 'announcement', 'organization_invite', 'digest'
+));
+
+COMMIT;
@@ -0,0 +1,53 @@
+-- Сеть передержек: анкеты волонтёров, размещения питомцев и отчёты
+-- Дата: 2026-10-19
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS foster_homes (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    capacity INTEGER NOT NULL DEFAULT 1 CHECK (capacity > 0),
+    species TEXT NOT NULL DEFAULT '[]', -- JSON-массив видов
+    city VARCHAR(255),
+    conditions TEXT,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS foster_placements (
+    id SERIAL PRIMARY KEY,
+    organization_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    pet_id INTEGER NOT NULL REFERENCES pets(id) ON DELETE CASCADE,
+    foster_home_id INTEGER NOT NULL REFERENCES foster_homes(id) ON DELETE CASCADE,
+    foster_user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    start_date DATE NOT NULL,
+    end_date DATE,
+    ended_at TIMESTAMP,
+    status VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'completed', 'cancelled')),
+    notes TEXT,
+    checkin_interval_days INTEGER NOT NULL DEFAULT 7 CHECK (checkin_interval_days > 0),
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (end_date IS NULL OR end_date >= start_date)
+);
+
+-- Питомец может находиться только на одной передержке
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_foster_placements_active_pet ON foster_placements(pet_id) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_foster_placements_org ON foster_placements(organization_id, status);
+CREATE INDEX IF NOT EXISTS idx_foster_placements_foster ON foster_placements(foster_user_id, status);
+
+CREATE TABLE IF NOT EXISTS foster_checkins (
+    id SERIAL PRIMARY KEY,
+    placement_id INTEGER NOT NULL REFERENCES foster_placements(id) ON DELETE CASCADE,
+    author_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    wellbeing VARCHAR(20) NOT NULL DEFAULT 'good' CHECK (wellbeing IN ('good', 'attention', 'urgent')),
+    text TEXT NOT NULL,
+    attachments TEXT NOT NULL DEFAULT '[]', -- JSON-массив вложений, как у постов
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_foster_checkins_placement ON foster_checkins(placement_id, created_at DESC);
+
+COMMIT;
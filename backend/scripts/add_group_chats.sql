@@ -0,0 +1,37 @@
+-- Групповые чаты: участники, администраторы, прочтения по участникам, чаты сотрудников организаций
+-- Дата: 2026-10-19
+
+BEGIN;
+
+-- Тип чата: direct - диалог двух пользователей (user1_id/user2_id), group - групповой,
+-- organization_staff - чат сотрудников, состав синхронизируется с organization_members
+ALTER TABLE chats ADD COLUMN IF NOT EXISTS type VARCHAR(20) NOT NULL DEFAULT 'direct';
+ALTER TABLE chats ADD COLUMN IF NOT EXISTS title VARCHAR(255);
+ALTER TABLE chats ADD COLUMN IF NOT EXISTS avatar TEXT;
+ALTER TABLE chats ADD COLUMN IF NOT EXISTS created_by INTEGER REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE chats ADD COLUMN IF NOT EXISTS organization_id INTEGER REFERENCES organizations(id) ON DELETE CASCADE;
+ALTER TABLE chats ALTER COLUMN user1_id DROP NOT NULL;
+ALTER TABLE chats ALTER COLUMN user2_id DROP NOT NULL;
+
+-- Один чат сотрудников на организацию
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_chats_organization_staff ON chats(organization_id) WHERE type = 'organization_staff';
+
+-- В групповых чатах у сообщения нет единственного получателя
+ALTER TABLE messages ALTER COLUMN receiver_id DROP NOT NULL;
+
+-- Участники групповых чатов; прочтение хранится по участнику (последнее прочитанное сообщение)
+CREATE TABLE IF NOT EXISTS chat_members (
+    chat_id INTEGER NOT NULL REFERENCES chats(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL DEFAULT 'member' CHECK (role IN ('member', 'admin')),
+    invited_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    joined_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_read_message_id INTEGER,
+    last_read_at TIMESTAMP,
+    PRIMARY KEY (chat_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_members_user ON chat_members(user_id);
+CREATE INDEX IF NOT EXISTS idx_messages_chat_id_id ON messages(chat_id, id);
+
+COMMIT;
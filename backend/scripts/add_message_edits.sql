@@ -0,0 +1,23 @@
+-- Редактирование, удаление и ответы на сообщения в мессенджере
+-- Дата: 2026-10-19
+
+BEGIN;
+
+-- edited_at - время последнего редактирования, deleted_at - удалено для всех (текст и вложения стираются)
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS edited_at TIMESTAMP;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS reply_to_message_id INTEGER REFERENCES messages(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_messages_reply_to ON messages(reply_to_message_id) WHERE reply_to_message_id IS NOT NULL;
+
+-- Сообщения, удалённые пользователем только у себя
+CREATE TABLE IF NOT EXISTS message_hidden (
+    message_id INTEGER NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    hidden_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (message_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_hidden_user ON message_hidden(user_id);
+
+COMMIT;
@@ -0,0 +1,30 @@
+-- Группировка уведомлений и ежедневная сводка
+-- Дата: 2026-10-19
+
+BEGIN;
+
+-- Сгруппированное уведомление: actor_id - последний участник, actors_count - сколько всего
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS actors_count INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP;
+
+-- Поиск открытой группы: непрочитанное уведомление того же типа по той же сущности
+CREATE INDEX IF NOT EXISTS idx_notifications_group
+    ON notifications(user_id, type, entity_type, entity_id, created_at)
+    WHERE is_read = FALSE;
+
+-- Участники группы (повторный лайк того же пользователя не увеличивает счётчик)
+CREATE TABLE IF NOT EXISTS notification_actors (
+    notification_id INTEGER NOT NULL REFERENCES notifications(id) ON DELETE CASCADE,
+    actor_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (notification_id, actor_id)
+);
+
+-- Настройки уведомлений пользователя
+CREATE TABLE IF NOT EXISTS user_notification_settings (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    daily_digest BOOLEAN NOT NULL DEFAULT FALSE,
+    digest_last_sent_at TIMESTAMP
+);
+
+COMMIT;
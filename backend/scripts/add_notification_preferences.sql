@@ -0,0 +1,29 @@
+-- Настройки уведомлений по категориям и каналам, заглушённые посты и чаты
+-- Дата: 2026-10-19
+
+BEGIN;
+
+-- Каналы доставки для категории; отсутствие строки - значения по умолчанию (in_app и push включены, email выключен)
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    category VARCHAR(50) NOT NULL CHECK (category IN (
+        'comment', 'like', 'friend_request', 'mention', 'message', 'announcement', 'organization_invite'
+    )),
+    in_app BOOLEAN NOT NULL DEFAULT TRUE,
+    push BOOLEAN NOT NULL DEFAULT TRUE,
+    email BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, category)
+);
+
+-- Заглушённые посты и чаты; muted_until IS NULL - бессрочно
+CREATE TABLE IF NOT EXISTS notification_mutes (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    entity_type VARCHAR(20) NOT NULL CHECK (entity_type IN ('post', 'chat')),
+    entity_id INTEGER NOT NULL,
+    muted_until TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, entity_type, entity_id)
+);
+
+COMMIT;
@@ -0,0 +1,37 @@
+-- Приглашения в организации и заявки на вступление
+-- Дата: 2026-10-19
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS organization_invitations (
+    id SERIAL PRIMARY KEY,
+    organization_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('invite', 'request')),
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE, -- NULL, пока приглашённый по email не зарегистрирован
+    email VARCHAR(255),
+    role VARCHAR(20) NOT NULL DEFAULT 'member' CHECK (role IN ('admin', 'moderator', 'member')),
+    position VARCHAR(255),
+    can_post BOOLEAN NOT NULL DEFAULT FALSE,
+    can_edit BOOLEAN NOT NULL DEFAULT FALSE,
+    can_manage_members BOOLEAN NOT NULL DEFAULT FALSE,
+    message TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined', 'expired', 'cancelled')),
+    invited_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    responded_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP NOT NULL,
+    responded_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (user_id IS NOT NULL OR email IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_invitations_org ON organization_invitations(organization_id, status);
+CREATE INDEX IF NOT EXISTS idx_organization_invitations_user ON organization_invitations(user_id, status);
+CREATE INDEX IF NOT EXISTS idx_organization_invitations_email ON organization_invitations(LOWER(email)) WHERE status = 'pending';
+
+-- Не более одного активного приглашения/заявки на пользователя в организации
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_organization_invitations_pending_user
+    ON organization_invitations(organization_id, user_id) WHERE status = 'pending' AND user_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_organization_invitations_pending_email
+    ON organization_invitations(organization_id, LOWER(email)) WHERE status = 'pending' AND user_id IS NULL;
+
+COMMIT;
@@ -0,0 +1,12 @@
+-- Периодическая сверка организаций с реестром (ЕГРЮЛ)
+-- Дата: 2026-10-19
+
+BEGIN;
+
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS registry_checked_at TIMESTAMP; -- Последняя сверка с реестром
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS liquidation_flagged_at TIMESTAMP; -- Когда реестр сообщил о ликвидации
+
+CREATE INDEX IF NOT EXISTS idx_organizations_registry_checked ON organizations(registry_checked_at NULLS FIRST) WHERE inn IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_organizations_liquidation_flagged ON organizations(liquidation_flagged_at) WHERE liquidation_flagged_at IS NOT NULL;
+
+COMMIT;
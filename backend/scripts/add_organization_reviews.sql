@@ -0,0 +1,27 @@
+-- Отзывы и рейтинг организаций
+-- Дата: 2026-10-19
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS organization_reviews (
+    id SERIAL PRIMARY KEY,
+    organization_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    text TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'published' CHECK (status IN ('published', 'hidden')),
+    reply TEXT,
+    reply_author_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    replied_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (organization_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_reviews_org ON organization_reviews(organization_id, status, created_at DESC);
+
+-- Кэш сводного рейтинга (пересчитывается при изменении отзывов)
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS rating_average NUMERIC(3, 2) NOT NULL DEFAULT 0;
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS reviews_count INTEGER NOT NULL DEFAULT 0;
+
+COMMIT;
@@ -0,0 +1,50 @@
+-- Режим работы, услуги и прайс-лист организаций
+-- Дата: 2026-10-19
+
+BEGIN;
+
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT 'Europe/Moscow';
+
+-- Обычный режим работы по дням недели (несколько интервалов в день - например, с перерывом)
+CREATE TABLE IF NOT EXISTS organization_opening_hours (
+    id SERIAL PRIMARY KEY,
+    organization_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    weekday SMALLINT NOT NULL CHECK (weekday BETWEEN 1 AND 7), -- 1 - понедельник
+    opens_at TIME NOT NULL,
+    closes_at TIME NOT NULL -- Не позже opens_at - работа через полночь
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_opening_hours_org ON organization_opening_hours(organization_id, weekday);
+
+-- Особый режим на даты (праздники, санитарные дни)
+CREATE TABLE IF NOT EXISTS organization_hours_exceptions (
+    id SERIAL PRIMARY KEY,
+    organization_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    is_closed BOOLEAN NOT NULL DEFAULT TRUE,
+    opens_at TIME,
+    closes_at TIME,
+    note TEXT,
+    UNIQUE (organization_id, date),
+    CHECK (is_closed OR (opens_at IS NOT NULL AND closes_at IS NOT NULL))
+);
+
+-- Услуги и прайс-лист
+CREATE TABLE IF NOT EXISTS organization_services (
+    id SERIAL PRIMARY KEY,
+    organization_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    category VARCHAR(100),
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    price_from INTEGER CHECK (price_from >= 0),
+    price_to INTEGER CHECK (price_to >= 0),
+    duration_minutes INTEGER CHECK (duration_minutes > 0),
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_services_org ON organization_services(organization_id, is_active, category, sort_order);
+
+COMMIT;
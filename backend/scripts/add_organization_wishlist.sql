@@ -0,0 +1,54 @@
+-- Список нужд приютов (вишлист) и обещания пользователей
+-- Дата: 2026-10-19
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS organization_wishlist_items (
+    id SERIAL PRIMARY KEY,
+    organization_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    category VARCHAR(50),
+    unit VARCHAR(20) NOT NULL DEFAULT 'шт',
+    quantity_needed INTEGER NOT NULL CHECK (quantity_needed > 0),
+    quantity_pledged INTEGER NOT NULL DEFAULT 0 CHECK (quantity_pledged >= 0),
+    quantity_received INTEGER NOT NULL DEFAULT 0 CHECK (quantity_received >= 0),
+    is_urgent BOOLEAN NOT NULL DEFAULT FALSE,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'fulfilled', 'closed')),
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_wishlist_items_org ON organization_wishlist_items(organization_id, status);
+
+CREATE TABLE IF NOT EXISTS organization_wishlist_pledges (
+    id SERIAL PRIMARY KEY,
+    item_id INTEGER NOT NULL REFERENCES organization_wishlist_items(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    quantity_received INTEGER CHECK (quantity_received >= 0),
+    comment TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pledged' CHECK (status IN ('pledged', 'received', 'cancelled')),
+    received_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    received_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_wishlist_pledges_item ON organization_wishlist_pledges(item_id, status);
+CREATE INDEX IF NOT EXISTS idx_organization_wishlist_pledges_user ON organization_wishlist_pledges(user_id);
+
+-- Привязка позиций к постам организаций и к объявлениям о сборе средств
+CREATE TABLE IF NOT EXISTS post_wishlist_items (
+    post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    item_id INTEGER NOT NULL REFERENCES organization_wishlist_items(id) ON DELETE CASCADE,
+    PRIMARY KEY (post_id, item_id)
+);
+
+CREATE TABLE IF NOT EXISTS announcement_wishlist_items (
+    announcement_id INTEGER NOT NULL REFERENCES pet_announcements(id) ON DELETE CASCADE,
+    item_id INTEGER NOT NULL REFERENCES organization_wishlist_items(id) ON DELETE CASCADE,
+    PRIMARY KEY (announcement_id, item_id)
+);
+
+COMMIT;
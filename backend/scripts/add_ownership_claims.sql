@@ -0,0 +1,32 @@
+-- Заявки на владение организациями с проверкой документов
+-- Дата: 2026-10-19
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS organization_ownership_claims (
+    id SERIAL PRIMARY KEY,
+    organization_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    inn VARCHAR(12) NOT NULL,
+    ogrn VARCHAR(15) NOT NULL,
+    comment TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected', 'cancelled')),
+    reviewed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    review_comment TEXT,
+    reviewed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Документы заявки (устав, доверенность) из медиатеки заявителя
+CREATE TABLE IF NOT EXISTS organization_ownership_claim_documents (
+    claim_id INTEGER NOT NULL REFERENCES organization_ownership_claims(id) ON DELETE CASCADE,
+    media_id INTEGER NOT NULL REFERENCES user_media(id) ON DELETE CASCADE,
+    PRIMARY KEY (claim_id, media_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ownership_claims_status ON organization_ownership_claims(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_ownership_claims_org ON organization_ownership_claims(organization_id);
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_ownership_claims_pending
+    ON organization_ownership_claims(organization_id, user_id) WHERE status = 'pending';
+
+COMMIT;
@@ -0,0 +1,27 @@
+-- Передача владения организацией
+-- Дата: 2026-10-19
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS organization_ownership_transfers (
+    id SERIAL PRIMARY KEY,
+    organization_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    from_user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    to_user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    previous_owner_role VARCHAR(20) NOT NULL DEFAULT 'admin' CHECK (previous_owner_role IN ('owner', 'admin', 'member', 'leave')),
+    message TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined', 'cancelled', 'expired')),
+    expires_at TIMESTAMP NOT NULL,
+    responded_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (from_user_id <> to_user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ownership_transfers_org ON organization_ownership_transfers(organization_id, status);
+CREATE INDEX IF NOT EXISTS idx_ownership_transfers_to_user ON organization_ownership_transfers(to_user_id, status);
+
+-- Одна активная передача на организацию
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_ownership_transfers_pending
+    ON organization_ownership_transfers(organization_id) WHERE status = 'pending';
+
+COMMIT;
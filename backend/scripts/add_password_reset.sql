@@ -0,0 +1,35 @@
+-- Самостоятельный сброс пароля и отзыв сеансов
+-- Дата: 2026-10-19
+
+BEGIN;
+
+-- JWT, выданные раньше этого момента, считаются отозванными
+ALTER TABLE users ADD COLUMN IF NOT EXISTS sessions_revoked_at TIMESTAMP;
+
+-- Хранится только SHA-256 токена; токен одноразовый
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    request_ip VARCHAR(64),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user ON password_reset_tokens(user_id) WHERE used_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_expires ON password_reset_tokens(expires_at);
+
+-- Попытки для ограничения частоты запросов (по IP и по хешу email)
+CREATE TABLE IF NOT EXISTS password_reset_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    action VARCHAR(20) NOT NULL,
+    ip_address VARCHAR(64),
+    email_hash CHAR(64),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_reset_attempts_ip ON password_reset_attempts(action, ip_address, created_at);
+CREATE INDEX IF NOT EXISTS idx_password_reset_attempts_email ON password_reset_attempts(action, email_hash, created_at);
+
+COMMIT;
@@ -0,0 +1,48 @@
+-- Поисковые выходы волонтёров по объявлениям о потере
+-- Дата: 2026-10-19
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS announcement_search_sessions (
+    id SERIAL PRIMARY KEY,
+    announcement_id INTEGER NOT NULL REFERENCES pet_announcements(id) ON DELETE CASCADE,
+    leader_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    starts_at TIMESTAMP NOT NULL,
+    meeting_point TEXT NOT NULL,
+    meeting_lat DECIMAL(10, 8),
+    meeting_lon DECIMAL(11, 8),
+    area_description TEXT NOT NULL,
+    area_geojson JSONB, -- Полигон района поиска
+    status TEXT NOT NULL DEFAULT 'planned', -- planned, active, finished, cancelled
+    notes TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS search_session_participants (
+    id SERIAL PRIMARY KEY,
+    session_id INTEGER NOT NULL REFERENCES announcement_search_sessions(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'signed_up', -- signed_up, checked_in, checked_out
+    signed_up_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    checked_in_at TIMESTAMP,
+    checked_out_at TIMESTAMP,
+    UNIQUE (session_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS search_coverage_reports (
+    id SERIAL PRIMARY KEY,
+    session_id INTEGER NOT NULL REFERENCES announcement_search_sessions(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    area_name TEXT NOT NULL,
+    area_geojson JSONB,
+    found_traces BOOLEAN NOT NULL DEFAULT FALSE,
+    notes TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_search_sessions_announcement ON announcement_search_sessions(announcement_id, starts_at);
+CREATE INDEX IF NOT EXISTS idx_search_participants_user ON search_session_participants(user_id);
+CREATE INDEX IF NOT EXISTS idx_search_reports_session ON search_coverage_reports(session_id);
+
+COMMIT;
@@ -0,0 +1,45 @@
+-- Запись на приём в ветеринарные клиники
+-- Дата: 2026-10-19
+
+BEGIN;
+
+-- Окна приёма врачей
+CREATE TABLE IF NOT EXISTS vet_appointment_slots (
+    id SERIAL PRIMARY KEY,
+    organization_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    doctor_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    service_id INTEGER REFERENCES organization_services(id) ON DELETE SET NULL,
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'closed')),
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (ends_at > starts_at),
+    UNIQUE (doctor_id, starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_vet_appointment_slots_org_time ON vet_appointment_slots(organization_id, starts_at);
+
+-- Записи питомцев
+CREATE TABLE IF NOT EXISTS vet_appointments (
+    id SERIAL PRIMARY KEY,
+    slot_id INTEGER NOT NULL REFERENCES vet_appointment_slots(id) ON DELETE CASCADE,
+    organization_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    pet_id INTEGER NOT NULL REFERENCES pets(id) ON DELETE CASCADE,
+    owner_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'booked' CHECK (status IN ('booked', 'cancelled', 'completed', 'no_show')),
+    comment TEXT,
+    cancelled_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    cancel_reason TEXT,
+    cancelled_at TIMESTAMP,
+    reminder_sent_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Страховка от двойной записи на уровне БД
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_vet_appointments_active_slot ON vet_appointments(slot_id) WHERE status = 'booked';
+CREATE INDEX IF NOT EXISTS idx_vet_appointments_owner ON vet_appointments(owner_id, status);
+CREATE INDEX IF NOT EXISTS idx_vet_appointments_reminders ON vet_appointments(status, reminder_sent_at) WHERE status = 'booked' AND reminder_sent_at IS NULL;
+
+COMMIT;
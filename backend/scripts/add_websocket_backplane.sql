@@ -0,0 +1,16 @@
+-- Шина WebSocket между экземплярами backend (WS_BACKPLANE=postgres)
+-- Дата: 2026-10-19
+
+BEGIN;
+
+-- События крупнее лимита NOTIFY (8000 байт): в канал ws_events уходит только ссылка на запись.
+-- Записи старше нескольких минут удаляются самим backend.
+CREATE TABLE IF NOT EXISTS ws_backplane_events (
+    id BIGSERIAL PRIMARY KEY,
+    payload TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ws_backplane_events_created_at ON ws_backplane_events(created_at);
+
+COMMIT;
@@ -0,0 +1,25 @@
+-- Номера событий WebSocket и буфер для повтора при переподключении
+-- Дата: 2026-10-19
+
+BEGIN;
+
+-- Последний выданный пользователю seq (монотонно растёт, общий для всех экземпляров backend)
+CREATE TABLE IF NOT EXISTS ws_user_sequences (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    last_seq BIGINT NOT NULL DEFAULT 0
+);
+
+-- Последние события пользователя (не больше 200 на пользователя, не старше суток).
+-- Клиент, переподключившийся с /ws?since=<seq>, получает отсюда пропущенные события.
+CREATE TABLE IF NOT EXISTS ws_user_events (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    seq BIGINT NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    data TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, seq)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ws_user_events_created_at ON ws_user_events(created_at);
+
+COMMIT;
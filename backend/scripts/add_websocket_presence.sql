@@ -0,0 +1,17 @@
+-- Онлайн-статус пользователей WebSocket на нескольких экземплярах backend (WS_BACKPLANE=postgres)
+-- Дата: 2026-10-19
+
+BEGIN;
+
+-- Есть ли у пользователя подключения к экземпляру. Экземпляр обновляет heartbeat_at своих записей
+-- раз в минуту и удаляет их при остановке; записи упавших экземпляров перестают учитываться через 3 минуты.
+CREATE TABLE IF NOT EXISTS ws_presence (
+    instance_id VARCHAR(64) NOT NULL,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    heartbeat_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (instance_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ws_presence_user ON ws_presence(user_id, heartbeat_at);
+
+COMMIT;
@@ -0,0 +1,10 @@
+-- Стирание текста уже отправленных и окончательно неотправленных писем (одноразовые ссылки в очереди)
+-- Дата: 2026-10-19
+
+BEGIN;
+
+UPDATE email_outbox
+SET text_body = '', html_body = NULL
+WHERE status IN ('sent', 'failed') AND (text_body <> '' OR html_body IS NOT NULL);
+
+COMMIT;
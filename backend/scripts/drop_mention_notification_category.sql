@@ -0,0 +1,13 @@
+-- Категория настроек "mention" убрана: уведомлений об упоминаниях в системе нет, переключатель ни на что не влиял
+-- Дата: 2026-10-19
+
+BEGIN;
+
+DELETE FROM notification_preferences WHERE category = 'mention';
+
+ALTER TABLE notification_preferences DROP CONSTRAINT IF EXISTS notification_preferences_category_check;
+ALTER TABLE notification_preferences ADD CONSTRAINT notification_preferences_category_check CHECK (category IN (
+    'comment', 'like', 'friend_request', 'message', 'announcement', 'organization_invite'
+));
+
+COMMIT;
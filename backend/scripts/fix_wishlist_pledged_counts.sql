@@ -0,0 +1,13 @@
+-- Пересчёт quantity_pledged: только недоставленные обещания
+-- Дата: 2026-10-19
+
+BEGIN;
+
+-- Раньше при получении обещания доставленное оставалось в quantity_pledged и учитывалось дважды
+UPDATE organization_wishlist_items i
+SET quantity_pledged = COALESCE((
+    SELECT SUM(p.quantity) FROM organization_wishlist_pledges p
+    WHERE p.item_id = i.id AND p.status = 'pledged'
+), 0);
+
+COMMIT;
@@ -3,6 +3,7 @@
 import { useEffect, useState } from 'react';
 import { useParams, useRouter } from 'next/navigation';
 import { useAuth } from '../../../../contexts/AuthContext';
+import { useMediaUpload, UploadedMedia } from '../../../hooks/useMediaUpload';
 import { 
   CalendarIcon, 
   MapPinIcon,
@@ -32,6 +33,12 @@ export default function OrganizationPage() {
   const [membersLoading, setMembersLoading] = useState(true);
   const [postsLoading, setPostsLoading] = useState(true);
   const [claimingOwnership, setClaimingOwnership] = useState(false);
+  const [claimFormOpen, setClaimFormOpen] = useState(false);
+  const [claimInn, setClaimInn] = useState('');
+  const [claimOgrn, setClaimOgrn] = useState('');
+  const [claimComment, setClaimComment] = useState('');
+  const [claimDocuments, setClaimDocuments] = useState<UploadedMedia[]>([]);
+  const { uploadFile, uploading: uploadingDocument } = useMediaUpload();
 
   // Проверка является ли пользователь участником организации
   const isMember = () => {
@@ -127,24 +134,49 @@ export default function OrganizationPage() {
     }
   };
 
-  // Заявить о владении организацией
+  // Загрузить документ (устав, доверенность) для заявки на владение
+  const handleClaimDocument = async (e: React.ChangeEvent<HTMLInputElement>) => {
+    const files = Array.from(e.target.files || []);
+    e.target.value = '';
+    for (const file of files) {
+      // Сканы и фото документов загружаются как фото: тип 'document' на сервере принимает только PDF и Word
+      const mediaType = file.type.startsWith('image/') ? 'photo' : 'document';
+      const uploaded = await uploadFile(file, mediaType);
+      if (uploaded) {
+        setClaimDocuments((prev) => [...prev, uploaded]);
+      }
+    }
+  };
+
+  // Отправить заявку на владение организацией (рассматривается модератором)
   const handleClaimOwnership = async () => {
     if (!org || !user) return;
 
-    if (!confirm('Вы уверены, что хотите заявить о владении этой организацией?')) {
+    if (!claimInn.trim() || !claimOgrn.trim()) {
+      alert('Укажите ИНН и ОГРН организации');
+      return;
+    }
+    if (claimDocuments.length === 0) {
+      alert('Приложите хотя бы один документ, подтверждающий полномочия');
       return;
     }
 
     try {
       setClaimingOwnership(true);
-      const response = await organizationsApi.claimOwnership(org.id);
-      
+      const response = await organizationsApi.claimOwnership(org.id, {
+        inn: claimInn.trim(),
+        ogrn: claimOgrn.trim(),
+        document_media_ids: claimDocuments.map((doc) => doc.id),
+        comment: claimComment.trim() || undefined,
+      });
+
       if (response.success) {
-        alert('Вы стали владельцем организации!');
-        // Перезагружаем участников
-        await loadMembers();
+        alert('Заявка отправлена на проверку модератору');
+        setClaimFormOpen(false);
+        setClaimDocuments([]);
+        setClaimComment('');
       } else {
-        alert(response.error || 'Не удалось заявить о владении');
+        alert(response.error || 'Не удалось отправить заявку');
       }
     } catch (error) {
       console.error('Error claiming ownership:', error);
@@ -404,13 +436,74 @@ export default function OrganizationPage() {
               <p className="text-sm text-gray-600 mb-4">
                 У этой организации нет подтвержденного владельца. Если вы являетесь официальным представителем организации, вы можете подтвердить владение.
               </p>
-              <button
-                onClick={handleClaimOwnership}
-                disabled={claimingOwnership}
-                className="w-full px-4 py-2.5 bg-blue-500 hover:bg-blue-600 text-white rounded-lg font-medium transition-colors disabled:opacity-50 disabled:cursor-not-allowed"
-              >
-                {claimingOwnership ? 'Обработка...' : 'Подтвердить владение'}
-              </button>
+              {!claimFormOpen ? (
+                <button
+                  onClick={() => setClaimFormOpen(true)}
+                  className="w-full px-4 py-2.5 bg-blue-500 hover:bg-blue-600 text-white rounded-lg font-medium transition-colors"
+                >
+                  Подтвердить владение
+                </button>
+              ) : (
+                <div className="space-y-3">
+                  <input
+                    type="text"
+                    value={claimInn}
+                    onChange={(e) => setClaimInn(e.target.value)}
+                    placeholder="ИНН"
+                    className="w-full px-3 py-2 border border-gray-200 rounded-lg text-sm"
+                  />
+                  <input
+                    type="text"
+                    value={claimOgrn}
+                    onChange={(e) => setClaimOgrn(e.target.value)}
+                    placeholder="ОГРН"
+                    className="w-full px-3 py-2 border border-gray-200 rounded-lg text-sm"
+                  />
+                  <textarea
+                    value={claimComment}
+                    onChange={(e) => setClaimComment(e.target.value)}
+                    placeholder="Комментарий для модератора (необязательно)"
+                    rows={2}
+                    className="w-full px-3 py-2 border border-gray-200 rounded-lg text-sm"
+                  />
+                  <div>
+                    <label className="block text-sm text-gray-600 mb-1">
+                      Документы (устав, доверенность)
+                    </label>
+                    <input
+                      type="file"
+                      multiple
+                      accept=".pdf,.doc,.docx,image/jpeg,image/png,image/webp"
+                      onChange={handleClaimDocument}
+                      disabled={uploadingDocument}
+                      className="w-full text-sm"
+                    />
+                    {uploadingDocument && <p className="text-xs text-gray-500 mt-1">Загрузка...</p>}
+                    {claimDocuments.length > 0 && (
+                      <ul className="mt-2 space-y-1">
+                        {claimDocuments.map((doc) => (
+                          <li key={doc.id} className="flex items-center justify-between text-xs text-gray-700">
+                            <span className="truncate">{doc.original_name}</span>
+                            <button
+                              onClick={() => setClaimDocuments((prev) => prev.filter((d) => d.id !== doc.id))}
+                              className="text-red-500 hover:text-red-600 ml-2"
+                            >
+                              Убрать
+                            </button>
+                          </li>
+                        ))}
+                      </ul>
+                    )}
+                  </div>
+                  <button
+                    onClick={handleClaimOwnership}
+                    disabled={claimingOwnership || uploadingDocument}
+                    className="w-full px-4 py-2.5 bg-blue-500 hover:bg-blue-600 text-white rounded-lg font-medium transition-colors disabled:opacity-50 disabled:cursor-not-allowed"
+                  >
+                    {claimingOwnership ? 'Обработка...' : 'Отправить заявку'}
+                  </button>
+                </div>
+              )}
             </div>
           )}
 
@@ -9,6 +9,7 @@ import {
   MagnifyingGlassIcon
 } from '@heroicons/react/24/outline';
 import { getOrganizationTypeName } from '../../../lib/organizations-api';
+import MyInvitations from '../../components/organizations/MyInvitations';
 
 interface CitiesData {
   regions: Array<{
@@ -183,6 +184,9 @@ export default function OrganizationsPage() {
     <div className="flex gap-4">
         {/* Middle column - Organizations list */}
         <div className="w-full xl:w-[600px] xl:flex-shrink-0">
+          {/* Приглашения в организации */}
+          <MyInvitations />
+
           {/* Organizations List */}
           {filteredOrganizations.length === 0 ? (
             <div className="bg-white rounded-xl shadow-sm border border-gray-100 p-12 text-center">
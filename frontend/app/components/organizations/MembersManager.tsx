@@ -1,14 +1,16 @@
 'use client';
 
-import { useState } from 'react';
+import { useEffect, useState } from 'react';
 import { 
   UserPlusIcon, 
   UserMinusIcon, 
   PencilIcon,
   XMarkIcon,
-  MagnifyingGlassIcon
+  MagnifyingGlassIcon,
+  CheckIcon,
+  EnvelopeIcon
 } from '@heroicons/react/24/outline';
-import { organizationsApi, usersApi } from '@/lib/api';
+import { organizationsApi, usersApi, OrganizationInvitation } from '@/lib/api';
 
 interface Member {
   id: number;
@@ -49,6 +51,8 @@ export default function MembersManager({ organizationId, members, currentUserId,
   const [searching, setSearching] = useState(false);
   const [loading, setLoading] = useState(false);
   const [error, setError] = useState('');
+  const [notice, setNotice] = useState('');
+  const [invitations, setInvitations] = useState<OrganizationInvitation[]>([]);
   
   // Форма добавления
   const [newMember, setNewMember] = useState({
@@ -83,6 +87,24 @@ export default function MembersManager({ organizationId, members, currentUserId,
     return colors[role] || 'bg-gray-100 text-gray-700';
   };
 
+  // Ожидающие приглашения и заявки на вступление
+  const loadInvitations = async () => {
+    try {
+      const response = await organizationsApi.getInvitations(organizationId);
+      if (response.success && response.data) {
+        setInvitations(response.data);
+      }
+    } catch (err) {
+      console.error(err);
+    }
+  };
+
+  useEffect(() => {
+    if (canManage) {
+      loadInvitations();
+    }
+  }, [organizationId, canManage]);
+
   // Поиск пользователей
   const searchUsers = async () => {
     if (!searchQuery || searchQuery.length < 2) {
@@ -96,10 +118,12 @@ export default function MembersManager({ organizationId, members, currentUserId,
     try {
       const response = await usersApi.getAll();
       if (response.success && response.data) {
-        // Фильтруем по имени и исключаем уже добавленных
+        // Фильтруем по имени и исключаем участников и уже приглашённых
         const memberIds = members.map(m => m.user_id);
+        const invitedIds = invitations.map(i => i.user_id);
         const filtered = response.data.filter(user => 
           !memberIds.includes(user.id) &&
+          !invitedIds.includes(user.id) &&
           (user.name.toLowerCase().includes(searchQuery.toLowerCase()) ||
            user.email.toLowerCase().includes(searchQuery.toLowerCase()))
         );
@@ -120,7 +144,7 @@ export default function MembersManager({ organizationId, members, currentUserId,
     setSearchQuery(`${user.name}${user.last_name ? ' ' + user.last_name : ''}`);
   };
 
-  // Добавить участника
+  // Пригласить участника: он появится в списке после того, как примет приглашение
   const handleAddMember = async () => {
     if (!newMember.userId) {
       setError('Выберите пользователя');
@@ -129,6 +153,7 @@ export default function MembersManager({ organizationId, members, currentUserId,
 
     setLoading(true);
     setError('');
+    setNotice('');
 
     try {
       const response = await organizationsApi.addMember(
@@ -142,12 +167,66 @@ export default function MembersManager({ organizationId, members, currentUserId,
         setShowAddModal(false);
         setNewMember({ userId: 0, role: 'member', position: '' });
         setSearchQuery('');
-        onMembersChange();
+        setNotice('Приглашение отправлено. Пользователь станет участником, когда примет его');
+        loadInvitations();
+      } else {
+        setError(response.error || 'Ошибка отправки приглашения');
+      }
+    } catch (err) {
+      setError('Ошибка отправки приглашения');
+      console.error(err);
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  // Ответить на заявку на вступление
+  const handleRespondToRequest = async (invitation: OrganizationInvitation, accept: boolean) => {
+    setLoading(true);
+    setError('');
+    setNotice('');
+
+    try {
+      const response = accept
+        ? await organizationsApi.acceptInvitation(invitation.id)
+        : await organizationsApi.declineInvitation(invitation.id);
+
+      if (response.success) {
+        loadInvitations();
+        if (accept) {
+          onMembersChange();
+        }
+      } else {
+        setError(response.error || 'Ошибка обработки заявки');
+      }
+    } catch (err) {
+      setError('Ошибка обработки заявки');
+      console.error(err);
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  // Отозвать приглашение
+  const handleCancelInvitation = async (invitation: OrganizationInvitation) => {
+    if (!confirm(`Отозвать приглашение для ${invitation.user_name?.trim() || invitation.email || 'пользователя'}?`)) {
+      return;
+    }
+
+    setLoading(true);
+    setError('');
+    setNotice('');
+
+    try {
+      const response = await organizationsApi.cancelInvitation(invitation.id);
+
+      if (response.success) {
+        loadInvitations();
       } else {
-        setError(response.error || 'Ошибка добавления участника');
+        setError(response.error || 'Ошибка отзыва приглашения');
       }
     } catch (err) {
-      setError('Ошибка добавления участника');
+      setError('Ошибка отзыва приглашения');
       console.error(err);
     } finally {
       setLoading(false);
@@ -227,15 +306,90 @@ export default function MembersManager({ organizationId, members, currentUserId,
         </div>
         {canManage && (
           <button
-            onClick={() => setShowAddModal(true)}
+            onClick={() => {
+              setError('');
+              setNotice('');
+              setShowAddModal(true);
+            }}
             className="flex items-center gap-2 px-4 py-2 bg-blue-500 text-white rounded-lg hover:bg-blue-600 transition-colors text-sm font-medium"
           >
             <UserPlusIcon className="w-4 h-4" />
-            Добавить
+            Пригласить
           </button>
         )}
       </div>
 
+      {notice && !showAddModal && (
+        <div className="mb-4 p-3 bg-green-50 text-green-700 text-sm rounded-lg">{notice}</div>
+      )}
+      {error && !showAddModal && !showEditModal && (
+        <div className="mb-4 p-3 bg-red-50 text-red-600 text-sm rounded-lg">{error}</div>
+      )}
+
+      {/* Ожидающие приглашения и заявки */}
+      {canManage && invitations.length > 0 && (
+        <div className="mb-6">
+          <h4 className="text-sm font-semibold text-gray-700 mb-3">Ожидают ответа ({invitations.length})</h4>
+          <div className="space-y-3">
+            {invitations.map((invitation) => (
+              <div key={invitation.id} className="flex items-center gap-3 p-3 border border-dashed border-gray-300 rounded-lg">
+                <div className="w-10 h-10 rounded-full bg-gray-100 flex items-center justify-center text-gray-500 flex-shrink-0">
+                  <EnvelopeIcon className="w-5 h-5" />
+                </div>
+
+                <div className="flex-1 min-w-0">
+                  <div className="font-medium text-gray-900 truncate">
+                    {invitation.user_name?.trim() || invitation.email || 'Пользователь'}
+                  </div>
+                  <div className="text-sm text-gray-600">
+                    {invitation.kind === 'invite'
+                      ? `Приглашение отправлено · до ${new Date(invitation.expires_at).toLocaleDateString('ru-RU')}`
+                      : 'Заявка на вступление'}
+                  </div>
+                  {invitation.message && (
+                    <div className="text-sm text-gray-500 mt-1 line-clamp-2">{invitation.message}</div>
+                  )}
+                </div>
+
+                <span className={`px-3 py-1 rounded-full text-xs font-medium ${getRoleColor(invitation.role)}`}>
+                  {getRoleName(invitation.role)}
+                </span>
+
+                {invitation.kind === 'invite' ? (
+                  <button
+                    onClick={() => handleCancelInvitation(invitation)}
+                    disabled={loading}
+                    className="p-2 text-gray-600 hover:text-red-600 hover:bg-red-50 rounded-lg transition-colors"
+                    title="Отозвать приглашение"
+                  >
+                    <XMarkIcon className="w-4 h-4" />
+                  </button>
+                ) : (
+                  <div className="flex items-center gap-2">
+                    <button
+                      onClick={() => handleRespondToRequest(invitation, true)}
+                      disabled={loading}
+                      className="p-2 text-gray-600 hover:text-green-600 hover:bg-green-50 rounded-lg transition-colors"
+                      title="Принять"
+                    >
+                      <CheckIcon className="w-4 h-4" />
+                    </button>
+                    <button
+                      onClick={() => handleRespondToRequest(invitation, false)}
+                      disabled={loading}
+                      className="p-2 text-gray-600 hover:text-red-600 hover:bg-red-50 rounded-lg transition-colors"
+                      title="Отклонить"
+                    >
+                      <XMarkIcon className="w-4 h-4" />
+                    </button>
+                  </div>
+                )}
+              </div>
+            ))}
+          </div>
+        </div>
+      )}
+
       {/* Список участников */}
       <div className="space-y-3">
         {members.map((member) => (
@@ -294,7 +448,7 @@ export default function MembersManager({ organizationId, members, currentUserId,
         <div className="fixed inset-0 bg-black bg-opacity-50 flex items-center justify-center z-50 p-4">
           <div className="bg-white rounded-xl shadow-xl max-w-md w-full p-6">
             <div className="flex items-center justify-between mb-6">
-              <h3 className="text-xl font-bold text-gray-900">Добавить участника</h3>
+              <h3 className="text-xl font-bold text-gray-900">Пригласить участника</h3>
               <button
                 onClick={() => {
                   setShowAddModal(false);
@@ -416,7 +570,7 @@ export default function MembersManager({ organizationId, members, currentUserId,
                   disabled={loading || !newMember.userId}
                   className="flex-1 px-4 py-2 bg-blue-500 text-white rounded-lg hover:bg-blue-600 disabled:bg-gray-300 disabled:cursor-not-allowed transition-colors"
                 >
-                  {loading ? 'Добавление...' : 'Добавить'}
+                  {loading ? 'Отправка...' : 'Пригласить'}
                 </button>
               </div>
             </div>
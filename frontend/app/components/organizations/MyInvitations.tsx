@@ -0,0 +1,151 @@
+'use client';
+
+import { useEffect, useState } from 'react';
+import { useRouter } from 'next/navigation';
+import { BuildingOfficeIcon, CheckIcon, XMarkIcon } from '@heroicons/react/24/outline';
+import { organizationsApi, OrganizationInvitation } from '@/lib/api';
+
+// Входящие приглашения в организации и собственные заявки на вступление
+export default function MyInvitations() {
+  const router = useRouter();
+  const [invitations, setInvitations] = useState<OrganizationInvitation[]>([]);
+  const [processingId, setProcessingId] = useState<number | null>(null);
+  const [error, setError] = useState('');
+
+  const loadInvitations = async () => {
+    try {
+      const response = await organizationsApi.getMyInvitations();
+      if (response.success && response.data) {
+        setInvitations(response.data);
+      }
+    } catch (err) {
+      console.error('Error loading invitations:', err);
+    }
+  };
+
+  useEffect(() => {
+    loadInvitations();
+  }, []);
+
+  const getRoleName = (role: string) => {
+    const roles: Record<string, string> = {
+      admin: 'администратора',
+      moderator: 'модератора',
+      member: 'участника',
+    };
+    return roles[role] || role;
+  };
+
+  // Принять или отклонить приглашение
+  const handleRespond = async (invitation: OrganizationInvitation, accept: boolean) => {
+    setProcessingId(invitation.id);
+    setError('');
+
+    try {
+      const response = accept
+        ? await organizationsApi.acceptInvitation(invitation.id)
+        : await organizationsApi.declineInvitation(invitation.id);
+
+      if (response.success) {
+        setInvitations(prev => prev.filter(i => i.id !== invitation.id));
+        if (accept) {
+          router.push(`/org/${invitation.organization_id}`);
+        }
+      } else {
+        setError(response.error || 'Не удалось ответить на приглашение');
+        loadInvitations();
+      }
+    } catch (err) {
+      setError('Не удалось ответить на приглашение');
+      console.error(err);
+    } finally {
+      setProcessingId(null);
+    }
+  };
+
+  // Отозвать свою заявку
+  const handleCancel = async (invitation: OrganizationInvitation) => {
+    setProcessingId(invitation.id);
+    setError('');
+
+    try {
+      const response = await organizationsApi.cancelInvitation(invitation.id);
+      if (response.success) {
+        setInvitations(prev => prev.filter(i => i.id !== invitation.id));
+      } else {
+        setError(response.error || 'Не удалось отозвать заявку');
+        loadInvitations();
+      }
+    } catch (err) {
+      setError('Не удалось отозвать заявку');
+      console.error(err);
+    } finally {
+      setProcessingId(null);
+    }
+  };
+
+  if (invitations.length === 0) {
+    return null;
+  }
+
+  return (
+    <div className="bg-white rounded-xl shadow-sm border border-gray-100 p-4 mb-2.5">
+      <h3 className="text-base font-bold text-gray-900 mb-3">Приглашения и заявки</h3>
+
+      {error && (
+        <div className="mb-3 text-sm text-red-600">{error}</div>
+      )}
+
+      <div className="space-y-2">
+        {invitations.map((invitation) => (
+          <div key={invitation.id} className="flex items-center gap-3 p-3 border border-gray-200 rounded-lg">
+            <div className="w-10 h-10 rounded-lg bg-gradient-to-br from-blue-100 to-purple-100 flex items-center justify-center flex-shrink-0">
+              <BuildingOfficeIcon className="w-5 h-5 text-gray-400" />
+            </div>
+
+            <div
+              className="flex-1 min-w-0 cursor-pointer"
+              onClick={() => router.push(`/org/${invitation.organization_id}`)}
+            >
+              <div className="font-medium text-gray-900 truncate">{invitation.organization_name}</div>
+              <div className="text-sm text-gray-600">
+                {invitation.kind === 'invite'
+                  ? `Приглашение на роль ${getRoleName(invitation.role)} · до ${new Date(invitation.expires_at).toLocaleDateString('ru-RU')}`
+                  : 'Ваша заявка на вступление ожидает рассмотрения'}
+              </div>
+            </div>
+
+            {invitation.kind === 'invite' ? (
+              <div className="flex items-center gap-2">
+                <button
+                  onClick={() => handleRespond(invitation, true)}
+                  disabled={processingId === invitation.id}
+                  className="flex items-center gap-1 px-3 py-1.5 bg-blue-500 text-white rounded-lg hover:bg-blue-600 disabled:bg-gray-300 transition-colors text-sm font-medium"
+                >
+                  <CheckIcon className="w-4 h-4" />
+                  Принять
+                </button>
+                <button
+                  onClick={() => handleRespond(invitation, false)}
+                  disabled={processingId === invitation.id}
+                  className="p-1.5 text-gray-600 hover:text-red-600 hover:bg-red-50 rounded-lg transition-colors"
+                  title="Отклонить"
+                >
+                  <XMarkIcon className="w-5 h-5" />
+                </button>
+              </div>
+            ) : (
+              <button
+                onClick={() => handleCancel(invitation)}
+                disabled={processingId === invitation.id}
+                className="px-3 py-1.5 border border-gray-300 text-gray-700 rounded-lg hover:bg-gray-50 transition-colors text-sm"
+              >
+                Отозвать
+              </button>
+            )}
+          </div>
+        ))}
+      </div>
+    </div>
+  );
+}
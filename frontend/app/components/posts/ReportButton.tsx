@@ -4,7 +4,7 @@ import { useState } from 'react';
 import { Flag, X } from 'lucide-react';
 
 interface ReportButtonProps {
-  targetType: 'post' | 'comment' | 'user' | 'organization' | 'pet';
+  targetType: 'post' | 'comment' | 'user' | 'organization' | 'pet' | 'organization_review';
   targetId: number;
   targetName?: string;
   isOpen: boolean;
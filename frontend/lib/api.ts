@@ -525,6 +525,26 @@ export const organizationsApi = {
     apiClient.delete<{ message: string }>('/api/organizations/members/remove', {
       member_id: memberId,
     }),
+  
+  // Мои приглашения и заявки на вступление
+  getMyInvitations: () =>
+    apiClient.get<OrganizationInvitation[]>('/api/organizations/invitations'),
+  
+  // Ожидающие приглашения и заявки организации (для управляющих участниками)
+  getInvitations: (organizationId: number) =>
+    apiClient.get<OrganizationInvitation[]>(`/api/organizations/${organizationId}/invitations`),
+  
+  // Принять приглашение или одобрить заявку
+  acceptInvitation: (invitationId: number) =>
+    apiClient.post<OrganizationInvitation>(`/api/organizations/invitations/${invitationId}/accept`, {}),
+  
+  // Отклонить приглашение или заявку
+  declineInvitation: (invitationId: number) =>
+    apiClient.post<OrganizationInvitation>(`/api/organizations/invitations/${invitationId}/decline`, {}),
+  
+  // Отозвать приглашение или свою заявку
+  cancelInvitation: (invitationId: number) =>
+    apiClient.delete<{ message: string }>(`/api/organizations/invitations/${invitationId}`),
 };
 
 // Типы
@@ -688,6 +708,29 @@ export interface Notification {
   actor?: User;
 }
 
+// Приглашение в организацию (kind = invite) или заявка на вступление (kind = request)
+export interface OrganizationInvitation {
+  id: number;
+  organization_id: number;
+  kind: 'invite' | 'request';
+  user_id?: number;
+  email?: string;
+  role: string;
+  position?: string;
+  can_post: boolean;
+  can_edit: boolean;
+  can_manage_members: boolean;
+  message?: string;
+  status: 'pending' | 'accepted' | 'declined' | 'expired' | 'cancelled';
+  invited_by?: number;
+  responded_by?: number;
+  expires_at: string;
+  responded_at?: string;
+  created_at: string;
+  organization_name?: string;
+  user_name?: string;
+}
+
 // Типы для организаций
 export interface Organization {
   id: number;
@@ -216,8 +216,9 @@ export const organizationsApi = {
   },
 
   // Заявить о владении организацией (если у нее нет владельца)
-  async claimOwnership(orgId: number) {
-    return apiClient.post<{ message: string }>(`/api/organizations/claim-ownership/${orgId}`, {});
+  // Заявка на владение уходит модераторам: ИНН/ОГРН должны совпадать с карточкой, нужен хотя бы один документ
+  async claimOwnership(orgId: number, data: ClaimOwnershipRequest) {
+    return apiClient.post<{ message: string }>(`/api/organizations/claim-ownership/${orgId}`, data);
   },
 
   // Проверить существование организации по ИНН
@@ -226,6 +227,13 @@ export const organizationsApi = {
   },
 };
 
+export interface ClaimOwnershipRequest {
+  inn: string;
+  ogrn: string;
+  document_media_ids: number[]; // ID файлов из медиатеки (устав, доверенность)
+  comment?: string;
+}
+
 // Типы организаций
 export const ORGANIZATION_TYPES = {
   shelter: 'Приют для животных',